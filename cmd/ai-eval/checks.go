@@ -0,0 +1,94 @@
+package main
+
+import (
+	"chatvis-chat/internal/domain"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Nombres de las verificaciones de cada turno
+const (
+	checkJSON        = "json_valido"
+	checkWords       = "limite_palabras"
+	checkColor       = "prefijo_color"
+	checkAnswerID    = "answer_id_valido"
+	checkExpectation = "expectativa"
+)
+
+// checkNames fija el orden de las verificaciones en los reportes
+var checkNames = []string{checkJSON, checkWords, checkColor, checkAnswerID, checkExpectation}
+
+// anyColorPrefix es el prefijo aceptado cuando la conversación no indica el color del bot
+var anyColorPrefix = regexp.MustCompile(`^\p{L}+:\s`)
+
+// Check es el resultado de una verificación en un turno
+type Check struct {
+	Name   string `json:"name"`
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// checkJSONOutput verifica que la salida cumpla el schema de respuesta (un silencio es válido)
+func checkJSONOutput(parseErr error) Check {
+	if parseErr != nil {
+		return Check{Name: checkJSON, Detail: parseErr.Error()}
+	}
+	return Check{Name: checkJSON, Pass: true}
+}
+
+// checkWordLimit verifica que ningún mensaje supere maxWords palabras, sin contar el prefijo de color
+func checkWordLimit(mensajes []domain.RespuestaIA, maxWords int) Check {
+	for i, m := range mensajes {
+		content := m.Content
+		if loc := anyColorPrefix.FindStringIndex(content); loc != nil {
+			content = content[loc[1]:]
+		}
+		if words := len(strings.Fields(content)); words > maxWords {
+			return Check{Name: checkWords, Detail: fmt.Sprintf("el mensaje %d tiene %d palabras (máximo %d)", i+1, words, maxWords)}
+		}
+	}
+	return Check{Name: checkWords, Pass: true}
+}
+
+// checkColorPrefix verifica que cada mensaje empiece con el color del bot ("Azul: ...")
+func checkColorPrefix(mensajes []domain.RespuestaIA, color string) Check {
+	for i, m := range mensajes {
+		ok := anyColorPrefix.MatchString(m.Content)
+		if color != "" {
+			ok = strings.HasPrefix(strings.ToLower(m.Content), strings.ToLower(color)+":")
+		}
+		if !ok {
+			return Check{Name: checkColor, Detail: fmt.Sprintf("el mensaje %d no empieza con el color: %q", i+1, m.Content)}
+		}
+	}
+	return Check{Name: checkColor, Pass: true}
+}
+
+// checkAnswerIDs verifica que cada answer_id sea un mensaje que el modelo tenía a la vista
+func checkAnswerIDs(mensajes []domain.RespuestaIA, window []domain.Mensaje) Check {
+	visibles := make(map[string]bool, len(window))
+	for _, m := range window {
+		visibles[strconv.FormatUint(m.Id, 10)] = true
+	}
+
+	for i, m := range mensajes {
+		if m.AnswerId != "" && !visibles[m.AnswerId] {
+			return Check{Name: checkAnswerID, Detail: fmt.Sprintf("el mensaje %d responde a %s, que no está en el historial", i+1, m.AnswerId)}
+		}
+	}
+	return Check{Name: checkAnswerID, Pass: true}
+}
+
+// checkExpected compara la decisión del bot de hablar o callar con la esperada
+func checkExpected(esperado string, silent bool) Check {
+	got := esperadoResponder
+	if silent {
+		got = esperadoSilencio
+	}
+	if got != esperado {
+		return Check{Name: checkExpectation, Detail: fmt.Sprintf("se esperaba %s y el bot eligió %s", esperado, got)}
+	}
+	return Check{Name: checkExpectation, Pass: true}
+}
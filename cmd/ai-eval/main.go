@@ -0,0 +1,237 @@
+// ai-eval reproduce conversaciones grabadas de grupos con la persona de un bot y puntúa sus
+// respuestas con verificaciones por reglas: JSON válido, límite de palabras, prefijo de color,
+// tasa de silencio y validez de los answer_id. Genera un reporte JSON y otro HTML que puede
+// compararse con un reporte anterior para evaluar un cambio de prompt o de modelo.
+//
+//	go run ./cmd/ai-eval -transcripts conversaciones/ -provider ollama_chat \
+//	  -base-url http://localhost:11434 -model llama3 -prompt persona.tmpl \
+//	  -baseline eval-out/anterior.json
+package main
+
+import (
+	"chatvis-chat/internal/ia"
+	"chatvis-chat/internal/llm"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// providerStub usa el servidor determinista de stub.go en lugar de un modelo real
+const providerStub = "stub"
+
+type options struct {
+	transcripts   string
+	provider      string
+	baseURL       string
+	model         string
+	apiKeyEnv     string
+	promptFile    string
+	historyFormat string
+	temperature   float64
+	maxTokens     int
+	structured    bool
+	maxWords      int
+	label         string
+	outDir        string
+	baseline      string
+	timeout       time.Duration
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.transcripts, "transcripts", "", "archivo JSON o directorio con las conversaciones grabadas")
+	flag.StringVar(&opts.provider, "provider", providerStub, "proveedor: stub, openai, ollama_chat, ollama_generate o lmstudio")
+	flag.StringVar(&opts.baseURL, "base-url", "", "URL base del servidor del modelo")
+	flag.StringVar(&opts.model, "model", "stub", "nombre del modelo")
+	flag.StringVar(&opts.apiKeyEnv, "api-key-env", "", "variable de entorno con la API key")
+	flag.StringVar(&opts.promptFile, "prompt", "", "archivo con la plantilla del prompt de sistema (vacío usa la persona por defecto)")
+	flag.StringVar(&opts.historyFormat, "history-format", ia.HistoryFormatInline, "formato del historial: inline o json")
+	flag.Float64Var(&opts.temperature, "temperature", 0.7, "temperatura de muestreo")
+	flag.IntVar(&opts.maxTokens, "max-tokens", 0, "máximo de tokens por respuesta (0 = el del servidor)")
+	flag.BoolVar(&opts.structured, "structured", false, "enviar el JSON schema de respuesta al proveedor")
+	flag.IntVar(&opts.maxWords, "max-words", 5, "máximo de palabras por mensaje")
+	flag.StringVar(&opts.label, "label", "", "nombre de la ejecución (por defecto modelo y prompt)")
+	flag.StringVar(&opts.outDir, "out", "eval-out", "directorio de los reportes")
+	flag.StringVar(&opts.baseline, "baseline", "", "reporte JSON anterior con el que comparar")
+	flag.DurationVar(&opts.timeout, "timeout", 60*time.Second, "tiempo máximo por respuesta")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		log.Fatalf("ai-eval: %v", err)
+	}
+}
+
+func run(opts options) error {
+	if opts.transcripts == "" {
+		return errors.New("falta -transcripts")
+	}
+
+	transcripts, err := loadTranscripts(opts.transcripts)
+	if err != nil {
+		return err
+	}
+
+	var baseline *Report
+	if opts.baseline != "" {
+		if baseline, err = loadReport(opts.baseline); err != nil {
+			return fmt.Errorf("error al leer el reporte de referencia: %w", err)
+		}
+	}
+
+	config, err := evalConfig(opts)
+	if err != nil {
+		return err
+	}
+	if opts.provider == providerStub {
+		stub := newStubServer()
+		defer stub.Close()
+		config.Provider = llm.ProviderOpenAI
+		config.LLMBaseURL = stub.URL
+	}
+
+	// El servicio no se inicia: Respond no usa el hub ni la base de datos
+	service, err := ia.NewAIService(nil, nil, nil, nil, config)
+	if err != nil {
+		return err
+	}
+
+	report := &Report{
+		Label:       opts.label,
+		Provider:    opts.provider,
+		Model:       opts.model,
+		PromptFile:  opts.promptFile,
+		MaxWords:    opts.maxWords,
+		GeneratedAt: time.Now(),
+	}
+	if report.Label == "" {
+		report.Label = defaultLabel(opts)
+	}
+
+	for _, t := range transcripts {
+		report.Transcripts = append(report.Transcripts, evaluate(service, t, opts))
+	}
+
+	var all []TurnReport
+	for _, tr := range report.Transcripts {
+		all = append(all, tr.Turns...)
+	}
+	report.Summary = summarize(all)
+
+	if err := os.MkdirAll(opts.outDir, 0o755); err != nil {
+		return err
+	}
+	jsonPath := filepath.Join(opts.outDir, report.Label+".json")
+	htmlPath := filepath.Join(opts.outDir, report.Label+".html")
+	if err := report.writeJSON(jsonPath); err != nil {
+		return err
+	}
+	if err := report.writeHTML(htmlPath, baseline); err != nil {
+		return err
+	}
+
+	log.Printf("ai-eval: %d turnos, score %.1f%%, silencio %.1f%%, %d errores del proveedor",
+		report.Summary.Turns, report.Summary.Score*100, report.Summary.SilenceRate*100, report.Summary.ProviderErrors)
+	log.Printf("ai-eval: reportes en %s y %s", jsonPath, htmlPath)
+	return nil
+}
+
+// evalConfig arma la configuración del bot evaluado a partir de las opciones
+func evalConfig(opts options) (ia.IAConfig, error) {
+	config := ia.IAConfig{
+		UserID:           strconv.Itoa(botUserID),
+		LLMBaseURL:       opts.baseURL,
+		LLMName:          opts.model,
+		IsPromt:          true,
+		HistoryFormat:    opts.historyFormat,
+		Provider:         llm.ProviderType(opts.provider),
+		Temperature:      opts.temperature,
+		MaxTokens:        opts.maxTokens,
+		Timeout:          opts.timeout,
+		StructuredOutput: opts.structured,
+	}
+	if opts.apiKeyEnv != "" {
+		config.LLMAPIKey = os.Getenv(opts.apiKeyEnv)
+	}
+
+	if opts.promptFile != "" {
+		data, err := os.ReadFile(opts.promptFile)
+		if err != nil {
+			return config, fmt.Errorf("error al leer el prompt: %w", err)
+		}
+		config.PromptTemplate = string(data)
+	}
+
+	if opts.provider != providerStub && !llm.IsValidProviderType(config.Provider) {
+		return config, fmt.Errorf("tipo de proveedor no soportado: %q", opts.provider)
+	}
+	return config, nil
+}
+
+var unsafeLabel = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func defaultLabel(opts options) string {
+	label := opts.model
+	if opts.promptFile != "" {
+		label += "-" + filepath.Base(opts.promptFile)
+	}
+	return unsafeLabel.ReplaceAllString(label, "_")
+}
+
+// evaluate pide una respuesta al bot tras cada mensaje de otro integrante y la verifica
+func evaluate(service *ia.AIService, t Transcript, opts options) TranscriptReport {
+	data, turns := t.turns(time.Now())
+	tr := TranscriptReport{Name: t.Nombre}
+
+	for _, tu := range turns {
+		result := TurnReport{
+			MessageId: tu.Trigger.Id,
+			Apodo:     tu.Trigger.Apodo,
+			Trigger:   tu.Trigger.Contenido,
+			Expected:  tu.Trigger.Esperado,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+		resp, err := service.Respond(ctx, data, tu.Historial)
+		cancel()
+
+		if resp == nil {
+			result.Error = err.Error()
+			tr.Turns = append(tr.Turns, result)
+			continue
+		}
+
+		result.Output = resp.Output
+		result.Messages = resp.Messages
+		result.Silent = errors.Is(err, ia.ErrSilent)
+		result.LatencyMs = resp.Latency.Milliseconds()
+		result.PromptTokens = resp.Usage.PromptTokens
+		result.CompletionTokens = resp.Usage.CompletionTokens
+
+		if result.Silent {
+			err = nil
+		}
+		result.Checks = append(result.Checks, checkJSONOutput(err))
+		if len(result.Messages) > 0 {
+			result.Checks = append(result.Checks,
+				checkWordLimit(result.Messages, opts.maxWords),
+				checkColorPrefix(result.Messages, t.Bot.Color),
+				checkAnswerIDs(result.Messages, resp.Window),
+			)
+		}
+		if tu.Trigger.Esperado != "" && err == nil {
+			result.Checks = append(result.Checks, checkExpected(tu.Trigger.Esperado, result.Silent))
+		}
+
+		tr.Turns = append(tr.Turns, result)
+	}
+
+	tr.Summary = summarize(tr.Turns)
+	return tr
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"chatvis-chat/internal/domain"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+)
+
+// Report es el resultado de evaluar una configuración (modelo y prompt) sobre las conversaciones
+type Report struct {
+	Label       string             `json:"label"`
+	Provider    string             `json:"provider"`
+	Model       string             `json:"model"`
+	PromptFile  string             `json:"promptFile,omitempty"`
+	MaxWords    int                `json:"maxWords"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Summary     Summary            `json:"summary"`
+	Transcripts []TranscriptReport `json:"transcripts"`
+}
+
+// Summary agrega los resultados de un conjunto de turnos
+type Summary struct {
+	Turns          int         `json:"turns"`
+	Replies        int         `json:"replies"`
+	Silences       int         `json:"silences"`
+	ProviderErrors int         `json:"providerErrors"`
+	SilenceRate    float64     `json:"silenceRate"`
+	Checks         []CheckRate `json:"checks"`
+	// Score es el promedio de las tasas de aprobación de las verificaciones que aplicaron
+	Score            float64 `json:"score"`
+	AvgLatencyMs     int64   `json:"avgLatencyMs"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+}
+
+// CheckRate es la tasa de aprobación de una verificación sobre los turnos en que aplicó
+type CheckRate struct {
+	Name   string  `json:"name"`
+	Passed int     `json:"passed"`
+	Total  int     `json:"total"`
+	Rate   float64 `json:"rate"`
+}
+
+// TranscriptReport son los turnos evaluados de una conversación
+type TranscriptReport struct {
+	Name    string       `json:"name"`
+	Summary Summary      `json:"summary"`
+	Turns   []TurnReport `json:"turns"`
+}
+
+// TurnReport es la respuesta del bot tras un mensaje de la conversación
+type TurnReport struct {
+	MessageId        uint64               `json:"messageId"`
+	Apodo            string               `json:"apodo"`
+	Trigger          string               `json:"trigger"`
+	Expected         string               `json:"expected,omitempty"`
+	Output           string               `json:"output"`
+	Messages         []domain.RespuestaIA `json:"messages"`
+	Silent           bool                 `json:"silent"`
+	Error            string               `json:"error,omitempty"`
+	Checks           []Check              `json:"checks"`
+	LatencyMs        int64                `json:"latencyMs"`
+	PromptTokens     int                  `json:"promptTokens"`
+	CompletionTokens int                  `json:"completionTokens"`
+}
+
+// summarize calcula las métricas de un conjunto de turnos
+func summarize(turns []TurnReport) Summary {
+	summary := Summary{Turns: len(turns)}
+
+	counts := make(map[string]*CheckRate, len(checkNames))
+	var latency int64
+	for _, t := range turns {
+		switch {
+		case t.Error != "":
+			summary.ProviderErrors++
+			continue
+		case t.Silent:
+			summary.Silences++
+		case len(t.Messages) > 0:
+			summary.Replies++
+		}
+		latency += t.LatencyMs
+		summary.PromptTokens += t.PromptTokens
+		summary.CompletionTokens += t.CompletionTokens
+
+		for _, c := range t.Checks {
+			rate, ok := counts[c.Name]
+			if !ok {
+				rate = &CheckRate{Name: c.Name}
+				counts[c.Name] = rate
+			}
+			rate.Total++
+			if c.Pass {
+				rate.Passed++
+			}
+		}
+	}
+
+	completed := summary.Turns - summary.ProviderErrors
+	if completed > 0 {
+		summary.SilenceRate = float64(summary.Silences) / float64(completed)
+		summary.AvgLatencyMs = latency / int64(completed)
+	}
+
+	for _, name := range checkNames {
+		rate, ok := counts[name]
+		if !ok {
+			continue
+		}
+		rate.Rate = float64(rate.Passed) / float64(rate.Total)
+		summary.Checks = append(summary.Checks, *rate)
+		summary.Score += rate.Rate
+	}
+	if len(summary.Checks) > 0 {
+		summary.Score /= float64(len(summary.Checks))
+	}
+
+	return summary
+}
+
+func (r *Report) writeJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func loadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// metricRow es una fila de la tabla comparativa de métricas del HTML
+type metricRow struct {
+	Name     string
+	Current  string
+	Baseline string
+	Delta    string
+	Better   bool
+	Worse    bool
+}
+
+// turnRow pone lado a lado la respuesta actual y la de la referencia en un mismo mensaje
+type turnRow struct {
+	Turn     TurnReport
+	Baseline *TurnReport
+}
+
+type transcriptView struct {
+	Name string
+	Rows []turnRow
+}
+
+type htmlView struct {
+	Current     *Report
+	Baseline    *Report
+	Metrics     []metricRow
+	Transcripts []transcriptView
+}
+
+// writeHTML genera el reporte legible; con baseline compara ambas ejecuciones turno por turno
+func (r *Report) writeHTML(path string, baseline *Report) error {
+	view := htmlView{Current: r, Baseline: baseline, Metrics: compareMetrics(r.Summary, baseline)}
+
+	baselineTurns := make(map[string]*TurnReport)
+	if baseline != nil {
+		for i := range baseline.Transcripts {
+			for j := range baseline.Transcripts[i].Turns {
+				t := &baseline.Transcripts[i].Turns[j]
+				baselineTurns[fmt.Sprintf("%s/%d", baseline.Transcripts[i].Name, t.MessageId)] = t
+			}
+		}
+	}
+
+	for _, tr := range r.Transcripts {
+		tv := transcriptView{Name: tr.Name}
+		for _, t := range tr.Turns {
+			tv.Rows = append(tv.Rows, turnRow{Turn: t, Baseline: baselineTurns[fmt.Sprintf("%s/%d", tr.Name, t.MessageId)]})
+		}
+		view.Transcripts = append(view.Transcripts, tv)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return reportTemplate.Execute(f, view)
+}
+
+// compareMetrics arma las filas de métricas; en las tasas de verificación más alto es mejor
+func compareMetrics(current Summary, baseline *Report) []metricRow {
+	type metric struct {
+		name         string
+		value        func(Summary) (float64, bool)
+		percent      bool
+		higherBetter bool
+	}
+
+	metrics := []metric{
+		{name: "score", value: func(s Summary) (float64, bool) { return s.Score, true }, percent: true, higherBetter: true},
+		{name: "tasa_silencio", value: func(s Summary) (float64, bool) { return s.SilenceRate, true }, percent: true},
+		{name: "errores_proveedor", value: func(s Summary) (float64, bool) { return float64(s.ProviderErrors), true }},
+		{name: "latencia_promedio_ms", value: func(s Summary) (float64, bool) { return float64(s.AvgLatencyMs), true }},
+		{name: "tokens", value: func(s Summary) (float64, bool) { return float64(s.PromptTokens + s.CompletionTokens), true }},
+	}
+	for _, name := range checkNames {
+		metrics = append(metrics, metric{name: name, value: func(s Summary) (float64, bool) {
+			for _, c := range s.Checks {
+				if c.Name == name {
+					return c.Rate, true
+				}
+			}
+			return 0, false
+		}, percent: true, higherBetter: true})
+	}
+
+	format := func(v float64, ok bool, percent bool) string {
+		switch {
+		case !ok:
+			return "-"
+		case percent:
+			return fmt.Sprintf("%.1f%%", v*100)
+		default:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+
+	var rows []metricRow
+	for _, m := range metrics {
+		cur, curOK := m.value(current)
+		row := metricRow{Name: m.name, Current: format(cur, curOK, m.percent), Baseline: "-", Delta: "-"}
+
+		if baseline != nil {
+			base, baseOK := m.value(baseline.Summary)
+			row.Baseline = format(base, baseOK, m.percent)
+			if curOK && baseOK {
+				delta := cur - base
+				row.Delta = format(delta, true, m.percent)
+				if delta > 0 {
+					row.Delta = "+" + row.Delta
+				}
+				if m.higherBetter {
+					row.Better, row.Worse = delta > 0, delta < 0
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"failed": func(checks []Check) []Check {
+		var failed []Check
+		for _, c := range checks {
+			if !c.Pass {
+				failed = append(failed, c)
+			}
+		}
+		return failed
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>Evaluación {{.Current.Label}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2rem; width: 100%; }
+th, td { border: 1px solid #ccc; padding: .4rem .6rem; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+.better { color: #1a7f37; font-weight: bold; }
+.worse { color: #c0392b; font-weight: bold; }
+.silent { color: #888; font-style: italic; }
+.fail { color: #c0392b; font-size: .85em; }
+pre { white-space: pre-wrap; margin: 0; font-size: .85em; }
+</style>
+</head>
+<body>
+<h1>Evaluación: {{.Current.Label}}</h1>
+<p>{{.Current.Provider}} / {{.Current.Model}}{{with .Current.PromptFile}} — prompt {{.}}{{end}} — {{.Current.GeneratedAt.Format "2006-01-02 15:04"}} — máximo {{.Current.MaxWords}} palabras</p>
+{{with .Baseline}}<p>Referencia: <b>{{.Label}}</b> ({{.Provider}} / {{.Model}}{{with .PromptFile}} — prompt {{.}}{{end}})</p>{{end}}
+
+<h2>Métricas</h2>
+<table>
+<tr><th>Métrica</th><th>{{.Current.Label}}</th>{{if .Baseline}}<th>{{.Baseline.Label}}</th><th>Diferencia</th>{{end}}</tr>
+{{range .Metrics}}<tr><td>{{.Name}}</td><td>{{.Current}}</td>{{if $.Baseline}}<td>{{.Baseline}}</td><td class="{{if .Better}}better{{else if .Worse}}worse{{end}}">{{.Delta}}</td>{{end}}</tr>
+{{end}}</table>
+
+{{range .Transcripts}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Mensaje</th><th>Esperado</th><th>{{$.Current.Label}}</th>{{if $.Baseline}}<th>{{$.Baseline.Label}}</th>{{end}}</tr>
+{{range .Rows}}<tr>
+<td>#{{.Turn.MessageId}} @{{.Turn.Apodo}}: {{.Turn.Trigger}}</td>
+<td>{{.Turn.Expected}}</td>
+<td>{{template "turn" .Turn}}</td>
+{{if $.Baseline}}<td>{{with .Baseline}}{{template "turn" .}}{{else}}-{{end}}</td>{{end}}
+</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+{{define "turn"}}{{if .Error}}<span class="fail">error del proveedor: {{.Error}}</span>{{else if .Silent}}<span class="silent">silencio</span>{{else}}{{range .Messages}}<div>{{with .AnswerId}}↪ {{.}} {{end}}{{.Content}}</div>{{else}}<pre>{{.Output}}</pre>{{end}}{{end}}{{range failed .Checks}}<div class="fail">✗ {{.Name}}: {{.Detail}}</div>{{end}}{{end}}
+`))
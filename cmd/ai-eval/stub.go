@@ -0,0 +1,12 @@
+package main
+
+import "chatvis-chat/internal/llm/llmtest"
+
+// newStubServer levanta el servidor LLM falso con un handler determinista: contesta a las
+// preguntas (mensajes con "?") y guarda silencio en el resto. Sirve para probar el arnés y el
+// formato del reporte sin un modelo real, no para evaluar una persona.
+func newStubServer() *llmtest.Server {
+	stub := llmtest.NewServer()
+	stub.Handle(llmtest.AnswerQuestions)
+	return stub
+}
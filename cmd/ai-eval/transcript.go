@@ -0,0 +1,183 @@
+package main
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/ia"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ID con el que se carga el bot evaluado; los integrantes reciben IDs a partir del siguiente
+const botUserID = 1
+
+// Expectativas que puede indicar un mensaje de la conversación grabada
+const (
+	esperadoResponder = "responder"
+	esperadoSilencio  = "silencio"
+)
+
+// Transcript es una conversación grabada de un grupo
+type Transcript struct {
+	Nombre      string              `json:"nombre"`
+	Grupo       string              `json:"grupo"`
+	Bot         Persona             `json:"bot"`
+	Integrantes []Persona           `json:"integrantes"`
+	Mensajes    []TranscriptMessage `json:"mensajes"`
+}
+
+// Persona es un integrante de la conversación; Color solo aplica al bot evaluado
+type Persona struct {
+	Nombre string `json:"nombre"`
+	Apodo  string `json:"apodo"`
+	Color  string `json:"color,omitempty"`
+}
+
+// TranscriptMessage es un mensaje grabado. Tras cada mensaje de otro integrante se pide una
+// respuesta al bot; Esperado ("responder", "silencio" o vacío) indica qué debería hacer.
+type TranscriptMessage struct {
+	Id          uint64    `json:"id"`
+	Apodo       string    `json:"apodo"`
+	Contenido   string    `json:"contenido"`
+	Fecha       time.Time `json:"fecha"`
+	RespuestaId *uint64   `json:"respuestaId,omitempty"`
+	Esperado    string    `json:"esperado,omitempty"`
+}
+
+// turn es un punto de la conversación en el que se evalúa al bot
+type turn struct {
+	Trigger   TranscriptMessage
+	Historial []domain.Mensaje
+}
+
+// loadTranscripts lee un archivo JSON o todos los *.json de un directorio
+func loadTranscripts(path string) ([]Transcript, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var transcripts []Transcript
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var t Transcript
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if t.Nombre == "" {
+			t.Nombre = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		transcripts = append(transcripts, t)
+	}
+
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("no se encontraron conversaciones en %s", path)
+	}
+	return transcripts, nil
+}
+
+func (t *Transcript) validate() error {
+	if t.Bot.Apodo == "" {
+		return fmt.Errorf("la conversación %q no indica el apodo del bot", t.Nombre)
+	}
+
+	ids := make(map[uint64]bool, len(t.Mensajes))
+	for _, m := range t.Mensajes {
+		if m.Id == 0 || ids[m.Id] {
+			return fmt.Errorf("la conversación %q tiene un id de mensaje vacío o repetido (%d)", t.Nombre, m.Id)
+		}
+		ids[m.Id] = true
+
+		switch m.Esperado {
+		case "", esperadoResponder, esperadoSilencio:
+		default:
+			return fmt.Errorf("el mensaje %d tiene una expectativa no soportada: %q", m.Id, m.Esperado)
+		}
+	}
+	return nil
+}
+
+// users asigna un usuario a cada apodo: el bot con botUserID y el resto en orden de aparición
+func (t *Transcript) users() (map[string]*domain.Usuario, []domain.Usuario) {
+	byApodo := map[string]*domain.Usuario{
+		t.Bot.Apodo: {Id: botUserID, Nombre: t.Bot.Nombre, Apodo: t.Bot.Apodo, IsLlm: true},
+	}
+	order := []string{t.Bot.Apodo}
+
+	add := func(p Persona) {
+		if _, ok := byApodo[p.Apodo]; ok {
+			return
+		}
+		nombre := p.Nombre
+		if nombre == "" {
+			nombre = p.Apodo
+		}
+		byApodo[p.Apodo] = &domain.Usuario{Id: uint64(len(order) + botUserID), Nombre: nombre, Apodo: p.Apodo}
+		order = append(order, p.Apodo)
+	}
+	for _, p := range t.Integrantes {
+		add(p)
+	}
+	for _, m := range t.Mensajes {
+		add(Persona{Apodo: m.Apodo})
+	}
+
+	miembros := make([]domain.Usuario, 0, len(order))
+	for _, apodo := range order {
+		miembros = append(miembros, *byApodo[apodo])
+	}
+	return byApodo, miembros
+}
+
+// turns convierte la conversación en los mensajes del grupo y los puntos a evaluar
+func (t *Transcript) turns(now time.Time) (ia.PromptData, []turn) {
+	byApodo, miembros := t.users()
+	grupo := &domain.Grupo{Id: 1, Nombre: t.Grupo, Clave: t.Nombre}
+
+	mensajes := make([]domain.Mensaje, 0, len(t.Mensajes))
+	var turns []turn
+	for i, m := range t.Mensajes {
+		fecha := m.Fecha
+		if fecha.IsZero() {
+			// Sin fecha, los mensajes se separan un minuto terminando en now
+			fecha = now.Add(time.Duration(i-len(t.Mensajes)) * time.Minute)
+		}
+
+		usuario := byApodo[m.Apodo]
+		mensajes = append(mensajes, domain.Mensaje{
+			Id:         m.Id,
+			Contenido:  m.Contenido,
+			Fecha:      fecha,
+			GrupoId:    grupo.Id,
+			UsuarioId:  usuario.Id,
+			ResponseId: m.RespuestaId,
+			Usuario:    usuario,
+		})
+
+		if usuario.Id != botUserID {
+			turns = append(turns, turn{Trigger: m, Historial: mensajes[:len(mensajes):len(mensajes)]})
+		}
+	}
+
+	return ia.NewPromptData(grupo, miembros, byApodo[t.Bot.Apodo], now), turns
+}
@@ -0,0 +1,45 @@
+// llm-fake sirve el servidor LLM falso de internal/llm/llmtest para desarrollo local: permite
+// levantar el chat con bots sin un modelo real. Habla los formatos de OpenAI y de Ollama, así que
+// sirve con cualquier tipo de proveedor apuntando el baseUrl del bot a su dirección.
+//
+//	go run ./cmd/llm-fake -addr 127.0.0.1:11500 -latency 800ms
+package main
+
+import (
+	"chatvis-chat/internal/llm/llmtest"
+	"flag"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:11500", "dirección en la que escucha el servidor")
+	latency := flag.Duration("latency", 0, "demora antes de cada respuesta")
+	chunkDelay := flag.Duration("chunk-delay", 50*time.Millisecond, "demora entre fragmentos en streaming")
+	reply := flag.String("reply", "", "texto fijo de cada respuesta (vacío responde a las preguntas y calla en el resto)")
+	failEvery := flag.Int("fail-every", 0, "responde 503 cada N solicitudes (0 = nunca)")
+	flag.Parse()
+
+	fake := llmtest.NewFake()
+	var count atomic.Int64
+	fake.Handle(func(req llmtest.Request) llmtest.Response {
+		n := count.Add(1)
+		log.Printf("llm-fake: %s %s (modelo %q, stream %t): %q", req.Format, req.Path, req.Model, req.Stream, req.LastUserMessage())
+
+		resp := llmtest.AnswerQuestions(req)
+		if *reply != "" {
+			resp = llmtest.Reply(*reply)
+		}
+		if *failEvery > 0 && n%int64(*failEvery) == 0 {
+			resp = llmtest.Fail(http.StatusServiceUnavailable)
+		}
+		resp.Delay = *latency
+		resp.ChunkDelay = *chunkDelay
+		return resp
+	})
+
+	log.Printf("llm-fake: escuchando en http://%s (OpenAI en /v1/chat/completions y /v1/embeddings, Ollama en /api/chat, /api/generate y /api/embed)", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fake))
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BotHandler struct {
+	BUsecase domain.BotUseCase
+}
+
+// NewAdminBotHandler registra los endpoints de administración de bots
+func NewAdminBotHandler(group fiber.Router, bu domain.BotUseCase) {
+	handler := &BotHandler{
+		BUsecase: bu,
+	}
+
+	group.Get("/bots", handler.GetAllBots)
+	group.Get("/llm/health", handler.GetLLMHealth)
+	group.Get("/llm/cache", handler.GetLLMCache)
+	group.Get("/bots/:id", handler.GetBotById)
+	group.Post("/bots", handler.CreateBot)
+	group.Put("/bots/:id", handler.UpdateBot)
+	group.Delete("/bots/:id", handler.DeleteBot)
+	group.Post("/bots/:id/start", handler.StartBot)
+	group.Post("/bots/:id/stop", handler.StopBot)
+	group.Post("/bots/:id/reload", handler.ReloadBot)
+
+	group.Get("/bots/:id/prompts", handler.GetPrompts)
+	group.Get("/bots/:id/prompts/preview", handler.PreviewPrompt)
+	group.Post("/bots/:id/prompts", handler.CreatePromptVersion)
+	group.Post("/bots/:id/prompts/:version/activate", handler.ActivatePromptVersion)
+}
+
+func (h *BotHandler) GetAllBots(c *fiber.Ctx) error {
+	bots, err := h.BUsecase.GetAll()
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener bots", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bots obtenidos correctamente", "", bots)
+}
+
+func (h *BotHandler) GetLLMHealth(c *fiber.Ctx) error {
+	return pkg.ResponseJson(c, fiber.StatusOK, "Estado de los servidores LLM obtenido correctamente", "", h.BUsecase.GetLLMHealth())
+}
+
+func (h *BotHandler) GetLLMCache(c *fiber.Ctx) error {
+	return pkg.ResponseJson(c, fiber.StatusOK, "Métricas de la caché de respuestas obtenidas correctamente", "", h.BUsecase.GetLLMCache())
+}
+
+func (h *BotHandler) GetBotById(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener bot", "Error parametro", err.Error())
+	}
+
+	bot, err := h.BUsecase.GetById(id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot obtenido correctamente", "", bot)
+}
+
+func (h *BotHandler) CreateBot(c *fiber.Ctx) error {
+	var bot domain.Bot
+	if err := c.BodyParser(&bot); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al crear bot", "Error de parseo", err.Error())
+	}
+
+	if err := h.BUsecase.Create(&bot); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al crear bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusCreated, "Bot creado correctamente", "", bot)
+}
+
+func (h *BotHandler) UpdateBot(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar bot", "Error parametro", err.Error())
+	}
+
+	var bot domain.Bot
+	if err := c.BodyParser(&bot); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar bot", "Error de parseo", err.Error())
+	}
+
+	if err := h.BUsecase.Update(id, &bot); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al actualizar bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot actualizado correctamente", "", bot)
+}
+
+func (h *BotHandler) DeleteBot(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al eliminar bot", "Error parametro", err.Error())
+	}
+
+	if err := h.BUsecase.Delete(id); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al eliminar bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot eliminado correctamente", "", nil)
+}
+
+func (h *BotHandler) StartBot(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al iniciar bot", "Error parametro", err.Error())
+	}
+
+	if err := h.BUsecase.Start(id); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al iniciar bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot iniciado correctamente", "", nil)
+}
+
+func (h *BotHandler) StopBot(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al detener bot", "Error parametro", err.Error())
+	}
+
+	if err := h.BUsecase.Stop(id); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al detener bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot detenido correctamente", "", nil)
+}
+
+func (h *BotHandler) ReloadBot(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al recargar bot", "Error parametro", err.Error())
+	}
+
+	if err := h.BUsecase.Reload(id); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al recargar bot", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Bot recargado correctamente", "", nil)
+}
+
+func (h *BotHandler) GetPrompts(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener prompts", "Error parametro", err.Error())
+	}
+
+	prompts, err := h.BUsecase.GetPrompts(id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener prompts", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Prompts obtenidos correctamente", "", prompts)
+}
+
+func (h *BotHandler) CreatePromptVersion(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al guardar prompt", "Error parametro", err.Error())
+	}
+
+	var body struct {
+		Template string `json:"template"`
+	}
+
+	if err := c.BodyParser(&body); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al guardar prompt", "Error de parseo", err.Error())
+	}
+
+	prompt, err := h.BUsecase.CreatePromptVersion(id, body.Template)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al guardar prompt", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusCreated, "Versión de prompt creada correctamente", "", prompt)
+}
+
+func (h *BotHandler) ActivatePromptVersion(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al activar prompt", "Error parametro", err.Error())
+	}
+
+	version, err := c.ParamsInt("version")
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al activar prompt", "Error parametro", err.Error())
+	}
+
+	if err := h.BUsecase.ActivatePromptVersion(id, version); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al activar prompt", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Versión de prompt activada correctamente", "", nil)
+}
+
+func (h *BotHandler) PreviewPrompt(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al previsualizar prompt", "Error parametro", err.Error())
+	}
+
+	grupoId := c.QueryInt("grupoId", 0)
+	if grupoId <= 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al previsualizar prompt", "Error parametro", "El parámetro grupoId es requerido")
+	}
+
+	version := c.QueryInt("version", 0)
+
+	rendered, err := h.BUsecase.PreviewPrompt(id, uint64(grupoId), version)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al previsualizar prompt", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Prompt renderizado correctamente", "", map[string]string{
+		"prompt": rendered,
+	})
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type postgresBotRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresBotRepository(db *gorm.DB) domain.BotRepository {
+	return &postgresBotRepository{db: db}
+}
+
+func mapGormToDomainBot(gormBot *models.Bots) *domain.Bot {
+	if gormBot == nil {
+		return nil
+	}
+
+	domainBot := &domain.Bot{
+		Id:                   gormBot.Id,
+		UsuarioId:            gormBot.UsuarioId,
+		BaseURL:              gormBot.BaseURL,
+		ModelName:            gormBot.ModelName,
+		ProviderType:         gormBot.ProviderType,
+		APIKeyRef:            gormBot.APIKeyRef,
+		Prompt:               gormBot.Prompt,
+		PromptVersion:        gormBot.PromptVersion,
+		HistoryFormat:        gormBot.HistoryFormat,
+		IsPromt:              gormBot.IsPromt,
+		Temperature:          gormBot.Temperature,
+		MaxTokens:            gormBot.MaxTokens,
+		Stop:                 gormBot.Stop,
+		TimeoutSeconds:       gormBot.TimeoutSeconds,
+		Workers:              gormBot.Workers,
+		DebounceMs:           gormBot.DebounceMs,
+		Stream:               gormBot.Stream,
+		IdleAfterSeconds:     gormBot.IdleAfterSeconds,
+		MaxIdleMessages:      gormBot.MaxIdleMessages,
+		SoloMenciones:        gormBot.SoloMenciones,
+		ContextTokens:        gormBot.ContextTokens,
+		HistoryMessages:      gormBot.HistoryMessages,
+		StructuredOutput:     gormBot.StructuredOutput,
+		Fallbacks:            mapGormToDomainFallbacks(gormBot.Fallbacks),
+		MaxTokensDia:         gormBot.MaxTokensDia,
+		Recuerdos:            gormBot.Recuerdos,
+		Herramientas:         gormBot.Herramientas,
+		MaxPasosHerramientas: gormBot.MaxPasosHerramientas,
+		UsarCache:            gormBot.UsarCache,
+		IsActive:             gormBot.IsActive,
+		CreatedAt:            gormBot.CreatedAt,
+		UpdatedAt:            gormBot.UpdatedAt,
+	}
+
+	if gormBot.Usuario.Id != 0 {
+		domainBot.Usuario = &domain.Usuario{
+			Id:     gormBot.Usuario.Id,
+			Nombre: gormBot.Usuario.Nombre,
+			Apodo:  gormBot.Usuario.Apodo,
+			Email:  gormBot.Usuario.Email,
+			IsLlm:  gormBot.Usuario.IsLlm,
+		}
+	}
+
+	return domainBot
+}
+
+func mapDomainToGormBot(domainBot *domain.Bot) *models.Bots {
+	if domainBot == nil {
+		return nil
+	}
+	return &models.Bots{
+		Id:                   domainBot.Id,
+		UsuarioId:            domainBot.UsuarioId,
+		BaseURL:              domainBot.BaseURL,
+		ModelName:            domainBot.ModelName,
+		ProviderType:         domainBot.ProviderType,
+		APIKeyRef:            domainBot.APIKeyRef,
+		Prompt:               domainBot.Prompt,
+		PromptVersion:        domainBot.PromptVersion,
+		HistoryFormat:        domainBot.HistoryFormat,
+		IsPromt:              domainBot.IsPromt,
+		Temperature:          domainBot.Temperature,
+		MaxTokens:            domainBot.MaxTokens,
+		Stop:                 domainBot.Stop,
+		TimeoutSeconds:       domainBot.TimeoutSeconds,
+		Workers:              domainBot.Workers,
+		DebounceMs:           domainBot.DebounceMs,
+		Stream:               domainBot.Stream,
+		IdleAfterSeconds:     domainBot.IdleAfterSeconds,
+		MaxIdleMessages:      domainBot.MaxIdleMessages,
+		SoloMenciones:        domainBot.SoloMenciones,
+		ContextTokens:        domainBot.ContextTokens,
+		HistoryMessages:      domainBot.HistoryMessages,
+		StructuredOutput:     domainBot.StructuredOutput,
+		Fallbacks:            mapDomainToGormFallbacks(domainBot.Fallbacks),
+		MaxTokensDia:         domainBot.MaxTokensDia,
+		Recuerdos:            domainBot.Recuerdos,
+		Herramientas:         domainBot.Herramientas,
+		MaxPasosHerramientas: domainBot.MaxPasosHerramientas,
+		UsarCache:            domainBot.UsarCache,
+		IsActive:             domainBot.IsActive,
+	}
+}
+
+func mapGormToDomainFallbacks(gormFallbacks []models.BotFallback) []domain.BotFallback {
+	fallbacks := make([]domain.BotFallback, 0, len(gormFallbacks))
+	for _, f := range gormFallbacks {
+		fallbacks = append(fallbacks, domain.BotFallback{
+			ProviderType: f.ProviderType,
+			BaseURL:      f.BaseURL,
+			ModelName:    f.ModelName,
+			APIKeyRef:    f.APIKeyRef,
+		})
+	}
+	return fallbacks
+}
+
+func mapDomainToGormFallbacks(domainFallbacks []domain.BotFallback) []models.BotFallback {
+	fallbacks := make([]models.BotFallback, 0, len(domainFallbacks))
+	for _, f := range domainFallbacks {
+		fallbacks = append(fallbacks, models.BotFallback{
+			ProviderType: f.ProviderType,
+			BaseURL:      f.BaseURL,
+			ModelName:    f.ModelName,
+			APIKeyRef:    f.APIKeyRef,
+		})
+	}
+	return fallbacks
+}
+
+func (r *postgresBotRepository) usuarioPreload(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "nombre", "apodo", "email", "is_llm")
+}
+
+func (r *postgresBotRepository) GetAll() ([]domain.Bot, error) {
+	var gormBots []models.Bots
+
+	if err := r.db.Preload("Usuario", r.usuarioPreload).Order("id asc").Find(&gormBots).Error; err != nil {
+		return nil, err
+	}
+
+	bots := []domain.Bot{}
+	for _, gb := range gormBots {
+		bots = append(bots, *mapGormToDomainBot(&gb))
+	}
+
+	return bots, nil
+}
+
+func (r *postgresBotRepository) GetAllActive() ([]domain.Bot, error) {
+	var gormBots []models.Bots
+
+	err := r.db.
+		Preload("Usuario", r.usuarioPreload).
+		Where("is_active = ?", true).
+		Order("id asc").
+		Find(&gormBots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	bots := []domain.Bot{}
+	for _, gb := range gormBots {
+		bots = append(bots, *mapGormToDomainBot(&gb))
+	}
+
+	return bots, nil
+}
+
+func (r *postgresBotRepository) GetById(id uint64) (*domain.Bot, error) {
+	var gormBot models.Bots
+
+	if err := r.db.Preload("Usuario", r.usuarioPreload).First(&gormBot, id).Error; err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainBot(&gormBot), nil
+}
+
+func (r *postgresBotRepository) GetByUsuarioId(usuarioId uint64) (*domain.Bot, int, error) {
+	var gormBot models.Bots
+
+	err := r.db.Preload("Usuario", r.usuarioPreload).Where("id_usuario = ?", usuarioId).First(&gormBot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 404, nil
+		}
+		return nil, 500, fmt.Errorf("error al buscar bot por usuario: %w", err)
+	}
+
+	return mapGormToDomainBot(&gormBot), 200, nil
+}
+
+func (r *postgresBotRepository) Create(bot *domain.Bot) error {
+	gormBot := mapDomainToGormBot(bot)
+	if err := r.db.Create(gormBot).Error; err != nil {
+		return err
+	}
+
+	bot.Id = gormBot.Id
+	bot.CreatedAt = gormBot.CreatedAt
+	bot.UpdatedAt = gormBot.UpdatedAt
+	return nil
+}
+
+func (r *postgresBotRepository) Update(id uint64, bot *domain.Bot) error {
+	var existingGormBot models.Bots
+	if err := r.db.First(&existingGormBot, id).Error; err != nil {
+		return err
+	}
+
+	existingGormBot.BaseURL = bot.BaseURL
+	existingGormBot.ModelName = bot.ModelName
+	existingGormBot.ProviderType = bot.ProviderType
+	existingGormBot.APIKeyRef = bot.APIKeyRef
+	existingGormBot.IsPromt = bot.IsPromt
+	existingGormBot.HistoryFormat = bot.HistoryFormat
+	existingGormBot.Temperature = bot.Temperature
+	existingGormBot.MaxTokens = bot.MaxTokens
+	existingGormBot.Stop = bot.Stop
+	existingGormBot.TimeoutSeconds = bot.TimeoutSeconds
+	existingGormBot.Workers = bot.Workers
+	existingGormBot.DebounceMs = bot.DebounceMs
+	existingGormBot.Stream = bot.Stream
+	existingGormBot.IdleAfterSeconds = bot.IdleAfterSeconds
+	existingGormBot.MaxIdleMessages = bot.MaxIdleMessages
+	existingGormBot.SoloMenciones = bot.SoloMenciones
+	existingGormBot.ContextTokens = bot.ContextTokens
+	existingGormBot.HistoryMessages = bot.HistoryMessages
+	existingGormBot.StructuredOutput = bot.StructuredOutput
+	existingGormBot.Fallbacks = mapDomainToGormFallbacks(bot.Fallbacks)
+	existingGormBot.MaxTokensDia = bot.MaxTokensDia
+	existingGormBot.Recuerdos = bot.Recuerdos
+	existingGormBot.Herramientas = bot.Herramientas
+	existingGormBot.MaxPasosHerramientas = bot.MaxPasosHerramientas
+	existingGormBot.UsarCache = bot.UsarCache
+	existingGormBot.IsActive = bot.IsActive
+
+	return r.db.Save(&existingGormBot).Error
+}
+
+func (r *postgresBotRepository) Delete(id uint64) error {
+	return r.db.Delete(&models.Bots{}, id).Error
+}
+
+func mapGormToDomainBotPrompt(gormPrompt *models.BotPrompts) *domain.BotPrompt {
+	if gormPrompt == nil {
+		return nil
+	}
+	return &domain.BotPrompt{
+		Id:        gormPrompt.Id,
+		BotId:     gormPrompt.BotId,
+		Version:   gormPrompt.Version,
+		Template:  gormPrompt.Template,
+		CreatedAt: gormPrompt.CreatedAt,
+	}
+}
+
+func (r *postgresBotRepository) GetPrompts(botId uint64) ([]domain.BotPrompt, error) {
+	var gormBot models.Bots
+	if err := r.db.Select("id", "prompt_version").First(&gormBot, botId).Error; err != nil {
+		return nil, err
+	}
+
+	var gormPrompts []models.BotPrompts
+	if err := r.db.Where("id_bot = ?", botId).Order("version desc").Find(&gormPrompts).Error; err != nil {
+		return nil, err
+	}
+
+	prompts := []domain.BotPrompt{}
+	for _, gp := range gormPrompts {
+		prompt := mapGormToDomainBotPrompt(&gp)
+		prompt.Activa = gp.Version == gormBot.PromptVersion
+		prompts = append(prompts, *prompt)
+	}
+
+	return prompts, nil
+}
+
+func (r *postgresBotRepository) GetPrompt(botId uint64, version int) (*domain.BotPrompt, error) {
+	var gormPrompt models.BotPrompts
+
+	err := r.db.Where("id_bot = ? AND version = ?", botId, version).First(&gormPrompt).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainBotPrompt(&gormPrompt), nil
+}
+
+// CreatePrompt guarda una nueva versión y la deja como prompt activo del bot
+func (r *postgresBotRepository) CreatePrompt(prompt *domain.BotPrompt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var lastVersion int
+		err := tx.Model(&models.BotPrompts{}).
+			Where("id_bot = ?", prompt.BotId).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&lastVersion).Error
+		if err != nil {
+			return err
+		}
+
+		gormPrompt := &models.BotPrompts{
+			BotId:    prompt.BotId,
+			Version:  lastVersion + 1,
+			Template: prompt.Template,
+		}
+		if err := tx.Create(gormPrompt).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Bots{}).
+			Where("id = ?", prompt.BotId).
+			Updates(map[string]any{"prompt": gormPrompt.Template, "prompt_version": gormPrompt.Version}).Error
+		if err != nil {
+			return err
+		}
+
+		prompt.Id = gormPrompt.Id
+		prompt.Version = gormPrompt.Version
+		prompt.CreatedAt = gormPrompt.CreatedAt
+		prompt.Activa = true
+		return nil
+	})
+}
+
+// ActivatePrompt vuelve a dejar activa una versión existente del prompt
+func (r *postgresBotRepository) ActivatePrompt(botId uint64, version int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var gormPrompt models.BotPrompts
+		if err := tx.Where("id_bot = ? AND version = ?", botId, version).First(&gormPrompt).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Bots{}).
+			Where("id = ?", botId).
+			Updates(map[string]any{"prompt": gormPrompt.Template, "prompt_version": gormPrompt.Version}).Error
+	})
+}
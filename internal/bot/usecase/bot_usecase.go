@@ -0,0 +1,528 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/ia"
+	"chatvis-chat/internal/llm"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeoutSeconds  = 30
+	defaultWorkers         = 5
+	defaultDebounceMs      = 1500
+	defaultMaxIdleMessages = 1
+	defaultHistoryMessages = 40
+	// maxRecuerdos limita los mensajes recuperados por búsqueda semántica en cada prompt
+	maxRecuerdos = 20
+	// Rondas de llamadas a herramientas por respuesta
+	defaultMaxPasosHerramientas = 3
+	maxPasosHerramientas        = 10
+)
+
+type botUseCase struct {
+	repo        domain.BotRepository
+	repoUsuario domain.UsuarioRepository
+	repoGrupo   domain.GrupoRepository
+	runtime     domain.BotRuntime
+}
+
+// NewBotUseCase crea el caso de uso de bots. runtime puede ser nil si la IA está deshabilitada.
+func NewBotUseCase(repo domain.BotRepository, repoUsuario domain.UsuarioRepository, repoGrupo domain.GrupoRepository, runtime domain.BotRuntime) domain.BotUseCase {
+	return &botUseCase{
+		repo:        repo,
+		repoUsuario: repoUsuario,
+		repoGrupo:   repoGrupo,
+		runtime:     runtime,
+	}
+}
+
+func (u *botUseCase) withRuntimeState(bots []domain.Bot) []domain.Bot {
+	if u.runtime == nil {
+		return bots
+	}
+	for i := range bots {
+		bots[i].EnEjecucion = u.runtime.IsRunning(bots[i].UsuarioId)
+	}
+	return bots
+}
+
+func (u *botUseCase) GetAll() ([]domain.Bot, error) {
+	bots, err := u.repo.GetAll()
+	if err != nil {
+		return nil, errors.New("error al obtener los bots: " + err.Error())
+	}
+	return u.withRuntimeState(bots), nil
+}
+
+func (u *botUseCase) GetAllActive() ([]domain.Bot, error) {
+	bots, err := u.repo.GetAllActive()
+	if err != nil {
+		return nil, errors.New("error al obtener los bots activos: " + err.Error())
+	}
+	return u.withRuntimeState(bots), nil
+}
+
+func (u *botUseCase) GetById(id uint64) (*domain.Bot, error) {
+	if id <= 0 {
+		return nil, errors.New("el ID del bot debe ser mayor que cero")
+	}
+
+	bot, err := u.repo.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.runtime != nil {
+		bot.EnEjecucion = u.runtime.IsRunning(bot.UsuarioId)
+	}
+	return bot, nil
+}
+
+func (u *botUseCase) validate(bot *domain.Bot) error {
+	if bot == nil {
+		return errors.New("el bot no puede ser nulo")
+	}
+
+	if len(strings.TrimSpace(bot.BaseURL)) == 0 {
+		return errors.New("la URL base del bot no puede estar vacía")
+	}
+
+	if len(strings.TrimSpace(bot.ModelName)) == 0 {
+		return errors.New("el nombre del modelo no puede estar vacío")
+	}
+
+	if !llm.IsValidProviderType(llm.ProviderType(bot.ProviderType)) {
+		return fmt.Errorf("tipo de proveedor no soportado: %q", bot.ProviderType)
+	}
+
+	if bot.Temperature < 0 || bot.Temperature > 2 {
+		return errors.New("la temperatura debe estar entre 0 y 2")
+	}
+
+	if bot.MaxTokens < 0 {
+		return errors.New("el máximo de tokens no puede ser negativo")
+	}
+
+	switch bot.HistoryFormat {
+	case "":
+		bot.HistoryFormat = ia.HistoryFormatInline
+	case ia.HistoryFormatInline, ia.HistoryFormatJSON:
+	default:
+		return fmt.Errorf("formato de historial no soportado: %q", bot.HistoryFormat)
+	}
+
+	if bot.TimeoutSeconds <= 0 {
+		bot.TimeoutSeconds = defaultTimeoutSeconds
+	}
+
+	if bot.Workers <= 0 {
+		bot.Workers = defaultWorkers
+	}
+
+	if bot.DebounceMs <= 0 {
+		bot.DebounceMs = defaultDebounceMs
+	}
+
+	if bot.IdleAfterSeconds < 0 {
+		return errors.New("idleAfterSeconds no puede ser negativo")
+	}
+
+	if bot.MaxIdleMessages <= 0 {
+		bot.MaxIdleMessages = defaultMaxIdleMessages
+	}
+
+	if bot.ContextTokens < 0 {
+		return errors.New("contextTokens no puede ser negativo")
+	}
+
+	if bot.MaxTokensDia < 0 {
+		return errors.New("maxTokensDia no puede ser negativo")
+	}
+
+	if bot.Recuerdos < 0 || bot.Recuerdos > maxRecuerdos {
+		return fmt.Errorf("recuerdos debe estar entre 0 y %d", maxRecuerdos)
+	}
+
+	if bot.HistoryMessages <= 0 {
+		bot.HistoryMessages = defaultHistoryMessages
+	}
+
+	if err := validateHerramientas(bot); err != nil {
+		return err
+	}
+
+	for i, fallback := range bot.Fallbacks {
+		if len(strings.TrimSpace(fallback.BaseURL)) == 0 && fallback.ProviderType != string(llm.ProviderLMStudio) {
+			return fmt.Errorf("la URL base del respaldo %d no puede estar vacía", i+1)
+		}
+		if len(strings.TrimSpace(fallback.ModelName)) == 0 {
+			return fmt.Errorf("el nombre del modelo del respaldo %d no puede estar vacío", i+1)
+		}
+		if !llm.IsValidProviderType(llm.ProviderType(fallback.ProviderType)) {
+			return fmt.Errorf("tipo de proveedor no soportado en el respaldo %d: %q", i+1, fallback.ProviderType)
+		}
+	}
+
+	if _, err := ia.ParsePromptTemplate(bot.Prompt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *botUseCase) Create(bot *domain.Bot) error {
+	if err := u.validate(bot); err != nil {
+		return err
+	}
+
+	if bot.UsuarioId <= 0 {
+		return errors.New("el ID del usuario del bot debe ser mayor que cero")
+	}
+
+	usuario, err := u.repoUsuario.GetById(bot.UsuarioId)
+	if err != nil {
+		return fmt.Errorf("error al obtener el usuario del bot: %w", err)
+	}
+
+	if !usuario.IsLlm {
+		return errors.New("el usuario asociado al bot debe tener IsLlm=true")
+	}
+
+	existingBot, code, err := u.repo.GetByUsuarioId(bot.UsuarioId)
+	if err != nil && code != 404 {
+		return err
+	}
+
+	if existingBot != nil {
+		return errors.New("ya existe un bot configurado para ese usuario")
+	}
+
+	if err := u.repo.Create(bot); err != nil {
+		return fmt.Errorf("error al crear el bot: %w", err)
+	}
+
+	if strings.TrimSpace(bot.Prompt) != "" {
+		prompt := &domain.BotPrompt{BotId: bot.Id, Template: bot.Prompt}
+		if err := u.repo.CreatePrompt(prompt); err != nil {
+			return fmt.Errorf("error al guardar la primera versión del prompt: %w", err)
+		}
+		bot.PromptVersion = prompt.Version
+	}
+
+	if bot.IsActive && u.runtime != nil {
+		if err := u.runtime.StartBot(*bot); err != nil {
+			return fmt.Errorf("bot creado pero no se pudo iniciar: %w", err)
+		}
+		bot.EnEjecucion = true
+	}
+
+	return nil
+}
+
+func (u *botUseCase) Update(id uint64, bot *domain.Bot) error {
+	if id <= 0 {
+		return errors.New("el ID del bot debe ser mayor que cero")
+	}
+
+	if err := u.validate(bot); err != nil {
+		return err
+	}
+
+	if err := u.repo.Update(id, bot); err != nil {
+		return fmt.Errorf("error al actualizar el bot: %w", err)
+	}
+
+	updated, err := u.repo.GetById(id)
+	if err != nil {
+		return err
+	}
+	*bot = *updated
+
+	if u.runtime == nil {
+		return nil
+	}
+
+	switch {
+	case !bot.IsActive && u.runtime.IsRunning(bot.UsuarioId):
+		err = u.runtime.StopBot(bot.UsuarioId)
+	case bot.IsActive:
+		err = u.runtime.ReloadBot(*bot)
+	}
+	if err != nil {
+		return fmt.Errorf("bot actualizado pero no se pudo aplicar en ejecución: %w", err)
+	}
+
+	bot.EnEjecucion = u.runtime.IsRunning(bot.UsuarioId)
+	return nil
+}
+
+func (u *botUseCase) Delete(id uint64) error {
+	bot, err := u.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	if u.runtime != nil && u.runtime.IsRunning(bot.UsuarioId) {
+		if err := u.runtime.StopBot(bot.UsuarioId); err != nil {
+			return fmt.Errorf("error al detener el bot: %w", err)
+		}
+	}
+
+	return u.repo.Delete(id)
+}
+
+func (u *botUseCase) requireRuntime() error {
+	if u.runtime == nil {
+		return errors.New("los servicios de IA están deshabilitados (ENABLE_AI_MODELS)")
+	}
+	return nil
+}
+
+func (u *botUseCase) Start(id uint64) error {
+	if err := u.requireRuntime(); err != nil {
+		return err
+	}
+
+	bot, err := u.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	if bot.EnEjecucion {
+		return errors.New("el bot ya está en ejecución")
+	}
+
+	return u.runtime.StartBot(*bot)
+}
+
+func (u *botUseCase) Stop(id uint64) error {
+	if err := u.requireRuntime(); err != nil {
+		return err
+	}
+
+	bot, err := u.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	if !bot.EnEjecucion {
+		return errors.New("el bot no está en ejecución")
+	}
+
+	return u.runtime.StopBot(bot.UsuarioId)
+}
+
+func (u *botUseCase) Reload(id uint64) error {
+	if err := u.requireRuntime(); err != nil {
+		return err
+	}
+
+	bot, err := u.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return u.runtime.ReloadBot(*bot)
+}
+
+func (u *botUseCase) GetPrompts(id uint64) ([]domain.BotPrompt, error) {
+	if id <= 0 {
+		return nil, errors.New("el ID del bot debe ser mayor que cero")
+	}
+
+	return u.repo.GetPrompts(id)
+}
+
+// reloadIfRunning aplica los cambios de prompt al AIService en ejecución
+func (u *botUseCase) reloadIfRunning(id uint64) error {
+	if u.runtime == nil {
+		return nil
+	}
+
+	bot, err := u.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	if !bot.EnEjecucion {
+		return nil
+	}
+
+	return u.runtime.ReloadBot(*bot)
+}
+
+func (u *botUseCase) CreatePromptVersion(id uint64, template string) (*domain.BotPrompt, error) {
+	if id <= 0 {
+		return nil, errors.New("el ID del bot debe ser mayor que cero")
+	}
+
+	if len(strings.TrimSpace(template)) == 0 {
+		return nil, errors.New("la plantilla del prompt no puede estar vacía")
+	}
+
+	if _, err := ia.ParsePromptTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.repo.GetById(id); err != nil {
+		return nil, err
+	}
+
+	prompt := &domain.BotPrompt{BotId: id, Template: template}
+	if err := u.repo.CreatePrompt(prompt); err != nil {
+		return nil, fmt.Errorf("error al guardar la versión del prompt: %w", err)
+	}
+
+	if err := u.reloadIfRunning(id); err != nil {
+		return prompt, fmt.Errorf("prompt guardado pero no se pudo recargar el bot: %w", err)
+	}
+
+	return prompt, nil
+}
+
+func (u *botUseCase) ActivatePromptVersion(id uint64, version int) error {
+	if id <= 0 {
+		return errors.New("el ID del bot debe ser mayor que cero")
+	}
+
+	if version <= 0 {
+		return errors.New("la versión del prompt debe ser mayor que cero")
+	}
+
+	if err := u.repo.ActivatePrompt(id, version); err != nil {
+		return fmt.Errorf("error al activar la versión del prompt: %w", err)
+	}
+
+	if err := u.reloadIfRunning(id); err != nil {
+		return fmt.Errorf("versión activada pero no se pudo recargar el bot: %w", err)
+	}
+
+	return nil
+}
+
+// PreviewPrompt renderiza el prompt del bot para un grupo. Con version = 0 usa la versión activa.
+func (u *botUseCase) PreviewPrompt(id uint64, grupoId uint64, version int) (string, error) {
+	if grupoId <= 0 {
+		return "", errors.New("el ID del grupo debe ser mayor que cero")
+	}
+
+	bot, err := u.GetById(id)
+	if err != nil {
+		return "", err
+	}
+
+	text := bot.Prompt
+	if version > 0 {
+		prompt, err := u.repo.GetPrompt(id, version)
+		if err != nil {
+			return "", fmt.Errorf("error al obtener la versión %d del prompt: %w", version, err)
+		}
+		text = prompt.Template
+	}
+
+	tmpl, err := ia.ParsePromptTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener el grupo: %w", err)
+	}
+
+	miembros, err := u.repoUsuario.GetAllByGrupoId(grupoId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener los integrantes del grupo: %w", err)
+	}
+
+	botUser, err := u.repoUsuario.GetById(bot.UsuarioId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener el usuario del bot: %w", err)
+	}
+
+	return ia.RenderPrompt(tmpl, ia.NewPromptData(grupo, miembros, botUser, time.Now()))
+}
+
+// GetLLMHealth devuelve el estado del circuit breaker de cada servidor de modelos usado
+func (u *botUseCase) GetLLMHealth() []domain.EndpointLLM {
+	statuses := llm.BreakerStatuses()
+	endpoints := make([]domain.EndpointLLM, 0, len(statuses))
+	for _, status := range statuses {
+		endpoint := domain.EndpointLLM{
+			Endpoint:       status.Endpoint,
+			Estado:         status.State,
+			FallosSeguidos: status.Failures,
+			UltimoError:    status.LastError,
+		}
+		if !status.LastFailure.IsZero() {
+			endpoint.UltimoFallo = &status.LastFailure
+		}
+		if !status.OpenedAt.IsZero() {
+			endpoint.AbiertoDesde = &status.OpenedAt
+			endpoint.ReintentoDesde = &status.RetryAvailable
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// GetLLMCache devuelve las métricas de la caché de respuestas. Aciertos incluye los obtenidos de
+// la tabla persistente, que también se informan por separado.
+func (u *botUseCase) GetLLMCache() domain.CacheLLM {
+	stats := llm.ResponseCacheStats()
+	cache := domain.CacheLLM{
+		Habilitada:           stats.Enabled,
+		Persistente:          stats.Persistent,
+		TTLSegundos:          int(stats.TTL.Seconds()),
+		Entradas:             stats.Entries,
+		MaxEntradas:          stats.MaxEntries,
+		Aciertos:             stats.MemoryHits + stats.StoreHits,
+		AciertosPersistentes: stats.StoreHits,
+		Fallos:               stats.Misses,
+		Desalojos:            stats.Evictions,
+		TasaAcierto:          stats.HitRate(),
+		Modelos:              make([]domain.CacheModeloLLM, 0, len(stats.Models)),
+	}
+	for _, model := range stats.Models {
+		cache.Modelos = append(cache.Modelos, domain.CacheModeloLLM{
+			Proveedor: model.Provider,
+			Modelo:    model.Model,
+			Aciertos:  model.Hits,
+			Fallos:    model.Misses,
+		})
+	}
+	return cache
+}
+
+// validateHerramientas verifica que las herramientas existan y que el proveedor pueda usarlas
+func validateHerramientas(bot *domain.Bot) error {
+	herramientas := make([]string, 0, len(bot.Herramientas))
+	for _, nombre := range bot.Herramientas {
+		nombre = strings.TrimSpace(nombre)
+		if !ia.IsKnownTool(nombre) {
+			return fmt.Errorf("herramienta desconocida: %q (disponibles: %s)", nombre, strings.Join(ia.ToolNames(), ", "))
+		}
+		if !slices.Contains(herramientas, nombre) {
+			herramientas = append(herramientas, nombre)
+		}
+	}
+	bot.Herramientas = herramientas
+
+	if len(herramientas) > 0 && !llm.SupportsTools(llm.ProviderType(bot.ProviderType)) {
+		return fmt.Errorf("el proveedor %q no admite herramientas", bot.ProviderType)
+	}
+
+	if bot.MaxPasosHerramientas <= 0 {
+		bot.MaxPasosHerramientas = defaultMaxPasosHerramientas
+	}
+	if bot.MaxPasosHerramientas > maxPasosHerramientas {
+		return fmt.Errorf("maxPasosHerramientas no puede ser mayor que %d", maxPasosHerramientas)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresCacheIARepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresCacheIARepository(db *gorm.DB) domain.CacheIARepository {
+	return &postgresCacheIARepository{db: db}
+}
+
+func mapGormToDomainRespuestaCache(gormRespuesta *models.RespuestasCacheIA) *domain.RespuestaCacheIA {
+	if gormRespuesta == nil {
+		return nil
+	}
+
+	return &domain.RespuestaCacheIA{
+		Clave:            gormRespuesta.Clave,
+		Proveedor:        gormRespuesta.Proveedor,
+		Modelo:           gormRespuesta.Modelo,
+		Contenido:        gormRespuesta.Contenido,
+		PromptTokens:     gormRespuesta.PromptTokens,
+		CompletionTokens: gormRespuesta.CompletionTokens,
+		ExpiraEn:         gormRespuesta.ExpiraEn,
+		CreatedAt:        gormRespuesta.CreatedAt,
+	}
+}
+
+func (r *postgresCacheIARepository) GetVigente(clave string, ahora time.Time) (*domain.RespuestaCacheIA, int, error) {
+	var gormRespuesta models.RespuestasCacheIA
+
+	err := r.db.Where("clave = ? AND expira_en > ?", clave, ahora).First(&gormRespuesta).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 404, nil
+		}
+		return nil, 500, fmt.Errorf("error al buscar la respuesta en caché: %w", err)
+	}
+
+	return mapGormToDomainRespuestaCache(&gormRespuesta), 200, nil
+}
+
+func (r *postgresCacheIARepository) Save(respuesta *domain.RespuestaCacheIA) error {
+	gormRespuesta := models.RespuestasCacheIA{
+		Clave:            respuesta.Clave,
+		Proveedor:        respuesta.Proveedor,
+		Modelo:           respuesta.Modelo,
+		Contenido:        respuesta.Contenido,
+		PromptTokens:     respuesta.PromptTokens,
+		CompletionTokens: respuesta.CompletionTokens,
+		ExpiraEn:         respuesta.ExpiraEn,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "clave"}},
+		DoUpdates: clause.AssignmentColumns([]string{"contenido", "prompt_tokens", "completion_tokens", "expira_en", "created_at"}),
+	}).Create(&gormRespuesta).Error
+	if err != nil {
+		return err
+	}
+
+	respuesta.CreatedAt = gormRespuesta.CreatedAt
+	return nil
+}
+
+func (r *postgresCacheIARepository) DeleteVencidas(ahora time.Time) (int64, error) {
+	result := r.db.Where("expira_en <= ?", ahora).Delete(&models.RespuestasCacheIA{})
+	return result.RowsAffected, result.Error
+}
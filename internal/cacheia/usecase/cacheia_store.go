@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"log"
+	"sync"
+	"time"
+)
+
+// intervaloLimpieza es cada cuánto se borran de la tabla las respuestas vencidas
+const intervaloLimpieza = time.Hour
+
+// cacheStore guarda la caché de respuestas de internal/llm en la tabla respuestas_cache_ia
+type cacheStore struct {
+	repo domain.CacheIARepository
+
+	mu             sync.Mutex
+	ultimaLimpieza time.Time
+}
+
+// NewCacheStore devuelve el almacenamiento persistente para llm.ConfigureCache
+func NewCacheStore(repo domain.CacheIARepository) llm.CacheStore {
+	return &cacheStore{repo: repo, ultimaLimpieza: time.Now()}
+}
+
+func (s *cacheStore) Get(key string) (*llm.CachedCompletion, error) {
+	respuesta, status, err := s.repo.GetVigente(key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if status == 404 {
+		return nil, nil
+	}
+
+	return &llm.CachedCompletion{
+		Key:      respuesta.Clave,
+		Provider: respuesta.Proveedor,
+		Model:    respuesta.Modelo,
+		Content:  respuesta.Contenido,
+		Usage: llm.Usage{
+			PromptTokens:     respuesta.PromptTokens,
+			CompletionTokens: respuesta.CompletionTokens,
+			TotalTokens:      respuesta.PromptTokens + respuesta.CompletionTokens,
+		},
+		ExpiresAt: respuesta.ExpiraEn,
+	}, nil
+}
+
+// Save guarda la respuesta y, como mucho una vez por intervaloLimpieza, borra las vencidas
+func (s *cacheStore) Save(entry llm.CachedCompletion) error {
+	err := s.repo.Save(&domain.RespuestaCacheIA{
+		Clave:            entry.Key,
+		Proveedor:        entry.Provider,
+		Modelo:           entry.Model,
+		Contenido:        entry.Content,
+		PromptTokens:     entry.Usage.PromptTokens,
+		CompletionTokens: entry.Usage.CompletionTokens,
+		ExpiraEn:         entry.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.limpiar()
+	return nil
+}
+
+func (s *cacheStore) limpiar() {
+	ahora := time.Now()
+
+	s.mu.Lock()
+	if ahora.Sub(s.ultimaLimpieza) < intervaloLimpieza {
+		s.mu.Unlock()
+		return
+	}
+	s.ultimaLimpieza = ahora
+	s.mu.Unlock()
+
+	borradas, err := s.repo.DeleteVencidas(ahora)
+	if err != nil {
+		log.Printf("Caché de respuestas: error al borrar las respuestas vencidas: %v", err)
+		return
+	}
+	if borradas > 0 {
+		log.Printf("Caché de respuestas: %d respuestas vencidas borradas", borradas)
+	}
+}
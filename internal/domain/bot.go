@@ -0,0 +1,153 @@
+package domain
+
+import "time"
+
+// Bot representa la configuración persistida de un usuario IA (IsLlm=true)
+type Bot struct {
+	Id               uint64   `json:"id"`
+	UsuarioId        uint64   `json:"usuarioId"`
+	BaseURL          string   `json:"baseUrl"`
+	ModelName        string   `json:"modelName"`
+	ProviderType     string   `json:"providerType"`
+	APIKeyRef        string   `json:"apiKeyRef"`
+	Prompt           string   `json:"prompt"`
+	PromptVersion    int      `json:"promptVersion"`
+	HistoryFormat    string   `json:"historyFormat"`
+	IsPromt          bool     `json:"isPromt"`
+	Temperature      float64  `json:"temperature"`
+	MaxTokens        int      `json:"maxTokens"`
+	Stop             []string `json:"stop"`
+	TimeoutSeconds   int      `json:"timeoutSeconds"`
+	Workers          int      `json:"workers"`
+	DebounceMs       int      `json:"debounceMs"`
+	Stream           bool     `json:"stream"`
+	IdleAfterSeconds int      `json:"idleAfterSeconds"`
+	MaxIdleMessages  int      `json:"maxIdleMessages"`
+	SoloMenciones    bool     `json:"soloMenciones"`
+	ContextTokens    int      `json:"contextTokens"`
+	HistoryMessages  int      `json:"historyMessages"`
+	StructuredOutput bool     `json:"structuredOutput"`
+	// Fallbacks se prueban en orden cuando el proveedor principal no está disponible
+	Fallbacks []BotFallback `json:"fallbacks"`
+	// MaxTokensDia es la cuota diaria de tokens del bot en todos sus grupos (0 = sin límite)
+	MaxTokensDia int `json:"maxTokensDia"`
+	// Recuerdos es la cantidad de mensajes anteriores relevantes que se recuperan por búsqueda
+	// semántica y se agregan al prompt (0 = desactivado)
+	Recuerdos int `json:"recuerdos"`
+	// Herramientas son los nombres de las herramientas que el bot puede invocar y
+	// MaxPasosHerramientas el máximo de rondas de llamadas antes de responder
+	Herramientas         []string `json:"herramientas"`
+	MaxPasosHerramientas int      `json:"maxPasosHerramientas"`
+	// UsarCache responde los prompts repetidos desde la caché de respuestas (ver LLM_CACHE_TTL_SECONDS)
+	UsarCache bool      `json:"usarCache"`
+	IsActive  bool      `json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Estado en tiempo de ejecución, no se persiste
+	EnEjecucion bool `json:"enEjecucion"`
+
+	Usuario *Usuario `json:"usuario,omitempty"`
+}
+
+// BotFallback es un proveedor y modelo alternativo de un bot
+type BotFallback struct {
+	ProviderType string `json:"providerType"`
+	BaseURL      string `json:"baseUrl"`
+	ModelName    string `json:"modelName"`
+	APIKeyRef    string `json:"apiKeyRef"`
+}
+
+// EndpointLLM es el estado del circuit breaker de un servidor de modelos
+type EndpointLLM struct {
+	Endpoint       string     `json:"endpoint"`
+	Estado         string     `json:"estado"`
+	FallosSeguidos int        `json:"fallosSeguidos"`
+	UltimoError    string     `json:"ultimoError,omitempty"`
+	UltimoFallo    *time.Time `json:"ultimoFallo,omitempty"`
+	AbiertoDesde   *time.Time `json:"abiertoDesde,omitempty"`
+	ReintentoDesde *time.Time `json:"reintentoDesde,omitempty"`
+}
+
+// CacheLLM son las métricas de la caché de respuestas de los modelos desde el arranque
+type CacheLLM struct {
+	Habilitada           bool             `json:"habilitada"`
+	Persistente          bool             `json:"persistente"`
+	TTLSegundos          int              `json:"ttlSegundos"`
+	Entradas             int              `json:"entradas"`
+	MaxEntradas          int              `json:"maxEntradas"`
+	Aciertos             int64            `json:"aciertos"`
+	AciertosPersistentes int64            `json:"aciertosPersistentes"`
+	Fallos               int64            `json:"fallos"`
+	Desalojos            int64            `json:"desalojos"`
+	TasaAcierto          float64          `json:"tasaAcierto"`
+	Modelos              []CacheModeloLLM `json:"modelos"`
+}
+
+// CacheModeloLLM son los aciertos y fallos de la caché para un proveedor y modelo
+type CacheModeloLLM struct {
+	Proveedor string `json:"proveedor"`
+	Modelo    string `json:"modelo"`
+	Aciertos  int64  `json:"aciertos"`
+	Fallos    int64  `json:"fallos"`
+}
+
+// BotPrompt es una versión de la plantilla de prompt de sistema de un bot
+type BotPrompt struct {
+	Id        uint64    `json:"id"`
+	BotId     uint64    `json:"botId"`
+	Version   int       `json:"version"`
+	Template  string    `json:"template"`
+	CreatedAt time.Time `json:"createdAt"`
+	Activa    bool      `json:"activa"`
+}
+
+// BotRepository define el acceso a datos de la configuración de bots
+type BotRepository interface {
+	GetAll() ([]Bot, error)
+	GetAllActive() ([]Bot, error)
+	GetById(id uint64) (*Bot, error)
+	GetByUsuarioId(usuarioId uint64) (*Bot, int, error)
+	Create(bot *Bot) error
+	Update(id uint64, bot *Bot) error
+	Delete(id uint64) error
+
+	// Versiones del prompt
+	GetPrompts(botId uint64) ([]BotPrompt, error)
+	GetPrompt(botId uint64, version int) (*BotPrompt, error)
+	CreatePrompt(prompt *BotPrompt) error
+	ActivatePrompt(botId uint64, version int) error
+}
+
+// BotRuntime controla las instancias de IA en ejecución
+type BotRuntime interface {
+	StartBot(bot Bot) error
+	StopBot(usuarioId uint64) error
+	ReloadBot(bot Bot) error
+	IsRunning(usuarioId uint64) bool
+}
+
+// BotUseCase define las reglas de negocio para administrar bots
+type BotUseCase interface {
+	GetAll() ([]Bot, error)
+	GetAllActive() ([]Bot, error)
+	GetById(id uint64) (*Bot, error)
+	Create(bot *Bot) error
+	Update(id uint64, bot *Bot) error
+	Delete(id uint64) error
+
+	Start(id uint64) error
+	Stop(id uint64) error
+	Reload(id uint64) error
+
+	// Versiones del prompt
+	GetPrompts(id uint64) ([]BotPrompt, error)
+	CreatePromptVersion(id uint64, template string) (*BotPrompt, error)
+	ActivatePromptVersion(id uint64, version int) error
+	PreviewPrompt(id uint64, grupoId uint64, version int) (string, error)
+
+	// Estado de los servidores de modelos usados por los bots
+	GetLLMHealth() []EndpointLLM
+	// Métricas de la caché de respuestas
+	GetLLMCache() CacheLLM
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// RespuestaCacheIA es una respuesta de un modelo guardada en la caché persistente. Clave es el
+// hash del proveedor, modelo, prompt normalizado y parámetros de muestreo.
+type RespuestaCacheIA struct {
+	Clave            string    `json:"clave"`
+	Proveedor        string    `json:"proveedor"`
+	Modelo           string    `json:"modelo"`
+	Contenido        string    `json:"contenido"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	ExpiraEn         time.Time `json:"expiraEn"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// CacheIARepository define el acceso a datos de la caché persistente de respuestas
+type CacheIARepository interface {
+	// GetVigente devuelve la respuesta de la clave si todavía no venció (404 si no hay)
+	GetVigente(clave string, ahora time.Time) (*RespuestaCacheIA, int, error)
+	// Save crea o reemplaza la respuesta de la clave
+	Save(respuesta *RespuestaCacheIA) error
+	DeleteVencidas(ahora time.Time) (int64, error)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// EmbeddingMensaje es el vector del contenido de un mensaje calculado con un modelo de embeddings
+type EmbeddingMensaje struct {
+	MensajeId uint64    `json:"mensajeId"`
+	GrupoId   uint64    `json:"grupoId"`
+	Modelo    string    `json:"modelo"`
+	Vector    []float32 `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SimilitudMensaje es un mensaje encontrado por la búsqueda vectorial y su similitud coseno con la consulta
+type SimilitudMensaje struct {
+	MensajeId uint64
+	Similitud float64
+}
+
+// ResultadoBusqueda es un mensaje devuelto por la búsqueda semántica
+type ResultadoBusqueda struct {
+	Mensaje   Mensaje `json:"mensaje"`
+	Similitud float64 `json:"similitud"`
+}
+
+// EmbeddingMensajeRepository define el almacenamiento y la búsqueda de los vectores de los mensajes
+type EmbeddingMensajeRepository interface {
+	Save(embeddings []EmbeddingMensaje) error
+	// GetPendientes devuelve los mensajes más antiguos que aún no tienen vector para el modelo
+	GetPendientes(modelo string, limite int) ([]Mensaje, error)
+	// Search devuelve los mensajes del grupo más parecidos al vector; antesDeId > 0 limita la
+	// búsqueda a los mensajes con ID menor
+	Search(grupoId uint64, modelo string, vector []float32, limite int, antesDeId uint64) ([]SimilitudMensaje, error)
+}
+
+// EmbeddingMensajeUseCase define la indexación y la búsqueda semántica del historial de los grupos
+type EmbeddingMensajeUseCase interface {
+	// IndexarPendientes calcula y guarda los vectores de hasta limite mensajes sin indexar
+	IndexarPendientes(ctx context.Context, limite int) (int, error)
+	// Buscar es la búsqueda de los usuarios: verifica que el usuario pertenezca al grupo
+	Buscar(ctx context.Context, usuarioId uint64, grupoId uint64, consulta string, limite int) ([]ResultadoBusqueda, error)
+	// Relevantes es la búsqueda de los bots sobre los mensajes anteriores a antesDeId
+	Relevantes(ctx context.Context, grupoId uint64, consulta string, limite int, antesDeId uint64) ([]ResultadoBusqueda, error)
+}
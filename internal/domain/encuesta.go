@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Encuesta es una votación de opción única dentro de un grupo
+type Encuesta struct {
+	Id        uint64   `json:"id"`
+	GrupoId   uint64   `json:"grupoId"`
+	UsuarioId uint64   `json:"usuarioId"`
+	Pregunta  string   `json:"pregunta"`
+	Opciones  []string `json:"opciones"`
+	// CierraEn es el momento a partir del cual no se aceptan votos (nil = sin cierre)
+	CierraEn  *time.Time `json:"cierraEn,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+
+	// Votos es la cantidad de votos por opción, en el mismo orden que Opciones
+	Votos []int `json:"votos"`
+}
+
+// VotoEncuesta es el voto de un usuario; Opcion es el índice en Opciones
+type VotoEncuesta struct {
+	EncuestaId uint64 `json:"encuestaId"`
+	UsuarioId  uint64 `json:"usuarioId"`
+	Opcion     int    `json:"opcion"`
+}
+
+// EncuestaRepository define el acceso a datos de las encuestas
+type EncuestaRepository interface {
+	Create(encuesta *Encuesta) error
+	GetById(id uint64) (*Encuesta, error)
+	GetByGrupoId(grupoId uint64) ([]Encuesta, error)
+	// Votar registra o reemplaza el voto del usuario
+	Votar(voto VotoEncuesta) error
+}
+
+// EncuestaUseCase define las reglas de negocio de las encuestas. Todas las operaciones
+// verifican que el usuario pertenezca al grupo de la encuesta.
+type EncuestaUseCase interface {
+	Create(encuesta *Encuesta) error
+	GetById(usuarioId uint64, id uint64) (*Encuesta, error)
+	GetByGrupoId(usuarioId uint64, grupoId uint64) ([]Encuesta, error)
+	Votar(usuarioId uint64, id uint64, opcion int) (*Encuesta, error)
+}
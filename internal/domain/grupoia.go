@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Modos de respuesta de los bots en un grupo
+const (
+	// ModoRespuestaSiempre aplica la configuración propia de cada bot
+	ModoRespuestaSiempre = "siempre"
+	// ModoRespuestaMenciones solo responde cuando mencionan al bot o responden a un mensaje suyo
+	ModoRespuestaMenciones = "menciones"
+	// ModoRespuestaInactividad solo habla por iniciativa propia cuando el grupo queda en silencio
+	ModoRespuestaInactividad = "inactividad"
+	// ModoRespuestaApagado silencia a todos los bots del grupo
+	ModoRespuestaApagado = "apagado"
+)
+
+// GrupoIA es la configuración de los bots dentro de un grupo, definida por su creador
+type GrupoIA struct {
+	Id      uint64 `json:"id"`
+	GrupoId uint64 `json:"grupoId"`
+
+	// BotsHabilitados son los usuarios IA del grupo que pueden responder; vacío habilita a todos
+	BotsHabilitados []uint64 `json:"botsHabilitados"`
+	ModoRespuesta   string   `json:"modoRespuesta"`
+	// Máximo de respuestas por hora entre todos los bots del grupo (0 = sin límite)
+	MaxRespuestasHora int `json:"maxRespuestasHora"`
+	// Persona reemplaza el perfil de los bots en este grupo; vacía usa el de cada bot
+	Persona string `json:"persona"`
+	// Traduccion traduce los mensajes al idioma preferido de cada integrante que lo haya elegido
+	Traduccion bool `json:"traduccion"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+	// PorDefecto indica que el grupo no tiene una configuración guardada
+	PorDefecto bool `json:"porDefecto"`
+}
+
+// BotHabilitado indica si el usuario IA puede responder en el grupo
+func (g GrupoIA) BotHabilitado(usuarioId uint64) bool {
+	return len(g.BotsHabilitados) == 0 || slices.Contains(g.BotsHabilitados, usuarioId)
+}
+
+// GrupoIARepository define el acceso a datos de la configuración de IA de los grupos
+type GrupoIARepository interface {
+	GetByGrupoId(grupoId uint64) (*GrupoIA, int, error)
+	Save(config *GrupoIA) error
+}
+
+// GrupoIAUseCase define las reglas de negocio de la configuración de IA de los grupos
+type GrupoIAUseCase interface {
+	// GetByGrupoId devuelve la configuración vigente del grupo (la guardada o la de por defecto)
+	GetByGrupoId(grupoId uint64) (*GrupoIA, error)
+	// Get devuelve la configuración a un integrante del grupo
+	Get(usuarioId uint64, grupoId uint64) (*GrupoIA, error)
+	// Update guarda la configuración; solo puede hacerlo el creador del grupo
+	Update(usuarioId uint64, grupoId uint64, config *GrupoIA) error
+}
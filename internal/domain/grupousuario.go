@@ -2,8 +2,28 @@ package domain
 
 // GrupoUsuario representa la relación muchos a muchos entre grupos y usuarios
 type GrupoUsuario struct {
-	IdGrupo   uint64 `json:"grupoId"`
-	IdUsuario uint64 `json:"usuarioId"`
+	IdGrupo    uint64 `json:"grupoId"`
+	IdUsuario  uint64 `json:"usuarioId"`
+	Silenciado bool   `json:"silenciado"`
+}
+
+// Tipos de evento de membresía
+const (
+	EventoMembresiaAlta = "alta"
+	EventoMembresiaBaja = "baja"
+)
+
+// EventoMembresia informa que un usuario (humano o bot) entró o salió de un grupo
+type EventoMembresia struct {
+	Tipo       string `json:"tipo"`
+	GrupoId    uint64 `json:"grupoId"`
+	GrupoClave string `json:"grupoClave"`
+	UsuarioId  uint64 `json:"usuarioId"`
+}
+
+// PublicadorMembresia recibe los cambios de membresía para actualizar las suscripciones en vivo
+type PublicadorMembresia interface {
+	PublicarMembresia(evento EventoMembresia)
 }
 
 // GrupoUsuarioRepository permite operar sobre la relación de grupos y usuarios
@@ -12,13 +32,22 @@ type GrupoUsuarioRepository interface {
 	GetByUsuarioId(usuarioId uint64) (*GrupoUsuario, error)
 	VerifyMembership(userId uint64, clave string) (bool, error)
 	Create(grupoUsuario *GrupoUsuario) error
+	// Delete quita al usuario del grupo; devuelve false si no pertenecía
+	Delete(userId uint64, grupoId uint64) (bool, error)
+	SetSilenciado(userId uint64, clave string, silenciado bool) (bool, error)
+	GetClavesSilenciadas(userId uint64) ([]string, error)
 }
 
 // GrupoUsuarioUseCase define las reglas de negocio para la membresía de grupos
 type GrupoUsuarioUseCase interface {
 	JoinGroup(userId uint64, claveGrupo string) error
 	JoinGroups(usersIds []uint64, groupsIds []uint64) error
+	LeaveGroup(userId uint64, grupoId uint64) error
+	LeaveGroups(usersIds []uint64, groupsIds []uint64) error
 	VerifyMembership(userId uint64, clave string) (bool, error)
 	GetUsersByGroupId(grupoId uint64) ([]GrupoUsuario, error)
 	GetByUsuarioId(usuarioId uint64) (*GrupoUsuario, error)
+	// SetSilenciado silencia o reactiva un grupo para el usuario (clave interna del grupo, no la de invitación)
+	SetSilenciado(userId uint64, clave string, silenciado bool) error
+	GetClavesSilenciadas(userId uint64) ([]string, error)
 }
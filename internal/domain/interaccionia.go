@@ -0,0 +1,119 @@
+package domain
+
+import "time"
+
+// Resultados posibles de una interacción de un bot con el modelo
+const (
+	ResultadoEnviada        = "enviada"
+	ResultadoSilencio       = "silencio"
+	ResultadoErrorParseo    = "error_parseo"
+	ResultadoErrorProveedor = "error_proveedor"
+	// ResultadoDescartada: la respuesta era válida pero no se publicó (sin turno o interrumpida)
+	ResultadoDescartada = "descartada"
+	// ResultadoModerada: la moderación bloqueó o retuvo todos los mensajes de la respuesta
+	ResultadoModerada = "moderada"
+)
+
+// InteraccionIA es una ejecución completa de un bot en un grupo: el prompt enviado, la respuesta
+// cruda del modelo, lo que se interpretó de ella y cómo terminó
+type InteraccionIA struct {
+	Id        uint64 `json:"id"`
+	UsuarioId uint64 `json:"usuarioId"`
+	GrupoId   uint64 `json:"grupoId"`
+	// MensajeId es el mensaje que disparó la respuesta (nil en los disparos por inactividad)
+	MensajeId *uint64           `json:"mensajeId,omitempty"`
+	Disparo   string            `json:"disparo"`
+	Proveedor string            `json:"proveedor"`
+	Modelo    string            `json:"modelo"`
+	Prompt    []MensajePromptIA `json:"prompt"`
+	// Respuesta es el texto crudo del modelo y Reparacion el obtenido al pedirle que lo corrija
+	Respuesta  string        `json:"respuesta"`
+	Reparacion string        `json:"reparacion,omitempty"`
+	Mensajes   []RespuestaIA `json:"mensajes"`
+	// Herramientas son las llamadas a herramientas hechas antes de la respuesta final
+	Herramientas []LlamadaHerramienta `json:"herramientas,omitempty"`
+	Resultado    string               `json:"resultado"`
+	Error        string               `json:"error,omitempty"`
+	// DuracionLlmMs es lo que tardó el modelo y DuracionMs la ejecución completa, incluida la entrega
+	DuracionLlmMs int64     `json:"duracionLlmMs"`
+	DuracionMs    int64     `json:"duracionMs"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// MensajePromptIA es un mensaje del prompt tal como se envió al modelo
+type MensajePromptIA struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// RespuestaIA es un mensaje interpretado de la respuesta del modelo
+type RespuestaIA struct {
+	AnswerId string `json:"answerId,omitempty"`
+	Content  string `json:"content"`
+	DelayMs  int64  `json:"delayMs"`
+}
+
+// LlamadaHerramienta registra una invocación de herramienta de un bot
+type LlamadaHerramienta struct {
+	Nombre     string `json:"nombre"`
+	Argumentos string `json:"argumentos"`
+	Resultado  string `json:"resultado"`
+	Error      string `json:"error,omitempty"`
+	DuracionMs int64  `json:"duracionMs"`
+}
+
+// FiltroInteraccionIA acota el listado de interacciones; los campos en cero no filtran
+type FiltroInteraccionIA struct {
+	BotId     uint64
+	GrupoId   uint64
+	Resultado string
+	Desde     string
+	Hasta     string
+	Limite    int
+}
+
+// VarianteIA indica qué cambiar al volver a ejecutar una interacción; los campos vacíos
+// conservan la configuración actual del bot
+type VarianteIA struct {
+	ProviderType string   `json:"providerType"`
+	BaseURL      string   `json:"baseUrl"`
+	ModelName    string   `json:"modelName"`
+	APIKeyRef    string   `json:"apiKeyRef"`
+	Temperature  *float64 `json:"temperature"`
+	// Prompt es una plantilla de prompt de sistema; PromptVersion una versión guardada del bot
+	Prompt        string `json:"prompt"`
+	PromptVersion int    `json:"promptVersion"`
+}
+
+// ResultadoVarianteIA es la respuesta obtenida al volver a ejecutar una interacción
+type ResultadoVarianteIA struct {
+	Proveedor     string        `json:"proveedor"`
+	Modelo        string        `json:"modelo"`
+	Prompt        string        `json:"prompt,omitempty"`
+	Respuesta     string        `json:"respuesta"`
+	Mensajes      []RespuestaIA `json:"mensajes"`
+	Resultado     string        `json:"resultado"`
+	Error         string        `json:"error,omitempty"`
+	DuracionLlmMs int64         `json:"duracionLlmMs"`
+}
+
+// ComparacionIA pone lado a lado una interacción guardada y su nueva ejecución
+type ComparacionIA struct {
+	Original *InteraccionIA      `json:"original"`
+	Variante ResultadoVarianteIA `json:"variante"`
+}
+
+// InteraccionIARepository define el acceso a datos de las interacciones de los bots
+type InteraccionIARepository interface {
+	Create(interaccion *InteraccionIA) error
+	GetById(id uint64) (*InteraccionIA, error)
+	GetAll(usuarioId uint64, filtro FiltroInteraccionIA) ([]InteraccionIA, error)
+}
+
+// InteraccionIAUseCase define las reglas de negocio de las interacciones de los bots
+type InteraccionIAUseCase interface {
+	Registrar(interaccion *InteraccionIA) error
+	GetById(id uint64) (*InteraccionIA, error)
+	GetAll(filtro FiltroInteraccionIA) ([]InteraccionIA, error)
+	Reejecutar(id uint64, variante VarianteIA) (*ComparacionIA, error)
+}
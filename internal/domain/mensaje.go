@@ -11,8 +11,26 @@ type Mensaje struct {
 	UsuarioId  uint64    `json:"usuarioId"`
 	ResponseId *uint64   `json:"respuestaId,omitempty"`
 
-	Respuesta *Mensaje `json:"respuesta,omitempty"`
-	Usuario   *Usuario `json:"usuario,omitempty"`
+	Respuesta *Mensaje  `json:"respuesta,omitempty"`
+	Usuario   *Usuario  `json:"usuario,omitempty"`
+	Menciones []Mencion `json:"menciones,omitempty"`
+	// Traduccion es el contenido en el idioma del lector, si el grupo tiene la traducción habilitada
+	Traduccion *Traduccion `json:"traduccion,omitempty"`
+}
+
+// ResumenGrupo es el resumen acumulado que un bot mantiene de la conversación de un grupo.
+// Cubre los mensajes con ID menor o igual a HastaMensajeId.
+type ResumenGrupo struct {
+	Texto          string `json:"texto"`
+	HastaMensajeId uint64 `json:"hastaMensajeId"`
+}
+
+// Mencion representa un @apodo dentro de un mensaje que apunta a un integrante del grupo
+type Mencion struct {
+	Id        uint64 `json:"id"`
+	MensajeId uint64 `json:"mensajeId"`
+	UsuarioId uint64 `json:"usuarioId"`
+	Apodo     string `json:"apodo"`
 }
 
 // MensajeRepository define los métodos que cualquier implementación de DB debe cumplir
@@ -20,13 +38,27 @@ type MensajeRepository interface {
 	GetAll() ([]Mensaje, error)
 	GetById(id uint64) (*Mensaje, error)
 	GetAllByGrupoId(grupoId uint64, startDate time.Time, endDate time.Time) ([]Mensaje, error)
+	// ContarPorFechas devuelve cuántos mensajes tiene el grupo entre las fechas (días completos,
+	// como GetAllByGrupoId) y el ID del más reciente, sin cargarlos
+	ContarPorFechas(grupoId uint64, startDate time.Time, endDate time.Time) (int, uint64, error)
+	// GetUltimosPorFechas devuelve los mensajes más recientes del grupo entre las fechas, en orden
+	GetUltimosPorFechas(grupoId uint64, startDate time.Time, endDate time.Time, limite int) ([]Mensaje, error)
 	GetAllByGrupoClave(clave string) ([]Mensaje, error)
 	Create(mensaje *Mensaje) (*Mensaje, error)
 	Update(id uint64, mensaje *Mensaje) error
 
 	// IA Checkpoints
 	GetNuevosMensajesParaIA(aiID uint64, grupoID uint64) ([]Mensaje, error)
-	ActualizarPuntoControl(aiID uint64, grupoID uint64, ultimoID uint64) error
+	GetUltimosMensajes(grupoID uint64, limite int) ([]Mensaje, error)
+	GetUltimoMensajeId(grupoID uint64, excluirUsuarioID uint64) (uint64, error)
+	GetMensajesRango(grupoID uint64, desdeID uint64, hastaID uint64, limite int) ([]Mensaje, error)
+	GetByIds(ids []uint64) ([]Mensaje, error)
+	// BuscarTexto devuelve los mensajes más recientes del grupo que contienen el texto
+	BuscarTexto(grupoID uint64, texto string, limite int) ([]Mensaje, error)
+	GetPuntoControl(aiID uint64, grupoID uint64) (uint64, error)
+	ActualizarPuntoControl(aiID uint64, grupoID uint64, anteriorID uint64, ultimoID uint64) (bool, error)
+	GetResumen(aiID uint64, grupoID uint64) (*ResumenGrupo, error)
+	GuardarResumen(aiID uint64, grupoID uint64, resumen *ResumenGrupo) error
 }
 
 // MensajeUseCase define la lógica de negocio expuesta a los controladores
@@ -40,5 +72,14 @@ type MensajeUseCase interface {
 
 	// IA Checkpoints
 	GetNuevosMensajesParaIA(aiID uint64, grupoID uint64) ([]Mensaje, error)
-	ActualizarPuntoControl(aiID uint64, grupoID uint64, ultimoID uint64) error
+	GetUltimosMensajes(grupoID uint64, limite int) ([]Mensaje, error)
+	GetUltimoMensajeId(grupoID uint64, excluirUsuarioID uint64) (uint64, error)
+	GetMensajesRango(grupoID uint64, desdeID uint64, hastaID uint64, limite int) ([]Mensaje, error)
+	GetByIds(ids []uint64) ([]Mensaje, error)
+	// BuscarTexto devuelve los mensajes más recientes del grupo que contienen el texto
+	BuscarTexto(grupoID uint64, texto string, limite int) ([]Mensaje, error)
+	GetPuntoControl(aiID uint64, grupoID uint64) (uint64, error)
+	ActualizarPuntoControl(aiID uint64, grupoID uint64, anteriorID uint64, ultimoID uint64) (bool, error)
+	GetResumen(aiID uint64, grupoID uint64) (*ResumenGrupo, error)
+	GuardarResumen(aiID uint64, grupoID uint64, resumen *ResumenGrupo) error
 }
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DecisionModeracion es el veredicto de un verificador sobre un mensaje
+type DecisionModeracion string
+
+const (
+	ModeracionPermitir DecisionModeracion = "allow"
+	ModeracionMarcar   DecisionModeracion = "flag"
+	ModeracionBloquear DecisionModeracion = "block"
+)
+
+// Severidad ordena las decisiones: bloquear pesa más que marcar y marcar más que permitir
+func (d DecisionModeracion) Severidad() int {
+	switch d {
+	case ModeracionBloquear:
+		return 2
+	case ModeracionMarcar:
+		return 1
+	}
+	return 0
+}
+
+// Estados de un mensaje en la cola de moderación
+const (
+	EstadoModeracionPendiente = "pendiente"
+	EstadoModeracionAprobado  = "aprobado"
+	EstadoModeracionRechazado = "rechazado"
+	EstadoModeracionBloqueado = "bloqueado"
+)
+
+// ResultadoModeracion es la decisión de la cadena y qué verificador la tomó
+type ResultadoModeracion struct {
+	Decision DecisionModeracion `json:"decision"`
+	// Verificador es el nombre del verificador que decidió (vacío si se permitió)
+	Verificador string `json:"verificador,omitempty"`
+	Motivo      string `json:"motivo,omitempty"`
+	// ModeradoId es el ID en la cola de moderación cuando el mensaje se marcó o se bloqueó
+	ModeradoId uint64 `json:"moderadoId,omitempty"`
+}
+
+// ModeracionError es el error de MensajeUseCase.Create y Update cuando la moderación no permite el
+// mensaje; Resultado indica si se bloqueó o quedó retenido para revisión
+type ModeracionError struct {
+	Resultado ResultadoModeracion
+}
+
+func (e *ModeracionError) Error() string {
+	return fmt.Sprintf("mensaje no permitido por moderación (%s, %s): %s", e.Resultado.Decision, e.Resultado.Verificador, e.Resultado.Motivo)
+}
+
+// VerificadorModeracion es un eslabón de la cadena de moderación
+type VerificadorModeracion interface {
+	Nombre() string
+	Verificar(ctx context.Context, mensaje *Mensaje) (ResultadoModeracion, error)
+}
+
+// MensajeModerado es un mensaje retenido por la moderación. Los marcados esperan la revisión de un
+// administrador; los bloqueados se guardan solo como registro.
+type MensajeModerado struct {
+	Id          uint64             `json:"id"`
+	GrupoId     uint64             `json:"grupoId"`
+	UsuarioId   uint64             `json:"usuarioId"`
+	ResponseId  *uint64            `json:"respuestaId,omitempty"`
+	Contenido   string             `json:"contenido"`
+	Decision    DecisionModeracion `json:"decision"`
+	Verificador string             `json:"verificador"`
+	Motivo      string             `json:"motivo"`
+	Estado      string             `json:"estado"`
+	// MensajeId es el mensaje publicado al aprobarlo
+	MensajeId     *uint64    `json:"mensajeId,omitempty"`
+	RevisadoPor   *uint64    `json:"revisadoPor,omitempty"`
+	FechaRevision *time.Time `json:"fechaRevision,omitempty"`
+	Nota          string     `json:"nota,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// PublicadorMensajes envía a los integrantes conectados un mensaje ya guardado. Lo implementa el
+// Hub de WebSocket.
+type PublicadorMensajes interface {
+	PublicarMensaje(grupoClave string, mensaje *Mensaje)
+}
+
+// ModeracionRepository define el acceso a datos de la cola de moderación
+type ModeracionRepository interface {
+	Create(moderado *MensajeModerado) error
+	GetById(id uint64) (*MensajeModerado, error)
+	GetByEstado(estado string, limite int) ([]MensajeModerado, error)
+	// Revisar cambia el estado solo si sigue pendiente; devuelve false si otro administrador ya lo revisó
+	Revisar(moderado *MensajeModerado) (bool, error)
+	// AsignarMensaje enlaza la revisión aprobada con el mensaje que se guardó a partir de ella
+	AsignarMensaje(id uint64, mensajeId uint64) error
+	// Reabrir vuelve a dejar pendiente una aprobación cuyo mensaje no se pudo guardar
+	Reabrir(id uint64) error
+}
+
+// ModeracionUseCase define la cadena de moderación y la revisión de los mensajes marcados
+type ModeracionUseCase interface {
+	// Moderar pasa el mensaje por la cadena. Los marcados quedan pendientes de revisión y los
+	// bloqueados registrados; en ambos casos el mensaje no debe guardarse ni publicarse. Un
+	// mensaje con Id es la edición de uno ya guardado: si se marca, se bloquea.
+	Moderar(ctx context.Context, mensaje *Mensaje) (*ResultadoModeracion, error)
+
+	GetByEstado(estado string, limite int) ([]MensajeModerado, error)
+	// Aprobar guarda y publica el mensaje marcado
+	Aprobar(adminId uint64, id uint64) (*MensajeModerado, error)
+	Rechazar(adminId uint64, id uint64, nota string) (*MensajeModerado, error)
+}
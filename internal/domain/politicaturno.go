@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// PoliticaTurno define cómo se reparten los turnos de los bots dentro de un grupo
+type PoliticaTurno struct {
+	Id      uint64 `json:"id"`
+	GrupoId uint64 `json:"grupoId"`
+
+	// Máximo de mensajes de bot seguidos sin que hable un humano (0 = sin límite)
+	MaxBotConsecutivos int `json:"maxBotConsecutivos"`
+	// Segundos que debe esperar cada bot entre dos mensajes propios
+	CooldownSegundos int `json:"cooldownSegundos"`
+	// Si es true, después de un mensaje de bot debe hablar un humano antes del siguiente turno de bot
+	RequiereHumano bool `json:"requiereHumano"`
+	// Si es true, los bots del grupo responden por turnos en lugar de todos a la vez
+	RoundRobin bool `json:"roundRobin"`
+	// Máximo de llamadas al modelo por hora entre todos los bots del grupo (0 = sin límite)
+	MaxLlamadasHora int `json:"maxLlamadasHora"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+	// PorDefecto indica que el grupo no tiene una política guardada y se usan los valores por defecto
+	PorDefecto bool `json:"porDefecto"`
+}
+
+// PoliticaTurnoRepository define el acceso a datos de las políticas de turno
+type PoliticaTurnoRepository interface {
+	GetByGrupoId(grupoId uint64) (*PoliticaTurno, int, error)
+	Save(politica *PoliticaTurno) error
+}
+
+// PoliticaTurnoUseCase define las reglas de negocio de las políticas de turno
+type PoliticaTurnoUseCase interface {
+	// GetByGrupoId devuelve la política vigente del grupo (la guardada o la de por defecto)
+	GetByGrupoId(grupoId uint64) (*PoliticaTurno, error)
+	Update(grupoId uint64, politica *PoliticaTurno) error
+}
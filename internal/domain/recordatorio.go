@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Recordatorio es un aviso programado que un bot publica en el grupo al llegar la fecha
+type Recordatorio struct {
+	Id      uint64 `json:"id"`
+	GrupoId uint64 `json:"grupoId"`
+	// UsuarioId es quien lo programó (el bot, cuando lo crea con una herramienta)
+	UsuarioId uint64    `json:"usuarioId"`
+	Texto     string    `json:"texto"`
+	Fecha     time.Time `json:"fecha"`
+	Enviado   bool      `json:"enviado"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RecordatorioRepository define el acceso a datos de los recordatorios
+type RecordatorioRepository interface {
+	Create(recordatorio *Recordatorio) error
+	CountPendientesByGrupoId(grupoId uint64) (int64, error)
+	GetPendientesByGrupoId(grupoId uint64) ([]Recordatorio, error)
+	GetVencidos(hasta time.Time, limite int) ([]Recordatorio, error)
+	MarcarEnviado(id uint64) error
+}
+
+// RecordatorioUseCase define las reglas de negocio de los recordatorios
+type RecordatorioUseCase interface {
+	Programar(recordatorio *Recordatorio) error
+	GetPendientesByGrupoId(usuarioId uint64, grupoId uint64) ([]Recordatorio, error)
+	GetVencidos(hasta time.Time, limite int) ([]Recordatorio, error)
+	MarcarEnviado(id uint64) error
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TareaResumen es una acción pendiente detectada en la conversación
+type TareaResumen struct {
+	// Responsable es el @apodo de quien quedó a cargo; vacío si no se asignó
+	Responsable string `json:"responsable"`
+	Tarea       string `json:"tarea"`
+}
+
+// ContenidoResumen es lo que produce el modelo al resumir una conversación
+type ContenidoResumen struct {
+	Resumen           string         `json:"resumen"`
+	Decisiones        []string       `json:"decisiones"`
+	PreguntasAbiertas []string       `json:"preguntasAbiertas"`
+	Tareas            []TareaResumen `json:"tareas"`
+}
+
+// ResumenIA es el resumen de los mensajes de un grupo en un rango de fechas. Se guarda por
+// (grupo, rango) y se reutiliza mientras el rango no tenga mensajes nuevos.
+type ResumenIA struct {
+	Id      uint64 `json:"id"`
+	GrupoId uint64 `json:"grupoId"`
+	// Rango identifica el rango de días resumido, por ejemplo "2025-03-01..2025-03-07" o "..2025-03-07"
+	Rango string `json:"rango"`
+	ContenidoResumen
+	// CantidadMensajes y HastaMensajeId describen los mensajes cubiertos; si cambian, el resumen se regenera
+	CantidadMensajes int    `json:"cantidadMensajes"`
+	HastaMensajeId   uint64 `json:"hastaMensajeId"`
+	// Parcial indica que el rango tenía más mensajes de los que se resumen y se omitieron los más antiguos
+	Parcial   bool      `json:"parcial"`
+	Modelo    string    `json:"modelo"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SuscripcionDigest es la preferencia de un usuario para recibir el digest diario de sus grupos
+type SuscripcionDigest struct {
+	UsuarioId uint64 `json:"usuarioId"`
+	// Hora es la hora del día (UTC, 0 a 23) a partir de la cual se genera el digest
+	Hora   int  `json:"hora"`
+	Activo bool `json:"activo"`
+	// UltimoDigest es cuándo se generó el último digest (nil si nunca)
+	UltimoDigest *time.Time `json:"ultimoDigest,omitempty"`
+}
+
+// SeccionDigest es el resumen de un grupo dentro de un digest
+type SeccionDigest struct {
+	GrupoId     uint64 `json:"grupoId"`
+	GrupoNombre string `json:"grupoNombre"`
+	ContenidoResumen
+	CantidadMensajes int `json:"cantidadMensajes"`
+}
+
+// Digest reúne los resúmenes de la actividad reciente de todos los grupos de un usuario
+type Digest struct {
+	Id        uint64          `json:"id"`
+	UsuarioId uint64          `json:"usuarioId"`
+	Desde     time.Time       `json:"desde"`
+	Hasta     time.Time       `json:"hasta"`
+	Secciones []SeccionDigest `json:"secciones"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// ResumenRepository define el acceso a datos de los resúmenes y digests
+type ResumenRepository interface {
+	// GetByRango devuelve el resumen guardado del rango o nil si no existe
+	GetByRango(grupoId uint64, rango string) (*ResumenIA, error)
+	Save(resumen *ResumenIA) error
+
+	// GetSuscripcion devuelve la suscripción del usuario o nil si no existe
+	GetSuscripcion(usuarioId uint64) (*SuscripcionDigest, error)
+	SaveSuscripcion(suscripcion *SuscripcionDigest) error
+	// GetSuscripcionesPendientes devuelve las suscripciones activas cuya hora ya pasó y que no
+	// generaron un digest desde el inicio del día
+	GetSuscripcionesPendientes(ahora time.Time, limite int) ([]SuscripcionDigest, error)
+	// CreateDigest guarda el digest y actualiza UltimoDigest de la suscripción
+	CreateDigest(digest *Digest) error
+	GetDigests(usuarioId uint64, limite int) ([]Digest, error)
+}
+
+// ResumenUseCase define las reglas de negocio de los resúmenes y digests
+type ResumenUseCase interface {
+	// Resumir resume los mensajes del grupo entre las fechas (cero = sin límite), usando el
+	// resumen guardado si el rango no cambió
+	Resumir(ctx context.Context, usuarioId uint64, grupoId uint64, fechaInicio time.Time, fechaFin time.Time) (*ResumenIA, error)
+
+	GetSuscripcion(usuarioId uint64) (*SuscripcionDigest, error)
+	SaveSuscripcion(suscripcion *SuscripcionDigest) error
+	GetDigests(usuarioId uint64, limite int) ([]Digest, error)
+	// GenerarDigestsPendientes genera el digest de las suscripciones pendientes y devuelve cuántos generó
+	GenerarDigestsPendientes(ctx context.Context, ahora time.Time, limite int) (int, error)
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// SalidaFallida guarda una respuesta de un bot que no se pudo interpretar, incluso tras el intento de reparación
+type SalidaFallida struct {
+	Id        uint64 `json:"id"`
+	UsuarioId uint64 `json:"usuarioId"`
+	GrupoId   uint64 `json:"grupoId"`
+	// Salida es la respuesta original del modelo y Reparacion la obtenida al pedirle que la corrija
+	Salida     string    `json:"salida"`
+	Reparacion string    `json:"reparacion"`
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// SalidaFallidaRepository define el acceso a datos de las salidas fallidas
+type SalidaFallidaRepository interface {
+	Create(salida *SalidaFallida) error
+	GetByUsuarioId(usuarioId uint64, limite int) ([]SalidaFallida, error)
+}
+
+// SalidaFallidaUseCase define las reglas de negocio de las salidas fallidas
+type SalidaFallidaUseCase interface {
+	Registrar(salida *SalidaFallida) error
+	GetByBotId(botId uint64, limite int) ([]SalidaFallida, error)
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// idiomas son los idiomas a los que se pueden traducir los mensajes, por código ISO 639-1
+var idiomas = map[string]string{
+	"es": "español",
+	"en": "inglés",
+	"pt": "portugués",
+	"fr": "francés",
+	"de": "alemán",
+	"it": "italiano",
+}
+
+// IdiomaSoportado indica si el código de idioma se puede elegir como idioma preferido
+func IdiomaSoportado(codigo string) bool {
+	_, ok := idiomas[codigo]
+	return ok
+}
+
+// NombreIdioma devuelve el nombre del idioma en español ("" si no está soportado)
+func NombreIdioma(codigo string) string {
+	return idiomas[codigo]
+}
+
+// CodigosIdioma devuelve los códigos soportados ordenados
+func CodigosIdioma() []string {
+	return slices.Sorted(maps.Keys(idiomas))
+}
+
+// Traduccion es el texto de un mensaje en el idioma del lector, que se muestra junto al original
+type Traduccion struct {
+	Idioma    string `json:"idioma"`
+	Contenido string `json:"contenido"`
+}
+
+// TraduccionMensaje es la traducción guardada de un mensaje a un idioma. Se genera la primera
+// vez que un lector la necesita y se reutiliza para los demás lectores con ese idioma.
+// HashOriginal identifica el contenido traducido, para volver a traducir un mensaje editado.
+type TraduccionMensaje struct {
+	MensajeId    uint64    `json:"mensajeId"`
+	Idioma       string    `json:"idioma"`
+	Contenido    string    `json:"contenido"`
+	HashOriginal string    `json:"hashOriginal"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// TraduccionRepository define el acceso a datos de las traducciones de mensajes
+type TraduccionRepository interface {
+	GetByMensajes(mensajesIds []uint64, idioma string) ([]TraduccionMensaje, error)
+	// Save crea o reemplaza la traducción del mensaje al idioma
+	Save(traduccion *TraduccionMensaje) error
+}
+
+// PublicadorTraducciones envía a un lector conectado la traducción de un mensaje que ya tiene. Lo
+// implementa el Hub de WebSocket.
+type PublicadorTraducciones interface {
+	PublicarTraduccion(lectorId uint64, grupoClave string, mensaje Mensaje, traduccion Traduccion)
+}
+
+// TraduccionUseCase traduce los mensajes de los grupos con la traducción habilitada
+type TraduccionUseCase interface {
+	// TraducirMensajes completa el campo Traduccion de los mensajes del grupo con las traducciones
+	// guardadas en el idioma del lector. Las que faltan se generan en segundo plano y se le envían
+	// al lector por WebSocket a medida que están listas.
+	TraducirMensajes(lectorId uint64, grupoClave string, mensajes []Mensaje) error
+	// TraducirParaLectores traduce un mensaje recién enviado al idioma de cada lector y devuelve la
+	// traducción de cada uno; los lectores sin idioma o que ya lo leen en su idioma no aparecen
+	TraducirParaLectores(grupoClave string, mensaje Mensaje, lectoresIds []uint64) (map[uint64]Traduccion, error)
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// UsoIA es el consumo acumulado de un bot en un grupo durante un día
+type UsoIA struct {
+	UsuarioId uint64 `json:"usuarioId"`
+	GrupoId   uint64 `json:"grupoId"`
+	// Fecha en formato YYYY-MM-DD; vacía en los totales
+	Fecha            string `json:"fecha,omitempty"`
+	Llamadas         int    `json:"llamadas"`
+	Errores          int    `json:"errores"`
+	Bloqueadas       int    `json:"bloqueadas"`
+	PromptTokens     int    `json:"promptTokens"`
+	CompletionTokens int    `json:"completionTokens"`
+	TotalTokens      int    `json:"totalTokens"`
+	LatenciaTotalMs  int64  `json:"latenciaTotalMs"`
+	LatenciaMaxMs    int64  `json:"latenciaMaxMs"`
+	// LatenciaPromedioMs se calcula al armar el reporte
+	LatenciaPromedioMs int64  `json:"latenciaPromedioMs"`
+	UltimoError        string `json:"ultimoError,omitempty"`
+}
+
+// LlamadaIA es una llamada de un bot al modelo (o una respuesta bloqueada por cuota)
+type LlamadaIA struct {
+	UsuarioId uint64
+	GrupoId   uint64
+	Fecha     time.Time
+	// Estado es "ok" o la clasificación del error (ver llm.CallStatus)
+	Estado   string
+	Latencia time.Duration
+	// Tokens informados por el servidor del modelo o estimados si no los informa
+	PromptTokens     int
+	CompletionTokens int
+	// Bloqueada indica que la respuesta no se generó por superar una cuota
+	Bloqueada bool
+}
+
+// FiltroUsoIA acota el reporte de uso; los IDs en cero no filtran
+type FiltroUsoIA struct {
+	BotId   uint64
+	GrupoId uint64
+	Desde   string
+	Hasta   string
+}
+
+// ReporteUsoIA es el uso por bot, grupo y día en un rango de fechas, con sus totales
+type ReporteUsoIA struct {
+	Desde   string  `json:"desde"`
+	Hasta   string  `json:"hasta"`
+	Filas   []UsoIA `json:"filas"`
+	Totales UsoIA   `json:"totales"`
+}
+
+// UsoIARepository define el acceso a datos del uso de los bots
+type UsoIARepository interface {
+	// Sumar agrega el consumo a la fila del bot, grupo y día (la crea si no existe)
+	Sumar(uso *UsoIA) error
+	GetTokensDia(usuarioId uint64, fecha string) (int, error)
+	GetRango(usuarioId uint64, grupoId uint64, desde string, hasta string) ([]UsoIA, error)
+}
+
+// UsoIAUseCase define las reglas de negocio del uso de los bots
+type UsoIAUseCase interface {
+	Registrar(llamada LlamadaIA) error
+	GetTokensDia(usuarioId uint64, fecha time.Time) (int, error)
+	GetReporte(filtro FiltroUsoIA) (*ReporteUsoIA, error)
+}
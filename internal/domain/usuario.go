@@ -14,6 +14,8 @@ type Usuario struct {
 	IsLlm    bool      `json:"isLlm"`
 	IsAdmin  bool      `json:"isAdmin"`
 	IsActive bool      `json:"isActive"`
+	// Idioma es el idioma preferido para leer los mensajes traducidos ("" = sin traducción)
+	Idioma string `json:"idioma"`
 }
 
 // UsuarioRepository define la interfaz que cualquier implementación de base de datos debe cumplir.
@@ -21,10 +23,12 @@ type UsuarioRepository interface {
 	GetById(id uint64) (*Usuario, error)
 	GetByEmail(email string) (*Usuario, int, error)
 	GetAllUsuarios() ([]Usuario, error)
+	GetAllByGrupoId(grupoId uint64) ([]Usuario, error)
 	Create(usuario *Usuario) error
 	Update(id uint64, usuario Usuario) error
 	UpdateToken(id uint64, token string) error
 	UpdateIsActive(id uint64, isActive bool) error
+	UpdateIdioma(id uint64, idioma string) error
 }
 
 // UsuarioUseCase define los métodos expuestos a la capa de entrega (HTTP).
@@ -32,8 +36,11 @@ type UsuarioUseCase interface {
 	GetById(id uint64) (*Usuario, error)
 	GetByEmail(email string) (*Usuario, error)
 	GetAllUsuarios() ([]Usuario, error)
+	GetAllByGrupoId(grupoId uint64) ([]Usuario, error)
 	Create(usuario *Usuario) error
 	Update(id uint64, usuario Usuario) error
 	UpdateIsActive(id uint64, isActive bool) error
+	// UpdateIdioma cambia el idioma preferido del usuario; vacío desactiva las traducciones
+	UpdateIdioma(id uint64, idioma string) error
 	ClearToken(id uint64) error
 }
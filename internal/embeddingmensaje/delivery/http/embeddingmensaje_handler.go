@@ -0,0 +1,51 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EmbeddingMensajeHandler struct {
+	EMUsecase domain.EmbeddingMensajeUseCase
+}
+
+// NewEmbeddingMensajeHandler registra la búsqueda semántica dentro del grupo de rutas de mensajes
+func NewEmbeddingMensajeHandler(group fiber.Router, emu domain.EmbeddingMensajeUseCase) {
+	handler := &EmbeddingMensajeHandler{
+		EMUsecase: emu,
+	}
+
+	group.Get("/search/semantic", handler.BuscarSemantico)
+}
+
+func (h *EmbeddingMensajeHandler) BuscarSemantico(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	grupoId := c.QueryInt("grupoId", 0)
+	if grupoId <= 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al buscar mensajes", "Error parametro", "grupoId debe ser mayor que cero")
+	}
+
+	consulta := c.Query("q")
+	if len(strings.TrimSpace(consulta)) == 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al buscar mensajes", "Error parametro", "La consulta q es requerida")
+	}
+
+	limite := c.QueryInt("limite", 0)
+	if limite < 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al buscar mensajes", "Error parametro", "limite no puede ser negativo")
+	}
+
+	resultados, err := h.EMUsecase.Buscar(c.UserContext(), userId, uint64(grupoId), consulta, limite)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al buscar mensajes", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Mensajes encontrados correctamente", "", resultados)
+}
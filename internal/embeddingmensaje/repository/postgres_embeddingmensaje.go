@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresEmbeddingMensajeRepository struct {
+	db *gorm.DB
+	// pgvector indica si la base tiene la extensión vector y la columna vector_pg
+	pgvector bool
+}
+
+// NewPostgresEmbeddingMensajeRepository detecta la extensión pgvector. Si está instalada agrega la
+// columna vector_pg y la búsqueda se hace en Postgres; si no, se calcula la similitud coseno en
+// memoria sobre los vectores del grupo.
+func NewPostgresEmbeddingMensajeRepository(db *gorm.DB) domain.EmbeddingMensajeRepository {
+	r := &postgresEmbeddingMensajeRepository{db: db}
+
+	var instalada bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')").Scan(&instalada).Error; err != nil {
+		log.Printf("Embeddings: no se pudo verificar la extensión pgvector: %v", err)
+		return r
+	}
+	if !instalada {
+		log.Println("Embeddings: pgvector no está instalado; la búsqueda semántica se calcula en memoria.")
+		return r
+	}
+
+	if err := db.Exec("ALTER TABLE embeddings_mensajes ADD COLUMN IF NOT EXISTS vector_pg vector").Error; err != nil {
+		log.Printf("Embeddings: no se pudo agregar la columna vector_pg: %v", err)
+		return r
+	}
+	r.pgvector = true
+	if err := r.backfillVectorPg(); err != nil {
+		log.Printf("Embeddings: no se pudieron copiar los vectores a vector_pg: %v", err)
+	}
+	return r
+}
+
+// backfillVectorPg copia a vector_pg todos los vectores que aún no la tienen. Recorre la tabla
+// entera, así que solo se corre al arrancar. El JSON de un []float32 tiene el mismo formato que
+// el texto de un vector de pgvector, así que basta con el cast.
+func (r *postgresEmbeddingMensajeRepository) backfillVectorPg() error {
+	return r.db.Exec("UPDATE embeddings_mensajes SET vector_pg = vector::vector WHERE vector_pg IS NULL").Error
+}
+
+// syncVectorPg copia a vector_pg solo los vectores de los mensajes indicados para un modelo
+func (r *postgresEmbeddingMensajeRepository) syncVectorPg(modelo string, mensajeIds []uint64) error {
+	return r.db.Exec("UPDATE embeddings_mensajes SET vector_pg = vector::vector WHERE id_mensaje IN ? AND modelo = ? AND vector_pg IS NULL", mensajeIds, modelo).Error
+}
+
+func (r *postgresEmbeddingMensajeRepository) Save(embeddings []domain.EmbeddingMensaje) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	gormEmbeddings := make([]models.EmbeddingsMensajes, 0, len(embeddings))
+	for _, e := range embeddings {
+		gormEmbeddings = append(gormEmbeddings, models.EmbeddingsMensajes{
+			MensajeId: e.MensajeId,
+			Modelo:    e.Modelo,
+			GrupoId:   e.GrupoId,
+			Vector:    e.Vector,
+		})
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&gormEmbeddings).Error; err != nil {
+		return err
+	}
+
+	if !r.pgvector {
+		return nil
+	}
+
+	idsPorModelo := make(map[string][]uint64)
+	for _, e := range embeddings {
+		idsPorModelo[e.Modelo] = append(idsPorModelo[e.Modelo], e.MensajeId)
+	}
+	for modelo, ids := range idsPorModelo {
+		if err := r.syncVectorPg(modelo, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postgresEmbeddingMensajeRepository) GetPendientes(modelo string, limite int) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	err := r.db.
+		Joins("LEFT JOIN embeddings_mensajes ON embeddings_mensajes.id_mensaje = mensajes.id AND embeddings_mensajes.modelo = ?", modelo).
+		Where("embeddings_mensajes.id_mensaje IS NULL AND trim(mensajes.contenido) <> ''").
+		Order("mensajes.id asc").
+		Limit(limite).
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for _, m := range gormMensajes {
+		mensajes = append(mensajes, domain.Mensaje{
+			Id:        m.Id,
+			Contenido: m.Contenido,
+			Fecha:     m.Fecha,
+			GrupoId:   m.GrupoId,
+			UsuarioId: m.UsuarioId,
+		})
+	}
+
+	return mensajes, nil
+}
+
+func (r *postgresEmbeddingMensajeRepository) Search(grupoId uint64, modelo string, vector []float32, limite int, antesDeId uint64) ([]domain.SimilitudMensaje, error) {
+	if r.pgvector {
+		return r.searchPgvector(grupoId, modelo, vector, limite, antesDeId)
+	}
+	return r.searchMemoria(grupoId, modelo, vector, limite, antesDeId)
+}
+
+// searchPgvector ordena por distancia coseno (<=>) en la base
+func (r *postgresEmbeddingMensajeRepository) searchPgvector(grupoId uint64, modelo string, vector []float32, limite int, antesDeId uint64) ([]domain.SimilitudMensaje, error) {
+	literal, err := json.Marshal(vector)
+	if err != nil {
+		return nil, fmt.Errorf("error al serializar el vector de la consulta: %w", err)
+	}
+
+	query := r.db.Table("embeddings_mensajes").
+		Select("id_mensaje AS mensaje_id, 1 - (vector_pg <=> ?::vector) AS similitud", string(literal)).
+		Where("id_grupo = ? AND modelo = ? AND vector_pg IS NOT NULL", grupoId, modelo)
+	if antesDeId > 0 {
+		query = query.Where("id_mensaje < ?", antesDeId)
+	}
+
+	var resultados []domain.SimilitudMensaje
+	err = query.
+		Order(clause.Expr{SQL: "vector_pg <=> ?::vector", Vars: []any{string(literal)}}).
+		Limit(limite).
+		Scan(&resultados).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return resultados, nil
+}
+
+// searchMemoria carga los vectores del grupo y calcula la similitud coseno en el proceso
+func (r *postgresEmbeddingMensajeRepository) searchMemoria(grupoId uint64, modelo string, vector []float32, limite int, antesDeId uint64) ([]domain.SimilitudMensaje, error) {
+	query := r.db.Select("id_mensaje", "vector").Where("id_grupo = ? AND modelo = ?", grupoId, modelo)
+	if antesDeId > 0 {
+		query = query.Where("id_mensaje < ?", antesDeId)
+	}
+
+	var gormEmbeddings []models.EmbeddingsMensajes
+	if err := query.Find(&gormEmbeddings).Error; err != nil {
+		return nil, err
+	}
+
+	resultados := make([]domain.SimilitudMensaje, 0, len(gormEmbeddings))
+	for _, e := range gormEmbeddings {
+		resultados = append(resultados, domain.SimilitudMensaje{
+			MensajeId: e.MensajeId,
+			Similitud: llm.CosineSimilarity(vector, e.Vector),
+		})
+	}
+
+	sort.SliceStable(resultados, func(i, j int) bool {
+		return resultados[i].Similitud > resultados[j].Similitud
+	})
+	if len(resultados) > limite {
+		resultados = resultados[:limite]
+	}
+
+	return resultados, nil
+}
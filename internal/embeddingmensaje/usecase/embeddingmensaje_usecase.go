@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultLimiteBusqueda = 10
+	maxLimiteBusqueda     = 50
+	// loteEmbeddings es la cantidad de textos que se envían por solicitud al endpoint de embeddings
+	loteEmbeddings = 32
+	// maxCaracteresEmbedding recorta los mensajes muy largos antes de calcular su vector
+	maxCaracteresEmbedding = 2000
+)
+
+// ErrBusquedaDeshabilitada indica que no hay un modelo de embeddings configurado
+var ErrBusquedaDeshabilitada = errors.New("la búsqueda semántica no está habilitada (EMBEDDINGS_MODEL vacío)")
+
+type embeddingMensajeUseCase struct {
+	repo             domain.EmbeddingMensajeRepository
+	repoMensaje      domain.MensajeRepository
+	repoGrupo        domain.GrupoRepository
+	repoGrupoUsuario domain.GrupoUsuarioRepository
+	// embedder es nil cuando la búsqueda semántica está deshabilitada
+	embedder llm.Embedder
+}
+
+func NewEmbeddingMensajeUseCase(repo domain.EmbeddingMensajeRepository, repoMensaje domain.MensajeRepository, repoGrupo domain.GrupoRepository, repoGrupoUsuario domain.GrupoUsuarioRepository, embedder llm.Embedder) domain.EmbeddingMensajeUseCase {
+	return &embeddingMensajeUseCase{
+		repo:             repo,
+		repoMensaje:      repoMensaje,
+		repoGrupo:        repoGrupo,
+		repoGrupoUsuario: repoGrupoUsuario,
+		embedder:         embedder,
+	}
+}
+
+func (u *embeddingMensajeUseCase) IndexarPendientes(ctx context.Context, limite int) (int, error) {
+	if u.embedder == nil {
+		return 0, ErrBusquedaDeshabilitada
+	}
+
+	pendientes, err := u.repo.GetPendientes(u.embedder.Model(), limite)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener los mensajes sin indexar: %w", err)
+	}
+
+	indexados := 0
+	for inicio := 0; inicio < len(pendientes); inicio += loteEmbeddings {
+		lote := pendientes[inicio:min(inicio+loteEmbeddings, len(pendientes))]
+
+		textos := make([]string, 0, len(lote))
+		for _, m := range lote {
+			textos = append(textos, textoEmbedding(m.Contenido))
+		}
+
+		vectores, err := u.embedder.Embed(ctx, textos)
+		if err != nil {
+			return indexados, fmt.Errorf("error al calcular los embeddings: %w", err)
+		}
+
+		embeddings := make([]domain.EmbeddingMensaje, 0, len(lote))
+		for i, m := range lote {
+			embeddings = append(embeddings, domain.EmbeddingMensaje{
+				MensajeId: m.Id,
+				GrupoId:   m.GrupoId,
+				Modelo:    u.embedder.Model(),
+				Vector:    vectores[i],
+			})
+		}
+
+		if err := u.repo.Save(embeddings); err != nil {
+			return indexados, fmt.Errorf("error al guardar los embeddings: %w", err)
+		}
+		indexados += len(lote)
+	}
+
+	return indexados, nil
+}
+
+func (u *embeddingMensajeUseCase) Buscar(ctx context.Context, usuarioId uint64, grupoId uint64, consulta string, limite int) ([]domain.ResultadoBusqueda, error) {
+	if grupoId <= 0 {
+		return nil, errors.New("el ID del grupo debe ser mayor que cero")
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+
+	esMiembro, err := u.repoGrupoUsuario.VerifyMembership(usuarioId, grupo.Clave)
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar la membresía: %w", err)
+	}
+	if !esMiembro {
+		return nil, errors.New("el usuario no pertenece al grupo")
+	}
+
+	return u.Relevantes(ctx, grupoId, consulta, limite, 0)
+}
+
+func (u *embeddingMensajeUseCase) Relevantes(ctx context.Context, grupoId uint64, consulta string, limite int, antesDeId uint64) ([]domain.ResultadoBusqueda, error) {
+	if u.embedder == nil {
+		return nil, ErrBusquedaDeshabilitada
+	}
+
+	consulta = strings.TrimSpace(consulta)
+	if consulta == "" {
+		return nil, errors.New("la consulta no puede estar vacía")
+	}
+
+	if limite <= 0 {
+		limite = defaultLimiteBusqueda
+	}
+	limite = min(limite, maxLimiteBusqueda)
+
+	vectores, err := u.embedder.Embed(ctx, []string{textoEmbedding(consulta)})
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular el embedding de la consulta: %w", err)
+	}
+
+	similitudes, err := u.repo.Search(grupoId, u.embedder.Model(), vectores[0], limite, antesDeId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar los mensajes: %w", err)
+	}
+	if len(similitudes) == 0 {
+		return []domain.ResultadoBusqueda{}, nil
+	}
+
+	ids := make([]uint64, 0, len(similitudes))
+	for _, s := range similitudes {
+		ids = append(ids, s.MensajeId)
+	}
+
+	mensajes, err := u.repoMensaje.GetByIds(ids)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los mensajes encontrados: %w", err)
+	}
+
+	similitudPorId := make(map[uint64]float64, len(similitudes))
+	for _, s := range similitudes {
+		similitudPorId[s.MensajeId] = s.Similitud
+	}
+
+	resultados := make([]domain.ResultadoBusqueda, 0, len(mensajes))
+	for _, m := range mensajes {
+		resultados = append(resultados, domain.ResultadoBusqueda{Mensaje: m, Similitud: similitudPorId[m.Id]})
+	}
+	sort.SliceStable(resultados, func(i, j int) bool {
+		return resultados[i].Similitud > resultados[j].Similitud
+	})
+
+	return resultados, nil
+}
+
+// textoEmbedding limpia y recorta el texto del que se calcula el vector
+func textoEmbedding(texto string) string {
+	texto = strings.TrimSpace(texto)
+	if runes := []rune(texto); len(runes) > maxCaracteresEmbedding {
+		texto = string(runes[:maxCaracteresEmbedding])
+	}
+	return texto
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EncuestaHandler struct {
+	EUsecase domain.EncuestaUseCase
+}
+
+func NewEncuestaHandler(group fiber.Router, eu domain.EncuestaUseCase) {
+	handler := &EncuestaHandler{
+		EUsecase: eu,
+	}
+
+	group.Post("", handler.CreateEncuesta)
+	group.Get("/group/:id", handler.GetEncuestasByGrupoId)
+	group.Get("/:id", handler.GetEncuestaById)
+	group.Post("/:id/voto", handler.Votar)
+}
+
+func (h *EncuestaHandler) CreateEncuesta(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	var body struct {
+		GrupoId  uint64     `json:"grupoId"`
+		Pregunta string     `json:"pregunta"`
+		Opciones []string   `json:"opciones"`
+		CierraEn *time.Time `json:"cierraEn"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al crear encuesta", "Error de parseo", err.Error())
+	}
+
+	encuesta := domain.Encuesta{
+		GrupoId:   body.GrupoId,
+		UsuarioId: userId,
+		Pregunta:  body.Pregunta,
+		Opciones:  body.Opciones,
+		CierraEn:  body.CierraEn,
+	}
+	if err := h.EUsecase.Create(&encuesta); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al crear encuesta", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusCreated, "Encuesta creada correctamente", "", encuesta)
+}
+
+func (h *EncuestaHandler) GetEncuestaById(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener encuesta", "Error parametro", err.Error())
+	}
+
+	result, err := h.EUsecase.GetById(userId, id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener encuesta", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Encuesta obtenida correctamente", "", result)
+}
+
+func (h *EncuestaHandler) GetEncuestasByGrupoId(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	grupoId, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener encuestas", "Error parametro", err.Error())
+	}
+
+	result, err := h.EUsecase.GetByGrupoId(userId, grupoId)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener encuestas", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Encuestas obtenidas correctamente", "", result)
+}
+
+func (h *EncuestaHandler) Votar(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al votar", "Error parametro", err.Error())
+	}
+
+	var body struct {
+		Opcion *int `json:"opcion"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al votar", "Error de parseo", err.Error())
+	}
+	if body.Opcion == nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al votar", "Error parametro", "La opción es requerida")
+	}
+
+	result, err := h.EUsecase.Votar(userId, id, *body.Opcion)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al votar", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Voto registrado correctamente", "", result)
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresEncuestaRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresEncuestaRepository(db *gorm.DB) domain.EncuestaRepository {
+	return &postgresEncuestaRepository{db: db}
+}
+
+func mapGormToDomainEncuesta(gormEncuesta *models.Encuestas) *domain.Encuesta {
+	if gormEncuesta == nil {
+		return nil
+	}
+
+	return &domain.Encuesta{
+		Id:        gormEncuesta.Id,
+		GrupoId:   gormEncuesta.GrupoId,
+		UsuarioId: gormEncuesta.UsuarioId,
+		Pregunta:  gormEncuesta.Pregunta,
+		Opciones:  gormEncuesta.Opciones,
+		CierraEn:  gormEncuesta.CierraEn,
+		CreatedAt: gormEncuesta.CreatedAt,
+		Votos:     make([]int, len(gormEncuesta.Opciones)),
+	}
+}
+
+func (r *postgresEncuestaRepository) Create(encuesta *domain.Encuesta) error {
+	gormEncuesta := models.Encuestas{
+		GrupoId:   encuesta.GrupoId,
+		UsuarioId: encuesta.UsuarioId,
+		Pregunta:  encuesta.Pregunta,
+		Opciones:  encuesta.Opciones,
+		CierraEn:  encuesta.CierraEn,
+	}
+
+	if err := r.db.Create(&gormEncuesta).Error; err != nil {
+		return err
+	}
+
+	encuesta.Id = gormEncuesta.Id
+	encuesta.CreatedAt = gormEncuesta.CreatedAt
+	encuesta.Votos = make([]int, len(encuesta.Opciones))
+	return nil
+}
+
+func (r *postgresEncuestaRepository) GetById(id uint64) (*domain.Encuesta, error) {
+	var gormEncuesta models.Encuestas
+
+	if err := r.db.First(&gormEncuesta, id).Error; err != nil {
+		return nil, err
+	}
+
+	encuestas := []domain.Encuesta{*mapGormToDomainEncuesta(&gormEncuesta)}
+	if err := r.contarVotos(encuestas); err != nil {
+		return nil, err
+	}
+
+	return &encuestas[0], nil
+}
+
+// GetByGrupoId lista las encuestas del grupo, las más recientes primero
+func (r *postgresEncuestaRepository) GetByGrupoId(grupoId uint64) ([]domain.Encuesta, error) {
+	var gormEncuestas []models.Encuestas
+
+	if err := r.db.Where("id_grupo = ?", grupoId).Order("id desc").Find(&gormEncuestas).Error; err != nil {
+		return nil, err
+	}
+
+	encuestas := make([]domain.Encuesta, 0, len(gormEncuestas))
+	for i := range gormEncuestas {
+		encuestas = append(encuestas, *mapGormToDomainEncuesta(&gormEncuestas[i]))
+	}
+
+	if err := r.contarVotos(encuestas); err != nil {
+		return nil, err
+	}
+
+	return encuestas, nil
+}
+
+func (r *postgresEncuestaRepository) Votar(voto domain.VotoEncuesta) error {
+	gormVoto := models.VotosEncuestas{
+		EncuestaId: voto.EncuestaId,
+		UsuarioId:  voto.UsuarioId,
+		Opcion:     voto.Opcion,
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_encuesta"}, {Name: "id_usuario"}},
+		DoUpdates: clause.AssignmentColumns([]string{"opcion", "updated_at"}),
+	}).Create(&gormVoto).Error
+}
+
+// contarVotos completa Votos de cada encuesta con una sola consulta agrupada
+func (r *postgresEncuestaRepository) contarVotos(encuestas []domain.Encuesta) error {
+	if len(encuestas) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(encuestas))
+	indice := make(map[uint64]int, len(encuestas))
+	for i, e := range encuestas {
+		ids = append(ids, e.Id)
+		indice[e.Id] = i
+	}
+
+	var conteos []struct {
+		EncuestaId uint64 `gorm:"column:id_encuesta"`
+		Opcion     int
+		Total      int
+	}
+	err := r.db.Model(&models.VotosEncuestas{}).
+		Select("id_encuesta, opcion, count(*) as total").
+		Where("id_encuesta IN ?", ids).
+		Group("id_encuesta, opcion").
+		Scan(&conteos).Error
+	if err != nil {
+		return err
+	}
+
+	for _, c := range conteos {
+		votos := encuestas[indice[c.EncuestaId]].Votos
+		if c.Opcion >= 0 && c.Opcion < len(votos) {
+			votos[c.Opcion] = c.Total
+		}
+	}
+
+	return nil
+}
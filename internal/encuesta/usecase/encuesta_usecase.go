@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxLargoPregunta = 300
+	maxLargoOpcion   = 100
+	minOpciones      = 2
+	maxOpciones      = 10
+)
+
+type encuestaUseCase struct {
+	repo             domain.EncuestaRepository
+	repoGrupo        domain.GrupoRepository
+	repoGrupoUsuario domain.GrupoUsuarioRepository
+}
+
+func NewEncuestaUseCase(repo domain.EncuestaRepository, repoGrupo domain.GrupoRepository, repoGrupoUsuario domain.GrupoUsuarioRepository) domain.EncuestaUseCase {
+	return &encuestaUseCase{
+		repo:             repo,
+		repoGrupo:        repoGrupo,
+		repoGrupoUsuario: repoGrupoUsuario,
+	}
+}
+
+func (u *encuestaUseCase) Create(encuesta *domain.Encuesta) error {
+	if encuesta == nil {
+		return errors.New("la encuesta no puede ser nula")
+	}
+
+	encuesta.Pregunta = strings.TrimSpace(encuesta.Pregunta)
+	if encuesta.Pregunta == "" {
+		return errors.New("la pregunta no puede estar vacía")
+	}
+	if len([]rune(encuesta.Pregunta)) > maxLargoPregunta {
+		return fmt.Errorf("la pregunta no puede superar los %d caracteres", maxLargoPregunta)
+	}
+
+	opciones := make([]string, 0, len(encuesta.Opciones))
+	for _, opcion := range encuesta.Opciones {
+		opcion = strings.TrimSpace(opcion)
+		if opcion == "" {
+			return errors.New("las opciones no pueden estar vacías")
+		}
+		if len([]rune(opcion)) > maxLargoOpcion {
+			return fmt.Errorf("las opciones no pueden superar los %d caracteres", maxLargoOpcion)
+		}
+		if slices.Contains(opciones, opcion) {
+			return fmt.Errorf("la opción %q está repetida", opcion)
+		}
+		opciones = append(opciones, opcion)
+	}
+	if len(opciones) < minOpciones || len(opciones) > maxOpciones {
+		return fmt.Errorf("la encuesta debe tener entre %d y %d opciones", minOpciones, maxOpciones)
+	}
+	encuesta.Opciones = opciones
+
+	if encuesta.CierraEn != nil && !encuesta.CierraEn.After(time.Now()) {
+		return errors.New("la fecha de cierre debe ser futura")
+	}
+
+	if err := u.verificarMiembro(encuesta.UsuarioId, encuesta.GrupoId); err != nil {
+		return err
+	}
+
+	return u.repo.Create(encuesta)
+}
+
+func (u *encuestaUseCase) GetById(usuarioId uint64, id uint64) (*domain.Encuesta, error) {
+	encuesta, err := u.repo.GetById(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar la encuesta: %w", err)
+	}
+
+	if err := u.verificarMiembro(usuarioId, encuesta.GrupoId); err != nil {
+		return nil, err
+	}
+
+	return encuesta, nil
+}
+
+func (u *encuestaUseCase) GetByGrupoId(usuarioId uint64, grupoId uint64) ([]domain.Encuesta, error) {
+	if err := u.verificarMiembro(usuarioId, grupoId); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByGrupoId(grupoId)
+}
+
+func (u *encuestaUseCase) Votar(usuarioId uint64, id uint64, opcion int) (*domain.Encuesta, error) {
+	encuesta, err := u.GetById(usuarioId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if opcion < 0 || opcion >= len(encuesta.Opciones) {
+		return nil, fmt.Errorf("la opción debe estar entre 0 y %d", len(encuesta.Opciones)-1)
+	}
+
+	if encuesta.CierraEn != nil && !encuesta.CierraEn.After(time.Now()) {
+		return nil, errors.New("la encuesta está cerrada")
+	}
+
+	if err := u.repo.Votar(domain.VotoEncuesta{EncuestaId: id, UsuarioId: usuarioId, Opcion: opcion}); err != nil {
+		return nil, fmt.Errorf("error al registrar el voto: %w", err)
+	}
+
+	return u.repo.GetById(id)
+}
+
+func (u *encuestaUseCase) verificarMiembro(usuarioId uint64, grupoId uint64) error {
+	if grupoId <= 0 {
+		return errors.New("el ID del grupo debe ser mayor que cero")
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+
+	esMiembro, err := u.repoGrupoUsuario.VerifyMembership(usuarioId, grupo.Clave)
+	if err != nil {
+		return fmt.Errorf("error al verificar la membresía: %w", err)
+	}
+	if !esMiembro {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GrupoIAHandler struct {
+	GIUsecase domain.GrupoIAUseCase
+}
+
+// NewGrupoIAHandler registra la configuración de IA dentro del grupo de rutas de grupos
+func NewGrupoIAHandler(group fiber.Router, giu domain.GrupoIAUseCase) {
+	handler := &GrupoIAHandler{
+		GIUsecase: giu,
+	}
+
+	group.Get("/:id/ai", handler.GetGrupoIA)
+	group.Put("/:id/ai", handler.UpdateGrupoIA)
+}
+
+func (h *GrupoIAHandler) GetGrupoIA(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener la configuración de IA", "Error parametro", err.Error())
+	}
+
+	config, err := h.GIUsecase.Get(userId, id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener la configuración de IA", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Configuración de IA obtenida correctamente", "", config)
+}
+
+func (h *GrupoIAHandler) UpdateGrupoIA(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar la configuración de IA", "Error parametro", err.Error())
+	}
+
+	var config domain.GrupoIA
+	if err := c.BodyParser(&config); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar la configuración de IA", "Error de parseo", err.Error())
+	}
+
+	if err := h.GIUsecase.Update(userId, id, &config); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al actualizar la configuración de IA", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Configuración de IA actualizada correctamente", "", config)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresGrupoIARepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresGrupoIARepository(db *gorm.DB) domain.GrupoIARepository {
+	return &postgresGrupoIARepository{db: db}
+}
+
+func mapGormToDomainGrupoIA(gormConfig *models.GruposIA) *domain.GrupoIA {
+	if gormConfig == nil {
+		return nil
+	}
+
+	return &domain.GrupoIA{
+		Id:                gormConfig.Id,
+		GrupoId:           gormConfig.GrupoId,
+		BotsHabilitados:   gormConfig.BotsHabilitados,
+		ModoRespuesta:     gormConfig.ModoRespuesta,
+		MaxRespuestasHora: gormConfig.MaxRespuestasHora,
+		Persona:           gormConfig.Persona,
+		Traduccion:        gormConfig.Traduccion,
+		UpdatedAt:         gormConfig.UpdatedAt,
+	}
+}
+
+func (r *postgresGrupoIARepository) GetByGrupoId(grupoId uint64) (*domain.GrupoIA, int, error) {
+	var gormConfig models.GruposIA
+
+	err := r.db.Where("id_grupo = ?", grupoId).First(&gormConfig).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 404, nil
+		}
+		return nil, 500, fmt.Errorf("error al buscar la configuración de IA del grupo: %w", err)
+	}
+
+	return mapGormToDomainGrupoIA(&gormConfig), 200, nil
+}
+
+// Save crea o actualiza la configuración del grupo (una por grupo)
+func (r *postgresGrupoIARepository) Save(config *domain.GrupoIA) error {
+	gormConfig := models.GruposIA{
+		GrupoId:           config.GrupoId,
+		BotsHabilitados:   config.BotsHabilitados,
+		ModoRespuesta:     config.ModoRespuesta,
+		MaxRespuestasHora: config.MaxRespuestasHora,
+		Persona:           config.Persona,
+		Traduccion:        config.Traduccion,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_grupo"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bots_habilitados", "modo_respuesta", "max_respuestas_hora", "persona", "traduccion", "updated_at"}),
+	}).Create(&gormConfig).Error
+	if err != nil {
+		return err
+	}
+
+	config.Id = gormConfig.Id
+	config.UpdatedAt = gormConfig.UpdatedAt
+	return nil
+}
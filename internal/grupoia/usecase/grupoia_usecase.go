@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLargoPersona limita la persona, que se envía en cada prompt de los bots del grupo
+const maxLargoPersona = 2000
+
+var modosRespuesta = []string{
+	domain.ModoRespuestaSiempre,
+	domain.ModoRespuestaMenciones,
+	domain.ModoRespuestaInactividad,
+	domain.ModoRespuestaApagado,
+}
+
+type grupoIAUseCase struct {
+	repo             domain.GrupoIARepository
+	repoGrupo        domain.GrupoRepository
+	repoGrupoUsuario domain.GrupoUsuarioRepository
+	repoUsuario      domain.UsuarioRepository
+}
+
+func NewGrupoIAUseCase(repo domain.GrupoIARepository, repoGrupo domain.GrupoRepository, repoGrupoUsuario domain.GrupoUsuarioRepository, repoUsuario domain.UsuarioRepository) domain.GrupoIAUseCase {
+	return &grupoIAUseCase{
+		repo:             repo,
+		repoGrupo:        repoGrupo,
+		repoGrupoUsuario: repoGrupoUsuario,
+		repoUsuario:      repoUsuario,
+	}
+}
+
+func (u *grupoIAUseCase) GetByGrupoId(grupoId uint64) (*domain.GrupoIA, error) {
+	config, status, err := u.repo.GetByGrupoId(grupoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == 404 {
+		return &domain.GrupoIA{
+			GrupoId:         grupoId,
+			BotsHabilitados: []uint64{},
+			ModoRespuesta:   domain.ModoRespuestaSiempre,
+			PorDefecto:      true,
+		}, nil
+	}
+
+	return config, nil
+}
+
+func (u *grupoIAUseCase) Get(usuarioId uint64, grupoId uint64) (*domain.GrupoIA, error) {
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+
+	esMiembro, err := u.repoGrupoUsuario.VerifyMembership(usuarioId, grupo.Clave)
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar la membresía: %w", err)
+	}
+	if !esMiembro {
+		return nil, errors.New("el usuario no pertenece al grupo")
+	}
+
+	return u.GetByGrupoId(grupoId)
+}
+
+func (u *grupoIAUseCase) Update(usuarioId uint64, grupoId uint64, config *domain.GrupoIA) error {
+	if config == nil {
+		return errors.New("la configuración no puede ser nula")
+	}
+
+	if config.ModoRespuesta == "" {
+		config.ModoRespuesta = domain.ModoRespuestaSiempre
+	}
+	if !slices.Contains(modosRespuesta, config.ModoRespuesta) {
+		return fmt.Errorf("modoRespuesta inválido %q; válidos: %v", config.ModoRespuesta, modosRespuesta)
+	}
+
+	if config.MaxRespuestasHora < 0 {
+		return errors.New("maxRespuestasHora no puede ser negativo")
+	}
+
+	config.Persona = strings.TrimSpace(config.Persona)
+	if utf8.RuneCountInString(config.Persona) > maxLargoPersona {
+		return fmt.Errorf("la persona no puede superar los %d caracteres", maxLargoPersona)
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+	if grupo == nil {
+		return errors.New("el grupo no existe")
+	}
+	if grupo.CreatedById != usuarioId {
+		return errors.New("solo el creador del grupo puede cambiar la configuración de IA")
+	}
+
+	if config.BotsHabilitados == nil {
+		config.BotsHabilitados = []uint64{}
+	}
+	if err := u.validarBots(grupoId, config.BotsHabilitados); err != nil {
+		return err
+	}
+
+	config.GrupoId = grupoId
+	config.PorDefecto = false
+	return u.repo.Save(config)
+}
+
+// validarBots comprueba que los bots habilitados sean usuarios IA del grupo
+func (u *grupoIAUseCase) validarBots(grupoId uint64, bots []uint64) error {
+	if len(bots) == 0 {
+		return nil
+	}
+
+	integrantes, err := u.repoUsuario.GetAllByGrupoId(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al obtener los integrantes del grupo: %w", err)
+	}
+
+	for _, botId := range bots {
+		esBot := slices.ContainsFunc(integrantes, func(integrante domain.Usuario) bool {
+			return integrante.Id == botId && integrante.IsLlm
+		})
+		if !esBot {
+			return fmt.Errorf("el usuario %d no es un bot del grupo", botId)
+		}
+	}
+
+	return nil
+}
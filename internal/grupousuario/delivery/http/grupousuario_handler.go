@@ -20,6 +20,7 @@ func NewGrupoUsuarioHandler(group fiber.Router, uc domain.GrupoUsuarioUseCase) {
 	group.Get("/group/:userId", handler.GetByUsuarioId)
 	group.Post("/add", handler.AddUserToGroup)
 	group.Post("/verify-membership", handler.VerifyMembership)
+	group.Delete("/:id", handler.LeaveGroup)
 }
 
 func NewAdminGrupoUsuarioHandler(group fiber.Router, uc domain.GrupoUsuarioUseCase) {
@@ -27,7 +28,8 @@ func NewAdminGrupoUsuarioHandler(group fiber.Router, uc domain.GrupoUsuarioUseCa
 		Usecase: uc,
 	}
 
-	group.Post("/group-users", handler.AdminAddUserToGroup)
+	group.Post("/group-users", handler.AdminAddUsersToGroup)
+	group.Post("/group-users/remove", handler.AdminRemoveUsersFromGroups)
 	group.Post("/group/:id/user", handler.AdminAddUserToGroup)
 }
 
@@ -140,3 +142,39 @@ func (h *GrupoUsuarioHandler) AdminAddUsersToGroup(c *fiber.Ctx) error {
 
 	return pkg.ResponseJson(c, fiber.StatusCreated, "Grupos asignados correctamente a los usuarios", "", nil)
 }
+
+func (h *GrupoUsuarioHandler) LeaveGroup(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	grupoId, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al salir del grupo", "Error parametro", err.Error())
+	}
+
+	if err := h.Usecase.LeaveGroup(userId, grupoId); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al salir del grupo", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Salida del grupo realizada correctamente", "", nil)
+}
+
+func (h *GrupoUsuarioHandler) AdminRemoveUsersFromGroups(c *fiber.Ctx) error {
+
+	var body struct {
+		GroupsIds []uint64 `json:"groupsIds"`
+		UsersIds  []uint64 `json:"usersIds"`
+	}
+
+	if err := c.BodyParser(&body); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al parsear el cuerpo de la solicitud", "Error de formato", err.Error())
+	}
+
+	if err := h.Usecase.LeaveGroups(body.UsersIds, body.GroupsIds); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al quitar usuarios de los grupos", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Usuarios quitados correctamente de los grupos", "", nil)
+}
@@ -20,8 +20,9 @@ func mapGormToDomainGrupoUsuario(gu *models.GruposUsuarios) *domain.GrupoUsuario
 		return nil
 	}
 	return &domain.GrupoUsuario{
-		IdGrupo:   gu.IdGrupo,
-		IdUsuario: gu.IdUsuario,
+		IdGrupo:    gu.IdGrupo,
+		IdUsuario:  gu.IdUsuario,
+		Silenciado: gu.Silenciado,
 	}
 }
 
@@ -72,6 +73,37 @@ func (r *postgresGrupoUsuarioRepository) VerifyMembership(userId uint64, clave s
 	return exists, nil
 }
 
+// SetSilenciado actualiza la preferencia de silencio; devuelve false si el usuario no pertenece al grupo
+func (r *postgresGrupoUsuarioRepository) SetSilenciado(userId uint64, clave string, silenciado bool) (bool, error) {
+	result := r.db.Exec(`
+        UPDATE grupos_usuarios SET silenciado = ?
+        FROM grupos
+        WHERE grupos_usuarios.id_grupo = grupos.id
+          AND grupos_usuarios.id_usuario = ? AND grupos.clave = ?`,
+		silenciado, userId, clave)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *postgresGrupoUsuarioRepository) GetClavesSilenciadas(userId uint64) ([]string, error) {
+	var claves []string
+
+	err := r.db.Model(&models.GruposUsuarios{}).
+		Joins("JOIN grupos ON grupos_usuarios.id_grupo = grupos.id").
+		Where("grupos_usuarios.id_usuario = ? AND grupos_usuarios.silenciado", userId).
+		Pluck("grupos.clave", &claves).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return claves, nil
+}
+
 func (r *postgresGrupoUsuarioRepository) Create(grupoUsuario *domain.GrupoUsuario) error {
 	gormGu := mapDomainToGormGrupoUsuario(grupoUsuario)
 	if err := r.db.Create(gormGu).Error; err != nil {
@@ -79,3 +111,11 @@ func (r *postgresGrupoUsuarioRepository) Create(grupoUsuario *domain.GrupoUsuari
 	}
 	return nil
 }
+
+func (r *postgresGrupoUsuarioRepository) Delete(userId uint64, grupoId uint64) (bool, error) {
+	result := r.db.Where("id_usuario = ? AND id_grupo = ?", userId, grupoId).Delete(&models.GruposUsuarios{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -4,6 +4,7 @@ import (
 	"chatvis-chat/internal/domain"
 	"chatvis-chat/internal/pkg"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 )
@@ -11,12 +12,15 @@ import (
 type grupoUsuarioUseCase struct {
 	repo      domain.GrupoUsuarioRepository
 	repoGrupo domain.GrupoRepository
+	// publicador recibe las altas y bajas para actualizar las suscripciones en vivo; puede ser nil
+	publicador domain.PublicadorMembresia
 }
 
-func NewGrupoUsuarioUseCase(repo domain.GrupoUsuarioRepository, repoGrupo domain.GrupoRepository) domain.GrupoUsuarioUseCase {
+func NewGrupoUsuarioUseCase(repo domain.GrupoUsuarioRepository, repoGrupo domain.GrupoRepository, publicador domain.PublicadorMembresia) domain.GrupoUsuarioUseCase {
 	return &grupoUsuarioUseCase{
-		repo:      repo,
-		repoGrupo: repoGrupo,
+		repo:       repo,
+		repoGrupo:  repoGrupo,
+		publicador: publicador,
 	}
 }
 
@@ -36,6 +40,35 @@ func (u *grupoUsuarioUseCase) GetByUsuarioId(usuarioId uint64) (*domain.GrupoUsu
 	return u.repo.GetByUsuarioId(usuarioId)
 }
 
+func (u *grupoUsuarioUseCase) SetSilenciado(userId uint64, clave string, silenciado bool) error {
+	if userId <= 0 {
+		return errors.New("el ID del usuario debe ser mayor que cero")
+	}
+
+	if len(strings.TrimSpace(clave)) == 0 {
+		return errors.New("la clave no puede estar vacía")
+	}
+
+	ok, err := u.repo.SetSilenciado(userId, clave, silenciado)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+
+	return nil
+}
+
+func (u *grupoUsuarioUseCase) GetClavesSilenciadas(userId uint64) ([]string, error) {
+	if userId <= 0 {
+		return nil, errors.New("el ID del usuario debe ser mayor que cero")
+	}
+
+	return u.repo.GetClavesSilenciadas(userId)
+}
+
 func (u *grupoUsuarioUseCase) VerifyMembership(userId uint64, clave string) (bool, error) {
 	log.Println("VerifyMembership - Clave:", clave, "ID Usuario:", userId)
 
@@ -82,7 +115,12 @@ func (u *grupoUsuarioUseCase) JoinGroup(userId uint64, clave string) error {
 		IdUsuario: userId,
 	}
 
-	return u.repo.Create(newGroupUser)
+	if err := u.repo.Create(newGroupUser); err != nil {
+		return err
+	}
+
+	u.publicar(domain.EventoMembresiaAlta, existsGroup, userId)
+	return nil
 }
 
 func (u *grupoUsuarioUseCase) JoinGroups(usersIds []uint64, gruposIds []uint64) error {
@@ -96,18 +134,111 @@ func (u *grupoUsuarioUseCase) JoinGroups(usersIds []uint64, gruposIds []uint64)
 		return errors.New("la lista de IDs de grupos debe tener al menos un elemento")
 	}
 
+	grupos, err := u.grupos(gruposIds)
+	if err != nil {
+		return err
+	}
+
 	for _, userId := range usersIds {
-		for _, groupId := range gruposIds {
+		for _, grupo := range grupos {
 			newGroupUser := &domain.GrupoUsuario{
-				IdGrupo:   groupId,
+				IdGrupo:   grupo.Id,
 				IdUsuario: userId,
 			}
 
 			if err := u.repo.Create(newGroupUser); err != nil {
 				return err
 			}
+
+			u.publicar(domain.EventoMembresiaAlta, grupo, userId)
 		}
 	}
 
 	return nil
 }
+
+func (u *grupoUsuarioUseCase) LeaveGroup(userId uint64, grupoId uint64) error {
+	if userId <= 0 {
+		return errors.New("el ID del usuario debe ser mayor que cero")
+	}
+
+	grupos, err := u.grupos([]uint64{grupoId})
+	if err != nil {
+		return err
+	}
+
+	ok, err := u.repo.Delete(userId, grupoId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+
+	u.publicar(domain.EventoMembresiaBaja, grupos[0], userId)
+	return nil
+}
+
+// LeaveGroups quita a los usuarios de los grupos; las combinaciones sin membresía se ignoran
+func (u *grupoUsuarioUseCase) LeaveGroups(usersIds []uint64, gruposIds []uint64) error {
+	log.Println("LeaveGroups - IDs Usuarios:", usersIds)
+
+	if len(usersIds) == 0 {
+		return errors.New("la lista de IDs de usuarios debe tener al menos un elemento")
+	}
+
+	if len(gruposIds) == 0 {
+		return errors.New("la lista de IDs de grupos debe tener al menos un elemento")
+	}
+
+	grupos, err := u.grupos(gruposIds)
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range usersIds {
+		for _, grupo := range grupos {
+			ok, err := u.repo.Delete(userId, grupo.Id)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				u.publicar(domain.EventoMembresiaBaja, grupo, userId)
+			}
+		}
+	}
+
+	return nil
+}
+
+// grupos busca los grupos por ID; la clave hace falta para los eventos de membresía
+func (u *grupoUsuarioUseCase) grupos(gruposIds []uint64) ([]*domain.Grupo, error) {
+	grupos := make([]*domain.Grupo, 0, len(gruposIds))
+	for _, grupoId := range gruposIds {
+		if grupoId <= 0 {
+			return nil, errors.New("el ID del grupo debe ser mayor que cero")
+		}
+
+		grupo, err := u.repoGrupo.GetById(grupoId)
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar el grupo %d: %w", grupoId, err)
+		}
+		grupos = append(grupos, grupo)
+	}
+
+	return grupos, nil
+}
+
+func (u *grupoUsuarioUseCase) publicar(tipo string, grupo *domain.Grupo, userId uint64) {
+	if u.publicador == nil {
+		return
+	}
+
+	u.publicador.PublicarMembresia(domain.EventoMembresia{
+		Tipo:       tipo,
+		GrupoId:    grupo.Id,
+		GrupoClave: grupo.Clave,
+		UsuarioId:  userId,
+	})
+}
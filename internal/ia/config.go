@@ -1,6 +1,12 @@
 package ia
 
-import "os"
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"os"
+	"strconv"
+	"time"
+)
 
 type IAConfig struct {
 	UserID     string
@@ -8,32 +14,139 @@ type IAConfig struct {
 	LLMName    string
 	LLMAPIKey  string
 	IsPromt    bool
+
+	// Plantilla text/template del prompt de sistema; vacía usa DefaultPromptTemplate
+	PromptTemplate string
+
+	// Formato con el que se envía cada turno del historial (HistoryFormatInline o HistoryFormatJSON)
+	HistoryFormat string
+
+	// Proveedor y parámetros de muestreo propios de cada bot
+	Provider    llm.ProviderType
+	Temperature float64
+	MaxTokens   int
+	Stop        []string
+	Timeout     time.Duration
+	Workers     int
+
+	// Debounce es la ventana en la que una ráfaga de mensajes del grupo se agrupa en una sola respuesta
+	Debounce time.Duration
+
+	// Stream habilita el envío incremental de la respuesta (frames ai_delta)
+	Stream bool
+
+	// IdleAfter es el tiempo sin actividad tras el cual el bot habla por iniciativa propia; 0 lo desactiva
+	IdleAfter time.Duration
+	// MaxIdleMessages limita los mensajes no solicitados seguidos antes de que vuelva a hablar un humano
+	MaxIdleMessages int
+
+	// ContextTokens es la ventana de contexto del modelo; 0 la estima a partir de LLMName
+	ContextTokens int
+	// HistoryMessages es el máximo de mensajes recientes que se envían como historial
+	HistoryMessages int
+
+	// StructuredOutput envía el schema de respuesta como response_format (OpenAI, LM Studio) o format (Ollama)
+	StructuredOutput bool
+
+	// OnlyMentions hace que el bot solo responda cuando lo mencionan o responden a uno de sus mensajes
+	OnlyMentions bool
+
+	// MaxTokensPerDay es la cuota diaria de tokens del bot en todos sus grupos; 0 = sin límite
+	MaxTokensPerDay int
+
+	// RecallMessages es la cantidad de mensajes anteriores relevantes que se recuperan por búsqueda
+	// semántica; 0 lo desactiva
+	RecallMessages int
+
+	// Tools son las herramientas integradas que el bot puede invocar (ver tools.go) y MaxToolSteps
+	// el máximo de rondas de llamadas antes de exigir la respuesta final
+	Tools        []string
+	MaxToolSteps int
+
+	// Fallbacks son los proveedores y modelos que se prueban cuando el principal no está disponible
+	Fallbacks []llm.FallbackConfig
+
+	// Cache responde los prompts repetidos desde la caché de respuestas compartida
+	Cache bool
+}
+
+// ProviderConfig devuelve la configuración necesaria para construir el llm.Provider del bot
+func (c IAConfig) ProviderConfig() llm.ProviderConfig {
+	return llm.ProviderConfig{
+		Type:    c.Provider,
+		BaseURL: c.LLMBaseURL,
+		APIKey:  c.LLMAPIKey,
+		Timeout: c.Timeout,
+
+		Fallbacks: c.Fallbacks,
+		Cache:     c.Cache,
+	}
+}
+
+// CompletionOptions devuelve los parámetros de muestreo configurados para el bot
+func (c IAConfig) CompletionOptions() llm.CompletionOptions {
+	options := llm.CompletionOptions{
+		Temperature: c.Temperature,
+		MaxTokens:   c.MaxTokens,
+		Stop:        c.Stop,
+	}
+	if c.StructuredOutput {
+		options.ResponseFormat = replyResponseFormat
+	}
+	return options
+}
+
+// ConfigFromBot construye la configuración en ejecución a partir del bot persistido.
+// La API key se resuelve desde la variable de entorno indicada en APIKeyRef.
+func ConfigFromBot(bot domain.Bot) IAConfig {
+	var fallbacks []llm.FallbackConfig
+	for _, fallback := range bot.Fallbacks {
+		fallbacks = append(fallbacks, llm.FallbackConfig{
+			Provider: llm.ProviderConfig{
+				Type:    llm.ProviderType(fallback.ProviderType),
+				BaseURL: fallback.BaseURL,
+				APIKey:  apiKeyFromEnv(fallback.APIKeyRef),
+				Timeout: time.Duration(bot.TimeoutSeconds) * time.Second,
+			},
+			Model: fallback.ModelName,
+		})
+	}
+
+	return IAConfig{
+		UserID:           strconv.FormatUint(bot.UsuarioId, 10),
+		LLMBaseURL:       bot.BaseURL,
+		LLMName:          bot.ModelName,
+		LLMAPIKey:        apiKeyFromEnv(bot.APIKeyRef),
+		IsPromt:          bot.IsPromt,
+		PromptTemplate:   bot.Prompt,
+		HistoryFormat:    bot.HistoryFormat,
+		Provider:         llm.ProviderType(bot.ProviderType),
+		Temperature:      bot.Temperature,
+		MaxTokens:        bot.MaxTokens,
+		Stop:             bot.Stop,
+		Timeout:          time.Duration(bot.TimeoutSeconds) * time.Second,
+		Workers:          bot.Workers,
+		Debounce:         time.Duration(bot.DebounceMs) * time.Millisecond,
+		Stream:           bot.Stream,
+		IdleAfter:        time.Duration(bot.IdleAfterSeconds) * time.Second,
+		MaxIdleMessages:  bot.MaxIdleMessages,
+		OnlyMentions:     bot.SoloMenciones,
+		ContextTokens:    bot.ContextTokens,
+		HistoryMessages:  bot.HistoryMessages,
+		StructuredOutput: bot.StructuredOutput,
+		Fallbacks:        fallbacks,
+		MaxTokensPerDay:  bot.MaxTokensDia,
+		RecallMessages:   bot.Recuerdos,
+		Tools:            bot.Herramientas,
+		MaxToolSteps:     bot.MaxPasosHerramientas,
+		Cache:            bot.UsarCache,
+	}
 }
 
-var AiConfigurations = []IAConfig{
-	{
-		UserID:     "5",
-		LLMBaseURL: "http://localhost:11434/v1",
-		LLMName:    "gemma3:27b",
-		LLMAPIKey:  os.Getenv("LLM_API_KEY_1"),
-	},
-	{
-		UserID:     "6",
-		LLMBaseURL: "http://localhost:1234/v1/chat/completions",
-		LLMName:    "google/gemma-3-27b",
-		LLMAPIKey:  os.Getenv("LLM_API_KEY_2"),
-		IsPromt:    false,
-	},
-	// {
-	// 	UserID:     "7",
-	// 	LLMBaseURL: "http://localhost:1234/api/chat",
-	// 	LLMName:    "gemma3:27b",
-	// 	LLMAPIKey:  os.Getenv("LLM_API_KEY_3"),
-	// },
-	// {
-	// 	UserID:     "8",
-	// 	LLMBaseURL: "http://localhost:1234/api/chat",
-	// 	LLMName:    "qwen3-coder:480b-cloud",
-	// 	LLMAPIKey:  os.Getenv("LLM_API_KEY_4"),
-	// },
+// apiKeyFromEnv resuelve la API key desde la variable de entorno indicada ("" si no hay referencia)
+func apiKeyFromEnv(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return os.Getenv(ref)
 }
@@ -0,0 +1,316 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultHistoryMessages es la cantidad máxima de mensajes recientes que se envían al modelo
+	defaultHistoryMessages = 40
+	// defaultReplyTokens se reserva para la respuesta cuando el bot no define MaxTokens
+	defaultReplyTokens = 1024
+	// instructionTokens se reserva para instrucciones extra (por ejemplo, el aviso de inactividad)
+	instructionTokens = 128
+	// maxQuotedMessages limita los mensajes citados fuera de la ventana que se agregan al prompt
+	maxQuotedMessages = 5
+	// summaryChunks y summaryChunkMessages acotan cuánto historial pendiente se resume por respuesta
+	summaryChunks        = 4
+	summaryChunkMessages = 200
+	// minSummaryChunkTokens evita bloques de resumen demasiado pequeños en modelos con poco contexto
+	minSummaryChunkTokens = 512
+	// recallQueryMessages es la cantidad de mensajes recientes de otros integrantes con los que se
+	// arma la consulta de los recuerdos
+	recallQueryMessages = 3
+)
+
+// promptContext es el historial que recibe el modelo: un resumen de lo anterior, los mensajes
+// anteriores relevantes recuperados por búsqueda semántica, los mensajes citados que quedaron
+// fuera de la ventana y los últimos mensajes del grupo.
+type promptContext struct {
+	Resumen   string
+	Recuerdos []domain.Mensaje
+	Citados   []domain.Mensaje
+	Mensajes  []domain.Mensaje
+}
+
+// Visible devuelve los mensajes que el modelo tiene a la vista (válidos como answer_id)
+func (c promptContext) Visible() []domain.Mensaje {
+	visible := append(slices.Clone(c.Recuerdos), c.Citados...)
+	return append(visible, c.Mensajes...)
+}
+
+// contextBudget reparte la ventana de contexto del modelo, en tokens estimados
+type contextBudget struct {
+	Total   int
+	Reply   int
+	System  int
+	Summary int
+	Quoted  int
+	// Recalled es la parte de los recuerdos; 0 si el bot no los usa
+	Recalled int
+	History  int
+}
+
+// budget calcula el presupuesto de tokens del prompt para el modelo del bot
+func (s *AIService) budget(systemPrompt string) contextBudget {
+	total := s.Config.ContextTokens
+	if total <= 0 {
+		total = llm.ContextWindow(s.Config.LLMName)
+	}
+
+	reply := s.Config.MaxTokens
+	if reply <= 0 {
+		reply = defaultReplyTokens
+	}
+
+	b := contextBudget{
+		Total:   total,
+		Reply:   reply,
+		System:  llm.EstimateTokens(systemPrompt) + instructionTokens,
+		Summary: total / 8,
+		Quoted:  total / 16,
+	}
+	if s.recallEnabled() {
+		b.Recalled = total / 16
+	}
+	b.History = max(b.Total-b.Reply-b.System-b.Summary-b.Quoted-b.Recalled, 0)
+	return b
+}
+
+// buildContext arma el historial del prompt sin superar el presupuesto, sin importar cuántos
+// mensajes se hayan acumulado. Lo que queda fuera de la ventana se incorpora al resumen.
+func (s *AIService) buildContext(ctx context.Context, aiUserID uint64, grupoID uint64, systemPrompt string) (promptContext, error) {
+	b := s.budget(systemPrompt)
+
+	limite := s.Config.HistoryMessages
+	if limite <= 0 {
+		limite = defaultHistoryMessages
+	}
+
+	recientes, err := s.MensajeUseCase.GetUltimosMensajes(grupoID, limite)
+	if err != nil {
+		return promptContext{}, fmt.Errorf("error al obtener los últimos mensajes: %w", err)
+	}
+
+	mensajes := s.fitHistory(recientes, b.History)
+	if len(mensajes) == 0 {
+		return promptContext{}, nil
+	}
+
+	citados := s.quotedMessages(grupoID, mensajes, b.Quoted)
+	return promptContext{
+		Resumen:   s.updateSummary(ctx, aiUserID, grupoID, mensajes[0].Id, b),
+		Recuerdos: s.recalledMessages(ctx, aiUserID, grupoID, mensajes, citados, b.Recalled),
+		Citados:   citados,
+		Mensajes:  mensajes,
+	}, nil
+}
+
+// fitHistory conserva los mensajes más recientes que caben en el presupuesto (al menos el último)
+func (s *AIService) fitHistory(mensajes []domain.Mensaje, budget int) []domain.Mensaje {
+	used := 0
+	start := len(mensajes)
+	for i := len(mensajes) - 1; i >= 0; i-- {
+		_, content := s.historyTurn(mensajes[i])
+		cost := llm.EstimateTokens(content) + 4
+		if used+cost > budget && start < len(mensajes) {
+			break
+		}
+		used += cost
+		start = i
+	}
+	return mensajes[start:]
+}
+
+// quotedMessages busca los mensajes a los que responde la ventana y que quedaron fuera de ella
+func (s *AIService) quotedMessages(grupoID uint64, mensajes []domain.Mensaje, budget int) []domain.Mensaje {
+	enVentana := make(map[uint64]bool, len(mensajes))
+	for _, m := range mensajes {
+		enVentana[m.Id] = true
+	}
+
+	var ids []uint64
+	for i := len(mensajes) - 1; i >= 0 && len(ids) < maxQuotedMessages; i-- {
+		respuesta := mensajes[i].ResponseId
+		if respuesta != nil && !enVentana[*respuesta] && !slices.Contains(ids, *respuesta) {
+			ids = append(ids, *respuesta)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	citados, err := s.MensajeUseCase.GetByIds(ids)
+	if err != nil {
+		log.Printf("AIService: error al obtener los mensajes citados: %v", err)
+		return nil
+	}
+
+	var result []domain.Mensaje
+	used := 0
+	for _, m := range citados {
+		if m.GrupoId != grupoID {
+			continue
+		}
+		cost := llm.EstimateTokens(formatHistoryMessage(m, s.Config.HistoryFormat)) + 4
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		result = append(result, m)
+	}
+	return result
+}
+
+// recallEnabled indica si el bot recupera mensajes anteriores relevantes
+func (s *AIService) recallEnabled() bool {
+	return s.Recuerdos != nil && s.Config.RecallMessages > 0
+}
+
+// recalledMessages busca, entre los mensajes anteriores a la ventana, los más parecidos a lo
+// último que escribieron los demás integrantes. Se devuelven en orden cronológico.
+func (s *AIService) recalledMessages(ctx context.Context, aiUserID uint64, grupoID uint64, mensajes []domain.Mensaje, citados []domain.Mensaje, budget int) []domain.Mensaje {
+	if !s.recallEnabled() {
+		return nil
+	}
+
+	var consulta []string
+	for i := len(mensajes) - 1; i >= 0 && len(consulta) < recallQueryMessages; i-- {
+		if mensajes[i].UsuarioId != aiUserID && strings.TrimSpace(mensajes[i].Contenido) != "" {
+			consulta = append(consulta, mensajes[i].Contenido)
+		}
+	}
+	if len(consulta) == 0 {
+		return nil
+	}
+	slices.Reverse(consulta)
+
+	resultados, err := s.Recuerdos.Relevantes(ctx, grupoID, strings.Join(consulta, "\n"), s.Config.RecallMessages, mensajes[0].Id)
+	if err != nil {
+		log.Printf("AIService: error al buscar mensajes relevantes del grupo %d: %v", grupoID, err)
+		return nil
+	}
+
+	var result []domain.Mensaje
+	used := 0
+	for _, r := range resultados {
+		if slices.ContainsFunc(citados, func(m domain.Mensaje) bool { return m.Id == r.Mensaje.Id }) {
+			continue
+		}
+		cost := llm.EstimateTokens(formatHistoryMessage(r.Mensaje, s.Config.HistoryFormat)) + 4
+		if used+cost > budget {
+			continue
+		}
+		used += cost
+		result = append(result, r.Mensaje)
+	}
+
+	slices.SortFunc(result, func(a, b domain.Mensaje) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return result
+}
+
+// updateSummary incorpora al resumen del grupo los mensajes anteriores a la ventana que aún no
+// cubre y lo guarda junto al checkpoint. Si hay más pendiente de lo que se resume por respuesta,
+// se priorizan los mensajes más recientes.
+func (s *AIService) updateSummary(ctx context.Context, aiUserID uint64, grupoID uint64, windowStartID uint64, b contextBudget) string {
+	resumen, err := s.MensajeUseCase.GetResumen(aiUserID, grupoID)
+	if err != nil {
+		log.Printf("AIService: error al obtener el resumen del grupo %d: %v", grupoID, err)
+		return ""
+	}
+
+	limite := summaryChunks * summaryChunkMessages
+	pendientes, err := s.MensajeUseCase.GetMensajesRango(grupoID, resumen.HastaMensajeId, windowStartID, limite)
+	if err != nil {
+		log.Printf("AIService: error al obtener los mensajes a resumir del grupo %d: %v", grupoID, err)
+		return resumen.Texto
+	}
+	if len(pendientes) == 0 {
+		return resumen.Texto
+	}
+	if len(pendientes) == limite {
+		log.Printf("AIService: el grupo %d tiene más de %d mensajes sin resumir; se omiten los más antiguos.", grupoID, limite)
+	}
+
+	chunkBudget := max(b.Total/2-b.Summary, minSummaryChunkTokens)
+	actualizado := *resumen
+	for _, chunk := range chunkMessages(pendientes, chunkBudget) {
+		texto, err := s.summarize(ctx, grupoID, actualizado.Texto, chunk, b.Summary)
+		if err != nil {
+			log.Printf("AIService: error al resumir el historial del grupo %d: %v", grupoID, err)
+			break
+		}
+		actualizado.Texto = texto
+		actualizado.HastaMensajeId = chunk[len(chunk)-1].Id
+	}
+
+	if actualizado.HastaMensajeId != resumen.HastaMensajeId {
+		if err := s.MensajeUseCase.GuardarResumen(aiUserID, grupoID, &actualizado); err != nil {
+			log.Printf("AIService: error al guardar el resumen del grupo %d: %v", grupoID, err)
+		}
+	}
+
+	return actualizado.Texto
+}
+
+// chunkMessages divide los mensajes en bloques que caben en el presupuesto de tokens
+func chunkMessages(mensajes []domain.Mensaje, budget int) [][]domain.Mensaje {
+	return llm.ChunkByTokens(mensajes, budget, func(m domain.Mensaje) string {
+		return formatHistoryMessage(m, HistoryFormatInline)
+	})
+}
+
+// summarize pide al modelo del bot un resumen actualizado con los mensajes nuevos
+func (s *AIService) summarize(ctx context.Context, grupoID uint64, previo string, mensajes []domain.Mensaje, maxTokens int) (string, error) {
+	var historial strings.Builder
+	for _, m := range mensajes {
+		historial.WriteString(formatHistoryMessage(m, HistoryFormatInline))
+		historial.WriteString("\n")
+	}
+
+	if previo == "" {
+		previo = "(sin resumen previo)"
+	}
+
+	options := s.Config.CompletionOptions()
+	options.MaxTokens = maxTokens
+	options.Stop = nil
+	options.ResponseFormat = nil
+
+	completion, err := s.completeAndRecord(ctx, grupoID, llm.CompletionRequest{
+		Model: s.Config.LLMName,
+		Messages: []llm.ChatMessage{
+			{
+				Role: "system",
+				Content: "Resumes conversaciones de un chat grupal. Conserva quién dijo qué (@apodo), los temas, " +
+					"acuerdos, planes y preguntas pendientes. Responde solo con el resumen en texto plano, en un máximo de " +
+					strconv.Itoa(maxTokens*3/4) + " palabras.",
+			},
+			{
+				Role:    "user",
+				Content: "Resumen anterior:\n" + previo + "\n\nMensajes nuevos:\n" + historial.String(),
+			},
+		},
+		Options: options,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	texto := strings.TrimSpace(completion.Content)
+	if texto == "" {
+		return "", errors.New("el modelo devolvió un resumen vacío")
+	}
+	return texto, nil
+}
@@ -0,0 +1,52 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// digestInterval es cada cuánto se buscan suscripciones con el digest del día pendiente
+	digestInterval = time.Minute
+	// digestBatch limita los digests que se generan por ronda
+	digestBatch = 20
+)
+
+// DigestScheduler genera el digest diario de los usuarios suscritos una vez pasada su hora.
+// Usa el modelo de resúmenes, así que no depende de que haya bots en ejecución.
+type DigestScheduler struct {
+	usecase  domain.ResumenUseCase
+	interval time.Duration
+}
+
+func NewDigestScheduler(ru domain.ResumenUseCase) *DigestScheduler {
+	return &DigestScheduler{
+		usecase:  ru,
+		interval: digestInterval,
+	}
+}
+
+// Run genera periódicamente los digests pendientes hasta que se cancele el contexto
+func (d *DigestScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := d.usecase.GenerarDigestsPendientes(ctx, time.Now(), digestBatch)
+		if err != nil {
+			log.Printf("DigestScheduler: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("DigestScheduler: %d digests generados", n)
+		}
+	}
+}
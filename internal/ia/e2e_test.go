@@ -0,0 +1,880 @@
+package ia_test
+
+import (
+	"chatvis-chat/internal/domain"
+	embeddingUseCase "chatvis-chat/internal/embeddingmensaje/usecase"
+	"chatvis-chat/internal/ia"
+	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/llm/llmtest"
+	moderacionUseCase "chatvis-chat/internal/moderacion/usecase"
+	resumenUseCase "chatvis-chat/internal/resumen/usecase"
+	traduccionUseCase "chatvis-chat/internal/traduccion/usecase"
+	"chatvis-chat/internal/websocket"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	fiberws "github.com/gofiber/websocket/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Pruebas de punta a punta del circuito de los bots: un cliente WebSocket real escribe en el
+// grupo a través del Hub, el enrutador de Manager entrega el mensaje a los AIService y estos
+// llaman al servidor LLM falso de llmtest. La base de datos se reemplaza por memory.
+
+const (
+	humanoID = 1
+	botID    = 2
+	grupoID  = 1
+	clave    = "grupo-e2e"
+
+	waitTimeout = 5 * time.Second
+)
+
+type harness struct {
+	t       *testing.T
+	store   *memory
+	hub     *websocket.Hub
+	manager *ia.Manager
+	wsURL   string
+}
+
+// newHarness levanta el Hub, el controlador WebSocket, el Manager y su enrutador con un humano
+// (humanoID) y los bots indicados en el grupo clave
+func newHarness(t *testing.T, bots ...domain.Bot) *harness {
+	t.Helper()
+	t.Setenv("SECRET_KEY_JWT", "e2e-secret")
+
+	store := newMemory()
+	store.addUsuario(domain.Usuario{Id: humanoID, Nombre: "Ana", Apodo: "ana", IsActive: true})
+	miembros := []uint64{humanoID}
+	for _, bot := range bots {
+		store.addUsuario(domain.Usuario{Id: bot.UsuarioId, Nombre: "Azul " + strconv.FormatUint(bot.UsuarioId, 10), Apodo: "azul" + strconv.FormatUint(bot.UsuarioId, 10), IsLlm: true, IsActive: true})
+		miembros = append(miembros, bot.UsuarioId)
+	}
+	store.addGrupo(domain.Grupo{Id: grupoID, Clave: clave, Nombre: "Pruebas"}, miembros...)
+
+	hub := websocket.NewHub()
+	go hub.Run()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager := ia.NewManager(ctx, hub, memMensajes{store}, memGrupos{store}, memUsuarios{store}, memPoliticas{store}, memUso{store}, memGruposIA{store})
+	manager.SalidaFallidaUseCase = memSalidas{store}
+	manager.InteraccionIAUseCase = memInteracciones{store}
+	for _, bot := range bots {
+		if err := manager.StartBot(bot); err != nil {
+			t.Fatalf("no se pudo iniciar el bot %d: %v", bot.UsuarioId, err)
+		}
+	}
+	go manager.Route(ctx, hub.AIChannel(), nil)
+
+	controller := websocket.NewWebSocketController(hub, memGrupos{store}, memUsuarios{store}, memGrupoUsuarios{store}, memMensajes{store})
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", controller.WebSocketUpgrade, fiberws.New(controller.WebSocketChat))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("no se pudo abrir el puerto del servidor: %v", err)
+	}
+	go app.Listener(ln)
+
+	t.Cleanup(func() {
+		manager.StopAll()
+		cancel()
+		_ = app.Shutdown()
+		hub.Shutdown()
+	})
+
+	return &harness{t: t, store: store, hub: hub, manager: manager, wsURL: "ws://" + ln.Addr().String() + "/ws"}
+}
+
+// newBot devuelve la configuración de un bot que usa el servidor falso
+func newBot(usuarioID uint64, srv *llmtest.Server, provider llm.ProviderType) domain.Bot {
+	return domain.Bot{
+		UsuarioId:      usuarioID,
+		BaseURL:        srv.URL,
+		ModelName:      "fake-model",
+		ProviderType:   string(provider),
+		IsPromt:        true,
+		Temperature:    0.2,
+		TimeoutSeconds: 5,
+		IsActive:       true,
+	}
+}
+
+// client es un integrante conectado por WebSocket
+type client struct {
+	t      *testing.T
+	h      *harness
+	userID uint64
+	conn   *fws.Conn
+	frames chan websocket.Message
+}
+
+// connect autentica al usuario en el WebSocket y empieza a leer sus frames
+func (h *harness) connect(userID uint64) *client {
+	h.t.Helper()
+
+	conn, _, err := fws.DefaultDialer.Dial(h.wsURL, nil)
+	if err != nil {
+		h.t.Fatalf("no se pudo conectar al WebSocket: %v", err)
+	}
+	h.t.Cleanup(func() { conn.Close() })
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": userID}).SignedString([]byte("e2e-secret"))
+	if err != nil {
+		h.t.Fatalf("no se pudo firmar el token: %v", err)
+	}
+	if err := conn.WriteJSON(map[string]string{"token": token}); err != nil {
+		h.t.Fatalf("no se pudo enviar el token: %v", err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "authentication_successful" {
+		h.t.Fatalf("autenticación fallida: %q, %v", data, err)
+	}
+
+	c := &client{t: h.t, h: h, userID: userID, conn: conn, frames: make(chan websocket.Message, 256)}
+	go func() {
+		for {
+			var msg websocket.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			c.frames <- msg
+		}
+	}()
+	return c
+}
+
+// say guarda el mensaje como lo hace la API de mensajes y lo publica en el grupo por WebSocket
+func (c *client) say(content string) uint64 {
+	c.t.Helper()
+
+	mensaje, _ := memMensajes{c.h.store}.Create(&domain.Mensaje{
+		Contenido: content,
+		Fecha:     time.Now(),
+		GrupoId:   grupoID,
+		UsuarioId: c.userID,
+	})
+	err := c.conn.WriteJSON(websocket.Message{
+		Id:      strconv.FormatUint(mensaje.Id, 10),
+		GroupID: clave,
+		Content: content,
+		Fecha:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		c.t.Fatalf("no se pudo enviar el mensaje: %v", err)
+	}
+	return mensaje.Id
+}
+
+// waitFor devuelve el primer frame que cumple match, descartando los anteriores
+func (c *client) waitFor(what string, match func(websocket.Message) bool) websocket.Message {
+	c.t.Helper()
+
+	timeout := time.After(waitTimeout)
+	for {
+		select {
+		case msg := <-c.frames:
+			if match(msg) {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("no llegó %s", what)
+			return websocket.Message{}
+		}
+	}
+}
+
+// expectNone falla si durante d llega un frame que cumple match
+func (c *client) expectNone(what string, d time.Duration, match func(websocket.Message) bool) {
+	c.t.Helper()
+
+	timeout := time.After(d)
+	for {
+		select {
+		case msg := <-c.frames:
+			if match(msg) {
+				c.t.Fatalf("no se esperaba %s: %+v", what, msg)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// waitInteraction espera a que el bot registre n interacciones
+func (h *harness) waitInteraction(usuarioID uint64, n int) []domain.InteraccionIA {
+	h.t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		if interacciones := h.store.interaccionesDe(usuarioID); len(interacciones) >= n {
+			return interacciones
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("el bot %d no registró %d interacciones: %+v", usuarioID, n, h.store.interaccionesDe(usuarioID))
+	return nil
+}
+
+func fromUser(userID uint64) func(websocket.Message) bool {
+	return func(msg websocket.Message) bool {
+		return msg.Type == "" && msg.SenderID == strconv.FormatUint(userID, 10)
+	}
+}
+
+func TestE2EBotRepliesThroughHub(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderOpenAI))
+	ana := h.connect(humanoID)
+
+	// El mensaje del humano es el primero del grupo, así que tendrá el ID 1
+	const triggerID = "1"
+	srv.Enqueue(llmtest.Say(triggerID, "Azul: sí, buenísimo"))
+	ana.say("¿alguien vio el partido?")
+
+	reply := ana.waitFor("la respuesta del bot", fromUser(botID))
+	if reply.Content != "Azul: sí, buenísimo" || reply.AnswerId != triggerID || reply.SenderApodo != "azul2" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("se esperaba 1 solicitud al modelo, hubo %d", len(requests))
+	}
+	req := requests[0]
+	if req.Format != llmtest.FormatOpenAI || req.Model != "fake-model" || req.Stream {
+		t.Fatalf("solicitud inesperada: %+v", req)
+	}
+	if req.SystemPrompt() == "" || !strings.Contains(req.LastUserMessage(), "¿alguien vio el partido?") {
+		t.Fatalf("el prompt no incluye el sistema o el mensaje del grupo: %+v", req.Messages)
+	}
+
+	guardados := h.store.mensajesDe(grupoID, botID)
+	if len(guardados) != 1 || guardados[0].ResponseId == nil || strconv.FormatUint(*guardados[0].ResponseId, 10) != triggerID {
+		t.Fatalf("la respuesta no se guardó como respuesta al mensaje: %+v", guardados)
+	}
+
+	interacciones := h.waitInteraction(botID, 1)
+	if interacciones[0].Resultado != domain.ResultadoEnviada || interacciones[0].Proveedor != string(llm.ProviderOpenAI) {
+		t.Fatalf("interacción inesperada: %+v", interacciones[0])
+	}
+}
+
+func TestE2EStreamingOllamaChat(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	bot := newBot(botID, srv, llm.ProviderOllamaChat)
+	bot.Stream = true
+	h := newHarness(t, bot)
+	ana := h.connect(humanoID)
+
+	srv.Enqueue(llmtest.Response{
+		Content:    `{"messages": [{"answer_id": null, "content": "Azul: hola a todos"}]}`,
+		ChunkDelay: 5 * time.Millisecond,
+	})
+	ana.say("hola")
+
+	var streamed strings.Builder
+	final := ana.waitFor("el cierre del stream", func(msg websocket.Message) bool {
+		if msg.Type == websocket.TypeAIDelta {
+			streamed.WriteString(msg.Content)
+		}
+		return msg.Type == websocket.TypeAIFinal
+	})
+	if final.Content != "Azul: hola a todos" || final.StreamId == "" {
+		t.Fatalf("mensaje final inesperado: %+v", final)
+	}
+	if streamed.String() != "Azul: hola a todos" {
+		t.Fatalf("los fragmentos no reconstruyen el contenido: %q", streamed.String())
+	}
+
+	req := srv.Requests()[0]
+	if req.Format != llmtest.FormatOllamaChat || !req.Stream {
+		t.Fatalf("solicitud inesperada: %+v", req)
+	}
+}
+
+func TestE2ESilenceAndProviderErrors(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderOllamaGenerate))
+	ana := h.connect(humanoID)
+
+	srv.Enqueue(llmtest.Silence())
+	ana.say("nada importante")
+	h.waitInteraction(botID, 1)
+
+	// 400 no se reintenta: una sola solicitud y la interacción queda como error del proveedor
+	srv.Enqueue(llmtest.Fail(http.StatusBadRequest))
+	ana.say("otro mensaje")
+	interacciones := h.waitInteraction(botID, 2)
+
+	ana.expectNone("un mensaje del bot", 100*time.Millisecond, fromUser(botID))
+	if interacciones[0].Resultado != domain.ResultadoSilencio {
+		t.Fatalf("se esperaba silencio: %+v", interacciones[0])
+	}
+	if interacciones[1].Resultado != domain.ResultadoErrorProveedor || !strings.Contains(interacciones[1].Error, "400") {
+		t.Fatalf("se esperaba error del proveedor: %+v", interacciones[1])
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("se esperaban 2 solicitudes al modelo, hubo %d", n)
+	}
+	if got := srv.Requests()[1].LastUserMessage(); !strings.Contains(got, "otro mensaje") {
+		t.Fatalf("el prompt de /api/generate no termina con el último mensaje: %q", got)
+	}
+}
+
+func TestE2ERetriesUnavailableProvider(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderLMStudio))
+	ana := h.connect(humanoID)
+
+	srv.Enqueue(llmtest.Fail(http.StatusServiceUnavailable), llmtest.Say("", "Azul: ya volví"))
+	ana.say("¿hay alguien?")
+
+	reply := ana.waitFor("la respuesta tras el reintento", fromUser(botID))
+	if reply.Content != "Azul: ya volví" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("se esperaban 2 solicitudes (fallo y reintento), hubo %d", n)
+	}
+}
+
+func TestE2ERouterAppliesTurnPolicyBetweenBots(t *testing.T) {
+	rapido := llmtest.NewServer()
+	defer rapido.Close()
+	lento := llmtest.NewServer()
+	defer lento.Close()
+
+	const otroBotID = 3
+	h := newHarness(t, newBot(botID, rapido, llm.ProviderOpenAI), newBot(otroBotID, lento, llm.ProviderOpenAI))
+	h.store.setPolitica(domain.PoliticaTurno{GrupoId: grupoID, MaxBotConsecutivos: 1})
+	ana := h.connect(humanoID)
+
+	rapido.Handle(func(llmtest.Request) llmtest.Response { return llmtest.Say("", "Azul: yo primero") })
+	lento.Handle(func(llmtest.Request) llmtest.Response {
+		resp := llmtest.Say("", "Azul: y yo después")
+		resp.Delay = 200 * time.Millisecond
+		return resp
+	})
+	ana.say("hola bots")
+
+	ana.waitFor("la respuesta del primer bot", fromUser(botID))
+	ana.expectNone("una segunda respuesta de bot", 500*time.Millisecond, func(msg websocket.Message) bool {
+		return msg.Type == "" && msg.SenderID != strconv.FormatUint(humanoID, 10)
+	})
+
+	// El bot lento generó su respuesta, pero el límite de mensajes de bot seguidos la descarta
+	interacciones := h.waitInteraction(otroBotID, 1)
+	if interacciones[0].Resultado != domain.ResultadoDescartada {
+		t.Fatalf("se esperaba la respuesta descartada: %+v", interacciones[0])
+	}
+}
+
+func TestE2ERecallsRelevantOlderMessages(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	bot := newBot(botID, srv, llm.ProviderOllamaChat)
+	bot.HistoryMessages = 2
+	bot.Recuerdos = 1
+	h := newHarness(t, bot)
+
+	embedder, err := llm.NewEmbedder(llm.EmbeddingConfig{Provider: srv.ProviderConfig(llm.ProviderOllamaChat), Model: "fake-embed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recuerdos := embeddingUseCase.NewEmbeddingMensajeUseCase(memEmbeddings{h.store}, memMensajes{h.store}, nil, nil, embedder)
+
+	// El bot se reinicia para tomar los recuerdos, como al habilitar la búsqueda semántica
+	h.manager.EmbeddingMensajeUseCase = recuerdos
+	if err := h.manager.StopBot(botID); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.manager.StartBot(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, contenido := range []string{"el asado es el sábado a las 8 en lo de juan", "alguien tiene los apuntes de cálculo", "hoy llueve todo el día"} {
+		memMensajes{h.store}.Create(&domain.Mensaje{Contenido: contenido, Fecha: time.Now(), GrupoId: grupoID, UsuarioId: humanoID})
+	}
+	// La ventana de dos mensajes empieza en el 3 y lo anterior ya está resumido, así que la única
+	// llamada al modelo es la respuesta
+	h.store.setResumen(botID, grupoID, domain.ResumenGrupo{Texto: "hablaron de planes", HastaMensajeId: 2})
+	if n, err := recuerdos.IndexarPendientes(context.Background(), 10); err != nil || n != 3 {
+		t.Fatalf("se esperaban 3 mensajes indexados: %d, %v", n, err)
+	}
+
+	ana := h.connect(humanoID)
+	srv.Enqueue(llmtest.Silence())
+	ana.say("a qué hora es el asado del sábado?")
+
+	requests, err := srv.Wait(1, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bloque string
+	for _, m := range requests[len(requests)-1].Messages {
+		if m.Role == "system" && strings.HasPrefix(m.Content, "MENSAJES ANTERIORES RELEVANTES") {
+			bloque = m.Content
+		}
+	}
+	if !strings.Contains(bloque, "el asado es el sábado a las 8") || strings.Contains(bloque, "apuntes") {
+		t.Fatalf("el prompt no recuperó el mensaje relevante: %q", bloque)
+	}
+	if embedded := srv.Embedded(); !strings.Contains(embedded[len(embedded)-1], "a qué hora es el asado") {
+		t.Fatalf("la consulta no usó los últimos mensajes del grupo: %q", embedded)
+	}
+}
+
+func TestE2EToolCallsWithStepLimit(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	bot := newBot(botID, srv, llm.ProviderOllamaChat)
+	bot.Herramientas = []string{"listar_integrantes", "obtener_mensaje"}
+	bot.MaxPasosHerramientas = 2
+	h := newHarness(t, bot)
+	ana := h.connect(humanoID)
+
+	// Tras dos rondas de herramientas la tercera llamada ya no las ofrece
+	srv.Enqueue(
+		llmtest.CallTool("listar_integrantes", map[string]any{}),
+		llmtest.CallTool("obtener_mensaje", map[string]any{"id": 999}),
+		llmtest.Say("", "Somos dos: ana y yo"),
+	)
+	ana.say("¿quiénes están en el grupo?")
+
+	reply := ana.waitFor("la respuesta del bot", fromUser(botID))
+	if reply.Content != "Somos dos: ana y yo" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("se esperaban 3 solicitudes al modelo, hubo %d", len(requests))
+	}
+	if len(requests[0].Tools) != 2 || len(requests[1].Tools) != 2 || len(requests[2].Tools) != 0 {
+		t.Fatalf("herramientas ofrecidas inesperadas: %v / %v / %v", requests[0].Tools, requests[1].Tools, requests[2].Tools)
+	}
+
+	integrantes := requests[1].Messages[len(requests[1].Messages)-1]
+	if integrantes.Role != "tool" || !strings.Contains(integrantes.Content, `"apodo":"ana"`) {
+		t.Fatalf("el resultado de listar_integrantes no llegó al modelo: %+v", integrantes)
+	}
+	final := requests[2].Messages
+	if !strings.Contains(final[len(final)-2].Content, "no existe el mensaje 999") || final[len(final)-1].Role != "system" {
+		t.Fatalf("faltan el error de obtener_mensaje o el aviso de límite: %+v", final)
+	}
+
+	interacciones := h.waitInteraction(botID, 1)
+	herramientas := interacciones[0].Herramientas
+	if interacciones[0].Resultado != domain.ResultadoEnviada || len(herramientas) != 2 {
+		t.Fatalf("interacción inesperada: %+v", interacciones[0])
+	}
+	if herramientas[0].Nombre != "listar_integrantes" || herramientas[0].Error != "" || herramientas[1].Error == "" {
+		t.Fatalf("llamadas mal registradas: %+v", herramientas)
+	}
+}
+
+func TestE2EPollAnnouncementGoesWithReply(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	bot := newBot(botID, srv, llm.ProviderOllamaChat)
+	bot.Herramientas = []string{"crear_encuesta"}
+	h := newHarness(t, bot)
+	h.manager.EncuestaUseCase = memEncuestas{h.store}
+	if err := h.manager.StopBot(botID); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.manager.StartBot(bot); err != nil {
+		t.Fatal(err)
+	}
+	ana := h.connect(humanoID)
+
+	encuesta := map[string]any{"pregunta": "¿Qué pedimos?", "opciones": []string{"Pizza", "Empanadas"}}
+
+	// El anuncio sale con la respuesta, antes que sus mensajes
+	srv.Enqueue(llmtest.CallTool("crear_encuesta", encuesta), llmtest.Say("", "Azul: ¡a votar!"))
+	ana.say("armemos una votación")
+	anuncio := ana.waitFor("el anuncio de la encuesta", fromUser(botID))
+	if !strings.HasPrefix(anuncio.Content, "Encuesta #1: ¿Qué pedimos?") {
+		t.Fatalf("anuncio inesperado: %+v", anuncio)
+	}
+	if reply := ana.waitFor("la respuesta del bot", fromUser(botID)); reply.Content != "Azul: ¡a votar!" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	// Si la respuesta no se publica, el anuncio tampoco: no esquiva los límites del grupo ni los turnos
+	srv.Enqueue(llmtest.CallTool("crear_encuesta", encuesta), llmtest.Silence())
+	ana.say("otra votación")
+	ana.expectNone("el segundo anuncio", 300*time.Millisecond, fromUser(botID))
+	interacciones := h.waitInteraction(botID, 2)
+	if interacciones[1].Resultado != domain.ResultadoSilencio || len(interacciones[1].Herramientas) != 1 {
+		t.Fatalf("interacción inesperada: %+v", interacciones[1])
+	}
+}
+
+func TestE2EModerationBlocksBotMessage(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	// Con moderación el bot no transmite aunque tenga streaming: el texto bloqueado no debe llegar
+	// a los clientes como fragmentos
+	bot := newBot(botID, srv, llm.ProviderOpenAI)
+	bot.Stream = true
+	h := newHarness(t, bot)
+
+	lista, err := moderacionUseCase.NewListaBloqueo([]string{"# prueba", "palabrota"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El bot se reinicia para tomar la moderación, como al configurarla en el arranque
+	h.manager.ModeracionUseCase = moderacionUseCase.NewModeracionUseCase(memModeracion{h.store}, memMensajes{h.store}, nil, nil, nil, lista)
+	if err := h.manager.StopBot(botID); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.manager.StartBot(bot); err != nil {
+		t.Fatal(err)
+	}
+	// Con una respuesta por hora, la segunda solo sale si la bloqueada no gastó el límite
+	h.store.setGrupoIA(domain.GrupoIA{GrupoId: grupoID, ModoRespuesta: domain.ModoRespuestaSiempre, MaxRespuestasHora: 1})
+	ana := h.connect(humanoID)
+
+	srv.Enqueue(
+		llmtest.Say("", "Azul: qué PALABROTA, che"),
+		llmtest.Say("", "Azul: perdón, me expresé mal"),
+	)
+	ana.say("contame algo")
+	ana.expectNone("el mensaje bloqueado", 300*time.Millisecond, func(msg websocket.Message) bool {
+		return msg.SenderID == strconv.Itoa(botID) && (msg.Type == "" || msg.Type == websocket.TypeAIDelta)
+	})
+
+	ana.say("¿y ahora?")
+	reply := ana.waitFor("la respuesta permitida", fromUser(botID))
+	if reply.Content != "Azul: perdón, me expresé mal" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	if guardados := h.store.mensajesDe(grupoID, botID); len(guardados) != 1 {
+		t.Fatalf("el mensaje bloqueado no debía guardarse: %+v", guardados)
+	}
+	bloqueados, _ := memModeracion{h.store}.GetByEstado(domain.EstadoModeracionBloqueado, 10)
+	if len(bloqueados) != 1 || bloqueados[0].UsuarioId != botID || bloqueados[0].Verificador != "lista_bloqueo" {
+		t.Fatalf("el bloqueo no quedó registrado: %+v", bloqueados)
+	}
+
+	interacciones := h.waitInteraction(botID, 2)
+	if interacciones[0].Resultado != domain.ResultadoModerada || interacciones[1].Resultado != domain.ResultadoEnviada {
+		t.Fatalf("resultados inesperados: %s, %s", interacciones[0].Resultado, interacciones[1].Resultado)
+	}
+}
+
+func TestE2EModerationApprovesOnce(t *testing.T) {
+	h := newHarness(t)
+	ana := h.connect(humanoID)
+
+	moderacion := moderacionUseCase.NewModeracionUseCase(memModeracion{h.store}, memMensajes{h.store}, memGrupoRepo{memGrupos{h.store}},
+		memUsuarioRepo{memUsuarios{h.store}}, h.hub, nil)
+	moderado := &domain.MensajeModerado{Contenido: "mensaje marcado", GrupoId: grupoID, UsuarioId: humanoID, Estado: domain.EstadoModeracionPendiente}
+	if err := (memModeracion{h.store}).Create(moderado); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dos administradores aprueban a la vez: solo uno guarda y publica el mensaje
+	var wg sync.WaitGroup
+	var aprobados atomic.Int32
+	for adminId := uint64(10); adminId < 12; adminId++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := moderacion.Aprobar(adminId, moderado.Id); err == nil {
+				aprobados.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := aprobados.Load(); n != 1 {
+		t.Fatalf("se esperaba 1 aprobación, hubo %d", n)
+	}
+
+	mensajes, _ := memMensajes{h.store}.GetAll()
+	if len(mensajes) != 1 || mensajes[0].Contenido != "mensaje marcado" {
+		t.Fatalf("se esperaba un único mensaje guardado: %+v", mensajes)
+	}
+	revisado, _ := memModeracion{h.store}.GetById(moderado.Id)
+	if revisado.Estado != domain.EstadoModeracionAprobado || revisado.MensajeId == nil || *revisado.MensajeId != mensajes[0].Id {
+		t.Fatalf("la revisión no quedó enlazada con el mensaje: %+v", revisado)
+	}
+	ana.waitFor("el mensaje aprobado", fromUser(humanoID))
+	ana.expectNone("una segunda publicación", 300*time.Millisecond, fromUser(humanoID))
+}
+
+func TestE2EWebSocketRelaysOnlySavedMessages(t *testing.T) {
+	h := newHarness(t)
+	ana := h.connect(humanoID)
+
+	// Un frame sin mensaje guardado (por ejemplo, uno retenido por la moderación) no se difunde
+	if err := ana.conn.WriteJSON(websocket.Message{Id: "999", GroupID: clave, Content: "texto retenido"}); err != nil {
+		t.Fatal(err)
+	}
+	ana.expectNone("el frame sin mensaje guardado", 300*time.Millisecond, fromUser(humanoID))
+
+	// Con un mensaje guardado se reenvía el contenido guardado, no el del frame
+	mensaje, _ := memMensajes{h.store}.Create(&domain.Mensaje{Contenido: "hola", Fecha: time.Now(), GrupoId: grupoID, UsuarioId: humanoID})
+	if err := ana.conn.WriteJSON(websocket.Message{Id: strconv.FormatUint(mensaje.Id, 10), GroupID: clave, Content: "otra cosa"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := ana.waitFor("el mensaje guardado", fromUser(humanoID)); msg.Content != "hola" {
+		t.Fatalf("se reenvió el contenido del frame: %+v", msg)
+	}
+
+	// Repetir el frame no vuelve a difundir el mensaje
+	if err := ana.conn.WriteJSON(websocket.Message{Id: strconv.FormatUint(mensaje.Id, 10), GroupID: clave, Content: "hola"}); err != nil {
+		t.Fatal(err)
+	}
+	ana.expectNone("el frame repetido", 300*time.Millisecond, fromUser(humanoID))
+
+	// Tampoco se difunde un mensaje guardado hace rato
+	viejo, _ := memMensajes{h.store}.Create(&domain.Mensaje{Contenido: "de ayer", Fecha: time.Now().Add(-24 * time.Hour), GrupoId: grupoID, UsuarioId: humanoID})
+	if err := ana.conn.WriteJSON(websocket.Message{Id: strconv.FormatUint(viejo.Id, 10), GroupID: clave, Content: "de ayer"}); err != nil {
+		t.Fatal(err)
+	}
+	ana.expectNone("el mensaje antiguo", 300*time.Millisecond, fromUser(humanoID))
+}
+
+func TestE2EGroupSettingsApplyLive(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderOpenAI))
+	ana := h.connect(humanoID)
+
+	// Solo menciones: un mensaje general no llega al modelo
+	h.store.setGrupoIA(domain.GrupoIA{GrupoId: grupoID, ModoRespuesta: domain.ModoRespuestaMenciones})
+	ana.say("hola a todos")
+	ana.expectNone("una respuesta del bot", 300*time.Millisecond, fromUser(botID))
+	if n := len(srv.Requests()); n != 0 {
+		t.Fatalf("no se esperaban solicitudes al modelo, hubo %d", n)
+	}
+
+	// El cambio se aplica sin reiniciar el bot: la persona del grupo llega al prompt y el límite
+	// de una respuesta por hora descarta la segunda
+	h.store.setGrupoIA(domain.GrupoIA{GrupoId: grupoID, ModoRespuesta: domain.ModoRespuestaSiempre, MaxRespuestasHora: 1, Persona: "Eres un pirata"})
+	srv.Enqueue(llmtest.Say("", "Azul: arr"))
+	ana.say("¿y ahora?")
+	ana.waitFor("la respuesta del bot", fromUser(botID))
+	if prompt := srv.Requests()[0].SystemPrompt(); !strings.Contains(prompt, "Eres un pirata") {
+		t.Fatalf("el prompt no incluye la persona del grupo: %q", prompt)
+	}
+
+	ana.say("¿otra vez?")
+	ana.expectNone("una segunda respuesta", 300*time.Millisecond, fromUser(botID))
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("se esperaba 1 solicitud al modelo, hubo %d", n)
+	}
+}
+
+func TestE2EMembershipEventsUpdateBotSubscription(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderOpenAI))
+	ana := h.connect(humanoID)
+
+	// La baja llega como la publica grupoUsuarioUseCase: el bot deja de escuchar sin reiniciarse
+	h.hub.PublicarMembresia(domain.EventoMembresia{Tipo: domain.EventoMembresiaBaja, GrupoId: grupoID, GrupoClave: clave, UsuarioId: botID})
+	ana.say("¿sigue el bot?")
+	ana.expectNone("una respuesta del bot", 300*time.Millisecond, fromUser(botID))
+	if n := len(srv.Requests()); n != 0 {
+		t.Fatalf("no se esperaban solicitudes al modelo, hubo %d", n)
+	}
+
+	h.hub.PublicarMembresia(domain.EventoMembresia{Tipo: domain.EventoMembresiaAlta, GrupoId: grupoID, GrupoClave: clave, UsuarioId: botID})
+	srv.Enqueue(llmtest.Say("", "Azul: volví"))
+	ana.say("¿y ahora?")
+	if reply := ana.waitFor("la respuesta del bot", fromUser(botID)); reply.Content != "Azul: volví" {
+		t.Fatalf("respuesta inesperada: %+v", reply)
+	}
+
+	// El humano conectado recibe el aviso cuando lo quitan del grupo
+	h.hub.PublicarMembresia(domain.EventoMembresia{Tipo: domain.EventoMembresiaBaja, GrupoId: grupoID, GrupoClave: clave, UsuarioId: humanoID})
+	ana.waitFor("el aviso de baja", func(msg websocket.Message) bool {
+		return msg.Type == websocket.TypeGroupLeave && msg.GroupID == clave
+	})
+}
+
+func TestE2ETranslatesMessagesForReaderLanguage(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	traductor := llmtest.NewServer()
+	defer traductor.Close()
+
+	h := newHarness(t, newBot(botID, srv, llm.ProviderOpenAI))
+	h.store.setGrupoIA(domain.GrupoIA{GrupoId: grupoID, ModoRespuesta: domain.ModoRespuestaSiempre, Traduccion: true})
+	if err := (memUsuarios{h.store}).UpdateIdioma(humanoID, "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := llm.NewProvider(traductor.ProviderConfig(llm.ProviderOpenAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	traducciones := traduccionUseCase.NewTraduccionUseCase(memTraducciones{h.store}, memGrupoRepo{memGrupos{h.store}},
+		memUsuarioRepo{memUsuarios{h.store}}, memGruposIA{h.store}, h.hub, provider, "fake-model")
+	h.hub.SetTraductor(traducciones)
+	ana := h.connect(humanoID)
+
+	// La respuesta del bot llega primero en el original y después traducida al idioma de Ana
+	srv.Enqueue(llmtest.Say("", "Azul: hola a todos"))
+	traductor.Enqueue(llmtest.Reply("Azul: hello everyone"))
+	ana.say("hi there")
+	reply := ana.waitFor("la respuesta del bot", fromUser(botID))
+	traduccion := ana.waitFor("la traducción", func(msg websocket.Message) bool { return msg.Type == websocket.TypeTranslation })
+	if traduccion.Id != reply.Id || traduccion.Language != "en" || traduccion.Content != "Azul: hello everyone" {
+		t.Fatalf("traducción inesperada: %+v", traduccion)
+	}
+	if prompt := traductor.Requests()[0].SystemPrompt(); !strings.Contains(prompt, "inglés") {
+		t.Fatalf("el prompt no indica el idioma del lector: %q", prompt)
+	}
+
+	// Al listar, la traducción guardada se reutiliza y el mensaje propio no se traduce
+	mensajes, err := memMensajes{h.store}.GetAllByGrupoClave(clave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := traducciones.TraducirMensajes(humanoID, clave, mensajes); err != nil {
+		t.Fatal(err)
+	}
+	for _, mensaje := range mensajes {
+		switch mensaje.UsuarioId {
+		case botID:
+			if mensaje.Traduccion == nil || mensaje.Traduccion.Contenido != "Azul: hello everyone" {
+				t.Fatalf("el mensaje del bot no trae su traducción: %+v", mensaje)
+			}
+		case humanoID:
+			if mensaje.Traduccion != nil {
+				t.Fatalf("el mensaje propio no debería traducirse: %+v", mensaje)
+			}
+		}
+	}
+	if n := len(traductor.Requests()); n != 1 {
+		t.Fatalf("se esperaba 1 solicitud de traducción, hubo %d", n)
+	}
+
+	// Un mensaje sin traducción guardada se lista sin esperar al modelo y la traducción llega
+	// después por WebSocket
+	nuevo, err := memMensajes{h.store}.Create(&domain.Mensaje{Contenido: "Azul: ¿quién viene mañana?", GrupoId: grupoID, UsuarioId: botID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traductor.Enqueue(llmtest.Reply("Azul: who is coming tomorrow?"))
+	mensajes, err = memMensajes{h.store}.GetAllByGrupoClave(clave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := traducciones.TraducirMensajes(humanoID, clave, mensajes); err != nil {
+		t.Fatal(err)
+	}
+	for _, mensaje := range mensajes {
+		if mensaje.Id == nuevo.Id && mensaje.Traduccion != nil {
+			t.Fatalf("la traducción nueva no debería esperar a la consulta: %+v", mensaje)
+		}
+	}
+	traduccion = ana.waitFor("la traducción en segundo plano", func(msg websocket.Message) bool {
+		return msg.Type == websocket.TypeTranslation && msg.Id == strconv.FormatUint(nuevo.Id, 10)
+	})
+	if traduccion.Content != "Azul: who is coming tomorrow?" || traduccion.Language != "en" {
+		t.Fatalf("traducción inesperada: %+v", traduccion)
+	}
+}
+
+func TestE2ESummariesAreCachedAndDigestsGenerated(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	h := newHarness(t)
+	provider, err := llm.NewProvider(srv.ProviderConfig(llm.ProviderOpenAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumenes := resumenUseCase.NewResumenUseCase(memResumenes{h.store}, memMensajes{h.store}, memGrupoRepo{memGrupos{h.store}},
+		memGrupoUsuarioRepo{memGrupoUsuarios{h.store}}, provider, "fake-model")
+
+	ahora := time.Now().UTC()
+	escribir := func(contenido string) {
+		t.Helper()
+		if _, err := (memMensajes{h.store}).Create(&domain.Mensaje{Contenido: contenido, Fecha: ahora.Add(-time.Hour), GrupoId: grupoID, UsuarioId: humanoID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	escribir("¿Hacemos asado el sábado?")
+	escribir("Yo llevo el carbón")
+
+	srv.Enqueue(llmtest.Reply(`{"resumen": "Se organiza un asado", "decisiones": ["El sábado"], "preguntasAbiertas": [], "tareas": [{"responsable": "@ana", "tarea": "llevar el carbón"}]}`))
+	resumen, err := resumenes.Resumir(context.Background(), humanoID, grupoID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumen.Resumen != "Se organiza un asado" || len(resumen.Tareas) != 1 || resumen.CantidadMensajes != 2 || resumen.HastaMensajeId != 2 || resumen.Parcial {
+		t.Fatalf("resumen inesperado: %+v", resumen)
+	}
+	if prompt := srv.Requests()[0].LastUserMessage(); !strings.Contains(prompt, "@ana: Yo llevo el carbón") {
+		t.Fatalf("el prompt no trae los mensajes del grupo: %q", prompt)
+	}
+
+	// Sin mensajes nuevos en el rango se devuelve el resumen guardado sin llamar al modelo
+	cacheado, err := resumenes.Resumir(context.Background(), humanoID, grupoID, time.Time{}, time.Time{})
+	if err != nil || cacheado.Resumen != "Se organiza un asado" {
+		t.Fatalf("se esperaba el resumen guardado: %+v, %v", cacheado, err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("se esperaba 1 solicitud de resumen, hubo %d", n)
+	}
+
+	// Un mensaje nuevo invalida el resumen guardado
+	escribir("Confirmado, a las 13")
+	srv.Enqueue(llmtest.Reply("Asado confirmado el sábado a las 13"))
+	actualizado, err := resumenes.Resumir(context.Background(), humanoID, grupoID, time.Time{}, time.Time{})
+	if err != nil || actualizado.Resumen != "Asado confirmado el sábado a las 13" || actualizado.CantidadMensajes != 3 {
+		t.Fatalf("se esperaba un resumen nuevo: %+v, %v", actualizado, err)
+	}
+
+	// El digest resume los grupos con mensajes del último día, una vez por día
+	if err := resumenes.SaveSuscripcion(&domain.SuscripcionDigest{UsuarioId: humanoID, Hora: 0, Activo: true}); err != nil {
+		t.Fatal(err)
+	}
+	srv.Enqueue(llmtest.Reply(`{"resumen": "Asado el sábado", "decisiones": [], "preguntasAbiertas": [], "tareas": []}`))
+	if n, err := resumenes.GenerarDigestsPendientes(context.Background(), ahora, 10); err != nil || n != 1 {
+		t.Fatalf("se esperaba 1 digest generado: %d, %v", n, err)
+	}
+	if n, err := resumenes.GenerarDigestsPendientes(context.Background(), ahora, 10); err != nil || n != 0 {
+		t.Fatalf("el digest del día ya estaba generado: %d, %v", n, err)
+	}
+
+	digests, err := resumenes.GetDigests(humanoID, 0)
+	if err != nil || len(digests) != 1 {
+		t.Fatalf("se esperaba 1 digest: %+v, %v", digests, err)
+	}
+	if secciones := digests[0].Secciones; len(secciones) != 1 || secciones[0].GrupoId != grupoID || secciones[0].Resumen != "Asado el sábado" || secciones[0].CantidadMensajes != 3 {
+		t.Fatalf("secciones inesperadas: %+v", secciones)
+	}
+}
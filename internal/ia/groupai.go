@@ -0,0 +1,151 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/websocket"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// GroupSettings aplica la configuración de IA que el creador define para su grupo
+// (domain.GrupoIA): qué bots responden, en qué modo, cuántas respuestas por hora y con qué
+// persona. La configuración se lee en cada disparo, así que los cambios se aplican sin reiniciar
+// los bots; las respuestas de la última hora se cuentan en memoria.
+type GroupSettings struct {
+	settings domain.GrupoIAUseCase
+
+	mu      sync.Mutex
+	replies map[uint64][]time.Time
+}
+
+// NewGroupSettings crea la configuración compartida por todos los bots; settings puede ser nil,
+// en cuyo caso todos los grupos usan los valores por defecto
+func NewGroupSettings(settings domain.GrupoIAUseCase) *GroupSettings {
+	return &GroupSettings{
+		settings: settings,
+		replies:  make(map[uint64][]time.Time),
+	}
+}
+
+// Rules devuelve la configuración vigente del grupo
+func (g *GroupSettings) Rules(grupoID uint64) (domain.GrupoIA, error) {
+	if g.settings == nil {
+		return domain.GrupoIA{GrupoId: grupoID, ModoRespuesta: domain.ModoRespuestaSiempre, PorDefecto: true}, nil
+	}
+
+	config, err := g.settings.GetByGrupoId(grupoID)
+	if err != nil {
+		return domain.GrupoIA{}, fmt.Errorf("error al obtener la configuración de IA del grupo %d: %w", grupoID, err)
+	}
+	return *config, nil
+}
+
+// allowTrigger indica si el bot puede responder al disparo según el modo del grupo. addressed
+// informa si el mensaje menciona al bot o le responde; solo se evalúa en el modo de menciones.
+func allowTrigger(rules domain.GrupoIA, botID uint64, trigger TriggerReason, addressed func() bool) (bool, string) {
+	if !rules.BotHabilitado(botID) {
+		return false, "el bot no está habilitado en el grupo"
+	}
+
+	switch rules.ModoRespuesta {
+	case domain.ModoRespuestaApagado:
+		return false, "los bots están apagados en el grupo"
+	case domain.ModoRespuestaInactividad:
+		if trigger != TriggerIdle {
+			return false, "el grupo solo admite mensajes por inactividad"
+		}
+	case domain.ModoRespuestaMenciones:
+		if trigger != TriggerMessage || !addressed() {
+			return false, "el grupo solo admite respuestas a menciones"
+		}
+	}
+	return true, ""
+}
+
+// Allow indica si el grupo todavía admite respuestas de bots en la última hora. Se consulta
+// antes de llamar al modelo para no gastar llamadas que no se podrán publicar.
+func (g *GroupSettings) Allow(rules domain.GrupoIA) (bool, string) {
+	if rules.MaxRespuestasHora <= 0 {
+		return true, ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.allow(rules, time.Now())
+}
+
+// Commit vuelve a comprobar el límite justo antes de publicar y, si se permite, registra la
+// respuesta. Como en TurnPolicy.Commit, la comprobación y el registro son atómicos.
+func (g *GroupSettings) Commit(rules domain.GrupoIA) (bool, string) {
+	if rules.MaxRespuestasHora <= 0 {
+		return true, ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if ok, reason := g.allow(rules, now); !ok {
+		return false, reason
+	}
+	g.replies[rules.GrupoId] = append(g.replies[rules.GrupoId], now)
+	return true, ""
+}
+
+// allow descarta las respuestas de hace más de una hora y compara con el límite; requiere g.mu
+func (g *GroupSettings) allow(rules domain.GrupoIA, now time.Time) (bool, string) {
+	replies := g.replies[rules.GrupoId]
+	start := 0
+	for start < len(replies) && now.Sub(replies[start]) >= time.Hour {
+		start++
+	}
+	replies = replies[start:]
+	g.replies[rules.GrupoId] = replies
+
+	if len(replies) >= rules.MaxRespuestasHora {
+		return false, fmt.Sprintf("el grupo alcanzó su límite de %d respuestas por hora", rules.MaxRespuestasHora)
+	}
+	return true, ""
+}
+
+// personaNotice es la instrucción que se añade al prompt de sistema cuando el grupo define una persona
+func personaNotice(persona string) string {
+	return "PERSONA EN ESTE GRUPO: el creador del grupo definió cómo debes comportarte aquí. Tiene prioridad " +
+		"sobre el perfil anterior, pero sigue respetando el formato de salida.\n" + persona
+}
+
+// allowedInGroup aplica la configuración del grupo a un disparo recibido por clave de grupo.
+// msg es el mensaje que dispara al bot (nil para los disparos por inactividad).
+func (s *AIService) allowedInGroup(groupID string, trigger TriggerReason, msg *websocket.Message) bool {
+	if s.GroupAI == nil {
+		return true
+	}
+
+	botID, err := strconv.ParseUint(s.Config.UserID, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	grupo, err := s.GrupoUseCase.GetByClave(groupID)
+	if err != nil || grupo == nil {
+		log.Printf("AIService: error al obtener el grupo por clave %s: %v", groupID, err)
+		return false
+	}
+
+	rules, err := s.GroupAI.Rules(grupo.Id)
+	if err != nil {
+		log.Printf("AIService: %v", err)
+		return false
+	}
+
+	ok, reason := allowTrigger(rules, botID, trigger, func() bool { return msg != nil && s.isAddressed(*msg) })
+	// El IdleScheduler reintenta en cada revisión, así que esos rechazos no se registran
+	if !ok && trigger != TriggerIdle {
+		log.Printf("AIService: el bot %s no responde en el grupo %s: %s", s.Config.UserID, groupID, reason)
+	}
+	return ok
+}
@@ -0,0 +1,60 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// HistoryFormatInline antepone al contenido un encabezado [id | fecha | @apodo | responde a]
+	HistoryFormatInline = "inline"
+	// HistoryFormatJSON envía cada turno como un objeto JSON con los mismos campos
+	HistoryFormatJSON = "json"
+)
+
+const historyTimeLayout = "2006-01-02 15:04"
+
+// historyTurn es la representación estructurada de un mensaje del historial
+type historyTurn struct {
+	Id        uint64  `json:"id"`
+	Apodo     string  `json:"apodo"`
+	Fecha     string  `json:"fecha"`
+	AnswerTo  *uint64 `json:"answer_to"`
+	Contenido string  `json:"content"`
+}
+
+func speakerApodo(msg domain.Mensaje) string {
+	if msg.Usuario != nil && msg.Usuario.Apodo != "" {
+		return msg.Usuario.Apodo
+	}
+	return fmt.Sprintf("usuario%d", msg.UsuarioId)
+}
+
+// formatHistoryMessage convierte un mensaje en el texto de un turno según el formato configurado
+func formatHistoryMessage(msg domain.Mensaje, format string) string {
+	if format == HistoryFormatJSON {
+		turn := historyTurn{
+			Id:        msg.Id,
+			Apodo:     speakerApodo(msg),
+			Fecha:     msg.Fecha.Format(time.RFC3339),
+			AnswerTo:  msg.ResponseId,
+			Contenido: msg.Contenido,
+		}
+		encoded, err := json.Marshal(turn)
+		if err == nil {
+			return string(encoded)
+		}
+	}
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "[id:%d | %s | @%s", msg.Id, msg.Fecha.Format(historyTimeLayout), speakerApodo(msg))
+	if msg.ResponseId != nil {
+		fmt.Fprintf(&header, " | responde a:%d", *msg.ResponseId)
+	}
+	header.WriteString("] ")
+
+	return header.String() + msg.Contenido
+}
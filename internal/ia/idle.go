@@ -0,0 +1,142 @@
+package ia
+
+import (
+	"chatvis-chat/internal/websocket"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// idleCheckInterval es cada cuánto se revisan los grupos en silencio
+const idleCheckInterval = 5 * time.Second
+
+// idleReplyGrace es cuánto se conserva un grupo sin bots pendientes después del último disparo,
+// para contar la respuesta de un bot que todavía la está generando
+const idleReplyGrace = 10 * time.Minute
+
+// IdleScheduler registra la última actividad de cada grupo (a partir de los mensajes del hub)
+// y dispara a los bots con TriggerIdle cuando el grupo lleva su periodo configurado en silencio.
+//
+// Reglas para no saturar un grupo inactivo:
+//   - cada bot se dispara como máximo una vez por periodo de silencio;
+//   - un bot no envía más de MaxIdleMessages mensajes no solicitados seguidos; solo cuentan las
+//     respuestas que publicó, no los disparos en los que eligió callar;
+//   - el contador se reinicia cuando escribe un humano.
+//
+// Un grupo se olvida cuando ningún bot puede dispararse en él; vuelve a registrarse con el
+// siguiente mensaje.
+type IdleScheduler struct {
+	manager  *Manager
+	interval time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*idleGroup
+}
+
+// idleGroup es el estado de inactividad de un grupo
+type idleGroup struct {
+	lastActivity time.Time
+	// Mensajes no solicitados seguidos por bot desde que habló un humano
+	unprompted map[string]int
+	// Bots ya disparados en el silencio actual
+	fired map[string]bool
+	// lastFired es el último disparo en el grupo
+	lastFired time.Time
+}
+
+func NewIdleScheduler(manager *Manager) *IdleScheduler {
+	return &IdleScheduler{
+		manager:  manager,
+		interval: idleCheckInterval,
+		groups:   make(map[string]*idleGroup),
+	}
+}
+
+// Touch registra actividad en el grupo del mensaje. Solo los mensajes de humanos reinician
+// el contador de mensajes no solicitados, y el mensaje de un bot disparado por el silencio lo
+// incrementa; cualquier mensaje inicia un nuevo periodo de silencio.
+func (s *IdleScheduler) Touch(msg websocket.Message, fromBot bool) {
+	if msg.GroupID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[msg.GroupID]
+	if !ok {
+		group = &idleGroup{
+			unprompted: make(map[string]int),
+			fired:      make(map[string]bool),
+		}
+		s.groups[msg.GroupID] = group
+	}
+
+	group.lastActivity = time.Now()
+	if !fromBot {
+		clear(group.unprompted)
+	} else if group.fired[msg.SenderID] {
+		group.unprompted[msg.SenderID]++
+	}
+	clear(group.fired)
+}
+
+// Run revisa periódicamente los grupos hasta que se cancele el contexto
+func (s *IdleScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+// check dispara a los bots cuyos grupos superaron su periodo de inactividad y olvida los grupos
+// en los que ya no queda ningún bot por disparar
+func (s *IdleScheduler) check(now time.Time) {
+	services := s.manager.Services()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for groupID, group := range s.groups {
+		silence := now.Sub(group.lastActivity)
+		pending := false
+
+		for _, service := range services {
+			botID := service.Config.UserID
+			idleAfter := service.Config.IdleAfter
+
+			if idleAfter <= 0 || group.fired[botID] || group.unprompted[botID] >= service.Config.MaxIdleMessages {
+				continue
+			}
+			if !s.manager.Hub.CheckUserInGroup(botID, groupID) {
+				continue
+			}
+			if silence < idleAfter || !service.Trigger(groupID, TriggerIdle) {
+				pending = true
+				continue
+			}
+
+			group.fired[botID] = true
+			group.lastFired = now
+		}
+
+		if !pending && now.Sub(group.lastFired) >= idleReplyGrace {
+			delete(s.groups, groupID)
+		}
+	}
+}
+
+// idleNotice es la instrucción que se añade al prompt cuando el bot habla por inactividad
+func idleNotice(idleAfter time.Duration) string {
+	return fmt.Sprintf("El grupo lleva al menos %s sin mensajes. Nadie te ha escrito: si tienes algo natural que aportar "+
+		"(retomar el tema, una pregunta, un comentario), escríbelo sin answer_id; si no, responde {\"messages\": []}.",
+		idleAfter.Round(time.Second))
+}
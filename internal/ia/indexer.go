@@ -0,0 +1,55 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// indexInterval es cada cuánto se buscan mensajes sin vector
+	indexInterval = 10 * time.Second
+	// indexBatch limita los mensajes que se indexan por ronda
+	indexBatch = 256
+)
+
+// EmbeddingIndexer calcula en segundo plano los embeddings de los mensajes nuevos para que la
+// búsqueda semántica y los recuerdos de los bots los encuentren.
+type EmbeddingIndexer struct {
+	usecase  domain.EmbeddingMensajeUseCase
+	interval time.Duration
+}
+
+func NewEmbeddingIndexer(emu domain.EmbeddingMensajeUseCase) *EmbeddingIndexer {
+	return &EmbeddingIndexer{
+		usecase:  emu,
+		interval: indexInterval,
+	}
+}
+
+// Run indexa periódicamente los mensajes pendientes hasta que se cancele el contexto. Si hay más
+// pendientes que indexBatch (por ejemplo, el historial existente) sigue sin esperar al ticker.
+func (x *EmbeddingIndexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(x.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := x.usecase.IndexarPendientes(ctx, indexBatch)
+			if err != nil {
+				log.Printf("EmbeddingIndexer: %v", err)
+				break
+			}
+			if n < indexBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
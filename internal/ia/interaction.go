@@ -0,0 +1,121 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// recordInteraction guarda la ejecución del bot; las que terminaron antes de llamar al modelo no tienen resultado
+func (s *AIService) recordInteraction(interaccion *domain.InteraccionIA) {
+	if s.Interacciones == nil || interaccion.Resultado == "" {
+		return
+	}
+
+	if err := s.Interacciones.Registrar(interaccion); err != nil {
+		log.Printf("AIService: error al registrar la interacción del bot %s: %v", s.Config.UserID, err)
+	}
+}
+
+// triggerMessageID devuelve el último mensaje de otro usuario en la ventana, que es el que
+// disparó la respuesta; nil si el bot habló por inactividad
+func triggerMessageID(job aiJob, mensajes []domain.Mensaje, aiUserID uint64) *uint64 {
+	if job.Trigger == TriggerIdle {
+		return nil
+	}
+
+	for i := len(mensajes) - 1; i >= 0; i-- {
+		if mensajes[i].UsuarioId != aiUserID {
+			id := mensajes[i].Id
+			return &id
+		}
+	}
+	return nil
+}
+
+// promptMessages copia el prompt enviado al modelo para guardarlo con la interacción
+func promptMessages(messages []llm.ChatMessage) []domain.MensajePromptIA {
+	prompt := make([]domain.MensajePromptIA, 0, len(messages))
+	for _, m := range messages {
+		prompt = append(prompt, domain.MensajePromptIA{Role: m.Role, Content: m.Content})
+	}
+	return prompt
+}
+
+// replySummary resume los mensajes interpretados de la respuesta para guardarlos con la interacción
+func replySummary(replies []ReplyMessage) []domain.RespuestaIA {
+	respuestas := make([]domain.RespuestaIA, 0, len(replies))
+	for _, reply := range replies {
+		respuestas = append(respuestas, domain.RespuestaIA{
+			AnswerId: reply.Message.AnswerId,
+			Content:  reply.Message.Content,
+			DelayMs:  reply.Delay.Milliseconds(),
+		})
+	}
+	return respuestas
+}
+
+// Replay vuelve a enviar el prompt de una interacción guardada al proveedor y modelo del bot. Si
+// systemPrompt no está vacío reemplaza el prompt de sistema original. No publica nada en el grupo;
+// los errores del proveedor o de interpretación quedan en el resultado, y una respuesta válida
+// se informa como enviada aunque solo se haya generado.
+func Replay(ctx context.Context, bot domain.Bot, interaccion *domain.InteraccionIA, systemPrompt string) (*domain.ResultadoVarianteIA, error) {
+	config := ConfigFromBot(bot)
+	provider, err := llm.NewProvider(config.ProviderConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.ChatMessage, 0, len(interaccion.Prompt)+1)
+	for _, m := range interaccion.Prompt {
+		messages = append(messages, llm.ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	if systemPrompt != "" {
+		if len(messages) > 0 && messages[0].Role == "system" {
+			messages[0].Content = systemPrompt
+		} else {
+			messages = append([]llm.ChatMessage{{Role: "system", Content: systemPrompt}}, messages...)
+		}
+	}
+
+	resultado := &domain.ResultadoVarianteIA{
+		Proveedor: provider.Name(),
+		Modelo:    config.LLMName,
+		Prompt:    systemPrompt,
+	}
+
+	started := time.Now()
+	completion, err := provider.Complete(ctx, llm.CompletionRequest{
+		Model:    config.LLMName,
+		Messages: messages,
+		Options:  config.CompletionOptions(),
+	})
+	resultado.DuracionLlmMs = time.Since(started).Milliseconds()
+	if err != nil {
+		resultado.Resultado = domain.ResultadoErrorProveedor
+		resultado.Error = err.Error()
+		return resultado, nil
+	}
+
+	if completion.Model != "" {
+		resultado.Modelo = completion.Model
+	}
+	resultado.Respuesta = completion.Content
+
+	respuestas, err := interpretReply(completion.Content)
+	switch {
+	case errors.Is(err, ErrSilent):
+		resultado.Resultado = domain.ResultadoSilencio
+	case err != nil:
+		resultado.Resultado = domain.ResultadoErrorParseo
+		resultado.Error = err.Error()
+	default:
+		resultado.Resultado = domain.ResultadoEnviada
+		resultado.Mensajes = respuestas
+	}
+
+	return resultado, nil
+}
@@ -0,0 +1,238 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/websocket"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Manager mantiene las instancias de AIService en ejecución y permite
+// iniciarlas, detenerlas y recargarlas sin reiniciar el servidor. Como en AIService, los casos de
+// uso opcionales son campos exportados que se asignan antes de iniciar los bots.
+type Manager struct {
+	Hub            *websocket.Hub
+	MensajeUseCase domain.MensajeUseCase
+	GrupoUseCase   domain.GrupoUseCase
+	UsuarioUseCase domain.UsuarioUseCase
+
+	// Turns reparte los turnos entre los bots de cada grupo
+	Turns *TurnPolicy
+	// SalidaFallidaUseCase registra las respuestas de los bots que no se pudieron interpretar; puede ser nil
+	SalidaFallidaUseCase domain.SalidaFallidaUseCase
+	// Usage registra el consumo de todos los bots y aplica sus cuotas
+	Usage *UsageTracker
+	// InteraccionIAUseCase guarda cada ejecución de los bots; puede ser nil
+	InteraccionIAUseCase domain.InteraccionIAUseCase
+	// EmbeddingMensajeUseCase recupera mensajes anteriores relevantes; nil si la búsqueda semántica está deshabilitada
+	EmbeddingMensajeUseCase domain.EmbeddingMensajeUseCase
+	// EncuestaUseCase y RecordatorioUseCase respaldan las herramientas de los bots; pueden ser nil
+	EncuestaUseCase     domain.EncuestaUseCase
+	RecordatorioUseCase domain.RecordatorioUseCase
+	// ModeracionUseCase revisa los mensajes de los bots antes de guardarlos; nil no modera
+	ModeracionUseCase domain.ModeracionUseCase
+	// GroupAI aplica la configuración de IA que cada grupo define para sus bots
+	GroupAI *GroupSettings
+
+	ctx      context.Context
+	mu       sync.RWMutex
+	services map[string]*AIService
+}
+
+// NewManager recibe los casos de uso que comparten todos los bots: los de mensajes, grupos y
+// usuarios, y los que respaldan los turnos, las cuotas y la configuración de IA de cada grupo
+func NewManager(ctx context.Context, h *websocket.Hub, mu domain.MensajeUseCase, gu domain.GrupoUseCase, uu domain.UsuarioUseCase, ptu domain.PoliticaTurnoUseCase, uiu domain.UsoIAUseCase, giu domain.GrupoIAUseCase) *Manager {
+	m := &Manager{
+		Hub:            h,
+		MensajeUseCase: mu,
+		GrupoUseCase:   gu,
+		UsuarioUseCase: uu,
+		ctx:            ctx,
+		services:       make(map[string]*AIService),
+	}
+	m.Turns = NewTurnPolicy(ptu, m.BotsInGroup)
+	m.Usage = NewUsageTracker(uiu)
+	m.GroupAI = NewGroupSettings(giu)
+	h.OnMembership(m.onMembership)
+
+	return m
+}
+
+// StartBot crea e inicia el AIService del bot
+func (m *Manager) StartBot(bot domain.Bot) error {
+	config := ConfigFromBot(bot)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.services[config.UserID]; ok {
+		return fmt.Errorf("el bot del usuario %s ya está en ejecución", config.UserID)
+	}
+
+	service, err := NewAIService(m.Hub, m.MensajeUseCase, m.GrupoUseCase, m.UsuarioUseCase, config)
+	if err != nil {
+		return err
+	}
+
+	service.Turns = m.Turns
+	service.Usage = m.Usage
+	service.SalidasFallidas = m.SalidaFallidaUseCase
+	service.Interacciones = m.InteraccionIAUseCase
+	service.Recuerdos = m.EmbeddingMensajeUseCase
+	service.Encuestas = m.EncuestaUseCase
+	service.Recordatorios = m.RecordatorioUseCase
+	service.Moderacion = m.ModeracionUseCase
+	service.GroupAI = m.GroupAI
+	m.services[config.UserID] = service
+	service.Start(m.ctx)
+
+	log.Printf("Manager: bot %s iniciado (%s, %s)", config.UserID, config.Provider, config.LLMName)
+	return nil
+}
+
+// StopBot detiene el AIService del usuario IA indicado
+func (m *Manager) StopBot(usuarioId uint64) error {
+	userID := strconv.FormatUint(usuarioId, 10)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	service, ok := m.services[userID]
+	if !ok {
+		return fmt.Errorf("el bot del usuario %s no está en ejecución", userID)
+	}
+
+	service.Stop()
+	delete(m.services, userID)
+
+	log.Printf("Manager: bot %s detenido", userID)
+	return nil
+}
+
+// ReloadBot detiene la instancia actual (si existe) e inicia una nueva con la configuración recibida
+func (m *Manager) ReloadBot(bot domain.Bot) error {
+	if m.IsRunning(bot.UsuarioId) {
+		if err := m.StopBot(bot.UsuarioId); err != nil {
+			return err
+		}
+	}
+
+	if !bot.IsActive {
+		return nil
+	}
+
+	return m.StartBot(bot)
+}
+
+// IsRunning indica si el bot del usuario tiene un AIService en ejecución
+func (m *Manager) IsRunning(usuarioId uint64) bool {
+	return m.IsBot(strconv.FormatUint(usuarioId, 10))
+}
+
+// IsBot indica si el ID de usuario corresponde a un bot en ejecución
+func (m *Manager) IsBot(userID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.services[userID]
+	return ok
+}
+
+// service devuelve el AIService en ejecución del usuario IA, o nil
+func (m *Manager) service(userID string) *AIService {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.services[userID]
+}
+
+// Services devuelve una copia de los servicios en ejecución
+func (m *Manager) Services() []*AIService {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	services := make([]*AIService, 0, len(m.services))
+	for _, service := range m.services {
+		services = append(services, service)
+	}
+	return services
+}
+
+// BotsInGroup devuelve, ordenados, los IDs de los bots en ejecución que pertenecen al grupo
+func (m *Manager) BotsInGroup(groupID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var bots []string
+	for userID := range m.services {
+		if m.Hub.CheckUserInGroup(userID, groupID) {
+			bots = append(bots, userID)
+		}
+	}
+	slices.Sort(bots)
+	return bots
+}
+
+// Route entrega a los bots los mensajes publicados en el hub hasta que se cancele ctx o se
+// cierre el canal. Los
+// mensajes de bot también llegan a los demás bots; la política de turnos (m.Turns) evita que se
+// respondan indefinidamente. idle puede ser nil.
+func (m *Manager) Route(ctx context.Context, messages <-chan websocket.Message, idle *IdleScheduler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			m.routeMessage(msg, idle)
+		}
+	}
+}
+
+func (m *Manager) routeMessage(msg websocket.Message, idle *IdleScheduler) {
+	isBot := m.IsBot(msg.SenderID)
+	if idle != nil {
+		idle.Touch(msg, isBot)
+	}
+	if !isBot {
+		m.Turns.Observe(msg.GroupID, msg.SenderID)
+	}
+
+	for _, service := range m.Services() {
+		if service.Config.UserID == msg.SenderID {
+			continue
+		}
+		if m.Hub.CheckUserInGroup(service.Config.UserID, msg.GroupID) {
+			// Si escribe un humano, el bot deja de publicar la respuesta en curso
+			if !isBot {
+				service.Interrupt(msg.GroupID)
+			}
+			service.Enqueue(msg)
+		}
+	}
+}
+
+// onMembership entrega al bot afectado los cambios de membresía; el Hub ya actualizó sus
+// suscripciones, así que los bots que no están en ejecución no necesitan nada más
+func (m *Manager) onMembership(evento domain.EventoMembresia) {
+	if service := m.service(strconv.FormatUint(evento.UsuarioId, 10)); service != nil {
+		service.OnMembership(evento)
+	}
+}
+
+// StopAll detiene todas las instancias en ejecución
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for userID, service := range m.services {
+		service.Stop()
+		delete(m.services, userID)
+	}
+}
@@ -0,0 +1,967 @@
+package ia_test
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memory es una base de datos en memoria con los usuarios, grupos y mensajes de las pruebas.
+// Cada tipo fake implementa el caso de uso de una entidad sobre el mismo estado.
+type memory struct {
+	mu sync.Mutex
+
+	usuarios      map[uint64]*domain.Usuario
+	grupos        map[uint64]*domain.Grupo
+	miembros      map[uint64][]uint64 // grupo -> usuarios
+	mensajes      []domain.Mensaje
+	checkpoints   map[[2]uint64]uint64 // (bot, grupo) -> último mensaje atendido
+	resumenes     map[[2]uint64]domain.ResumenGrupo
+	politicas     map[uint64]domain.PoliticaTurno
+	interacciones []domain.InteraccionIA
+	salidas       []domain.SalidaFallida
+	llamadas      []domain.LlamadaIA
+	embeddings    []domain.EmbeddingMensaje
+	moderados     []domain.MensajeModerado
+	encuestas     []domain.Encuesta
+	gruposIA      map[uint64]domain.GrupoIA
+	traducciones  []domain.TraduccionMensaje
+	resumenesIA   map[string]domain.ResumenIA // grupo y rango -> resumen
+	suscripciones map[uint64]domain.SuscripcionDigest
+	digests       []domain.Digest
+}
+
+func newMemory() *memory {
+	return &memory{
+		usuarios:    make(map[uint64]*domain.Usuario),
+		grupos:      make(map[uint64]*domain.Grupo),
+		miembros:    make(map[uint64][]uint64),
+		checkpoints: make(map[[2]uint64]uint64),
+		resumenes:   make(map[[2]uint64]domain.ResumenGrupo),
+		politicas:   make(map[uint64]domain.PoliticaTurno),
+		gruposIA:    make(map[uint64]domain.GrupoIA),
+
+		resumenesIA:   make(map[string]domain.ResumenIA),
+		suscripciones: make(map[uint64]domain.SuscripcionDigest),
+	}
+}
+
+func (m *memory) addUsuario(u domain.Usuario) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.usuarios[u.Id] = &u
+}
+
+func (m *memory) addGrupo(g domain.Grupo, miembros ...uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.grupos[g.Id] = &g
+	m.miembros[g.Id] = miembros
+}
+
+func (m *memory) setResumen(aiID uint64, grupoID uint64, resumen domain.ResumenGrupo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resumenes[[2]uint64{aiID, grupoID}] = resumen
+}
+
+func (m *memory) setPolitica(p domain.PoliticaTurno) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.politicas[p.GrupoId] = p
+}
+
+func (m *memory) setGrupoIA(g domain.GrupoIA) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gruposIA[g.GrupoId] = g
+}
+
+// mensajesDe devuelve los mensajes del grupo enviados por el usuario
+func (m *memory) mensajesDe(grupoID uint64, usuarioID uint64) []domain.Mensaje {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoID && msg.UsuarioId == usuarioID {
+			mensajes = append(mensajes, msg)
+		}
+	}
+	return mensajes
+}
+
+func (m *memory) interaccionesDe(usuarioID uint64) []domain.InteraccionIA {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var interacciones []domain.InteraccionIA
+	for _, i := range m.interacciones {
+		if i.UsuarioId == usuarioID {
+			interacciones = append(interacciones, i)
+		}
+	}
+	return interacciones
+}
+
+// withUsuario completa el usuario del mensaje como lo hace el Preload del repositorio; requiere m.mu
+func (m *memory) withUsuario(msg domain.Mensaje) domain.Mensaje {
+	if u, ok := m.usuarios[msg.UsuarioId]; ok {
+		copia := *u
+		msg.Usuario = &copia
+	}
+	return msg
+}
+
+var errNotFound = errors.New("no encontrado")
+
+// memMensajes implementa domain.MensajeUseCase
+type memMensajes struct{ *memory }
+
+func (m memMensajes) GetAll() ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.mensajes), nil
+}
+
+func (m memMensajes) GetById(id uint64) (*domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range m.mensajes {
+		if msg.Id == id {
+			found := m.withUsuario(msg)
+			return &found, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memMensajes) GetAllByGrupoId(grupoId uint64, startDate time.Time, endDate time.Time) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoId && !msg.Fecha.Before(startDate) && !msg.Fecha.After(endDate) {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes, nil
+}
+
+func (m memMensajes) ContarPorFechas(grupoId uint64, startDate time.Time, endDate time.Time) (int, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cantidad, ultimoId := 0, uint64(0)
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoId && enFechas(msg.Fecha, startDate, endDate) {
+			cantidad++
+			ultimoId = max(ultimoId, msg.Id)
+		}
+	}
+	return cantidad, ultimoId, nil
+}
+
+func (m memMensajes) GetUltimosPorFechas(grupoId uint64, startDate time.Time, endDate time.Time, limite int) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoId && enFechas(msg.Fecha, startDate, endDate) {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	if len(mensajes) > limite {
+		mensajes = mensajes[len(mensajes)-limite:]
+	}
+	return mensajes, nil
+}
+
+// enFechas indica si la fecha está entre los límites (cero = sin límite)
+func enFechas(fecha time.Time, startDate time.Time, endDate time.Time) bool {
+	return (startDate.IsZero() || !fecha.Before(startDate)) && (endDate.IsZero() || !fecha.After(endDate))
+}
+
+func (m memMensajes) GetAllByGrupoClave(clave string) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if g, ok := m.grupos[msg.GrupoId]; ok && g.Clave == clave {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes, nil
+}
+
+func (m memMensajes) Create(mensaje *domain.Mensaje) (*domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mensaje.Id = uint64(len(m.mensajes) + 1)
+	m.mensajes = append(m.mensajes, *mensaje)
+	return mensaje, nil
+}
+
+func (m memMensajes) Update(id uint64, mensaje *domain.Mensaje) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.mensajes {
+		if m.mensajes[i].Id == id {
+			m.mensajes[i].Contenido = mensaje.Contenido
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (m memMensajes) GetNuevosMensajesParaIA(aiID uint64, grupoID uint64) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	desde := m.checkpoints[[2]uint64{aiID, grupoID}]
+	limite := len(m.mensajes)
+	m.mu.Unlock()
+
+	return m.GetMensajesRango(grupoID, desde, ^uint64(0), limite)
+}
+
+func (m memMensajes) GetUltimosMensajes(grupoID uint64, limite int) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoID {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes[max(len(mensajes)-limite, 0):], nil
+}
+
+func (m memMensajes) GetUltimoMensajeId(grupoID uint64, excluirUsuarioID uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ultimo uint64
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoID && msg.UsuarioId != excluirUsuarioID {
+			ultimo = max(ultimo, msg.Id)
+		}
+	}
+	return ultimo, nil
+}
+
+func (m memMensajes) GetMensajesRango(grupoID uint64, desdeID uint64, hastaID uint64, limite int) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if msg.GrupoId == grupoID && msg.Id > desdeID && msg.Id < hastaID {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes[max(len(mensajes)-limite, 0):], nil
+}
+
+func (m memMensajes) GetByIds(ids []uint64) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		if slices.Contains(ids, msg.Id) {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes, nil
+}
+
+func (m memMensajes) BuscarTexto(grupoID uint64, texto string, limite int) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mensajes []domain.Mensaje
+	for i := len(m.mensajes) - 1; i >= 0 && len(mensajes) < limite; i-- {
+		msg := m.mensajes[i]
+		if msg.GrupoId == grupoID && strings.Contains(strings.ToLower(msg.Contenido), strings.ToLower(texto)) {
+			mensajes = append(mensajes, m.withUsuario(msg))
+		}
+	}
+	return mensajes, nil
+}
+
+func (m memMensajes) GetPuntoControl(aiID uint64, grupoID uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.checkpoints[[2]uint64{aiID, grupoID}], nil
+}
+
+func (m memMensajes) ActualizarPuntoControl(aiID uint64, grupoID uint64, anteriorID uint64, ultimoID uint64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]uint64{aiID, grupoID}
+	if m.checkpoints[key] != anteriorID {
+		return false, nil
+	}
+	m.checkpoints[key] = ultimoID
+	return true, nil
+}
+
+func (m memMensajes) GetResumen(aiID uint64, grupoID uint64) (*domain.ResumenGrupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resumen := m.resumenes[[2]uint64{aiID, grupoID}]
+	return &resumen, nil
+}
+
+func (m memMensajes) GuardarResumen(aiID uint64, grupoID uint64, resumen *domain.ResumenGrupo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resumenes[[2]uint64{aiID, grupoID}] = *resumen
+	return nil
+}
+
+// memGrupos implementa domain.GrupoUseCase
+type memGrupos struct{ *memory }
+
+func (m memGrupos) GetAll() ([]domain.Grupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var grupos []domain.Grupo
+	for _, g := range m.grupos {
+		grupos = append(grupos, *g)
+	}
+	return grupos, nil
+}
+
+func (m memGrupos) GetById(id uint64) (*domain.Grupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if g, ok := m.grupos[id]; ok {
+		copia := *g
+		return &copia, nil
+	}
+	return nil, errNotFound
+}
+
+func (m memGrupos) GetByClave(clave string) (*domain.Grupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, g := range m.grupos {
+		if g.Clave == clave {
+			copia := *g
+			return &copia, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memGrupos) GetAllByUsuarioId(usuarioId uint64) ([]domain.Grupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var grupos []domain.Grupo
+	for id, miembros := range m.miembros {
+		if slices.Contains(miembros, usuarioId) {
+			grupos = append(grupos, *m.grupos[id])
+		}
+	}
+	return grupos, nil
+}
+
+func (m memGrupos) GetAllGruposByUsuarioIdToClaves(usuarioId uint64) ([]string, error) {
+	grupos, err := m.GetAllByUsuarioId(usuarioId)
+	if err != nil {
+		return nil, err
+	}
+
+	claves := make([]string, 0, len(grupos))
+	for _, g := range grupos {
+		claves = append(claves, g.Clave)
+	}
+	return claves, nil
+}
+
+func (m memGrupos) GetByName(name string) (*domain.Grupo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, g := range m.grupos {
+		if strings.EqualFold(g.Nombre, name) {
+			copia := *g
+			return &copia, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memGrupos) CreateInvitationUrl(id uint64) (string, string, error) {
+	return "", "", errors.New("no soportado")
+}
+
+func (m memGrupos) Create(grupo *domain.Grupo) error {
+	m.addGrupo(*grupo)
+	return nil
+}
+
+// memGrupoRepo implementa domain.GrupoRepository sobre memGrupos
+type memGrupoRepo struct{ memGrupos }
+
+func (m memGrupoRepo) GetByClave(clave string) (*domain.Grupo, int, error) {
+	g, err := m.memGrupos.GetByClave(clave)
+	if err != nil {
+		return nil, 404, nil
+	}
+	return g, 200, nil
+}
+
+func (m memGrupoRepo) GetByName(name string) (*domain.Grupo, int, error) {
+	g, err := m.memGrupos.GetByName(name)
+	if err != nil {
+		return nil, 404, nil
+	}
+	return g, 200, nil
+}
+
+// memUsuarios implementa domain.UsuarioUseCase
+type memUsuarios struct{ *memory }
+
+func (m memUsuarios) GetById(id uint64) (*domain.Usuario, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.usuarios[id]; ok {
+		copia := *u
+		return &copia, nil
+	}
+	return nil, errNotFound
+}
+
+func (m memUsuarios) GetByEmail(email string) (*domain.Usuario, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.usuarios {
+		if u.Email == email {
+			copia := *u
+			return &copia, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memUsuarios) GetAllUsuarios() ([]domain.Usuario, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var usuarios []domain.Usuario
+	for _, u := range m.usuarios {
+		usuarios = append(usuarios, *u)
+	}
+	return usuarios, nil
+}
+
+func (m memUsuarios) GetAllByGrupoId(grupoId uint64) ([]domain.Usuario, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var usuarios []domain.Usuario
+	for _, id := range m.miembros[grupoId] {
+		usuarios = append(usuarios, *m.usuarios[id])
+	}
+	return usuarios, nil
+}
+
+func (m memUsuarios) Create(usuario *domain.Usuario) error {
+	m.addUsuario(*usuario)
+	return nil
+}
+
+func (m memUsuarios) Update(id uint64, usuario domain.Usuario) error {
+	usuario.Id = id
+	m.addUsuario(usuario)
+	return nil
+}
+
+func (m memUsuarios) UpdateIsActive(id uint64, isActive bool) error { return nil }
+
+func (m memUsuarios) UpdateIdioma(id uint64, idioma string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.usuarios[id]
+	if !ok {
+		return errNotFound
+	}
+	u.Idioma = idioma
+	return nil
+}
+
+func (m memUsuarios) ClearToken(id uint64) error { return nil }
+
+// memUsuarioRepo implementa domain.UsuarioRepository sobre memUsuarios
+type memUsuarioRepo struct{ memUsuarios }
+
+func (m memUsuarioRepo) GetByEmail(email string) (*domain.Usuario, int, error) {
+	u, err := m.memUsuarios.GetByEmail(email)
+	if err != nil {
+		return nil, 404, nil
+	}
+	return u, 200, nil
+}
+
+func (m memUsuarioRepo) UpdateToken(id uint64, token string) error { return nil }
+
+// memGrupoUsuarios implementa domain.GrupoUsuarioUseCase; ningún grupo está silenciado
+type memGrupoUsuarios struct{ *memory }
+
+func (m memGrupoUsuarios) JoinGroup(userId uint64, claveGrupo string) error {
+	return errors.New("no soportado")
+}
+
+func (m memGrupoUsuarios) JoinGroups(usersIds []uint64, groupsIds []uint64) error {
+	return errors.New("no soportado")
+}
+
+func (m memGrupoUsuarios) LeaveGroup(userId uint64, grupoId uint64) error {
+	return errors.New("no soportado")
+}
+
+func (m memGrupoUsuarios) LeaveGroups(usersIds []uint64, groupsIds []uint64) error {
+	return errors.New("no soportado")
+}
+
+func (m memGrupoUsuarios) VerifyMembership(userId uint64, clave string) (bool, error) {
+	claves, err := memGrupos(m).GetAllGruposByUsuarioIdToClaves(userId)
+	return slices.Contains(claves, clave), err
+}
+
+func (m memGrupoUsuarios) GetUsersByGroupId(grupoId uint64) ([]domain.GrupoUsuario, error) {
+	return nil, nil
+}
+
+func (m memGrupoUsuarios) GetByUsuarioId(usuarioId uint64) (*domain.GrupoUsuario, error) {
+	return nil, errNotFound
+}
+
+func (m memGrupoUsuarios) SetSilenciado(userId uint64, clave string, silenciado bool) error {
+	return nil
+}
+
+func (m memGrupoUsuarios) GetClavesSilenciadas(userId uint64) ([]string, error) {
+	return nil, nil
+}
+
+// memPoliticas implementa domain.PoliticaTurnoUseCase; sin política guardada no hay límites
+type memPoliticas struct{ *memory }
+
+func (m memPoliticas) GetByGrupoId(grupoId uint64) (*domain.PoliticaTurno, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	politica, ok := m.politicas[grupoId]
+	if !ok {
+		politica = domain.PoliticaTurno{GrupoId: grupoId, PorDefecto: true}
+	}
+	return &politica, nil
+}
+
+func (m memPoliticas) Update(grupoId uint64, politica *domain.PoliticaTurno) error {
+	politica.GrupoId = grupoId
+	m.setPolitica(*politica)
+	return nil
+}
+
+// memGruposIA implementa domain.GrupoIAUseCase; sin configuración guardada responden todos los bots
+type memGruposIA struct{ *memory }
+
+func (m memGruposIA) GetByGrupoId(grupoId uint64) (*domain.GrupoIA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config, ok := m.gruposIA[grupoId]
+	if !ok {
+		config = domain.GrupoIA{GrupoId: grupoId, ModoRespuesta: domain.ModoRespuestaSiempre, PorDefecto: true}
+	}
+	return &config, nil
+}
+
+func (m memGruposIA) Get(usuarioId uint64, grupoId uint64) (*domain.GrupoIA, error) {
+	return m.GetByGrupoId(grupoId)
+}
+
+func (m memGruposIA) Update(usuarioId uint64, grupoId uint64, config *domain.GrupoIA) error {
+	config.GrupoId = grupoId
+	m.setGrupoIA(*config)
+	return nil
+}
+
+// memSalidas implementa domain.SalidaFallidaUseCase
+type memSalidas struct{ *memory }
+
+func (m memSalidas) Registrar(salida *domain.SalidaFallida) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.salidas = append(m.salidas, *salida)
+	return nil
+}
+
+func (m memSalidas) GetByBotId(botId uint64, limite int) ([]domain.SalidaFallida, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.salidas), nil
+}
+
+// memUso implementa domain.UsoIAUseCase
+type memUso struct{ *memory }
+
+func (m memUso) Registrar(llamada domain.LlamadaIA) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.llamadas = append(m.llamadas, llamada)
+	return nil
+}
+
+func (m memUso) GetTokensDia(usuarioId uint64, fecha time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m memUso) GetReporte(filtro domain.FiltroUsoIA) (*domain.ReporteUsoIA, error) {
+	return &domain.ReporteUsoIA{}, nil
+}
+
+// memInteracciones implementa domain.InteraccionIAUseCase
+type memInteracciones struct{ *memory }
+
+func (m memInteracciones) Registrar(interaccion *domain.InteraccionIA) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	interaccion.Id = uint64(len(m.interacciones) + 1)
+	m.interacciones = append(m.interacciones, *interaccion)
+	return nil
+}
+
+func (m memInteracciones) GetById(id uint64) (*domain.InteraccionIA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, i := range m.interacciones {
+		if i.Id == id {
+			return &i, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memInteracciones) GetAll(filtro domain.FiltroInteraccionIA) ([]domain.InteraccionIA, error) {
+	return m.interaccionesDe(filtro.BotId), nil
+}
+
+func (m memInteracciones) Reejecutar(id uint64, variante domain.VarianteIA) (*domain.ComparacionIA, error) {
+	return nil, errors.New("no soportado")
+}
+
+// memEmbeddings implementa domain.EmbeddingMensajeRepository con la similitud calculada en memoria
+type memEmbeddings struct{ *memory }
+
+func (m memEmbeddings) Save(embeddings []domain.EmbeddingMensaje) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.embeddings = append(m.embeddings, embeddings...)
+	return nil
+}
+
+func (m memEmbeddings) GetPendientes(modelo string, limite int) ([]domain.Mensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pendientes []domain.Mensaje
+	for _, msg := range m.mensajes {
+		indexado := slices.ContainsFunc(m.embeddings, func(e domain.EmbeddingMensaje) bool {
+			return e.MensajeId == msg.Id && e.Modelo == modelo
+		})
+		if !indexado && len(pendientes) < limite {
+			pendientes = append(pendientes, msg)
+		}
+	}
+	return pendientes, nil
+}
+
+func (m memEmbeddings) Search(grupoId uint64, modelo string, vector []float32, limite int, antesDeId uint64) ([]domain.SimilitudMensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var resultados []domain.SimilitudMensaje
+	for _, e := range m.embeddings {
+		if e.GrupoId != grupoId || e.Modelo != modelo || (antesDeId > 0 && e.MensajeId >= antesDeId) {
+			continue
+		}
+		resultados = append(resultados, domain.SimilitudMensaje{MensajeId: e.MensajeId, Similitud: llm.CosineSimilarity(vector, e.Vector)})
+	}
+	sort.SliceStable(resultados, func(i, j int) bool { return resultados[i].Similitud > resultados[j].Similitud })
+	return resultados[:min(limite, len(resultados))], nil
+}
+
+// memModeracion implementa domain.ModeracionRepository
+type memModeracion struct{ *memory }
+
+func (m memModeracion) Create(moderado *domain.MensajeModerado) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	moderado.Id = uint64(len(m.moderados) + 1)
+	m.moderados = append(m.moderados, *moderado)
+	return nil
+}
+
+func (m memModeracion) GetById(id uint64) (*domain.MensajeModerado, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, moderado := range m.moderados {
+		if moderado.Id == id {
+			return &moderado, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (m memModeracion) GetByEstado(estado string, limite int) ([]domain.MensajeModerado, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var moderados []domain.MensajeModerado
+	for _, moderado := range m.moderados {
+		if moderado.Estado == estado && len(moderados) < limite {
+			moderados = append(moderados, moderado)
+		}
+	}
+	return moderados, nil
+}
+
+func (m memModeracion) Revisar(moderado *domain.MensajeModerado) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.moderados {
+		if m.moderados[i].Id == moderado.Id && m.moderados[i].Estado == domain.EstadoModeracionPendiente {
+			m.moderados[i] = *moderado
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m memModeracion) AsignarMensaje(id uint64, mensajeId uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.moderados {
+		if m.moderados[i].Id == id {
+			m.moderados[i].MensajeId = &mensajeId
+		}
+	}
+	return nil
+}
+
+func (m memModeracion) Reabrir(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.moderados {
+		if m.moderados[i].Id == id && m.moderados[i].Estado == domain.EstadoModeracionAprobado && m.moderados[i].MensajeId == nil {
+			m.moderados[i].Estado = domain.EstadoModeracionPendiente
+			m.moderados[i].RevisadoPor = nil
+			m.moderados[i].FechaRevision = nil
+		}
+	}
+	return nil
+}
+
+// memEncuestas implementa domain.EncuestaUseCase
+type memEncuestas struct{ *memory }
+
+func (m memEncuestas) Create(encuesta *domain.Encuesta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	encuesta.Id = uint64(len(m.encuestas) + 1)
+	m.encuestas = append(m.encuestas, *encuesta)
+	return nil
+}
+
+func (m memEncuestas) GetById(usuarioId uint64, id uint64) (*domain.Encuesta, error) {
+	return nil, errNotFound
+}
+
+func (m memEncuestas) GetByGrupoId(usuarioId uint64, grupoId uint64) ([]domain.Encuesta, error) {
+	return nil, nil
+}
+
+func (m memEncuestas) Votar(usuarioId uint64, id uint64, opcion int) (*domain.Encuesta, error) {
+	return nil, errNotFound
+}
+
+// memTraducciones implementa domain.TraduccionRepository
+type memTraducciones struct{ *memory }
+
+func (m memTraducciones) GetByMensajes(mensajesIds []uint64, idioma string) ([]domain.TraduccionMensaje, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var traducciones []domain.TraduccionMensaje
+	for _, traduccion := range m.traducciones {
+		if traduccion.Idioma == idioma && slices.Contains(mensajesIds, traduccion.MensajeId) {
+			traducciones = append(traducciones, traduccion)
+		}
+	}
+	return traducciones, nil
+}
+
+func (m memTraducciones) Save(traduccion *domain.TraduccionMensaje) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	traduccion.CreatedAt = time.Now()
+	for i := range m.traducciones {
+		if m.traducciones[i].MensajeId == traduccion.MensajeId && m.traducciones[i].Idioma == traduccion.Idioma {
+			m.traducciones[i] = *traduccion
+			return nil
+		}
+	}
+	m.traducciones = append(m.traducciones, *traduccion)
+	return nil
+}
+
+// memGrupoUsuarioRepo implementa domain.GrupoUsuarioRepository sobre memGrupoUsuarios
+type memGrupoUsuarioRepo struct{ memGrupoUsuarios }
+
+func (m memGrupoUsuarioRepo) GetByGrupoId(grupoId uint64) ([]domain.GrupoUsuario, error) {
+	return nil, nil
+}
+
+func (m memGrupoUsuarioRepo) Create(grupoUsuario *domain.GrupoUsuario) error {
+	return errors.New("no soportado")
+}
+
+func (m memGrupoUsuarioRepo) Delete(userId uint64, grupoId uint64) (bool, error) {
+	return false, errors.New("no soportado")
+}
+
+func (m memGrupoUsuarioRepo) SetSilenciado(userId uint64, clave string, silenciado bool) (bool, error) {
+	return true, nil
+}
+
+// memResumenes implementa domain.ResumenRepository
+type memResumenes struct{ *memory }
+
+func (m memResumenes) GetByRango(grupoId uint64, rango string) (*domain.ResumenIA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resumen, ok := m.resumenesIA[strconv.FormatUint(grupoId, 10)+" "+rango]
+	if !ok {
+		return nil, nil
+	}
+	return &resumen, nil
+}
+
+func (m memResumenes) Save(resumen *domain.ResumenIA) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resumen.UpdatedAt = time.Now()
+	m.resumenesIA[strconv.FormatUint(resumen.GrupoId, 10)+" "+resumen.Rango] = *resumen
+	return nil
+}
+
+func (m memResumenes) GetSuscripcion(usuarioId uint64) (*domain.SuscripcionDigest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	suscripcion, ok := m.suscripciones[usuarioId]
+	if !ok {
+		return nil, nil
+	}
+	return &suscripcion, nil
+}
+
+func (m memResumenes) SaveSuscripcion(suscripcion *domain.SuscripcionDigest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.suscripciones[suscripcion.UsuarioId] = *suscripcion
+	return nil
+}
+
+func (m memResumenes) GetSuscripcionesPendientes(ahora time.Time, limite int) ([]domain.SuscripcionDigest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inicioDia := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.UTC)
+	var pendientes []domain.SuscripcionDigest
+	for _, suscripcion := range m.suscripciones {
+		if suscripcion.Activo && suscripcion.Hora <= ahora.Hour() &&
+			(suscripcion.UltimoDigest == nil || suscripcion.UltimoDigest.Before(inicioDia)) && len(pendientes) < limite {
+			pendientes = append(pendientes, suscripcion)
+		}
+	}
+	return pendientes, nil
+}
+
+func (m memResumenes) CreateDigest(digest *domain.Digest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	digest.Id = uint64(len(m.digests) + 1)
+	digest.CreatedAt = time.Now()
+	m.digests = append(m.digests, *digest)
+
+	suscripcion := m.suscripciones[digest.UsuarioId]
+	hasta := digest.Hasta
+	suscripcion.UltimoDigest = &hasta
+	m.suscripciones[digest.UsuarioId] = suscripcion
+	return nil
+}
+
+func (m memResumenes) GetDigests(usuarioId uint64, limite int) ([]domain.Digest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var digests []domain.Digest
+	for i := len(m.digests) - 1; i >= 0 && len(digests) < limite; i-- {
+		if m.digests[i].UsuarioId == usuarioId {
+			digests = append(digests, m.digests[i])
+		}
+	}
+	return digests, nil
+}
@@ -0,0 +1,250 @@
+package ia
+
+import (
+	"chatvis-chat/internal/llm"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSilent indica que el modelo no devolvió ningún mensaje con contenido, es decir, que decidió no hablar
+var ErrSilent = errors.New("el bot decidió no responder")
+
+const (
+	// maxJSONCandidates limita cuántos objetos candidatos se prueban dentro de una misma respuesta
+	maxJSONCandidates = 20
+	// maxReplyMessages limita cuántos mensajes puede publicar el bot en una misma respuesta
+	maxReplyMessages = 5
+)
+
+// replySchema es el JSON schema de la respuesta que se pide a los bots: una lista de mensajes,
+// cada uno con su answer_id y una pausa sugerida antes de enviarlo. Un objeto suelto
+// {answer_id, content} también se acepta al interpretar la respuesta.
+var replySchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "messages": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "answer_id": {"type": ["string", "integer", "null"]},
+          "content": {"type": ["string", "null"]},
+          "delay_ms": {"type": ["integer", "null"]}
+        },
+        "required": ["answer_id", "content"]
+      }
+    }
+  },
+  "required": ["messages"]
+}`)
+
+// replyResponseFormat es el formato estructurado que se envía a los proveedores que lo soportan
+var replyResponseFormat = &llm.ResponseFormat{Name: "bot_reply", Schema: replySchema}
+
+// replyOutput es la respuesta del bot ya validada contra replySchema
+type replyOutput struct {
+	Parts []replyPart
+}
+
+// replyPart es uno de los mensajes de la respuesta
+type replyPart struct {
+	AnswerID any
+	// Content es nil cuando el modelo respondió content: null
+	Content *string
+	// DelayMs es la pausa sugerida antes de enviar el mensaje; nil si el modelo no la indicó
+	DelayMs *int
+}
+
+// decodeReply busca en el texto del modelo el primer valor JSON que cumpla replySchema: un
+// objeto {"messages": [...]}, una lista de mensajes o un único objeto {answer_id, content}.
+// Tolera texto antes o después del JSON, bloques de código Markdown y comillas simples.
+func decodeReply(text string) (*replyOutput, error) {
+	lastErr := errors.New("la respuesta no contiene un objeto JSON")
+
+	candidates := 0
+	for i := 0; i < len(text) && candidates < maxJSONCandidates; i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+
+		end := matchingBrace(text, i)
+		if end < 0 {
+			continue
+		}
+		candidates++
+
+		candidate := text[i : end+1]
+		if !json.Valid([]byte(candidate)) {
+			candidate = singleToDoubleQuotes(candidate)
+			if !json.Valid([]byte(candidate)) {
+				lastErr = fmt.Errorf("JSON inválido cerca de la posición %d", i)
+				continue
+			}
+		}
+
+		out, err := validateReply([]byte(candidate))
+		if err == nil {
+			return out, nil
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// matchingBrace devuelve la posición de la llave (o corchete) que cierra la abierta en start,
+// o -1. Las cadenas pueden ir entre comillas dobles o simples.
+func matchingBrace(text string, start int) int {
+	depth := 0
+	var quote byte
+	escaped := false
+
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// singleToDoubleQuotes convierte las cadenas entre comillas simples a cadenas JSON válidas
+func singleToDoubleQuotes(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+			b.WriteByte('"')
+		case quote != 0 && c == '\\' && i+1 < len(text):
+			next := text[i+1]
+			if quote == '\'' && next == '\'' {
+				b.WriteByte('\'')
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+			b.WriteByte('"')
+		case quote == '\'' && c == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// validateReply comprueba que el valor tenga la forma de replySchema
+func validateReply(data []byte) (*replyOutput, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("la respuesta no es un objeto JSON: %w", err)
+		}
+
+		rawMessages, ok := fields["messages"]
+		if !ok {
+			part, err := validatePart(fields)
+			if err != nil {
+				return nil, err
+			}
+			return &replyOutput{Parts: []replyPart{*part}}, nil
+		}
+		if err := json.Unmarshal(rawMessages, &items); err != nil {
+			return nil, errors.New("messages debe ser una lista")
+		}
+	}
+
+	out := &replyOutput{}
+	for i, item := range items {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(item, &fields); err != nil {
+			return nil, fmt.Errorf("el mensaje %d no es un objeto JSON", i+1)
+		}
+		part, err := validatePart(fields)
+		if err != nil {
+			return nil, fmt.Errorf("mensaje %d: %w", i+1, err)
+		}
+		out.Parts = append(out.Parts, *part)
+	}
+
+	return out, nil
+}
+
+// validatePart comprueba los campos de un mensaje de la respuesta
+func validatePart(fields map[string]json.RawMessage) (*replyPart, error) {
+	rawContent, ok := fields["content"]
+	if !ok {
+		return nil, errors.New("falta el campo content")
+	}
+
+	part := &replyPart{}
+	if string(rawContent) != "null" {
+		var content string
+		if err := json.Unmarshal(rawContent, &content); err != nil {
+			return nil, errors.New("content debe ser texto o null")
+		}
+		part.Content = &content
+	}
+
+	if rawAnswer, ok := fields["answer_id"]; ok {
+		if err := json.Unmarshal(rawAnswer, &part.AnswerID); err != nil {
+			return nil, fmt.Errorf("answer_id inválido: %w", err)
+		}
+		switch part.AnswerID.(type) {
+		case nil, string, float64:
+		default:
+			return nil, errors.New("answer_id debe ser un número, texto o null")
+		}
+	}
+
+	if rawDelay, ok := fields["delay_ms"]; ok && string(rawDelay) != "null" {
+		var delay float64
+		if err := json.Unmarshal(rawDelay, &delay); err != nil || delay < 0 {
+			return nil, errors.New("delay_ms debe ser un número de milisegundos no negativo")
+		}
+		ms := int(delay)
+		part.DelayMs = &ms
+	}
+
+	return part, nil
+}
+
+// repairInstruction es el mensaje con el que se pide al modelo que corrija una respuesta inválida
+func repairInstruction(parseErr error) string {
+	return fmt.Sprintf("Tu respuesta anterior no se pudo interpretar (%v). Responde de nuevo ÚNICAMENTE con un objeto JSON "+
+		`de la forma {"messages": [{"answer_id": <id o null>, "content": "<tu mensaje>", "delay_ms": <pausa opcional>}]}, `+
+		`sin texto adicional. Usa solo ids de los mensajes que recibiste. Si prefieres no hablar, responde {"messages": []}.`, parseErr)
+}
@@ -0,0 +1,134 @@
+package ia
+
+import (
+	"bytes"
+	"chatvis-chat/internal/domain"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Miembro es la vista de un integrante del grupo disponible en las plantillas
+type Miembro struct {
+	Nombre string
+	Apodo  string
+	EsBot  bool
+}
+
+// PromptData son los datos con los que se renderiza la plantilla del prompt de sistema
+type PromptData struct {
+	Grupo      string
+	GrupoClave string
+	Miembros   []Miembro
+	Apodos     []string
+	Nombre     string
+	Apodo      string
+	Ahora      time.Time
+	Fecha      string
+	Hora       string
+}
+
+var promptFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParsePromptTemplate compila una plantilla; si viene vacía se usa DefaultPromptTemplate
+func ParsePromptTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultPromptTemplate
+	}
+
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla de prompt inválida: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderPrompt ejecuta la plantilla con los datos del grupo
+func RenderPrompt(tmpl *template.Template, data PromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error al renderizar el prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// NewPromptData arma los datos de la plantilla a partir del grupo, sus integrantes y el bot
+func NewPromptData(grupo *domain.Grupo, miembros []domain.Usuario, bot *domain.Usuario, ahora time.Time) PromptData {
+	data := PromptData{
+		Ahora: ahora,
+		Fecha: ahora.Format("2006-01-02"),
+		Hora:  ahora.Format("15:04"),
+	}
+
+	if grupo != nil {
+		data.Grupo = grupo.Nombre
+		data.GrupoClave = grupo.Clave
+	}
+
+	if bot != nil {
+		data.Nombre = bot.Nombre
+		data.Apodo = bot.Apodo
+	}
+
+	for _, m := range miembros {
+		data.Miembros = append(data.Miembros, Miembro{Nombre: m.Nombre, Apodo: m.Apodo, EsBot: m.IsLlm})
+		data.Apodos = append(data.Apodos, m.Apodo)
+	}
+
+	return data
+}
+
+// DefaultPromptTemplate es la persona usada cuando el bot no tiene plantilla propia
+const DefaultPromptTemplate = `CONTEXTO: Estás en el grupo "{{.Grupo}}". Tu apodo es {{.Apodo}}.
+Integrantes: {{join .Apodos ", "}}.
+Fecha y hora actual: {{.Fecha}} {{.Hora}}.
+Cada mensaje del chat trae su id, fecha, @apodo de quien habla y, si aplica, el id al que responde.
+Usa únicamente esos id como answer_id.
+
+Actúa bajo las siguientes reglas estrictas de rol y formato. No puedes salirte de ellas bajo ninguna circunstancia.
+
+1. PERFIL: Estudiante de Ingeniería en Sistemas/Computación en México.
+
+Lenguaje: Informal, "chilango" o norteño ligero (wey, neta, jalo, simón).
+
+Escritura: Máximo 5 palabras por mensaje. Si es mucho texto, divídelo en varios mensajes cortos.
+
+Ortografía: No uses ¿, solo ? al final. Ignora tildes o mayúsculas a veces. Comete errores de dedo ocasionales.
+
+Identificador: Cada mensaje dentro del campo "content" DEBE empezar con tu color asignado (ej: "Azul: qué onda wey").
+
+2. REGLA DE ORO (SILENCIO):
+
+Solo respondes si: te preguntan directo, hay una tarea libre, hay un error en el código del grupo o el chat murió por 1 minuto.
+
+Si no hay razón para hablar, tu respuesta debe ser {"messages": []}.
+
+3. RESTRICCIÓN DE FORMATO (CRÍTICO):
+
+TU SALIDA DEBE SER EXCLUSIVAMENTE UN OBJETO JSON RAW.
+
+PROHIBIDO usar bloques de código de Markdown (nada json).
+
+PROHIBIDO incluir la palabra "json" fuera del objeto.
+
+PROHIBIDO agregar introducciones como "Aquí tienes la respuesta" o despedidas.
+
+Cada elemento de "messages" es un mensaje del chat. Si la respuesta es directa a alguien:
+{"messages": [{"answer_id": "ID_DEL_MSG", "content": "Color: Tu respuesta"}]}
+
+Si es comentario general:
+{"messages": [{"answer_id": null, "content": "Color: Tu respuesta"}]}
+
+Para varios mensajes cortos agrega más elementos; cada uno puede responder a un id distinto y llevar
+"delay_ms" con la pausa en milisegundos antes de enviarlo.
+
+4. SIMULACIÓN DE TIEMPO: Espera 15 segundos mentalmente. (No lo menciones, solo actúa).
+
+EJEMPLO DE SALIDA ESPERADA (Y ÚNICA FORMA ACEPTADA):
+{"messages": [{"answer_id": "123", "content": "Rojo: yo jalo con eso"}, {"answer_id": null, "content": "Rojo: a q hora?", "delay_ms": 2500}]}
+`
@@ -0,0 +1,156 @@
+package ia
+
+import (
+	"chatvis-chat/internal/websocket"
+	"context"
+	"sync"
+	"time"
+)
+
+// TriggerReason indica por qué se invoca al bot en un grupo
+type TriggerReason string
+
+const (
+	// TriggerMessage: llegó al menos un mensaje nuevo al grupo
+	TriggerMessage TriggerReason = "message"
+	// TriggerIdle: el grupo lleva el tiempo configurado sin actividad
+	TriggerIdle TriggerReason = "idle"
+)
+
+// aiJob es una unidad de trabajo de IA para un grupo
+type aiJob struct {
+	GroupID string
+	Trigger TriggerReason
+	Msg     websocket.Message
+}
+
+// groupQueue serializa el trabajo de IA por grupo: nunca hay dos completions del mismo
+// grupo en curso, y una ráfaga de mensajes dentro de la ventana de debounce se agrupa
+// en una sola ejecución con el último mensaje recibido.
+type groupQueue struct {
+	mu       sync.Mutex
+	groups   map[string]*groupState
+	debounce time.Duration
+	slots    chan struct{} // limita cuántos grupos se procesan a la vez
+	run      func(ctx context.Context, job aiJob)
+}
+
+type groupState struct {
+	timer   *time.Timer
+	running bool
+	pending bool
+	last    aiJob
+}
+
+func newGroupQueue(debounce time.Duration, concurrency int, run func(ctx context.Context, job aiJob)) *groupQueue {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &groupQueue{
+		groups:   make(map[string]*groupState),
+		debounce: debounce,
+		slots:    make(chan struct{}, concurrency),
+		run:      run,
+	}
+}
+
+// Push registra un trabajo nuevo del grupo y (re)inicia la ventana de debounce.
+// Un trabajo por mensaje pendiente tiene prioridad sobre uno por inactividad.
+func (q *groupQueue) Push(ctx context.Context, job aiJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state, ok := q.groups[job.GroupID]
+	if !ok {
+		state = &groupState{}
+		q.groups[job.GroupID] = state
+	}
+
+	if !(state.pending && state.last.Trigger == TriggerMessage && job.Trigger == TriggerIdle) {
+		state.last = job
+	}
+	state.pending = true
+
+	// Si ya hay una ejecución en curso, se vuelve a programar al terminar
+	if state.running {
+		return
+	}
+
+	q.schedule(ctx, job.GroupID, state)
+}
+
+// schedule reinicia el temporizador de debounce del grupo. Requiere q.mu.
+func (q *groupQueue) schedule(ctx context.Context, groupID string, state *groupState) {
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	state.timer = time.AfterFunc(q.debounce, func() {
+		q.fire(ctx, groupID)
+	})
+}
+
+func (q *groupQueue) fire(ctx context.Context, groupID string) {
+	q.mu.Lock()
+	state, ok := q.groups[groupID]
+	if !ok || state.running || !state.pending {
+		q.mu.Unlock()
+		return
+	}
+	state.running = true
+	state.pending = false
+	state.timer = nil
+	job := state.last
+	q.mu.Unlock()
+
+	go func() {
+		defer q.finish(ctx, groupID)
+
+		select {
+		case <-ctx.Done():
+			return
+		case q.slots <- struct{}{}:
+		}
+		defer func() { <-q.slots }()
+
+		q.run(ctx, job)
+	}()
+}
+
+// finish libera el grupo y, si llegaron mensajes durante la ejecución, agenda otra
+func (q *groupQueue) finish(ctx context.Context, groupID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state, ok := q.groups[groupID]
+	if !ok {
+		return
+	}
+	state.running = false
+
+	if ctx.Err() != nil {
+		delete(q.groups, groupID)
+		return
+	}
+
+	if state.pending {
+		q.schedule(ctx, groupID, state)
+		return
+	}
+
+	delete(q.groups, groupID)
+}
+
+// Stop cancela los temporizadores pendientes
+func (q *groupQueue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for groupID, state := range q.groups {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+		if !state.running {
+			delete(q.groups, groupID)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// reminderInterval es cada cuánto se buscan recordatorios vencidos
+	reminderInterval = 15 * time.Second
+	// reminderBatch limita los recordatorios que se publican por ronda
+	reminderBatch = 50
+)
+
+// ReminderScheduler publica los recordatorios vencidos a través del bot que los programó. Si ese
+// bot no está en ejecución el recordatorio queda pendiente hasta que vuelva a estarlo.
+type ReminderScheduler struct {
+	manager  *Manager
+	usecase  domain.RecordatorioUseCase
+	interval time.Duration
+}
+
+func NewReminderScheduler(manager *Manager, ru domain.RecordatorioUseCase) *ReminderScheduler {
+	return &ReminderScheduler{
+		manager:  manager,
+		usecase:  ru,
+		interval: reminderInterval,
+	}
+}
+
+// Run revisa periódicamente los recordatorios hasta que se cancele el contexto
+func (r *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.check(ctx, now)
+		}
+	}
+}
+
+// check publica los recordatorios vencidos hasta now y los marca como enviados
+func (r *ReminderScheduler) check(ctx context.Context, now time.Time) {
+	vencidos, err := r.usecase.GetVencidos(now, reminderBatch)
+	if err != nil {
+		log.Printf("ReminderScheduler: error al obtener los recordatorios vencidos: %v", err)
+		return
+	}
+
+	for _, recordatorio := range vencidos {
+		service := r.manager.service(strconv.FormatUint(recordatorio.UsuarioId, 10))
+		if service == nil {
+			continue
+		}
+
+		if !service.deliverReminder(ctx, recordatorio) {
+			continue
+		}
+		if err := r.usecase.MarcarEnviado(recordatorio.Id); err != nil {
+			log.Printf("ReminderScheduler: error al marcar el recordatorio %d como enviado: %v", recordatorio.Id, err)
+		}
+	}
+}
+
+// deliverReminder publica el recordatorio en su grupo. Devuelve true si quedó resuelto: se
+// publicó o se descartó porque el bot ya no pertenece al grupo.
+func (s *AIService) deliverReminder(ctx context.Context, recordatorio domain.Recordatorio) bool {
+	grupo, err := s.GrupoUseCase.GetById(recordatorio.GrupoId)
+	if err != nil || grupo == nil {
+		log.Printf("AIService: no se encontró el grupo %d del recordatorio %d: %v", recordatorio.GrupoId, recordatorio.Id, err)
+		return false
+	}
+
+	if !s.Hub.CheckUserInGroup(s.Config.UserID, grupo.Clave) {
+		log.Printf("AIService: el bot %s ya no pertenece al grupo %s; se descarta el recordatorio %d", s.Config.UserID, grupo.Clave, recordatorio.Id)
+		return true
+	}
+
+	bot, err := s.UsuarioUseCase.GetById(recordatorio.UsuarioId)
+	if err != nil {
+		log.Printf("AIService: no se pudo obtener el usuario del bot %d: %v", recordatorio.UsuarioId, err)
+	}
+
+	scope := toolScope{GrupoID: grupo.Id, GroupClave: grupo.Clave, BotID: recordatorio.UsuarioId, Bot: bot}
+	return s.announce(ctx, scope, "Recordatorio: "+recordatorio.Texto)
+}
@@ -0,0 +1,154 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/websocket"
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxReplyDelay acota la pausa entre dos mensajes de una misma respuesta
+	maxReplyDelay = 15 * time.Second
+	// typingBaseDelay y typingDelayPerRune estiman la pausa cuando el modelo no sugiere una
+	typingBaseDelay    = 800 * time.Millisecond
+	typingDelayPerRune = 50 * time.Millisecond
+)
+
+// ReplyMessage es uno de los mensajes de una respuesta del bot y la pausa antes de enviarlo
+type ReplyMessage struct {
+	Message websocket.Message
+	Delay   time.Duration
+}
+
+// replySequence identifica una respuesta de varios mensajes en curso en un grupo
+type replySequence struct {
+	cancel context.CancelFunc
+}
+
+// replyDelay devuelve la pausa antes de enviar el mensaje: la sugerida por el modelo o, si no la
+// indicó, una estimación según su largo. El primer mensaje sale sin pausa salvo que se sugiera una.
+func replyDelay(part replyPart, first bool) time.Duration {
+	if part.DelayMs != nil {
+		return min(time.Duration(*part.DelayMs)*time.Millisecond, maxReplyDelay)
+	}
+	if first || part.Content == nil {
+		return 0
+	}
+	return min(typingBaseDelay+time.Duration(utf8.RuneCountInString(*part.Content))*typingDelayPerRune, maxReplyDelay)
+}
+
+// deliverReplies persiste y publica los mensajes de la respuesta en orden, mostrando el indicador
+// de escritura durante cada pausa. Si un humano escribe en el grupo mientras tanto (ver Interrupt),
+// los mensajes restantes se descartan. Con streaming, el primer mensaje cierra el stream streamID
+// y se publica sin pausa. Los mensajes ya deben estar moderados (ver moderateReplies). Devuelve
+// cuántos mensajes se publicaron; se publican en orden, así que con alguno se cerró el stream.
+func (s *AIService) deliverReplies(ctx context.Context, groupID string, replies []ReplyMessage, aiUser *domain.Usuario, streamID string) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sequence := &replySequence{cancel: cancel}
+	s.sequences.Store(groupID, sequence)
+	defer s.sequences.CompareAndDelete(groupID, sequence)
+
+	return s.publishReplies(ctx, groupID, replies, aiUser, streamID)
+}
+
+// publishReplies hace la entrega de deliverReplies sin registrarla como la respuesta en curso
+// del grupo, así que Interrupt no la cancela. Se usa para los anuncios fuera de una respuesta.
+func (s *AIService) publishReplies(ctx context.Context, groupID string, replies []ReplyMessage, aiUser *domain.Usuario, streamID string) int {
+	for i, reply := range replies {
+		msg := reply.Message
+		if aiUser != nil {
+			msg.SenderName = aiUser.Nombre
+			msg.SenderApodo = aiUser.Apodo
+		}
+
+		streamed := i == 0 && streamID != ""
+		if !streamed && reply.Delay > 0 && !s.typeAndWait(ctx, msg, reply.Delay) {
+			log.Printf("AIService: se interrumpe la respuesta del bot %s en el grupo %s tras %d de %d mensajes.", s.Config.UserID, groupID, i, len(replies))
+			return i
+		}
+		if ctx.Err() != nil {
+			log.Printf("AIService: se interrumpe la respuesta del bot %s en el grupo %s tras %d de %d mensajes.", s.Config.UserID, groupID, i, len(replies))
+			return i
+		}
+
+		msg.Fecha = time.Now().Format(time.RFC3339)
+		mensaje, err := s.aiMessage(&msg)
+		if err != nil {
+			log.Printf("Error al preparar el mensaje de IA: %v", err)
+			return i
+		}
+
+		gormMsg, err := s.saveAIToDB(mensaje)
+		if err != nil {
+			log.Printf("Error al guardar el mensaje de IA en la base de datos: %v", err)
+			return i
+		}
+
+		msg.Id = strconv.FormatUint(gormMsg.Id, 10)
+		for _, mencion := range gormMsg.Menciones {
+			msg.Mentions = append(msg.Mentions, strconv.FormatUint(mencion.UsuarioId, 10))
+		}
+		if streamed {
+			msg.Type = websocket.TypeAIFinal
+			msg.StreamId = streamID
+		}
+
+		// Enviar el mensaje de la IA a través del Hub
+		s.Hub.Broadcast(msg)
+	}
+
+	return len(replies)
+}
+
+// moderateReplies pasa cada mensaje de la respuesta por la moderación y devuelve los permitidos
+func (s *AIService) moderateReplies(ctx context.Context, replies []ReplyMessage) []ReplyMessage {
+	if s.Moderacion == nil {
+		return replies
+	}
+
+	var permitidos []ReplyMessage
+	for _, reply := range replies {
+		msg := reply.Message
+		mensaje, err := s.aiMessage(&msg)
+		if err != nil {
+			// deliverReplies vuelve a prepararlo y registra el error
+			permitidos = append(permitidos, reply)
+			continue
+		}
+		if s.moderate(ctx, mensaje) {
+			permitidos = append(permitidos, reply)
+		}
+	}
+	return permitidos
+}
+
+// typeAndWait muestra el indicador de escritura del bot durante delay. Devuelve false si la
+// respuesta se interrumpió antes de terminar la pausa; en ese caso retira el indicador.
+func (s *AIService) typeAndWait(ctx context.Context, msg websocket.Message, delay time.Duration) bool {
+	s.Hub.BroadcastTyping(msg, true)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		s.Hub.BroadcastTyping(msg, false)
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Interrupt cancela la respuesta de varios mensajes que el bot esté publicando en el grupo.
+// Se invoca cuando un humano escribe en el grupo.
+func (s *AIService) Interrupt(groupID string) {
+	if sequence, ok := s.sequences.Load(groupID); ok {
+		sequence.(*replySequence).cancel()
+	}
+}
@@ -0,0 +1,71 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Response es lo que generó el bot en Respond para una ventana de mensajes
+type Response struct {
+	// Window son los mensajes que entraron en el prompt (válidos como answer_id)
+	Window   []domain.Mensaje
+	Prompt   []llm.ChatMessage
+	Output   string
+	Messages []domain.RespuestaIA
+	Latency  time.Duration
+	Usage    llm.Usage
+}
+
+// Respond genera la respuesta del bot a un historial ya cargado sin leer ni escribir la base de
+// datos ni publicar en el grupo: arma el prompt igual que generateAndSendResponse (sin resumen ni
+// mensajes citados), llama al proveedor y decodifica la salida sin validar los answer_id ni pedir
+// correcciones. Lo usan las evaluaciones offline (cmd/ai-eval).
+//
+// Si el proveedor falla devuelve solo el error. Si la salida no se puede interpretar devuelve la
+// respuesta junto al error de decodificación, y ErrSilent si el bot decidió no hablar.
+func (s *AIService) Respond(ctx context.Context, data PromptData, historial []domain.Mensaje) (*Response, error) {
+	var systemPrompt string
+	if s.Config.IsPromt {
+		var err error
+		systemPrompt, err = RenderPrompt(s.promptTmpl, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	limite := s.Config.HistoryMessages
+	if limite <= 0 {
+		limite = defaultHistoryMessages
+	}
+	historial = historial[max(len(historial)-limite, 0):]
+
+	window := s.fitHistory(historial, s.budget(systemPrompt).History)
+	if len(window) == 0 {
+		return nil, errors.New("no hay mensajes para responder")
+	}
+
+	resp := &Response{
+		Window: window,
+		Prompt: s.buildPrompt(systemPrompt, promptContext{Mensajes: window}),
+	}
+
+	started := time.Now()
+	completion, err := s.provider.Complete(ctx, llm.CompletionRequest{
+		Model:    s.Config.LLMName,
+		Messages: resp.Prompt,
+		Options:  s.Config.CompletionOptions(),
+	})
+	resp.Latency = time.Since(started)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar la respuesta (%s): %w", s.provider.Name(), err)
+	}
+
+	resp.Output = completion.Content
+	resp.Usage = completion.Usage
+	resp.Messages, err = interpretReply(completion.Content)
+	return resp, err
+}
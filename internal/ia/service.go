@@ -3,14 +3,17 @@ package ia
 import (
 	"chatvis-chat/internal/domain"
 	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/pkg"
 	"chatvis-chat/internal/websocket"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 )
 
@@ -20,44 +23,94 @@ type AIService struct {
 	GrupoUseCase   domain.GrupoUseCase
 	UsuarioUseCase domain.UsuarioUseCase
 	conversations  sync.Map
-
-	Config IAConfig
+	// sequences guarda la respuesta de varios mensajes en curso por grupo (*replySequence)
+	sequences sync.Map
+
+	// Turns limita cuándo el bot puede hablar en cada grupo; nil no aplica límites
+	Turns *TurnPolicy
+	// Usage registra el consumo de las llamadas al modelo y aplica las cuotas; nil no registra nada
+	Usage *UsageTracker
+	// SalidasFallidas registra las respuestas que no se pudieron interpretar; puede ser nil
+	SalidasFallidas domain.SalidaFallidaUseCase
+	// Interacciones guarda cada ejecución del bot para depurarla y compararla; puede ser nil
+	Interacciones domain.InteraccionIAUseCase
+	// Recuerdos busca mensajes anteriores relevantes del grupo; nil desactiva los recuerdos
+	Recuerdos domain.EmbeddingMensajeUseCase
+	// Encuestas y Recordatorios respaldan las herramientas crear_encuesta y programar_recordatorio;
+	// si son nil esas herramientas responden que no están disponibles
+	Encuestas     domain.EncuestaUseCase
+	Recordatorios domain.RecordatorioUseCase
+	// Moderacion pasa cada mensaje por la cadena de moderación antes de guardarlo; puede ser nil.
+	// Con moderación las respuestas no se transmiten en streaming.
+	Moderacion domain.ModeracionUseCase
+	// GroupAI aplica la configuración de IA de cada grupo; nil responde en todos los grupos
+	GroupAI *GroupSettings
+
+	Config     IAConfig
+	provider   llm.Provider
+	promptTmpl *template.Template
 
 	inputChannel chan websocket.Message
-	jobs         chan websocket.Message
+	queue        *groupQueue
 	quit         chan struct{}
+	ctx          context.Context
+	cancel       context.CancelFunc
 	stopOnce     sync.Once
 }
 
-func NewAIService(h *websocket.Hub, mr domain.MensajeUseCase, gu domain.GrupoUseCase, uu domain.UsuarioUseCase, config IAConfig) *AIService {
-	return &AIService{
+func NewAIService(h *websocket.Hub, mr domain.MensajeUseCase, gu domain.GrupoUseCase, uu domain.UsuarioUseCase, config IAConfig) (*AIService, error) {
+	provider, err := llm.NewProvider(config.ProviderConfig())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el proveedor LLM del bot %s: %w", config.UserID, err)
+	}
+
+	promptTmpl, err := ParsePromptTemplate(config.PromptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error en la plantilla de prompt del bot %s: %w", config.UserID, err)
+	}
+
+	s := &AIService{
 		Hub:            h,
 		MensajeUseCase: mr,
 		GrupoUseCase:   gu,
 		UsuarioUseCase: uu,
 		quit:           make(chan struct{}),
 		Config:         config,
+		provider:       provider,
+		promptTmpl:     promptTmpl,
 		inputChannel:   make(chan websocket.Message, 100), // Buffer para manejar ráfagas de mensajes
-		jobs:           make(chan websocket.Message, 100), // Canal de trabajos para el pool de workers
 	}
+	s.queue = newGroupQueue(config.Debounce, config.Workers, s.generateAndSendResponse)
+
+	return s, nil
 }
 
 func (s *AIService) InputChannel() chan websocket.Message {
 	return s.inputChannel
 }
 
+// Enqueue entrega un mensaje al servicio; devuelve false si el servicio ya fue detenido.
+func (s *AIService) Enqueue(msg websocket.Message) bool {
+	select {
+	case <-s.quit:
+		return false
+	case s.inputChannel <- msg:
+		return true
+	}
+}
+
 // Start listens for new messages from the Hub and decides whether to respond.
-func (s *AIService) Start(ctx context.Context, workerCount int) {
+// El trabajo se serializa por grupo; Config.Workers limita cuántos grupos se atienden a la vez.
+func (s *AIService) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.ctx = ctx
+
 	if err := s.SuscribeToGroup(); err != nil {
 		log.Printf("Error al suscribir IA a grupos: %v", err)
 	} else {
 		log.Printf("IA suscrita a sus grupos correctamente")
 	}
 
-	for i := 0; i < workerCount; i++ {
-		go s.worker(ctx, i)
-	}
-
 	go s.listenForMessages(ctx)
 }
 
@@ -77,6 +130,18 @@ func (s *AIService) SuscribeToGroup() error {
 	return nil
 }
 
+// OnMembership recibe las altas y bajas del bot en grupos mientras está en ejecución. El Hub ya
+// actualizó la suscripción; al salir de un grupo se corta la respuesta que estuviera publicando.
+func (s *AIService) OnMembership(evento domain.EventoMembresia) {
+	switch evento.Tipo {
+	case domain.EventoMembresiaAlta:
+		log.Printf("AIService: el bot %s ahora escucha el grupo %s", s.Config.UserID, evento.GrupoClave)
+	case domain.EventoMembresiaBaja:
+		s.Interrupt(evento.GrupoClave)
+		log.Printf("AIService: el bot %s dejó el grupo %s", s.Config.UserID, evento.GrupoClave)
+	}
+}
+
 func (s *AIService) listenForMessages(ctx context.Context) {
 	for {
 		select {
@@ -84,12 +149,7 @@ func (s *AIService) listenForMessages(ctx context.Context) {
 			log.Println("AIService: Contexto cancelado, deteniendo gorutina de escucha.")
 			return
 
-		case msg, ok := <-s.inputChannel:
-			if !ok {
-				log.Println("AIService: Canal de entrada cerrado, deteniendo gorutina de escucha.")
-				return
-			}
-
+		case msg := <-s.inputChannel:
 			log.Printf("AIService: recibido mensaje en AIChannel: %+v", msg)
 
 			if msg.SenderID == s.Config.UserID {
@@ -101,34 +161,69 @@ func (s *AIService) listenForMessages(ctx context.Context) {
 				continue
 			}
 
-			select {
-			case <-ctx.Done():
-				return
-			case s.jobs <- msg:
+			if s.Config.OnlyMentions && !s.isAddressed(msg) {
+				continue
+			}
+
+			if !s.allowedInGroup(msg.GroupID, TriggerMessage, &msg) {
+				continue
 			}
+
+			s.queue.Push(ctx, aiJob{GroupID: msg.GroupID, Trigger: TriggerMessage, Msg: msg})
 		}
 	}
 }
 
-func (s *AIService) worker(ctx context.Context, id int) {
-	log.Printf("Worker %d iniciado", id)
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("Worker %d detenido", id)
-			return
-		case msg, ok := <-s.jobs:
-			if !ok {
-				log.Printf("Worker %d: canal jobs cerrado", id)
-				return
-			}
-			log.Printf("Worker %d procesando mensaje %+v", id, msg)
-			s.generateAndSendResponse(msg)
-		}
+// isAddressed indica si el mensaje menciona al bot o responde a un mensaje suyo
+func (s *AIService) isAddressed(msg websocket.Message) bool {
+	if slices.Contains(msg.Mentions, s.Config.UserID) {
+		return true
+	}
+
+	if msg.AnswerId == "" {
+		return false
 	}
+
+	answerID, err := strconv.ParseUint(msg.AnswerId, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	respondido, err := s.MensajeUseCase.GetById(answerID)
+	if err != nil || respondido == nil {
+		return false
+	}
+
+	return strconv.FormatUint(respondido.UsuarioId, 10) == s.Config.UserID
+}
+
+// Trigger invoca al bot en un grupo sin que haya llegado un mensaje (por ejemplo, por inactividad)
+func (s *AIService) Trigger(groupID string, reason TriggerReason) bool {
+	select {
+	case <-s.quit:
+		return false
+	default:
+	}
+
+	if !s.Hub.CheckUserInGroup(s.Config.UserID, groupID) {
+		return false
+	}
+
+	if !s.allowedInGroup(groupID, reason, nil) {
+		return false
+	}
+
+	s.queue.Push(s.ctx, aiJob{GroupID: groupID, Trigger: reason})
+	return true
 }
 
-func (s *AIService) generateAndSendResponse(incomingMsg websocket.Message) {
+func (s *AIService) generateAndSendResponse(ctx context.Context, job aiJob) {
+	started := time.Now()
+
+	// El bot pudo salir del grupo mientras el trabajo esperaba en la cola
+	if !s.Hub.CheckUserInGroup(s.Config.UserID, job.GroupID) {
+		return
+	}
 
 	aiUserID, err := strconv.ParseUint(s.Config.UserID, 10, 64)
 	if err != nil {
@@ -137,121 +232,455 @@ func (s *AIService) generateAndSendResponse(incomingMsg websocket.Message) {
 	}
 
 	// Obtener ID del Grupo
-	responseGrupo, err := s.GrupoUseCase.GetByClave(incomingMsg.GroupID)
+	responseGrupo, err := s.GrupoUseCase.GetByClave(job.GroupID)
 	if err != nil || responseGrupo == nil {
-		log.Printf("Error al obtener el grupo por clave %s: %v", incomingMsg.GroupID, err)
+		log.Printf("Error al obtener el grupo por clave %s: %v", job.GroupID, err)
 		return
 	}
 	grupoIDUint := responseGrupo.Id
 
-	allGroupMessages, err := s.MensajeUseCase.GetNuevosMensajesParaIA(aiUserID, grupoIDUint)
-	if err != nil {
-		log.Printf("Error al obtener mensajes nuevos del grupo %s: %v", incomingMsg.GroupID, err)
-		return
+	// Configuración del grupo: el modo y los bots habilitados ya se aplicaron al recibir el
+	// disparo; aquí se aplica el límite de respuestas por hora y se obtiene la persona
+	var groupRules domain.GrupoIA
+	if s.GroupAI != nil {
+		groupRules, err = s.GroupAI.Rules(grupoIDUint)
+		if err != nil {
+			log.Printf("AIService: %v", err)
+			return
+		}
+		if ok, reason := s.GroupAI.Allow(groupRules); !ok {
+			log.Printf("AIService: el bot %s no responde en el grupo %s: %s", s.Config.UserID, job.GroupID, reason)
+			return
+		}
 	}
 
-	if len(allGroupMessages) == 0 {
-		log.Println("No hay mensajes nuevos para procesar por la IA.")
+	// Política de turnos: se consulta antes de avanzar el checkpoint para que los mensajes
+	// sigan pendientes cuando el bot vuelva a tener turno.
+	var turnRules domain.PoliticaTurno
+	if s.Turns != nil {
+		turnRules, err = s.Turns.Rules(grupoIDUint)
+		if err != nil {
+			log.Printf("AIService: %v", err)
+			return
+		}
+		if ok, reason := s.Turns.Acquire(job.GroupID, s.Config.UserID, turnRules); !ok {
+			log.Printf("AIService: el bot %s no tiene turno en el grupo %s: %s", s.Config.UserID, job.GroupID, reason)
+			return
+		}
+	}
+
+	// Cuotas: igual que con los turnos, los mensajes quedan pendientes hasta que haya cuota
+	if s.Usage != nil {
+		if ok, reason := s.Usage.Allow(aiUserID, grupoIDUint, turnRules.MaxLlamadasHora, s.Config.MaxTokensPerDay); !ok {
+			log.Printf("AIService: el bot %s no responde en el grupo %s: %s", s.Config.UserID, job.GroupID, reason)
+			s.Usage.Blocked(aiUserID, grupoIDUint)
+			return
+		}
+	}
+
+	// Los disparos por inactividad no dependen de mensajes nuevos, así que no mueven el checkpoint
+	if job.Trigger != TriggerIdle && !s.claimNewMessages(aiUserID, grupoIDUint, job.GroupID) {
 		return
 	}
 
-	lastMessageProcessed := allGroupMessages[len(allGroupMessages)-1].Id
+	aiUserDB, err := s.UsuarioUseCase.GetById(aiUserID)
+	if err != nil || aiUserDB == nil {
+		log.Printf("No se pudo obtener el nombre real de la IA: %v", err)
+	}
+
+	var systemPrompt string
+	if s.Config.IsPromt {
+		systemPrompt, err = s.renderSystemPrompt(responseGrupo, aiUserDB)
+		if err != nil {
+			log.Printf("Error al construir el prompt de sistema: %v", err)
+			return
+		}
+		if groupRules.Persona != "" {
+			systemPrompt += "\n\n" + personaNotice(groupRules.Persona)
+		}
+	}
 
-	// Avanzar el Checkpoint inmediatamente para evitar bloqueos infinitos de parsing
-	err = s.MensajeUseCase.ActualizarPuntoControl(aiUserID, grupoIDUint, lastMessageProcessed)
+	promptCtx, err := s.buildContext(ctx, aiUserID, grupoIDUint, systemPrompt)
 	if err != nil {
-		log.Printf("Error al actualizar punto de control anticipado de IA: %v", err)
+		log.Printf("Error al construir el historial del grupo %s: %v", job.GroupID, err)
+		return
+	}
+	if len(promptCtx.Mensajes) == 0 {
+		log.Println("No hay mensajes para procesar por la IA.")
+		return
 	}
 
 	// Convertir los mensajes a un formato que el LLM entienda
-	llmMessages := s.buildPromptFromHistory(allGroupMessages)
+	llmMessages := s.buildPrompt(systemPrompt, promptCtx)
+	if job.Trigger == TriggerIdle {
+		llmMessages = append(llmMessages, llm.ChatMessage{
+			Role:    "system",
+			Content: idleNotice(s.Config.IdleAfter),
+		})
+	}
+
+	interaccion := &domain.InteraccionIA{
+		UsuarioId: aiUserID,
+		GrupoId:   grupoIDUint,
+		MensajeId: triggerMessageID(job, promptCtx.Mensajes, aiUserID),
+		Disparo:   string(job.Trigger),
+		Proveedor: s.provider.Name(),
+		Modelo:    s.Config.LLMName,
+		Prompt:    promptMessages(llmMessages),
+	}
+	defer func() {
+		interaccion.DuracionMs = time.Since(started).Milliseconds()
+		s.recordInteraction(interaccion)
+	}()
+
+	// Llamar al proveedor LLM del bot con todo el historial. Con herramientas la respuesta no se
+	// transmite en streaming: hasta la última llamada no se sabe si el modelo responde o pide otra.
+	llmStarted := time.Now()
+	request := llm.CompletionRequest{
+		Model:    s.Config.LLMName,
+		Messages: llmMessages,
+		Options:  s.Config.CompletionOptions(),
+	}
+	var completion *llm.CompletionResponse
+	var streamID string
+	var announcements []string
+	if s.toolsEnabled() {
+		scope := toolScope{GrupoID: grupoIDUint, GroupClave: job.GroupID, BotID: aiUserID, Bot: aiUserDB, Announcements: &announcements}
+		completion, llmMessages, err = s.completeWithTools(ctx, scope, request, interaccion)
+	} else {
+		completion, streamID, err = s.complete(ctx, job.GroupID, grupoIDUint, aiUserDB, request)
+	}
+	interaccion.DuracionLlmMs = time.Since(llmStarted).Milliseconds()
+
+	// Si hubo fragmentos enviados y la respuesta no termina persistida, avisar a los clientes
+	finalized := false
+	defer func() {
+		if streamID != "" && !finalized {
+			s.Hub.CancelStream(job.GroupID, streamID)
+		}
+	}()
 
-	// Llamar a la función del cliente LLM con todo el historial
-	aiResponse, err := llm.PostCompletion(llmMessages, s.Config.LLMBaseURL, s.Config.LLMName, s.Config.LLMAPIKey)
 	if err != nil {
-		log.Printf("Error al generar respuesta de IA: %v", err)
+		log.Printf("Error al generar respuesta de IA (%s): %v", s.provider.Name(), err)
+		interaccion.Resultado = domain.ResultadoErrorProveedor
+		interaccion.Error = err.Error()
 		return
 	}
 
+	if completion.Model != "" {
+		interaccion.Modelo = completion.Model
+	}
+	aiResponse := completion.Content
+	interaccion.Respuesta = aiResponse
 	if aiResponse == "" {
 		log.Println("Respuesta de IA vacía, no se envía el mensaje.")
+		interaccion.Resultado = domain.ResultadoErrorParseo
+		interaccion.Error = "respuesta vacía"
 		return
 	}
 
-	aiMsg, err := s.ParseAIResponse(aiResponse, incomingMsg.GroupID, s.Config.UserID)
+	window := promptCtx.Visible()
+	replies, err := s.ParseAIResponse(aiResponse, job.GroupID, s.Config.UserID, grupoIDUint, window)
+	if err != nil && !errors.Is(err, ErrSilent) {
+		replies, interaccion.Reparacion, err = s.repairReply(ctx, llmMessages, aiResponse, err, job.GroupID, grupoIDUint, window)
+	}
+	if errors.Is(err, ErrSilent) {
+		log.Printf("AIService: el bot %s decidió no hablar en el grupo %s (%s).", s.Config.UserID, job.GroupID, job.Trigger)
+		interaccion.Resultado = domain.ResultadoSilencio
+		return
+	}
 	if err != nil {
 		log.Printf("Error al parsear la respuesta de IA: %v", err)
+		interaccion.Resultado = domain.ResultadoErrorParseo
+		interaccion.Error = err.Error()
+		return
+	}
+	interaccion.Mensajes = replySummary(replies)
+	replies = append(s.announcementReplies(job.GroupID, announcements), replies...)
+
+	// La moderación va antes de los límites del grupo para que una respuesta rechazada no gaste
+	// una respuesta por hora ni un turno
+	replies = s.moderateReplies(ctx, replies)
+	if len(replies) == 0 {
+		interaccion.Resultado = domain.ResultadoModerada
+		interaccion.Error = "la moderación rechazó todos los mensajes de la respuesta"
 		return
 	}
 
-	aiUserDB, err := s.UsuarioUseCase.GetById(aiUserID)
-	if err == nil && aiUserDB != nil {
-		aiMsg.SenderName = aiUserDB.Nombre
-		aiMsg.SenderApodo = aiUserDB.Apodo
-	} else {
-		log.Printf("No se pudo obtener el nombre real de la IA: %v", err)
+	// Todos los mensajes de la respuesta cuentan como una sola respuesta del grupo y un solo turno del bot
+	if s.GroupAI != nil {
+		if ok, reason := s.GroupAI.Commit(groupRules); !ok {
+			log.Printf("AIService: se descarta la respuesta del bot %s en el grupo %s: %s", s.Config.UserID, job.GroupID, reason)
+			interaccion.Resultado = domain.ResultadoDescartada
+			interaccion.Error = reason
+			return
+		}
+	}
+	if s.Turns != nil {
+		if ok, reason := s.Turns.Commit(job.GroupID, s.Config.UserID, turnRules); !ok {
+			log.Printf("AIService: se descarta la respuesta del bot %s en el grupo %s: %s", s.Config.UserID, job.GroupID, reason)
+			interaccion.Resultado = domain.ResultadoDescartada
+			interaccion.Error = reason
+			return
+		}
 	}
 
-	gormMsg, err := s.saveAIToDB(aiMsg)
-	if err != nil {
-		log.Printf("Error al guardar el mensaje de IA en la base de datos: %v", err)
+	published := s.deliverReplies(ctx, job.GroupID, replies, aiUserDB, streamID)
+	finalized = published > 0
+	interaccion.Resultado = domain.ResultadoEnviada
+	if published == 0 {
+		interaccion.Resultado = domain.ResultadoDescartada
+		interaccion.Error = "no se pudo publicar la respuesta"
+	}
+}
+
+// complete llama al proveedor del bot. Con streaming habilitado reenvía al grupo el texto
+// del campo content a medida que llega y devuelve el ID del stream ("" si no se envió nada).
+// Con moderación no se transmite: los fragmentos llegarían a los clientes antes de moderar el
+// mensaje, y cancelar el stream después no retira lo que ya se mostró.
+func (s *AIService) complete(ctx context.Context, groupID string, grupoID uint64, aiUser *domain.Usuario, req llm.CompletionRequest) (*llm.CompletionResponse, string, error) {
+	streamer, ok := s.provider.(llm.StreamingProvider)
+	if !s.Config.Stream || !ok || s.Moderacion != nil {
+		completion, err := s.completeAndRecord(ctx, grupoID, req)
+		return completion, "", err
+	}
+
+	base := websocket.Message{
+		StreamId: pkg.GenerateUUID(),
+		SenderID: s.Config.UserID,
+		GroupID:  groupID,
+		Fecha:    time.Now().Format(time.RFC3339),
+	}
+	if aiUser != nil {
+		base.SenderName = aiUser.Nombre
+		base.SenderApodo = aiUser.Apodo
+	}
+
+	extractor := &contentStreamExtractor{}
+	sent := false
+
+	started := time.Now()
+	completion, err := streamer.Stream(ctx, req, func(delta string) {
+		text := extractor.Feed(delta)
+		if text == "" {
+			return
+		}
+		frame := base
+		frame.Content = text
+		s.Hub.BroadcastDelta(frame)
+		sent = true
+	})
+	s.recordUsage(grupoID, started, req, completion, err)
+
+	if !sent {
+		return completion, "", err
+	}
+	return completion, base.StreamId, err
+}
+
+// completeAndRecord llama al proveedor del bot y registra el consumo de la llamada en el grupo
+func (s *AIService) completeAndRecord(ctx context.Context, grupoID uint64, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	started := time.Now()
+	completion, err := s.provider.Complete(ctx, req)
+	s.recordUsage(grupoID, started, req, completion, err)
+	return completion, err
+}
+
+// recordUsage registra una llamada al modelo iniciada en started
+func (s *AIService) recordUsage(grupoID uint64, started time.Time, req llm.CompletionRequest, completion *llm.CompletionResponse, err error) {
+	if s.Usage == nil {
+		return
+	}
+
+	aiUserID, parseErr := strconv.ParseUint(s.Config.UserID, 10, 64)
+	if parseErr != nil {
 		return
 	}
 
-	aiMsg.Id = strconv.FormatUint(gormMsg.Id, 10)
+	s.Usage.Record(aiUserID, grupoID, started, req, completion, err)
+}
+
+// claimNewMessages avanza el checkpoint hasta el último mensaje del grupo con compare-and-swap.
+// Devuelve false si no hay mensajes nuevos o si otra ejecución ya tomó la ventana.
+func (s *AIService) claimNewMessages(aiUserID uint64, grupoID uint64, groupClave string) bool {
+	checkpointID, err := s.MensajeUseCase.GetPuntoControl(aiUserID, grupoID)
+	if err != nil {
+		log.Printf("Error al obtener el punto de control de IA del grupo %s: %v", groupClave, err)
+		return false
+	}
+
+	lastMessageProcessed, err := s.MensajeUseCase.GetUltimoMensajeId(grupoID, aiUserID)
+	if err != nil {
+		log.Printf("Error al obtener el último mensaje del grupo %s: %v", groupClave, err)
+		return false
+	}
+
+	if lastMessageProcessed <= checkpointID {
+		log.Println("No hay mensajes nuevos para procesar por la IA.")
+		return false
+	}
+
+	// Avanzar el Checkpoint inmediatamente (compare-and-swap) para evitar bloqueos infinitos de
+	// parsing; si otro proceso ya lo movió, esta ventana ya fue atendida y no se responde.
+	advanced, err := s.MensajeUseCase.ActualizarPuntoControl(aiUserID, grupoID, checkpointID, lastMessageProcessed)
+	if err != nil {
+		log.Printf("Error al actualizar punto de control anticipado de IA: %v", err)
+		return false
+	}
+	if !advanced {
+		log.Printf("AIService: el punto de control del grupo %s ya avanzó, se omite la ventana.", groupClave)
+		return false
+	}
+
+	return true
+}
+
+// renderSystemPrompt renderiza la plantilla del bot con los datos actuales del grupo
+func (s *AIService) renderSystemPrompt(grupo *domain.Grupo, aiUser *domain.Usuario) (string, error) {
+	miembros, err := s.UsuarioUseCase.GetAllByGrupoId(grupo.Id)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener los integrantes del grupo: %w", err)
+	}
 
-	// Enviar el mensaje de la IA a través del Hub
-	s.Hub.Broadcast(*aiMsg)
+	return RenderPrompt(s.promptTmpl, NewPromptData(grupo, miembros, aiUser, time.Now()))
 }
 
-func (s *AIService) buildPromptFromHistory(mensajes []domain.Mensaje) []llm.ChatMessage {
+func (s *AIService) buildPrompt(systemPrompt string, pc promptContext) []llm.ChatMessage {
 	var chatMessages []llm.ChatMessage
-	aiUserIDUint, _ := strconv.ParseUint(s.Config.UserID, 10, 64)
 	if s.Config.IsPromt {
 		chatMessages = append(chatMessages, llm.ChatMessage{
 			Role:    "system",
-			Content: s.getSystemPrompt()})
-		aiUserIDUint, _ = strconv.ParseUint(s.Config.UserID, 10, 64)
+			Content: systemPrompt})
+	}
+
+	if pc.Resumen != "" {
+		chatMessages = append(chatMessages, llm.ChatMessage{
+			Role:    "system",
+			Content: "RESUMEN DE LA CONVERSACIÓN ANTERIOR (mensajes que ya no aparecen en el historial):\n" + pc.Resumen,
+		})
 	}
-	for _, msg := range mensajes {
-		role := "user"
-		if msg.UsuarioId == aiUserIDUint && s.Config.IsPromt {
-			role = "assistant"
+
+	if len(pc.Recuerdos) > 0 {
+		var recuerdos strings.Builder
+		recuerdos.WriteString("MENSAJES ANTERIORES RELEVANTES (recuperados del historial del grupo por su relación con la conversación actual; puedes usar su id como answer_id):")
+		for _, msg := range pc.Recuerdos {
+			recuerdos.WriteString("\n")
+			recuerdos.WriteString(formatHistoryMessage(msg, s.Config.HistoryFormat))
+		}
+		chatMessages = append(chatMessages, llm.ChatMessage{
+			Role:    "system",
+			Content: recuerdos.String(),
+		})
+	}
+
+	if len(pc.Citados) > 0 {
+		var citados strings.Builder
+		citados.WriteString("MENSAJES CITADOS (fuera del historial reciente; puedes usar su id como answer_id):")
+		for _, msg := range pc.Citados {
+			citados.WriteString("\n")
+			citados.WriteString(formatHistoryMessage(msg, s.Config.HistoryFormat))
 		}
+		chatMessages = append(chatMessages, llm.ChatMessage{
+			Role:    "system",
+			Content: citados.String(),
+		})
+	}
 
+	for _, msg := range pc.Mensajes {
+		role, content := s.historyTurn(msg)
 		chatMessages = append(chatMessages, llm.ChatMessage{
 			Role:    role,
-			Content: msg.Contenido,
+			Content: content,
 		})
 	}
 	return chatMessages
 }
 
-func (s *AIService) ParseAIResponse(aiResponseJSON string, groupID string, senderID string) (*websocket.Message, error) {
-	// Limpiar formateo Markdown de bloques de código en caso de que la IA responda "```json ... ```"
-	aiResponseJSON = strings.TrimSpace(aiResponseJSON)
-	if strings.HasPrefix(aiResponseJSON, "```") {
-		aiResponseJSON = strings.TrimPrefix(aiResponseJSON, "```json")
-		aiResponseJSON = strings.TrimPrefix(aiResponseJSON, "```")
-		aiResponseJSON = strings.TrimSuffix(aiResponseJSON, "```")
-		aiResponseJSON = strings.TrimSpace(aiResponseJSON)
+// historyTurn devuelve el rol y el contenido con los que se envía un mensaje del historial
+func (s *AIService) historyTurn(msg domain.Mensaje) (string, string) {
+	aiUserIDUint, _ := strconv.ParseUint(s.Config.UserID, 10, 64)
+	if msg.UsuarioId == aiUserIDUint && s.Config.IsPromt {
+		return "assistant", msg.Contenido
 	}
+	return "user", formatHistoryMessage(msg, s.Config.HistoryFormat)
+}
 
-	// Usar interface{} para manejar tanto números como strings
-	var parsedResponse struct {
-		AnswerID any    `json:"answer_id"`
-		Content  string `json:"content"`
+// ParseAIResponse interpreta la salida del modelo: toma el primer JSON que cumpla el schema de
+// respuesta y devuelve los mensajes a publicar, en orden y con su pausa. Los mensajes con content
+// null o vacío se omiten y, si no queda ninguno, devuelve ErrSilent. Cada answer_id solo se acepta
+// si corresponde a un mensaje de la ventana enviada y pertenece al grupo grupoID.
+func (s *AIService) ParseAIResponse(aiResponseJSON string, groupID string, senderID string, grupoID uint64, window []domain.Mensaje) ([]ReplyMessage, error) {
+	respuestas, err := interpretReply(aiResponseJSON)
+	if err != nil {
+		return nil, err
 	}
 
-	err := json.Unmarshal([]byte(aiResponseJSON), &parsedResponse)
+	replies := make([]ReplyMessage, 0, len(respuestas))
+	for _, respuesta := range respuestas {
+		if respuesta.AnswerId != "" {
+			if err := validateAnswerID(respuesta.AnswerId, grupoID, window); err != nil {
+				return nil, err
+			}
+		}
+
+		replies = append(replies, ReplyMessage{
+			Message: websocket.Message{
+				SenderID: senderID,
+				GroupID:  groupID,
+				Content:  respuesta.Content,
+				Fecha:    time.Now().Format(time.RFC3339),
+				AnswerId: respuesta.AnswerId,
+			},
+			Delay: time.Duration(respuesta.DelayMs) * time.Millisecond,
+		})
+	}
+
+	return replies, nil
+}
+
+// interpretReply decodifica la salida del modelo en los mensajes a publicar sin validar los
+// answer_id contra una ventana de mensajes; devuelve ErrSilent si no queda ninguno con contenido.
+func interpretReply(text string) ([]domain.RespuestaIA, error) {
+	parsedResponse, err := decodeReply(text)
 	if err != nil {
 		return nil, err
 	}
 
+	var respuestas []domain.RespuestaIA
+	for _, part := range parsedResponse.Parts {
+		if part.Content == nil || strings.TrimSpace(*part.Content) == "" {
+			continue
+		}
+		if len(respuestas) == maxReplyMessages {
+			log.Printf("ADVERTENCIA: la respuesta trae más de %d mensajes, se omiten los restantes", maxReplyMessages)
+			break
+		}
+
+		answerID, err := parseAnswerID(part.AnswerID)
+		if err != nil {
+			return nil, err
+		}
+
+		respuestas = append(respuestas, domain.RespuestaIA{
+			AnswerId: answerID,
+			Content:  *part.Content,
+			DelayMs:  replyDelay(part, len(respuestas) == 0).Milliseconds(),
+		})
+	}
+
+	if len(respuestas) == 0 {
+		return nil, ErrSilent
+	}
+
+	return respuestas, nil
+}
+
+// parseAnswerID normaliza el answer_id del modelo a texto ("" si no responde a ningún mensaje)
+func parseAnswerID(raw any) (string, error) {
 	var answerID string
 
 	// Manejar diferentes tipos de answer_id
-	switch v := parsedResponse.AnswerID.(type) {
+	switch v := raw.(type) {
 	case nil:
 		// null -> cadena vacía
 		answerID = ""
@@ -275,18 +704,86 @@ func (s *AIService) ParseAIResponse(aiResponseJSON string, groupID string, sende
 		answerID = ""
 	}
 
-	aiMsg := &websocket.Message{
-		SenderID: senderID,
-		GroupID:  groupID,
-		Content:  parsedResponse.Content,
-		Fecha:    time.Now().Format(time.RFC3339),
-		AnswerId: answerID,
+	return answerID, nil
+}
+
+// repairReply pide una sola vez al modelo que corrija una respuesta que no cumple el schema y
+// devuelve también el texto de la corrección. Si la corrección también falla, ambas salidas se
+// registran como salida fallida.
+func (s *AIService) repairReply(ctx context.Context, llmMessages []llm.ChatMessage, salida string, parseErr error, groupID string, grupoID uint64, window []domain.Mensaje) ([]ReplyMessage, string, error) {
+	log.Printf("AIService: respuesta inválida del bot %s (%v); se solicita una corrección.", s.Config.UserID, parseErr)
+
+	messages := append(slices.Clone(llmMessages),
+		llm.ChatMessage{Role: "assistant", Content: salida},
+		llm.ChatMessage{Role: "user", Content: repairInstruction(parseErr)},
+	)
+
+	var reparacion string
+	completion, err := s.completeAndRecord(ctx, grupoID, llm.CompletionRequest{
+		Model:    s.Config.LLMName,
+		Messages: messages,
+		Options:  s.Config.CompletionOptions(),
+	})
+	if err == nil {
+		reparacion = completion.Content
+		replies, err := s.ParseAIResponse(reparacion, groupID, s.Config.UserID, grupoID, window)
+		if err == nil || errors.Is(err, ErrSilent) {
+			return replies, reparacion, err
+		}
+		parseErr = err
+	} else {
+		parseErr = fmt.Errorf("error al solicitar la corrección: %w", err)
+	}
+
+	s.recordFailure(grupoID, salida, reparacion, parseErr)
+	return nil, reparacion, parseErr
+}
+
+// recordFailure guarda una salida que no se pudo interpretar para revisarla después
+func (s *AIService) recordFailure(grupoID uint64, salida string, reparacion string, cause error) {
+	if s.SalidasFallidas == nil {
+		return
+	}
+
+	aiUserID, err := strconv.ParseUint(s.Config.UserID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	err = s.SalidasFallidas.Registrar(&domain.SalidaFallida{
+		UsuarioId:  aiUserID,
+		GrupoId:    grupoID,
+		Salida:     salida,
+		Reparacion: reparacion,
+		Error:      cause.Error(),
+	})
+	if err != nil {
+		log.Printf("AIService: error al registrar la salida fallida: %v", err)
+	}
+}
+
+// validateAnswerID comprueba que el answer_id apunte a un mensaje real de la ventana y del grupo
+func validateAnswerID(answerID string, grupoID uint64, window []domain.Mensaje) error {
+	id, err := strconv.ParseUint(answerID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("answer_id inválido %q: %w", answerID, err)
+	}
+
+	for _, msg := range window {
+		if msg.Id != id {
+			continue
+		}
+		if msg.GrupoId != grupoID {
+			return fmt.Errorf("answer_id %d no pertenece al grupo %d", id, grupoID)
+		}
+		return nil
 	}
 
-	return aiMsg, nil
+	return fmt.Errorf("answer_id %d no está en la ventana de mensajes enviada al modelo", id)
 }
 
-func (s *AIService) saveAIToDB(aiMsgHub *websocket.Message) (*domain.Mensaje, error) {
+// aiMessage convierte el mensaje del Hub en el mensaje de dominio que se modera y se guarda
+func (s *AIService) aiMessage(aiMsgHub *websocket.Message) (*domain.Mensaje, error) {
 	responseGrupo, err := s.GrupoUseCase.GetByClave(aiMsgHub.GroupID)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener el grupo por clave: %w", err)
@@ -312,14 +809,35 @@ func (s *AIService) saveAIToDB(aiMsgHub *websocket.Message) (*domain.Mensaje, er
 		responseID = &parsedResponseID
 	}
 
-	gormMsg := &domain.Mensaje{
+	return &domain.Mensaje{
 		Contenido:  aiMsgHub.Content,
 		Fecha:      time.Now(),
 		GrupoId:    groupID,
 		UsuarioId:  senderID,
 		ResponseId: responseID,
+	}, nil
+}
+
+// moderate pasa el mensaje del bot por la cadena de moderación. Devuelve false si se bloqueó o
+// quedó retenido para revisión; si la moderación falla, el mensaje se publica igual.
+func (s *AIService) moderate(ctx context.Context, mensaje *domain.Mensaje) bool {
+	if s.Moderacion == nil {
+		return true
+	}
+
+	resultado, err := s.Moderacion.Moderar(ctx, mensaje)
+	if err != nil {
+		log.Printf("AIService: error al moderar el mensaje del bot %s: %v", s.Config.UserID, err)
+		return true
 	}
+	if resultado.Decision != domain.ModeracionPermitir {
+		log.Printf("AIService: mensaje del bot %s no publicado por moderación (%s, %s): %s", s.Config.UserID, resultado.Decision, resultado.Verificador, resultado.Motivo)
+		return false
+	}
+	return true
+}
 
+func (s *AIService) saveAIToDB(gormMsg *domain.Mensaje) (*domain.Mensaje, error) {
 	response, err := s.MensajeUseCase.Create(gormMsg)
 	if err != nil {
 		return nil, fmt.Errorf("error al guardar el mensaje de la IA: %w", err)
@@ -329,55 +847,15 @@ func (s *AIService) saveAIToDB(aiMsgHub *websocket.Message) (*domain.Mensaje, er
 	return gormMsg, nil
 }
 
-func (s *AIService) getSystemPrompt() string {
-	return `
-	Actúa bajo las siguientes reglas estrictas de rol y formato. No puedes salirte de ellas bajo ninguna circunstancia.
-
-1. PERFIL: Estudiante de Ingeniería en Sistemas/Computación en México.
-
-Lenguaje: Informal, "chilango" o norteño ligero (wey, neta, jalo, simón).
-
-Escritura: Máximo 5 palabras por mensaje. Si es mucho texto, divídelo en varios mensajes cortos.
-
-Ortografía: No uses ¿, solo ? al final. Ignora tildes o mayúsculas a veces. Comete errores de dedo ocasionales.
-
-Identificador: Cada mensaje dentro del campo \"content\" DEBE empezar con tu color asignado (ej: "Azul: qué onda wey").
-
-2. REGLA DE ORO (SILENCIO):
-
-Solo respondes si: te preguntan directo, hay una tarea libre, hay un error en el código del grupo o el chat murió por 1 minuto.
-
-Si no hay razón para hablar, tu respuesta debe ser un JSON con content: null.
-
-3. RESTRICCIÓN DE FORMATO (CRÍTICO):
-
-TU SALIDA DEBE SER EXCLUSIVAMENTE UN OBJETO JSON RAW.
-
-PROHIBIDO usar bloques de código de Markdown (nada json).
-
-PROHIBIDO incluir la palabra "json" fuera del objeto.
-
-PROHIBIDO agregar introducciones como "Aquí tienes la respuesta" o despedidas.
-
-Si la respuesta es directa a alguien:
-{"answer_id": "ID_DEL_MSG", "content": "Color: Tu respuesta"}
-
-Si es comentario general:
-{"answer_id": null, "content": "Color: Tu respuesta"}
-
-4. SIMULACIÓN DE TIEMPO: Espera 15 segundos mentalmente. (No lo menciones, solo actúa).
-
-EJEMPLO DE SALIDA ESPERADA (Y ÚNICA FORMA ACEPTADA):
-{"answer_id": "123", "content": "Rojo: yo jalo con eso"}
-
-	`
-}
-
-// Stop detiene la gorutina que escucha los mensajes.
+// Stop detiene las gorutinas de escucha y los workers del servicio.
+// Los canales no se cierran para que el enrutador nunca escriba en un canal cerrado.
 func (s *AIService) Stop() {
 	s.stopOnce.Do(func() {
 		close(s.quit)
-		close(s.inputChannel)
-		close(s.jobs)
+		if s.cancel != nil {
+			s.cancel()
+		}
+		s.queue.Stop()
+		s.Hub.UnsubscribeUser(s.Config.UserID)
 	})
 }
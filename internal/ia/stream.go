@@ -0,0 +1,118 @@
+package ia
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var contentKeyPattern = regexp.MustCompile(`"content"\s*:\s*("|null)`)
+
+const (
+	extractorSearching = iota
+	extractorInside
+	extractorDone
+)
+
+// contentStreamExtractor extrae de forma incremental el valor del campo "content"
+// de una respuesta JSON que llega en fragmentos, para reenviar solo el texto visible.
+type contentStreamExtractor struct {
+	buf   []byte
+	pos   int
+	state int
+}
+
+// Feed agrega un fragmento y devuelve el texto nuevo del campo "content" ya decodificado
+func (e *contentStreamExtractor) Feed(chunk string) string {
+	e.buf = append(e.buf, chunk...)
+
+	if e.state == extractorSearching {
+		loc := contentKeyPattern.FindSubmatchIndex(e.buf[e.pos:])
+		if loc == nil {
+			return ""
+		}
+		if string(e.buf[e.pos+loc[2]:e.pos+loc[3]]) == "null" {
+			e.state = extractorDone
+			return ""
+		}
+		e.pos += loc[1]
+		e.state = extractorInside
+	}
+
+	if e.state != extractorInside {
+		return ""
+	}
+
+	var out strings.Builder
+	for e.pos < len(e.buf) {
+		c := e.buf[e.pos]
+
+		switch {
+		case c == '"':
+			e.state = extractorDone
+			return out.String()
+
+		case c == '\\':
+			consumed, text, ok := decodeEscape(e.buf[e.pos:])
+			if !ok {
+				return out.String() // secuencia incompleta, esperar el siguiente fragmento
+			}
+			out.WriteString(text)
+			e.pos += consumed
+
+		default:
+			if !utf8.FullRune(e.buf[e.pos:]) {
+				return out.String()
+			}
+			_, size := utf8.DecodeRune(e.buf[e.pos:])
+			out.Write(e.buf[e.pos : e.pos+size])
+			e.pos += size
+		}
+	}
+
+	return out.String()
+}
+
+// decodeEscape decodifica una secuencia de escape JSON al inicio de b.
+// Devuelve ok=false si la secuencia todavía está incompleta.
+func decodeEscape(b []byte) (int, string, bool) {
+	if len(b) < 2 {
+		return 0, "", false
+	}
+
+	switch b[1] {
+	case 'n':
+		return 2, "\n", true
+	case 't':
+		return 2, "\t", true
+	case 'r':
+		return 2, "\r", true
+	case 'b':
+		return 2, "\b", true
+	case 'f':
+		return 2, "\f", true
+	case 'u':
+		if len(b) < 6 {
+			return 0, "", false
+		}
+		r1, err := strconv.ParseUint(string(b[2:6]), 16, 32)
+		if err != nil {
+			return 6, "", true
+		}
+		if utf16.IsSurrogate(rune(r1)) {
+			if len(b) < 12 {
+				return 0, "", false
+			}
+			r2, err := strconv.ParseUint(string(b[8:12]), 16, 32)
+			if err != nil || b[6] != '\\' || b[7] != 'u' {
+				return 6, string(utf8.RuneError), true
+			}
+			return 12, string(utf16.DecodeRune(rune(r1), rune(r2))), true
+		}
+		return 6, string(rune(r1)), true
+	default:
+		return 2, string(b[1]), true
+	}
+}
@@ -0,0 +1,440 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMaxToolSteps son las rondas de llamadas a herramientas cuando el bot no indica otra cantidad
+	defaultMaxToolSteps = 3
+	// maxToolCallsPerStep limita las llamadas que se ejecutan de una misma respuesta del modelo
+	maxToolCallsPerStep = 5
+	// maxToolResultRunes recorta el resultado que se devuelve al modelo
+	maxToolResultRunes = 4000
+	// toolTimeout acota la ejecución de cada herramienta
+	toolTimeout = 10 * time.Second
+)
+
+// toolLimitNotice se agrega cuando el bot agotó sus rondas de herramientas
+const toolLimitNotice = "Ya no puedes usar más herramientas en esta respuesta. Responde con la información que ya tienes, con el formato JSON indicado."
+
+// errToolUnavailable indica que la herramienta existe pero el servidor no tiene lo que necesita para ejecutarla
+var errToolUnavailable = errors.New("la herramienta no está disponible en este servidor")
+
+// toolScope es el contexto en que corre una herramienta. Las herramientas nunca reciben el grupo
+// como argumento: siempre actúan sobre el grupo de la ejecución en curso y con el usuario del bot,
+// así que no pueden ver ni modificar nada a lo que el bot no tenga acceso.
+type toolScope struct {
+	GrupoID    uint64
+	GroupClave string
+	BotID      uint64
+	Bot        *domain.Usuario
+	// Announcements junta los mensajes que las herramientas publican en el grupo. Se envían antes
+	// de la respuesta y solo si esta pasa la moderación, los límites del grupo y los turnos.
+	Announcements *[]string
+}
+
+// toolHandler ejecuta una herramienta y devuelve el resultado que se serializa para el modelo
+type toolHandler func(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error)
+
+type builtinTool struct {
+	definition llm.Tool
+	run        toolHandler
+}
+
+// builtinTools son las herramientas que se pueden habilitar en un bot, por nombre
+var builtinTools = map[string]builtinTool{}
+
+func registerTool(name string, description string, parameters string, run toolHandler) {
+	builtinTools[name] = builtinTool{
+		definition: llm.Tool{Name: name, Description: description, Parameters: json.RawMessage(parameters)},
+		run:        run,
+	}
+}
+
+func init() {
+	registerTool("buscar_historial",
+		"Busca mensajes anteriores del grupo relacionados con una consulta. Devuelve los más relevantes con su ID, autor, fecha y contenido.",
+		`{"type":"object","properties":{"consulta":{"type":"string","description":"Qué buscar"},"limite":{"type":"integer","minimum":1,"maximum":10}},"required":["consulta"]}`,
+		toolBuscarHistorial)
+	registerTool("listar_integrantes",
+		"Lista los integrantes del grupo con su nombre y apodo (para mencionarlos con @apodo) e indica cuáles son bots.",
+		`{"type":"object","properties":{}}`,
+		toolListarIntegrantes)
+	registerTool("obtener_mensaje",
+		"Obtiene un mensaje del grupo por su ID, por ejemplo el que otro mensaje responde.",
+		`{"type":"object","properties":{"id":{"type":"integer","description":"ID del mensaje"}},"required":["id"]}`,
+		toolObtenerMensaje)
+	registerTool("crear_encuesta",
+		"Crea una encuesta de opción única en el grupo y la anuncia. Úsala solo si alguien pide votar algo.",
+		`{"type":"object","properties":{"pregunta":{"type":"string"},"opciones":{"type":"array","items":{"type":"string"},"minItems":2,"maxItems":10},"duracion_minutos":{"type":"integer","minimum":1,"description":"Minutos hasta el cierre; omitir para no cerrarla"}},"required":["pregunta","opciones"]}`,
+		toolCrearEncuesta)
+	registerTool("programar_recordatorio",
+		"Programa un recordatorio que el bot publicará en el grupo. Indica en_minutos o fecha (RFC 3339), no ambos.",
+		`{"type":"object","properties":{"texto":{"type":"string"},"en_minutos":{"type":"integer","minimum":1},"fecha":{"type":"string","description":"Fecha y hora, por ejemplo 2025-06-01T18:00:00-03:00"}},"required":["texto"]}`,
+		toolProgramarRecordatorio)
+}
+
+// IsKnownTool indica si name es una herramienta integrada que se puede habilitar en un bot
+func IsKnownTool(name string) bool {
+	_, ok := builtinTools[name]
+	return ok
+}
+
+// ToolNames devuelve, ordenados, los nombres de las herramientas integradas
+func ToolNames() []string {
+	names := make([]string, 0, len(builtinTools))
+	for name := range builtinTools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// toolsEnabled indica si el bot tiene herramientas y su proveedor principal puede usarlas
+func (s *AIService) toolsEnabled() bool {
+	return len(s.Config.Tools) > 0 && llm.SupportsTools(s.Config.Provider)
+}
+
+// toolDefinitions devuelve las definiciones de las herramientas habilitadas en el bot
+func (s *AIService) toolDefinitions() []llm.Tool {
+	var tools []llm.Tool
+	for _, name := range s.Config.Tools {
+		if tool, ok := builtinTools[name]; ok {
+			tools = append(tools, tool.definition)
+		}
+	}
+	return tools
+}
+
+// completeWithTools llama al modelo ofreciéndole las herramientas del bot y ejecuta las que pida,
+// hasta que responda sin pedir ninguna o se agoten las rondas (MaxToolSteps). Al agotarse se hace
+// una última llamada sin herramientas. Cada invocación se registra en la interacción. Devuelve la
+// respuesta final y la conversación completa, que incluye las llamadas y sus resultados.
+func (s *AIService) completeWithTools(ctx context.Context, scope toolScope, req llm.CompletionRequest, interaccion *domain.InteraccionIA) (*llm.CompletionResponse, []llm.ChatMessage, error) {
+	steps := s.Config.MaxToolSteps
+	if steps <= 0 {
+		steps = defaultMaxToolSteps
+	}
+	tools := s.toolDefinitions()
+	req.Messages = slices.Clone(req.Messages)
+
+	for step := 0; ; step++ {
+		req.Tools = tools
+		if step == steps {
+			req.Tools = nil
+			req.Messages = append(req.Messages, llm.ChatMessage{Role: "system", Content: toolLimitNotice})
+		}
+
+		completion, err := s.completeAndRecord(ctx, scope.GrupoID, req)
+		if err != nil || len(completion.ToolCalls) == 0 || req.Tools == nil {
+			return completion, req.Messages, err
+		}
+
+		req.Messages = append(req.Messages, llm.ChatMessage{Role: "assistant", Content: completion.Content, ToolCalls: completion.ToolCalls})
+		for i, call := range completion.ToolCalls {
+			var llamada domain.LlamadaHerramienta
+			if i < maxToolCallsPerStep {
+				llamada = s.runTool(ctx, scope, call)
+			} else {
+				llamada = domain.LlamadaHerramienta{
+					Nombre:     call.Name,
+					Argumentos: string(call.Arguments),
+					Error:      fmt.Sprintf("se superó el máximo de %d llamadas por paso", maxToolCallsPerStep),
+				}
+			}
+			interaccion.Herramientas = append(interaccion.Herramientas, llamada)
+			req.Messages = append(req.Messages, llm.ChatMessage{Role: "tool", ToolCallID: call.ID, Content: toolMessage(llamada)})
+		}
+	}
+}
+
+// runTool ejecuta una llamada del modelo dentro del grupo de scope y la registra en el log
+func (s *AIService) runTool(ctx context.Context, scope toolScope, call llm.ToolCall) domain.LlamadaHerramienta {
+	started := time.Now()
+	llamada := domain.LlamadaHerramienta{Nombre: call.Name, Argumentos: string(call.Arguments)}
+
+	tool, ok := builtinTools[call.Name]
+	if !ok || !slices.Contains(s.Config.Tools, call.Name) {
+		llamada.Error = fmt.Sprintf("herramienta no habilitada: %q", call.Name)
+	} else {
+		toolCtx, cancel := context.WithTimeout(ctx, toolTimeout)
+		result, err := tool.run(toolCtx, s, scope, call.Arguments)
+		cancel()
+
+		if err == nil {
+			var data []byte
+			if data, err = json.Marshal(result); err == nil {
+				llamada.Resultado = truncateRunes(string(data), maxToolResultRunes)
+			}
+		}
+		if err != nil {
+			llamada.Error = err.Error()
+		}
+	}
+	llamada.DuracionMs = time.Since(started).Milliseconds()
+
+	if llamada.Error != "" {
+		log.Printf("AIService: el bot %s usó la herramienta %s en el grupo %s con %s: error: %s (%d ms)", s.Config.UserID, call.Name, scope.GroupClave, llamada.Argumentos, llamada.Error, llamada.DuracionMs)
+	} else {
+		log.Printf("AIService: el bot %s usó la herramienta %s en el grupo %s con %s (%d ms)", s.Config.UserID, call.Name, scope.GroupClave, llamada.Argumentos, llamada.DuracionMs)
+	}
+	return llamada
+}
+
+// toolMessage es el contenido del mensaje "tool" que recibe el modelo
+func toolMessage(llamada domain.LlamadaHerramienta) string {
+	if llamada.Error != "" {
+		data, _ := json.Marshal(map[string]string{"error": llamada.Error})
+		return string(data)
+	}
+	return llamada.Resultado
+}
+
+// announcementReplies convierte los anuncios de las herramientas en mensajes de la respuesta
+func (s *AIService) announcementReplies(groupID string, announcements []string) []ReplyMessage {
+	replies := make([]ReplyMessage, 0, len(announcements))
+	for _, content := range announcements {
+		replies = append(replies, ReplyMessage{Message: websocket.Message{
+			SenderID: s.Config.UserID,
+			GroupID:  groupID,
+			Content:  content,
+		}})
+	}
+	return replies
+}
+
+// announce publica un mensaje del bot en el grupo de scope, fuera de una respuesta (por ejemplo,
+// un recordatorio vencido). No reemplaza a la respuesta que el bot esté publicando en el grupo.
+// Devuelve false si no se pudo publicar; un anuncio rechazado por la moderación no se reintenta
+// y cuenta como resuelto.
+func (s *AIService) announce(ctx context.Context, scope toolScope, content string) bool {
+	replies := s.moderateReplies(ctx, s.announcementReplies(scope.GroupClave, []string{content}))
+	if len(replies) == 0 {
+		return true
+	}
+	return s.publishReplies(ctx, scope.GroupClave, replies, scope.Bot, "") > 0
+}
+
+// decodeToolArgs decodifica los argumentos de una llamada; sin argumentos se usa un objeto vacío
+func decodeToolArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("argumentos inválidos: %w", err)
+	}
+	return nil
+}
+
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…(recortado)"
+}
+
+// toolMensaje es un mensaje del grupo tal como lo ven las herramientas
+type toolMensaje struct {
+	Id          uint64  `json:"id"`
+	Autor       string  `json:"autor"`
+	Fecha       string  `json:"fecha"`
+	Contenido   string  `json:"contenido"`
+	RespuestaId *uint64 `json:"respuestaId,omitempty"`
+}
+
+func newToolMensaje(msg domain.Mensaje) toolMensaje {
+	result := toolMensaje{
+		Id:          msg.Id,
+		Fecha:       msg.Fecha.Format(time.RFC3339),
+		Contenido:   msg.Contenido,
+		RespuestaId: msg.ResponseId,
+	}
+	if msg.Usuario != nil {
+		result.Autor = msg.Usuario.Apodo
+	}
+	return result
+}
+
+func toolBuscarHistorial(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error) {
+	var a struct {
+		Consulta string `json:"consulta"`
+		Limite   int    `json:"limite"`
+	}
+	if err := decodeToolArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Consulta) == "" {
+		return nil, errors.New("la consulta no puede estar vacía")
+	}
+	if a.Limite <= 0 {
+		a.Limite = 5
+	}
+	a.Limite = min(a.Limite, 10)
+
+	// Con búsqueda semántica se buscan mensajes parecidos; si no, los que contienen el texto
+	if s.Recuerdos != nil {
+		resultados, err := s.Recuerdos.Relevantes(ctx, scope.GrupoID, a.Consulta, a.Limite, 0)
+		if err == nil {
+			mensajes := make([]toolMensaje, 0, len(resultados))
+			for _, r := range resultados {
+				mensajes = append(mensajes, newToolMensaje(r.Mensaje))
+			}
+			return mensajes, nil
+		}
+		log.Printf("AIService: falló la búsqueda semántica de buscar_historial, se busca por texto: %v", err)
+	}
+
+	encontrados, err := s.MensajeUseCase.BuscarTexto(scope.GrupoID, a.Consulta, a.Limite)
+	if err != nil {
+		return nil, err
+	}
+	mensajes := make([]toolMensaje, 0, len(encontrados))
+	for _, m := range encontrados {
+		mensajes = append(mensajes, newToolMensaje(m))
+	}
+	return mensajes, nil
+}
+
+func toolListarIntegrantes(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error) {
+	miembros, err := s.UsuarioUseCase.GetAllByGrupoId(scope.GrupoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los integrantes: %w", err)
+	}
+
+	type integrante struct {
+		Id     uint64 `json:"id"`
+		Nombre string `json:"nombre"`
+		Apodo  string `json:"apodo"`
+		EsBot  bool   `json:"esBot"`
+	}
+	integrantes := make([]integrante, 0, len(miembros))
+	for _, m := range miembros {
+		integrantes = append(integrantes, integrante{Id: m.Id, Nombre: m.Nombre, Apodo: m.Apodo, EsBot: m.IsLlm})
+	}
+	return integrantes, nil
+}
+
+func toolObtenerMensaje(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error) {
+	var a struct {
+		Id uint64 `json:"id"`
+	}
+	if err := decodeToolArgs(args, &a); err != nil {
+		return nil, err
+	}
+
+	// Un mensaje de otro grupo se informa igual que uno inexistente
+	mensajes, err := s.MensajeUseCase.GetByIds([]uint64{a.Id})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el mensaje: %w", err)
+	}
+	if len(mensajes) == 0 || mensajes[0].GrupoId != scope.GrupoID {
+		return nil, fmt.Errorf("no existe el mensaje %d en este grupo", a.Id)
+	}
+
+	return newToolMensaje(mensajes[0]), nil
+}
+
+func toolCrearEncuesta(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error) {
+	if s.Encuestas == nil {
+		return nil, errToolUnavailable
+	}
+
+	var a struct {
+		Pregunta        string   `json:"pregunta"`
+		Opciones        []string `json:"opciones"`
+		DuracionMinutos int      `json:"duracion_minutos"`
+	}
+	if err := decodeToolArgs(args, &a); err != nil {
+		return nil, err
+	}
+
+	encuesta := domain.Encuesta{
+		GrupoId:   scope.GrupoID,
+		UsuarioId: scope.BotID,
+		Pregunta:  a.Pregunta,
+		Opciones:  a.Opciones,
+	}
+	if a.DuracionMinutos > 0 {
+		cierre := time.Now().Add(time.Duration(a.DuracionMinutos) * time.Minute)
+		encuesta.CierraEn = &cierre
+	}
+	if err := s.Encuestas.Create(&encuesta); err != nil {
+		return nil, err
+	}
+
+	var anuncio strings.Builder
+	fmt.Fprintf(&anuncio, "Encuesta #%d: %s", encuesta.Id, encuesta.Pregunta)
+	for i, opcion := range encuesta.Opciones {
+		fmt.Fprintf(&anuncio, "\n%d. %s", i+1, opcion)
+	}
+	if encuesta.CierraEn != nil {
+		fmt.Fprintf(&anuncio, "\nCierra: %s", encuesta.CierraEn.Format("02/01 15:04"))
+	}
+
+	result := map[string]any{
+		"id":       encuesta.Id,
+		"pregunta": encuesta.Pregunta,
+		"opciones": encuesta.Opciones,
+		"cierraEn": encuesta.CierraEn,
+	}
+	if scope.Announcements != nil {
+		*scope.Announcements = append(*scope.Announcements, anuncio.String())
+		result["anuncio"] = "se publica en el grupo antes de tu respuesta"
+	}
+	return result, nil
+}
+
+func toolProgramarRecordatorio(ctx context.Context, s *AIService, scope toolScope, args json.RawMessage) (any, error) {
+	if s.Recordatorios == nil {
+		return nil, errToolUnavailable
+	}
+
+	var a struct {
+		Texto     string `json:"texto"`
+		EnMinutos int    `json:"en_minutos"`
+		Fecha     string `json:"fecha"`
+	}
+	if err := decodeToolArgs(args, &a); err != nil {
+		return nil, err
+	}
+
+	var fecha time.Time
+	switch {
+	case a.EnMinutos > 0 && a.Fecha != "":
+		return nil, errors.New("indica en_minutos o fecha, no ambos")
+	case a.EnMinutos > 0:
+		fecha = time.Now().Add(time.Duration(a.EnMinutos) * time.Minute)
+	case a.Fecha != "":
+		parsed, err := time.Parse(time.RFC3339, a.Fecha)
+		if err != nil {
+			return nil, fmt.Errorf("fecha inválida %q, se espera RFC 3339: %w", a.Fecha, err)
+		}
+		fecha = parsed
+	default:
+		return nil, errors.New("falta en_minutos o fecha")
+	}
+
+	recordatorio := domain.Recordatorio{
+		GrupoId:   scope.GrupoID,
+		UsuarioId: scope.BotID,
+		Texto:     a.Texto,
+		Fecha:     fecha,
+	}
+	if err := s.Recordatorios.Programar(&recordatorio); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"id": recordatorio.Id, "fecha": recordatorio.Fecha.Format(time.RFC3339)}, nil
+}
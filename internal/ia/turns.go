@@ -0,0 +1,164 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TurnPolicy reparte los turnos de los bots dentro de cada grupo para evitar que dos bots
+// se respondan entre sí indefinidamente. Los límites de cada grupo se leen de su
+// domain.PoliticaTurno; el estado (mensajes seguidos, último mensaje por bot, turno
+// del round-robin) se mantiene en memoria.
+type TurnPolicy struct {
+	rules   domain.PoliticaTurnoUseCase
+	members func(groupID string) []string
+
+	mu     sync.Mutex
+	groups map[string]*turnState
+}
+
+// turnState es el estado de turnos de un grupo
+type turnState struct {
+	// Mensajes de bot seguidos desde el último mensaje humano
+	consecutiveBot int
+	// Indica si habló un humano después del último mensaje de bot
+	humanSinceBot bool
+	// Hora del último mensaje de cada bot (para el cooldown)
+	lastBotAt map[string]time.Time
+
+	// Cada mensaje observado abre un turno nuevo; owner es el bot al que le toca responderlo
+	turn       uint64
+	lastSender string
+	owner      string
+	ownerTurn  uint64
+}
+
+// NewTurnPolicy crea la política de turnos. members devuelve los bots en ejecución que pertenecen al grupo.
+func NewTurnPolicy(rules domain.PoliticaTurnoUseCase, members func(groupID string) []string) *TurnPolicy {
+	return &TurnPolicy{
+		rules:   rules,
+		members: members,
+		groups:  make(map[string]*turnState),
+	}
+}
+
+// Rules devuelve los límites vigentes del grupo
+func (p *TurnPolicy) Rules(grupoID uint64) (domain.PoliticaTurno, error) {
+	politica, err := p.rules.GetByGrupoId(grupoID)
+	if err != nil {
+		return domain.PoliticaTurno{}, fmt.Errorf("error al obtener la política de turnos del grupo %d: %w", grupoID, err)
+	}
+	return *politica, nil
+}
+
+// Observe registra un mensaje de un humano en el grupo: reinicia los límites de mensajes de bot
+// y abre un turno nuevo. Los mensajes de bot se registran con Commit.
+func (p *TurnPolicy) Observe(groupID string, senderID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.state(groupID)
+	state.consecutiveBot = 0
+	state.humanSinceBot = true
+	state.turn++
+	state.lastSender = senderID
+}
+
+// Acquire indica si el bot puede tomar el turno actual del grupo. Se consulta antes de llamar
+// al LLM; con round-robin solo un bot por turno obtiene permiso.
+func (p *TurnPolicy) Acquire(groupID string, botID string, rules domain.PoliticaTurno) (bool, string) {
+	var members []string
+	if rules.RoundRobin {
+		members = p.members(groupID)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.state(groupID)
+	if ok, reason := state.allow(botID, rules, time.Now()); !ok {
+		return false, reason
+	}
+
+	if rules.RoundRobin {
+		candidates := slices.DeleteFunc(members, func(id string) bool { return id == state.lastSender })
+		if state.ownerTurn != state.turn || !slices.Contains(candidates, state.owner) {
+			state.owner = nextInRotation(candidates, state.owner)
+			state.ownerTurn = state.turn
+		}
+		if state.owner != botID {
+			return false, fmt.Sprintf("turno de round-robin del bot %s", state.owner)
+		}
+	}
+
+	return true, ""
+}
+
+// Commit vuelve a comprobar los límites justo antes de publicar la respuesta del bot y, si
+// se permite, la registra. La comprobación y el registro son atómicos para que dos bots que
+// terminan a la vez no superen el límite.
+func (p *TurnPolicy) Commit(groupID string, botID string, rules domain.PoliticaTurno) (bool, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.state(groupID)
+	now := time.Now()
+	if ok, reason := state.allow(botID, rules, now); !ok {
+		return false, reason
+	}
+
+	state.consecutiveBot++
+	state.humanSinceBot = false
+	state.lastBotAt[botID] = now
+	state.turn++
+	state.lastSender = botID
+	return true, ""
+}
+
+// state devuelve (creándolo si no existe) el estado del grupo; requiere p.mu tomado
+func (p *TurnPolicy) state(groupID string) *turnState {
+	state, ok := p.groups[groupID]
+	if !ok {
+		state = &turnState{
+			humanSinceBot: true,
+			lastBotAt:     make(map[string]time.Time),
+		}
+		p.groups[groupID] = state
+	}
+	return state
+}
+
+// allow aplica los límites de mensajes seguidos, humano obligatorio y cooldown
+func (s *turnState) allow(botID string, rules domain.PoliticaTurno, now time.Time) (bool, string) {
+	if rules.MaxBotConsecutivos > 0 && s.consecutiveBot >= rules.MaxBotConsecutivos {
+		return false, fmt.Sprintf("se alcanzó el máximo de %d mensajes de bot seguidos", rules.MaxBotConsecutivos)
+	}
+
+	if rules.RequiereHumano && !s.humanSinceBot {
+		return false, "debe hablar un humano antes del siguiente turno de bot"
+	}
+
+	cooldown := time.Duration(rules.CooldownSegundos) * time.Second
+	if last, ok := s.lastBotAt[botID]; ok && now.Sub(last) < cooldown {
+		return false, fmt.Sprintf("el bot está en cooldown (%s)", cooldown)
+	}
+
+	return true, ""
+}
+
+// nextInRotation devuelve el bot siguiente a previous en la lista ordenada de candidatos
+func nextInRotation(candidates []string, previous string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	for _, id := range candidates {
+		if id > previous {
+			return id
+		}
+	}
+	return candidates[0]
+}
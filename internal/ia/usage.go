@@ -0,0 +1,131 @@
+package ia
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// UsageTracker registra el consumo de cada llamada al modelo y aplica las cuotas: llamadas por
+// hora en cada grupo (de su domain.PoliticaTurno) y tokens por día de cada bot. Los tokens del
+// día se cargan de la base de datos la primera vez que se consultan; las llamadas de la última
+// hora se cuentan en memoria.
+type UsageTracker struct {
+	uso domain.UsoIAUseCase
+
+	mu         sync.Mutex
+	groupCalls map[uint64][]time.Time
+	botTokens  map[uint64]*dailyTokens
+}
+
+// dailyTokens son los tokens consumidos por un bot en la fecha indicada
+type dailyTokens struct {
+	fecha  string
+	tokens int
+}
+
+// NewUsageTracker crea el registro de uso compartido por todos los bots
+func NewUsageTracker(uso domain.UsoIAUseCase) *UsageTracker {
+	return &UsageTracker{
+		uso:        uso,
+		groupCalls: make(map[uint64][]time.Time),
+		botTokens:  make(map[uint64]*dailyTokens),
+	}
+}
+
+// Allow indica si el bot puede generar una respuesta en el grupo sin superar sus cuotas.
+// Un límite en cero no se aplica.
+func (t *UsageTracker) Allow(botID uint64, grupoID uint64, maxCallsPerHour int, maxTokensPerDay int) (bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if maxCallsPerHour > 0 && len(t.recentCalls(grupoID, now)) >= maxCallsPerHour {
+		return false, fmt.Sprintf("el grupo alcanzó su cuota de %d llamadas por hora", maxCallsPerHour)
+	}
+
+	if maxTokensPerDay > 0 && t.tokensToday(botID, now).tokens >= maxTokensPerDay {
+		return false, fmt.Sprintf("el bot alcanzó su cuota de %d tokens por día", maxTokensPerDay)
+	}
+
+	return true, ""
+}
+
+// Blocked registra una respuesta que no se generó por superar una cuota
+func (t *UsageTracker) Blocked(botID uint64, grupoID uint64) {
+	t.persist(domain.LlamadaIA{UsuarioId: botID, GrupoId: grupoID, Fecha: time.Now(), Bloqueada: true})
+}
+
+// Record registra una llamada al modelo iniciada en started. Si el servidor no informó el
+// consumo de tokens, se estima a partir del prompt y la respuesta.
+func (t *UsageTracker) Record(botID uint64, grupoID uint64, started time.Time, req llm.CompletionRequest, completion *llm.CompletionResponse, err error) {
+	// Las respuestas de la caché no llegan al modelo, así que no cuentan para los límites ni el uso
+	if completion != nil && completion.Cached {
+		return
+	}
+
+	now := time.Now()
+	llamada := domain.LlamadaIA{
+		UsuarioId: botID,
+		GrupoId:   grupoID,
+		Fecha:     now,
+		Estado:    llm.CallStatus(err),
+		Latencia:  now.Sub(started),
+	}
+
+	if completion != nil {
+		llamada.PromptTokens = completion.Usage.PromptTokens
+		llamada.CompletionTokens = completion.Usage.CompletionTokens
+		if completion.Usage.TotalTokens == 0 {
+			llamada.PromptTokens = llm.EstimateMessagesTokens(req.Messages)
+			llamada.CompletionTokens = llm.EstimateTokens(completion.Content)
+		}
+	}
+
+	t.mu.Lock()
+	t.groupCalls[grupoID] = append(t.recentCalls(grupoID, now), now)
+	t.tokensToday(botID, now).tokens += llamada.PromptTokens + llamada.CompletionTokens
+	t.mu.Unlock()
+
+	t.persist(llamada)
+}
+
+func (t *UsageTracker) persist(llamada domain.LlamadaIA) {
+	if err := t.uso.Registrar(llamada); err != nil {
+		log.Printf("UsageTracker: error al registrar el uso del bot %d en el grupo %d: %v", llamada.UsuarioId, llamada.GrupoId, err)
+	}
+}
+
+// recentCalls descarta y devuelve las llamadas del grupo en la última hora; requiere t.mu tomado
+func (t *UsageTracker) recentCalls(grupoID uint64, now time.Time) []time.Time {
+	calls := t.groupCalls[grupoID]
+	start := 0
+	for start < len(calls) && now.Sub(calls[start]) >= time.Hour {
+		start++
+	}
+	calls = calls[start:]
+	t.groupCalls[grupoID] = calls
+	return calls
+}
+
+// tokensToday devuelve el contador del día del bot, cargándolo de la base de datos cuando cambia
+// la fecha; requiere t.mu tomado
+func (t *UsageTracker) tokensToday(botID uint64, now time.Time) *dailyTokens {
+	fecha := now.Format("2006-01-02")
+	daily, ok := t.botTokens[botID]
+	if ok && daily.fecha == fecha {
+		return daily
+	}
+
+	daily = &dailyTokens{fecha: fecha}
+	tokens, err := t.uso.GetTokensDia(botID, now)
+	if err != nil {
+		log.Printf("UsageTracker: error al obtener los tokens del día del bot %d: %v", botID, err)
+	}
+	daily.tokens = tokens
+	t.botTokens[botID] = daily
+	return daily
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InteraccionIAHandler struct {
+	IIUsecase domain.InteraccionIAUseCase
+}
+
+// NewAdminInteraccionIAHandler registra los endpoints para revisar y volver a ejecutar las interacciones de los bots
+func NewAdminInteraccionIAHandler(group fiber.Router, iiu domain.InteraccionIAUseCase) {
+	handler := &InteraccionIAHandler{
+		IIUsecase: iiu,
+	}
+
+	group.Get("/interacciones-ia", handler.GetInteracciones)
+	group.Get("/interacciones-ia/:id", handler.GetInteraccion)
+	group.Post("/interacciones-ia/:id/reejecutar", handler.Reejecutar)
+}
+
+func (h *InteraccionIAHandler) GetInteracciones(c *fiber.Ctx) error {
+	botId := c.QueryInt("botId", 0)
+	grupoId := c.QueryInt("grupoId", 0)
+	if botId < 0 || grupoId < 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener las interacciones", "Error parametro", "botId y grupoId deben ser positivos")
+	}
+
+	interacciones, err := h.IIUsecase.GetAll(domain.FiltroInteraccionIA{
+		BotId:     uint64(botId),
+		GrupoId:   uint64(grupoId),
+		Resultado: c.Query("resultado"),
+		Desde:     c.Query("desde"),
+		Hasta:     c.Query("hasta"),
+		Limite:    c.QueryInt("limit", 0),
+	})
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener las interacciones", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Interacciones obtenidas correctamente", "", interacciones)
+}
+
+func (h *InteraccionIAHandler) GetInteraccion(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener la interacción", "Error parametro", err.Error())
+	}
+
+	interaccion, err := h.IIUsecase.GetById(id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener la interacción", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Interacción obtenida correctamente", "", interaccion)
+}
+
+func (h *InteraccionIAHandler) Reejecutar(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al reejecutar la interacción", "Error parametro", err.Error())
+	}
+
+	var variante domain.VarianteIA
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&variante); err != nil {
+			return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al reejecutar la interacción", "Error de parseo", err.Error())
+		}
+	}
+
+	comparacion, err := h.IIUsecase.Reejecutar(id, variante)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al reejecutar la interacción", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Interacción reejecutada correctamente", "", comparacion)
+}
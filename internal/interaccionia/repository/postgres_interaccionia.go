@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const formatoFecha = "2006-01-02"
+
+type postgresInteraccionIARepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresInteraccionIARepository(db *gorm.DB) domain.InteraccionIARepository {
+	return &postgresInteraccionIARepository{db: db}
+}
+
+func mapGormToDomainInteraccion(gormInteraccion *models.AiInteracciones) *domain.InteraccionIA {
+	if gormInteraccion == nil {
+		return nil
+	}
+
+	prompt := make([]domain.MensajePromptIA, 0, len(gormInteraccion.Prompt))
+	for _, m := range gormInteraccion.Prompt {
+		prompt = append(prompt, domain.MensajePromptIA{Role: m.Role, Content: m.Content})
+	}
+
+	mensajes := make([]domain.RespuestaIA, 0, len(gormInteraccion.Mensajes))
+	for _, m := range gormInteraccion.Mensajes {
+		mensajes = append(mensajes, domain.RespuestaIA{AnswerId: m.AnswerId, Content: m.Content, DelayMs: m.DelayMs})
+	}
+
+	var herramientas []domain.LlamadaHerramienta
+	for _, h := range gormInteraccion.Herramientas {
+		herramientas = append(herramientas, domain.LlamadaHerramienta(h))
+	}
+
+	return &domain.InteraccionIA{
+		Id:            gormInteraccion.Id,
+		UsuarioId:     gormInteraccion.UsuarioId,
+		GrupoId:       gormInteraccion.GrupoId,
+		MensajeId:     gormInteraccion.MensajeId,
+		Disparo:       gormInteraccion.Disparo,
+		Proveedor:     gormInteraccion.Proveedor,
+		Modelo:        gormInteraccion.Modelo,
+		Prompt:        prompt,
+		Respuesta:     gormInteraccion.Respuesta,
+		Reparacion:    gormInteraccion.Reparacion,
+		Mensajes:      mensajes,
+		Herramientas:  herramientas,
+		Resultado:     gormInteraccion.Resultado,
+		Error:         gormInteraccion.Error,
+		DuracionLlmMs: gormInteraccion.DuracionLlmMs,
+		DuracionMs:    gormInteraccion.DuracionMs,
+		CreatedAt:     gormInteraccion.CreatedAt,
+	}
+}
+
+func (r *postgresInteraccionIARepository) Create(interaccion *domain.InteraccionIA) error {
+	prompt := make([]models.MensajePromptIA, 0, len(interaccion.Prompt))
+	for _, m := range interaccion.Prompt {
+		prompt = append(prompt, models.MensajePromptIA{Role: m.Role, Content: m.Content})
+	}
+
+	mensajes := make([]models.RespuestaIA, 0, len(interaccion.Mensajes))
+	for _, m := range interaccion.Mensajes {
+		mensajes = append(mensajes, models.RespuestaIA{AnswerId: m.AnswerId, Content: m.Content, DelayMs: m.DelayMs})
+	}
+
+	var herramientas []models.LlamadaHerramienta
+	for _, h := range interaccion.Herramientas {
+		herramientas = append(herramientas, models.LlamadaHerramienta(h))
+	}
+
+	gormInteraccion := models.AiInteracciones{
+		UsuarioId:     interaccion.UsuarioId,
+		GrupoId:       interaccion.GrupoId,
+		MensajeId:     interaccion.MensajeId,
+		Disparo:       interaccion.Disparo,
+		Proveedor:     interaccion.Proveedor,
+		Modelo:        interaccion.Modelo,
+		Prompt:        prompt,
+		Respuesta:     interaccion.Respuesta,
+		Reparacion:    interaccion.Reparacion,
+		Mensajes:      mensajes,
+		Herramientas:  herramientas,
+		Resultado:     interaccion.Resultado,
+		Error:         interaccion.Error,
+		DuracionLlmMs: interaccion.DuracionLlmMs,
+		DuracionMs:    interaccion.DuracionMs,
+	}
+
+	if err := r.db.Create(&gormInteraccion).Error; err != nil {
+		return err
+	}
+
+	interaccion.Id = gormInteraccion.Id
+	interaccion.CreatedAt = gormInteraccion.CreatedAt
+	return nil
+}
+
+func (r *postgresInteraccionIARepository) GetById(id uint64) (*domain.InteraccionIA, error) {
+	var gormInteraccion models.AiInteracciones
+
+	if err := r.db.First(&gormInteraccion, id).Error; err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainInteraccion(&gormInteraccion), nil
+}
+
+// GetAll lista las interacciones más recientes primero. Las fechas del filtro son inclusivas.
+func (r *postgresInteraccionIARepository) GetAll(usuarioId uint64, filtro domain.FiltroInteraccionIA) ([]domain.InteraccionIA, error) {
+	var gormInteracciones []models.AiInteracciones
+
+	query := r.db.Model(&models.AiInteracciones{})
+	if usuarioId > 0 {
+		query = query.Where("id_usuario = ?", usuarioId)
+	}
+	if filtro.GrupoId > 0 {
+		query = query.Where("id_grupo = ?", filtro.GrupoId)
+	}
+	if filtro.Resultado != "" {
+		query = query.Where("resultado = ?", filtro.Resultado)
+	}
+	if filtro.Desde != "" {
+		query = query.Where("created_at >= ?", filtro.Desde)
+	}
+	if filtro.Hasta != "" {
+		hasta, err := time.Parse(formatoFecha, filtro.Hasta)
+		if err != nil {
+			return nil, fmt.Errorf("fecha hasta inválida %q: %w", filtro.Hasta, err)
+		}
+		query = query.Where("created_at < ?", hasta.AddDate(0, 0, 1))
+	}
+
+	if err := query.Order("id desc").Limit(filtro.Limite).Find(&gormInteracciones).Error; err != nil {
+		return nil, err
+	}
+
+	interacciones := make([]domain.InteraccionIA, 0, len(gormInteracciones))
+	for i := range gormInteracciones {
+		interacciones = append(interacciones, *mapGormToDomainInteraccion(&gormInteracciones[i]))
+	}
+
+	return interacciones, nil
+}
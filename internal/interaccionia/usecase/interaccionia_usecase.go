@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/ia"
+	"chatvis-chat/internal/llm"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	formatoFecha = "2006-01-02"
+
+	defaultLimiteInteracciones = 50
+	maxLimiteInteracciones     = 500
+)
+
+type interaccionIAUseCase struct {
+	repo        domain.InteraccionIARepository
+	repoBot     domain.BotRepository
+	repoGrupo   domain.GrupoRepository
+	repoUsuario domain.UsuarioRepository
+}
+
+func NewInteraccionIAUseCase(repo domain.InteraccionIARepository, repoBot domain.BotRepository, repoGrupo domain.GrupoRepository, repoUsuario domain.UsuarioRepository) domain.InteraccionIAUseCase {
+	return &interaccionIAUseCase{
+		repo:        repo,
+		repoBot:     repoBot,
+		repoGrupo:   repoGrupo,
+		repoUsuario: repoUsuario,
+	}
+}
+
+func (u *interaccionIAUseCase) Registrar(interaccion *domain.InteraccionIA) error {
+	if interaccion == nil {
+		return errors.New("la interacción no puede ser nula")
+	}
+
+	if interaccion.UsuarioId <= 0 || interaccion.GrupoId <= 0 {
+		return errors.New("la interacción debe indicar el usuario IA y el grupo")
+	}
+
+	return u.repo.Create(interaccion)
+}
+
+func (u *interaccionIAUseCase) GetById(id uint64) (*domain.InteraccionIA, error) {
+	if id <= 0 {
+		return nil, errors.New("el ID de la interacción debe ser mayor que cero")
+	}
+
+	return u.repo.GetById(id)
+}
+
+func (u *interaccionIAUseCase) GetAll(filtro domain.FiltroInteraccionIA) ([]domain.InteraccionIA, error) {
+	if filtro.Limite <= 0 {
+		filtro.Limite = defaultLimiteInteracciones
+	}
+	filtro.Limite = min(filtro.Limite, maxLimiteInteracciones)
+
+	switch filtro.Resultado {
+	case "", domain.ResultadoEnviada, domain.ResultadoSilencio, domain.ResultadoErrorParseo,
+		domain.ResultadoErrorProveedor, domain.ResultadoDescartada, domain.ResultadoModerada:
+	default:
+		return nil, fmt.Errorf("resultado no soportado: %q", filtro.Resultado)
+	}
+
+	if filtro.Desde != "" {
+		if _, err := time.Parse(formatoFecha, filtro.Desde); err != nil {
+			return nil, fmt.Errorf("la fecha desde debe tener el formato AAAA-MM-DD: %w", err)
+		}
+	}
+	if filtro.Hasta != "" {
+		if _, err := time.Parse(formatoFecha, filtro.Hasta); err != nil {
+			return nil, fmt.Errorf("la fecha hasta debe tener el formato AAAA-MM-DD: %w", err)
+		}
+	}
+
+	var usuarioId uint64
+	if filtro.BotId > 0 {
+		bot, err := u.repoBot.GetById(filtro.BotId)
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar el bot: %w", err)
+		}
+		usuarioId = bot.UsuarioId
+	}
+
+	interacciones, err := u.repo.GetAll(usuarioId, filtro)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las interacciones: %w", err)
+	}
+
+	return interacciones, nil
+}
+
+// Reejecutar envía el prompt guardado de la interacción al bot que la generó con los cambios de la
+// variante. La variante no usa la cadena de respaldo del bot para que la respuesta sea siempre del
+// modelo indicado.
+func (u *interaccionIAUseCase) Reejecutar(id uint64, variante domain.VarianteIA) (*domain.ComparacionIA, error) {
+	interaccion, err := u.GetById(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar la interacción: %w", err)
+	}
+
+	bot, _, err := u.repoBot.GetByUsuarioId(interaccion.UsuarioId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el bot de la interacción: %w", err)
+	}
+	if bot == nil {
+		return nil, errors.New("el bot de la interacción ya no existe")
+	}
+
+	if variante.ProviderType != "" {
+		if !llm.IsValidProviderType(llm.ProviderType(variante.ProviderType)) {
+			return nil, fmt.Errorf("tipo de proveedor no soportado: %q", variante.ProviderType)
+		}
+		bot.ProviderType = variante.ProviderType
+	}
+	if variante.BaseURL != "" {
+		bot.BaseURL = variante.BaseURL
+	}
+	if variante.ModelName != "" {
+		bot.ModelName = variante.ModelName
+	}
+	if variante.APIKeyRef != "" {
+		bot.APIKeyRef = variante.APIKeyRef
+	}
+	if variante.Temperature != nil {
+		if *variante.Temperature < 0 || *variante.Temperature > 2 {
+			return nil, errors.New("la temperatura debe estar entre 0 y 2")
+		}
+		bot.Temperature = *variante.Temperature
+	}
+	bot.Fallbacks = nil
+
+	systemPrompt, err := u.renderVariantPrompt(bot, interaccion.GrupoId, variante)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado, err := ia.Replay(context.Background(), *bot, interaccion, systemPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el proveedor de la variante: %w", err)
+	}
+
+	return &domain.ComparacionIA{
+		Original: interaccion,
+		Variante: *resultado,
+	}, nil
+}
+
+// renderVariantPrompt renderiza el prompt de sistema de la variante para el grupo de la
+// interacción; devuelve "" si la variante conserva el prompt original
+func (u *interaccionIAUseCase) renderVariantPrompt(bot *domain.Bot, grupoId uint64, variante domain.VarianteIA) (string, error) {
+	text := variante.Prompt
+	if variante.PromptVersion > 0 {
+		prompt, err := u.repoBot.GetPrompt(bot.Id, variante.PromptVersion)
+		if err != nil {
+			return "", fmt.Errorf("error al obtener la versión %d del prompt: %w", variante.PromptVersion, err)
+		}
+		text = prompt.Template
+	}
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := ia.ParsePromptTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener el grupo: %w", err)
+	}
+
+	miembros, err := u.repoUsuario.GetAllByGrupoId(grupoId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener los integrantes del grupo: %w", err)
+	}
+
+	botUser, err := u.repoUsuario.GetById(bot.UsuarioId)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener el usuario del bot: %w", err)
+	}
+
+	return ia.RenderPrompt(tmpl, ia.NewPromptData(grupo, miembros, botUser, time.Now()))
+}
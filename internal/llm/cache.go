@@ -0,0 +1,337 @@
+package llm
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultCacheMaxEntries = 1000
+
+// CacheConfig configura la caché de respuestas que comparten los bots que la habilitan
+type CacheConfig struct {
+	// TTL es cuánto tiempo se reutiliza una respuesta; 0 deshabilita la caché
+	TTL time.Duration
+	// MaxEntries es la cantidad de respuestas en memoria; al superarla se descarta la menos usada
+	MaxEntries int
+}
+
+// Enabled indica si la caché está configurada
+func (c CacheConfig) Enabled() bool {
+	return c.TTL > 0
+}
+
+// CacheConfigFromEnv lee LLM_CACHE_TTL_SECONDS (sin definir o 0 deshabilita la caché) y
+// LLM_CACHE_MAX_ENTRIES (1000 por defecto)
+func CacheConfigFromEnv() CacheConfig {
+	config := CacheConfig{MaxEntries: defaultCacheMaxEntries}
+	if n, err := strconv.Atoi(os.Getenv("LLM_CACHE_TTL_SECONDS")); err == nil && n > 0 {
+		config.TTL = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_CACHE_MAX_ENTRIES")); err == nil && n > 0 {
+		config.MaxEntries = n
+	}
+	return config
+}
+
+// CachedCompletion es una respuesta guardada en la caché
+type CachedCompletion struct {
+	Key       string
+	Provider  string
+	Model     string
+	Content   string
+	Usage     Usage
+	ExpiresAt time.Time
+}
+
+// CacheStore persiste las respuestas para compartirlas entre reinicios e instancias del
+// servidor. Get devuelve nil sin error cuando no hay una respuesta vigente para la clave.
+type CacheStore interface {
+	Get(key string) (*CachedCompletion, error)
+	Save(entry CachedCompletion) error
+}
+
+// CacheStats son las métricas de la caché desde el arranque del servidor
+type CacheStats struct {
+	Enabled    bool
+	Entries    int
+	MaxEntries int
+	TTL        time.Duration
+	Persistent bool
+	// MemoryHits y StoreHits son los aciertos en memoria y en la tabla persistente
+	MemoryHits int64
+	StoreHits  int64
+	Misses     int64
+	Evictions  int64
+	Models     []CacheModelStats
+}
+
+// HitRate es la proporción de solicitudes respondidas desde la caché
+func (s CacheStats) HitRate() float64 {
+	hits := s.MemoryHits + s.StoreHits
+	if hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+s.Misses)
+}
+
+// CacheModelStats son los aciertos y fallos de un proveedor y modelo
+type CacheModelStats struct {
+	Provider string
+	Model    string
+	Hits     int64
+	Misses   int64
+}
+
+// responseCache es una caché LRU con vencimiento por TTL, opcionalmente respaldada por un CacheStore
+type responseCache struct {
+	config CacheConfig
+	store  CacheStore
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   CacheStats
+	models  map[[2]string]*CacheModelStats
+}
+
+// cache es la caché compartida por todos los proveedores con ProviderConfig.Cache; nil si está deshabilitada
+var cache atomic.Pointer[responseCache]
+
+// ConfigureCache activa la caché de respuestas; store puede ser nil para usar solo memoria.
+// Con una configuración deshabilitada, la caché se desactiva y se descartan las entradas.
+func ConfigureCache(config CacheConfig, store CacheStore) {
+	if !config.Enabled() {
+		cache.Store(nil)
+		return
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultCacheMaxEntries
+	}
+
+	cache.Store(&responseCache{
+		config:  config,
+		store:   store,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		models:  make(map[[2]string]*CacheModelStats),
+	})
+}
+
+// ResponseCacheStats devuelve las métricas de la caché, con los modelos ordenados por aciertos
+func ResponseCacheStats() CacheStats {
+	c := cache.Load()
+	if c == nil {
+		return CacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Enabled = true
+	stats.Entries = c.lru.Len()
+	stats.MaxEntries = c.config.MaxEntries
+	stats.TTL = c.config.TTL
+	stats.Persistent = c.store != nil
+	for _, model := range c.models {
+		stats.Models = append(stats.Models, *model)
+	}
+	slices.SortFunc(stats.Models, func(a, b CacheModelStats) int {
+		if a.Hits != b.Hits {
+			return cmp.Compare(b.Hits, a.Hits)
+		}
+		return strings.Compare(a.Provider+a.Model, b.Provider+b.Model)
+	})
+	return stats
+}
+
+// get busca la respuesta en memoria y, si no está, en el CacheStore
+func (c *responseCache) get(key string, provider string, model string) *CachedCompletion {
+	now := time.Now()
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*CachedCompletion)
+		if now.Before(entry.ExpiresAt) {
+			c.lru.MoveToFront(element)
+			c.stats.MemoryHits++
+			c.model(provider, model).Hits++
+			c.mu.Unlock()
+			return entry
+		}
+		c.lru.Remove(element)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	// La consulta a la tabla se hace sin el lock para no bloquear a los demás bots
+	var stored *CachedCompletion
+	if c.store != nil {
+		entry, err := c.store.Get(key)
+		if err != nil {
+			log.Printf("LLM: error al leer la caché persistente: %v", err)
+		} else if entry != nil && now.Before(entry.ExpiresAt) {
+			stored = entry
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored == nil {
+		c.stats.Misses++
+		c.model(provider, model).Misses++
+		return nil
+	}
+	c.stats.StoreHits++
+	c.model(provider, model).Hits++
+	c.add(stored)
+	return stored
+}
+
+// put guarda la respuesta en memoria y en el CacheStore
+func (c *responseCache) put(entry CachedCompletion) {
+	entry.ExpiresAt = time.Now().Add(c.config.TTL)
+
+	c.mu.Lock()
+	c.add(&entry)
+	c.mu.Unlock()
+
+	if c.store != nil {
+		if err := c.store.Save(entry); err != nil {
+			log.Printf("LLM: error al guardar en la caché persistente: %v", err)
+		}
+	}
+}
+
+// add inserta o reemplaza la entrada y descarta las menos usadas; requiere c.mu tomado
+func (c *responseCache) add(entry *CachedCompletion) {
+	if element, ok := c.entries[entry.Key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.Key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*CachedCompletion).Key)
+		c.stats.Evictions++
+	}
+}
+
+// model devuelve los contadores del proveedor y modelo; requiere c.mu tomado
+func (c *responseCache) model(provider string, model string) *CacheModelStats {
+	id := [2]string{provider, model}
+	stats, ok := c.models[id]
+	if !ok {
+		stats = &CacheModelStats{Provider: provider, Model: model}
+		c.models[id] = stats
+	}
+	return stats
+}
+
+// cacheKeyMessage es un mensaje normalizado para calcular la clave
+type cacheKeyMessage struct {
+	Role       string `json:"r"`
+	Content    string `json:"c"`
+	ToolCallID string `json:"t,omitempty"`
+}
+
+// cacheKey identifica la solicitud por proveedor, modelo, prompt normalizado y parámetros de
+// muestreo. Los espacios repetidos del prompt no cambian la clave.
+func cacheKey(provider string, req CompletionRequest) string {
+	messages := make([]cacheKeyMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		messages = append(messages, cacheKeyMessage{
+			Role:       message.Role,
+			Content:    strings.Join(strings.Fields(message.Content), " "),
+			ToolCallID: message.ToolCallID,
+		})
+	}
+
+	key := struct {
+		Provider    string            `json:"p"`
+		Model       string            `json:"m"`
+		Messages    []cacheKeyMessage `json:"msg"`
+		Temperature float64           `json:"temp"`
+		MaxTokens   int               `json:"max"`
+		Stop        []string          `json:"stop"`
+		Format      *ResponseFormat   `json:"fmt"`
+	}{provider, req.Model, messages, req.Options.Temperature, req.Options.MaxTokens, req.Options.Stop, req.Options.ResponseFormat}
+
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachingProvider responde desde la caché las solicitudes repetidas. Las solicitudes con
+// herramientas no se cachean: su resultado depende del estado del chat.
+type cachingProvider struct {
+	inner StreamingProvider
+	// provider identifica el tipo y endpoint del proveedor principal dentro de la clave
+	provider string
+}
+
+func (p *cachingProvider) Name() string {
+	return p.inner.Name()
+}
+
+func (p *cachingProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	return p.do(req, func(req CompletionRequest) (*CompletionResponse, error) {
+		return p.inner.Complete(ctx, req)
+	}, nil)
+}
+
+// Stream entrega una respuesta cacheada como un único fragmento
+func (p *cachingProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	return p.do(req, func(req CompletionRequest) (*CompletionResponse, error) {
+		return p.inner.Stream(ctx, req, onDelta)
+	}, onDelta)
+}
+
+func (p *cachingProvider) do(req CompletionRequest, call func(req CompletionRequest) (*CompletionResponse, error), onDelta func(delta string)) (*CompletionResponse, error) {
+	c := cache.Load()
+	if c == nil || len(req.Tools) > 0 {
+		return call(req)
+	}
+
+	key := cacheKey(p.provider, req)
+	if entry := c.get(key, p.provider, req.Model); entry != nil {
+		if onDelta != nil {
+			onDelta(entry.Content)
+		}
+		return &CompletionResponse{Content: entry.Content, Model: entry.Model, Usage: entry.Usage, Cached: true}, nil
+	}
+
+	completion, err := call(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Las respuestas de la cadena de respaldo no se guardan, aunque usen el mismo modelo: la clave
+	// es la del proveedor principal
+	if completion.Content != "" && len(completion.ToolCalls) == 0 && completion.Fallback == 0 {
+		c.put(CachedCompletion{
+			Key:      key,
+			Provider: p.provider,
+			Model:    completion.Model,
+			Content:  completion.Content,
+			Usage:    completion.Usage,
+		})
+	}
+	return completion, nil
+}
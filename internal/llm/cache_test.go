@@ -0,0 +1,122 @@
+package llm_test
+
+import (
+	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/llm/llmtest"
+	"context"
+	"testing"
+	"time"
+)
+
+func cacheRequest(content string) llm.CompletionRequest {
+	return llm.CompletionRequest{
+		Model:    "fake-model",
+		Messages: []llm.ChatMessage{{Role: "user", Content: content}},
+		Options:  llm.CompletionOptions{Temperature: 0.3, MaxTokens: 64},
+	}
+}
+
+// fakeCacheStore es una tabla persistente en memoria para la caché de respuestas
+type fakeCacheStore struct {
+	entries map[string]llm.CachedCompletion
+}
+
+func (s *fakeCacheStore) Get(key string) (*llm.CachedCompletion, error) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *fakeCacheStore) Save(entry llm.CachedCompletion) error {
+	s.entries[entry.Key] = entry
+	return nil
+}
+
+func TestResponseCache(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	store := &fakeCacheStore{entries: make(map[string]llm.CachedCompletion)}
+	llm.ConfigureCache(llm.CacheConfig{TTL: time.Minute, MaxEntries: 1}, store)
+	defer llm.ConfigureCache(llm.CacheConfig{}, nil)
+
+	cfg := srv.ProviderConfig(llm.ProviderOpenAI)
+	cfg.Cache = true
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("no se pudo crear el proveedor: %v", err)
+	}
+	streamer := provider.(llm.StreamingProvider)
+
+	srv.Enqueue(llmtest.Reply("primera"), llmtest.Reply("segunda"), llmtest.Reply("tercera"))
+
+	first, err := provider.Complete(context.Background(), cacheRequest("hola"))
+	if err != nil || first.Content != "primera" || first.Cached {
+		t.Fatalf("primera respuesta inesperada: %+v, %v", first, err)
+	}
+
+	// Los espacios extra no cambian la clave; por streaming llega como un único fragmento
+	var deltas []string
+	cached, err := streamer.Stream(context.Background(), cacheRequest("  hola \n"), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil || !cached.Cached || cached.Content != "primera" || len(deltas) != 1 || deltas[0] != "primera" {
+		t.Fatalf("se esperaba la respuesta cacheada: %+v, %v, %v", cached, deltas, err)
+	}
+
+	// Otra temperatura es otra clave, y con MaxEntries 1 desaloja a la primera de la memoria
+	other := cacheRequest("hola")
+	other.Options.Temperature = 0.9
+	second, err := provider.Complete(context.Background(), other)
+	if err != nil || second.Content != "segunda" || second.Cached {
+		t.Fatalf("otros parámetros de muestreo no deben compartir la caché: %+v, %v", second, err)
+	}
+
+	// La primera sigue en la tabla persistente
+	fromStore, err := provider.Complete(context.Background(), cacheRequest("hola"))
+	if err != nil || !fromStore.Cached || fromStore.Content != "primera" {
+		t.Fatalf("se esperaba la respuesta de la tabla persistente: %+v, %v", fromStore, err)
+	}
+
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("se esperaban 2 solicitudes al servidor, hubo %d", n)
+	}
+
+	stats := llm.ResponseCacheStats()
+	if stats.MemoryHits != 1 || stats.StoreHits != 1 || stats.Misses != 2 || stats.Evictions != 2 || stats.Entries != 1 {
+		t.Fatalf("métricas inesperadas: %+v", stats)
+	}
+}
+
+func TestResponseCacheSkipsFallback(t *testing.T) {
+	primary := llmtest.NewServer()
+	primary.Close()
+	fallback := llmtest.NewServer()
+	defer fallback.Close()
+
+	store := &fakeCacheStore{entries: make(map[string]llm.CachedCompletion)}
+	llm.ConfigureCache(llm.CacheConfig{TTL: time.Minute, MaxEntries: 10}, store)
+	defer llm.ConfigureCache(llm.CacheConfig{}, nil)
+
+	// El respaldo usa el mismo modelo (Model vacío) en otro endpoint
+	cfg := primary.ProviderConfig(llm.ProviderOpenAI)
+	cfg.Cache = true
+	cfg.Fallbacks = []llm.FallbackConfig{{Provider: fallback.ProviderConfig(llm.ProviderOpenAI)}}
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("no se pudo crear el proveedor: %v", err)
+	}
+
+	fallback.Enqueue(llmtest.Reply("del respaldo"), llmtest.Reply("otra vez del respaldo"))
+	for _, want := range []string{"del respaldo", "otra vez del respaldo"} {
+		completion, err := provider.Complete(context.Background(), cacheRequest("hola"))
+		if err != nil || completion.Content != want || completion.Cached || completion.Fallback != 1 {
+			t.Fatalf("se esperaba la respuesta sin caché del respaldo: %+v, %v", completion, err)
+		}
+	}
+	if len(store.entries) != 0 {
+		t.Fatalf("la respuesta del respaldo no debería guardarse: %+v", store.entries)
+	}
+}
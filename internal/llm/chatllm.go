@@ -2,100 +2,247 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 )
 
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+
+	// ToolCalls son las herramientas que pidió el asistente en este mensaje
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID identifica la llamada que responde un mensaje con rol "tool"
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// CompletionOptions agrupa los parámetros de muestreo configurables por bot
+type CompletionOptions struct {
+	Temperature float64
+	MaxTokens   int
+	Stop        []string
+
+	// ResponseFormat pide al proveedor una salida JSON que cumpla el schema; nil no lo restringe
+	ResponseFormat *ResponseFormat
+}
+
+// ResponseFormat describe la salida estructurada esperada mediante un JSON schema
+type ResponseFormat struct {
+	Name   string
+	Schema json.RawMessage
+}
+
+// openAIResponseFormat es el campo response_format de la API de OpenAI (y compatibles)
+type openAIResponseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema openAIJSONSchema `json:"json_schema"`
+}
+
+type openAIJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// openAIFormat convierte el ResponseFormat neutral al formato de OpenAI
+func openAIFormat(rf *ResponseFormat) *openAIResponseFormat {
+	if rf == nil {
+		return nil
+	}
+	return &openAIResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: openAIJSONSchema{Name: rf.Name, Schema: rf.Schema},
+	}
+}
+
+// ollamaFormat devuelve el schema para el campo format de Ollama
+func ollamaFormat(rf *ResponseFormat) json.RawMessage {
+	if rf == nil {
+		return nil
+	}
+	return rf.Schema
+}
+
+// CompletionRequest es la solicitud neutral que recibe cualquier Provider
+type CompletionRequest struct {
+	Model    string
+	Messages []ChatMessage
+	Options  CompletionOptions
+	// Tools son las herramientas que el modelo puede pedir; los proveedores sin soporte las ignoran
+	Tools []Tool
+}
+
+// CompletionResponse es la respuesta neutral devuelta por cualquier Provider
+type CompletionResponse struct {
+	Content string
+	// ToolCalls son las herramientas que pidió el modelo en lugar de (o además de) responder
+	ToolCalls []ToolCall
+	Raw       []byte
+	// Model es el modelo que generó la respuesta; puede ser uno de la cadena de respaldo
+	Model string
+	// Fallback es la posición en la cadena de respaldo del proveedor que respondió (0 el principal)
+	Fallback int
+	// Usage es el consumo de tokens informado por el servidor (en cero si no lo informa)
+	Usage Usage
+	// Cached indica que la respuesta se obtuvo de la caché sin llamar al servidor
+	Cached bool
+}
+
+// Usage es el consumo de tokens de una llamada
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// openAIUsage es el campo usage de las respuestas de OpenAI (y compatibles)
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// openAIStreamOptions pide que el último fragmento del stream incluya el consumo de tokens
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ollamaUsage convierte los contadores de Ollama (prompt_eval_count, eval_count) en Usage
+func ollamaUsage(promptEvalCount int, evalCount int) Usage {
+	return Usage{
+		PromptTokens:     promptEvalCount,
+		CompletionTokens: evalCount,
+		TotalTokens:      promptEvalCount + evalCount,
+	}
 }
 
 type OllamaChatResponse struct {
-	Model      string      `json:"model"`
-	RemoteHost string      `json:"remote_host"`
-	CreatedAt  string      `json:"created_at"`
-	Message    ChatMessage `json:"message"`
-	Done       bool        `json:"done"`
+	Model           string      `json:"model"`
+	RemoteHost      string      `json:"remote_host"`
+	CreatedAt       string      `json:"created_at"`
+	Message         ChatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
 }
+
 type CompletionBody struct {
 	Model       string        `json:"model"`
 	Messages    []ChatMessage `json:"messages"`
 	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
 	Stream      bool          `json:"stream"`
+
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	StreamOptions  *openAIStreamOptions  `json:"stream_options,omitempty"`
+	Tools          []openAITool          `json:"tools,omitempty"`
 }
 
 type ChatCompletionResponse struct {
 	Choices []struct {
 		Message ChatMessage `json:"message"`
 	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// OllamaOptions son los parámetros de muestreo de la API nativa de Ollama
+type OllamaOptions struct {
+	Temperature float64  `json:"temperature"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type OllamaChatBody struct {
+	Model    string              `json:"model"`
+	Messages []ollamaChatMessage `json:"messages"`
+	Stream   bool                `json:"stream"`
+	Format   json.RawMessage     `json:"format,omitempty"`
+	Options  OllamaOptions       `json:"options"`
+	Tools    []openAITool        `json:"tools,omitempty"`
 }
 
 type OllamaCompletionBody struct {
-	Model       string  `json:"model"`
-	Prompt      string  `json:"prompt"`
-	Temperature float64 `json:"temperature"`
-	Stream      bool    `json:"stream"`
+	Model   string          `json:"model"`
+	Prompt  string          `json:"prompt"`
+	System  string          `json:"system,omitempty"`
+	Stream  bool            `json:"stream"`
+	Format  json.RawMessage `json:"format,omitempty"`
+	Options OllamaOptions   `json:"options"`
 }
 
 type OllamaCompletionResponse struct {
-	Model     string `json:"model"`
-	Response  string `json:"response"`
-	CreatedAt string `json:"created_at"`
-	Done      bool   `json:"done"`
+	Model           string `json:"model"`
+	Response        string `json:"response"`
+	CreatedAt       string `json:"created_at"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
 }
 
+// endpointURL concatena la ruta al baseURL salvo que el baseURL ya la incluya
+func endpointURL(baseURL string, path string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(baseURL, path) {
+		return baseURL
+	}
+	return baseURL + path
+}
 
-func PostCompletion(messages []ChatMessage, baseURL string, nameLLM string, apiKey string) (string, error) {
-	requestBody := CompletionBody{
-		Model:       nameLLM,
-		Messages:    messages,
-		Temperature: 0.7,
-		Stream:      false,
+// newJSONRequest construye una solicitud POST con cuerpo JSON y autenticación Bearer opcional
+func newJSONRequest(ctx context.Context, url string, apiKey string, body any) (*http.Request, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("fallo al serializar el cuerpo de la solicitud: %w", err)
 	}
 
-	jsonBody, err := json.Marshal(requestBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("fallo al serializar el cuerpo de la solicitud: %w", err)
+		return nil, fmt.Errorf("fallo al crear la solicitud: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	return req, nil
+}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s", baseURL), bytes.NewBuffer(jsonBody))
+// postJSON serializa el cuerpo, lo envía por POST y devuelve la respuesta completa
+func postJSON(ctx context.Context, client *http.Client, url string, apiKey string, body any) ([]byte, error) {
+	req, err := newJSONRequest(ctx, url, apiKey, body)
 	if err != nil {
-		return "", fmt.Errorf("fallo al crear la solicitud: %w", err)
+		return nil, err
 	}
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("fallo al enviar la solicitud: %w", err)
+		return nil, fmt.Errorf("fallo al enviar la solicitud: %w", err)
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("fallo al leer la respuesta: %w", err)
-	}
-
-	fmt.Println(">>> Respuesta cruda del LLM:\n", string(bodyBytes))
-
-	// 1. Intentar decodificar como OpenAI
-	var completionResponse ChatCompletionResponse
-	if err := json.Unmarshal(bodyBytes, &completionResponse); err == nil && len(completionResponse.Choices) > 0 {
-		return completionResponse.Choices[0].Message.Content, nil
+		return nil, fmt.Errorf("fallo al leer la respuesta: %w", err)
 	}
 
-	// 2. Intentar decodificar como Ollama
-	var ollamaResponse OllamaChatResponse
-	if err := json.Unmarshal(bodyBytes, &ollamaResponse); err == nil && ollamaResponse.Message.Content != "" {
-		return ollamaResponse.Message.Content, nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newHTTPError(resp, bodyBytes)
 	}
 
-	return "", fmt.Errorf("no se encontró contenido válido en la respuesta del LLM")
+	return bodyBytes, nil
 }
-
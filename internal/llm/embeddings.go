@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+)
+
+// Embedder convierte textos en vectores con el endpoint de embeddings del proveedor
+type Embedder interface {
+	Name() string
+	Model() string
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
+// EmbeddingConfig contiene lo necesario para construir un Embedder
+type EmbeddingConfig struct {
+	Provider ProviderConfig
+	Model    string
+}
+
+// Enabled indica si hay un modelo de embeddings configurado
+func (c EmbeddingConfig) Enabled() bool {
+	return c.Model != ""
+}
+
+// EmbeddingConfigFromEnv lee la configuración de embeddings del entorno. EMBEDDINGS_MODEL vacío
+// desactiva la búsqueda semántica; la URL base cae en LLM_BASE_URL si no se indica otra.
+func EmbeddingConfigFromEnv() EmbeddingConfig {
+	return EmbeddingConfig{
+		Provider: providerConfigFromEnv("EMBEDDINGS"),
+		Model:    os.Getenv("EMBEDDINGS_MODEL"),
+	}
+}
+
+// NewEmbedder construye el Embedder correspondiente al tipo de proveedor configurado
+func NewEmbedder(cfg EmbeddingConfig) (Embedder, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("no hay un modelo de embeddings configurado")
+	}
+
+	timeout := cfg.Provider.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider.Type {
+	case ProviderOpenAI, ProviderLMStudio:
+		baseURL := cfg.Provider.BaseURL
+		if baseURL == "" && cfg.Provider.Type == ProviderLMStudio {
+			baseURL = lmStudioDefaultURL
+		}
+		return &openAIEmbedder{
+			name:   string(cfg.Provider.Type),
+			url:    endpointURL(baseURL, "/embeddings"),
+			apiKey: cfg.Provider.APIKey,
+			model:  cfg.Model,
+			client: client,
+		}, nil
+	case ProviderOllamaChat, ProviderOllamaGenerate:
+		return &ollamaEmbedder{
+			url:    ollamaBaseURL(cfg.Provider.BaseURL) + "/api/embed",
+			apiKey: cfg.Provider.APIKey,
+			model:  cfg.Model,
+			client: client,
+		}, nil
+	}
+	return nil, fmt.Errorf("tipo de proveedor de embeddings desconocido: %q", cfg.Provider.Type)
+}
+
+// EmbeddingBody es el cuerpo de /v1/embeddings (OpenAI) y /api/embed (Ollama)
+type EmbeddingBody struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse es la respuesta de /v1/embeddings de OpenAI
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// OllamaEmbedResponse es la respuesta de /api/embed de Ollama
+type OllamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// openAIEmbedder habla el endpoint /v1/embeddings de OpenAI (también lo expone LM Studio)
+type openAIEmbedder struct {
+	name   string
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func (e *openAIEmbedder) Name() string {
+	return e.name
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.model
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	bodyBytes, err := postJSON(ctx, e.client, e.url, e.apiKey, EmbeddingBody{Model: e.model, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+
+	var response EmbeddingResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, fmt.Errorf("fallo al decodificar los embeddings de %s: %w", e.name, err)
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, d := range response.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("índice de embedding fuera de rango en la respuesta de %s: %d", e.name, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return checkEmbeddings(vectors, e.name)
+}
+
+// ollamaEmbedder habla el endpoint nativo /api/embed de Ollama
+type ollamaEmbedder struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func (e *ollamaEmbedder) Name() string {
+	return "ollama"
+}
+
+func (e *ollamaEmbedder) Model() string {
+	return e.model
+}
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	bodyBytes, err := postJSON(ctx, e.client, e.url, e.apiKey, EmbeddingBody{Model: e.model, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+
+	var response OllamaEmbedResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, fmt.Errorf("fallo al decodificar los embeddings de ollama: %w", err)
+	}
+	if len(response.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("ollama devolvió %d embeddings para %d textos", len(response.Embeddings), len(inputs))
+	}
+	return checkEmbeddings(response.Embeddings, "ollama")
+}
+
+// checkEmbeddings verifica que cada texto haya recibido un vector no vacío
+func checkEmbeddings(vectors [][]float32, name string) ([][]float32, error) {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("%s no devolvió el embedding del texto %d", name, i)
+		}
+	}
+	return vectors, nil
+}
+
+// CosineSimilarity devuelve la similitud coseno entre dos vectores (0 si no son comparables)
+func CosineSimilarity(a []float32, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// FallbackConfig es un proveedor y modelo alternativo de la cadena de respaldo de un bot
+type FallbackConfig struct {
+	Provider ProviderConfig
+	Model    string
+}
+
+// fallbackEntry es un eslabón de la cadena; model vacío usa el modelo de la solicitud
+type fallbackEntry struct {
+	provider *resilientProvider
+	model    string
+}
+
+// fallbackProvider prueba los proveedores en orden y pasa al siguiente cuando el actual no está
+// disponible (circuito abierto, fallo de conexión, 429 o 5xx tras los reintentos).
+type fallbackProvider struct {
+	entries []fallbackEntry
+}
+
+func (p *fallbackProvider) Name() string {
+	return p.entries[0].provider.Name()
+}
+
+func (p *fallbackProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	return p.try(req, func(entry fallbackEntry, req CompletionRequest) (*CompletionResponse, bool, error) {
+		completion, err := entry.provider.Complete(ctx, req)
+		return completion, true, err
+	})
+}
+
+// Stream solo pasa al siguiente proveedor si el actual no entregó ningún fragmento
+func (p *fallbackProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	return p.try(req, func(entry fallbackEntry, req CompletionRequest) (*CompletionResponse, bool, error) {
+		sent := false
+		completion, err := entry.provider.Stream(ctx, req, func(delta string) {
+			sent = true
+			onDelta(delta)
+		})
+		return completion, !sent, err
+	})
+}
+
+// try recorre la cadena. call devuelve además si se puede probar el siguiente proveedor.
+func (p *fallbackProvider) try(req CompletionRequest, call func(entry fallbackEntry, req CompletionRequest) (*CompletionResponse, bool, error)) (*CompletionResponse, error) {
+	var errs []error
+	for i, entry := range p.entries {
+		attempt := req
+		if entry.model != "" {
+			attempt.Model = entry.model
+		}
+
+		completion, canFallback, err := call(entry, attempt)
+		if err == nil {
+			if i > 0 {
+				log.Printf("LLM: respuesta generada por el respaldo %s (%s)", entry.provider.breaker.endpoint, attempt.Model)
+			}
+			completion.Fallback = i
+			return completion, nil
+		}
+
+		if !canFallback || !IsUnavailable(err) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s (%s): %w", entry.provider.breaker.endpoint, attempt.Model, err))
+	}
+
+	return nil, fmt.Errorf("ningún proveedor de la cadena está disponible: %w", errors.Join(errs...))
+}
@@ -0,0 +1,86 @@
+package llmtest
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EmbeddingDimensions es el largo de los vectores que devuelve el servidor falso
+const EmbeddingDimensions = 64
+
+// Embedding es el vector determinista que el servidor falso asigna a un texto: una bolsa de
+// palabras con hashing, normalizada. Textos que comparten palabras quedan cerca, así que alcanza
+// para probar la búsqueda semántica sin un modelo real.
+func Embedding(text string) []float32 {
+	vector := make([]float32, EmbeddingDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vector[h.Sum32()%EmbeddingDimensions]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
+
+// Embedded devuelve una copia de los textos recibidos por los endpoints de embeddings. No se
+// registran en Requests para no alterar la cuenta de solicitudes de completado.
+func (f *Fake) Embedded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.embedded)
+}
+
+func isEmbeddingsPath(path string) bool {
+	return strings.HasSuffix(path, "/embeddings") || path == "/api/embed"
+}
+
+// serveEmbeddings responde /v1/embeddings (OpenAI, LM Studio) y /api/embed (Ollama)
+func (f *Fake) serveEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.embedded = append(f.embedded, body.Input...)
+	f.mu.Unlock()
+
+	vectors := make([][]float32, 0, len(body.Input))
+	for _, input := range body.Input {
+		vectors = append(vectors, Embedding(input))
+	}
+
+	if r.URL.Path == "/api/embed" {
+		writeJSON(w, map[string]any{"model": body.Model, "embeddings": vectors})
+		return
+	}
+
+	data := make([]map[string]any, 0, len(vectors))
+	for i, v := range vectors {
+		data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": v})
+	}
+	writeJSON(w, map[string]any{"object": "list", "model": body.Model, "data": data})
+}
@@ -0,0 +1,25 @@
+package llmtest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// questionMessageID extrae el id del último turno en formato inline ([id:12 | ...) o JSON ("id":12)
+var questionMessageID = regexp.MustCompile(`(?:\[id:|"id":)(\d+)`)
+
+// AnswerQuestions es un handler determinista para desarrollo: responde a las preguntas (el
+// último mensaje contiene "?") y guarda silencio en el resto. Sirve para probar el circuito
+// completo sin un modelo real, no para evaluar una persona.
+func AnswerQuestions(req Request) Response {
+	last := req.LastUserMessage()
+	if !strings.Contains(last, "?") {
+		return Silence()
+	}
+
+	answerID := ""
+	if match := questionMessageID.FindStringSubmatch(last); match != nil {
+		answerID = match[1]
+	}
+	return Say(answerID, "Stub: simon")
+}
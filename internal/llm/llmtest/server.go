@@ -0,0 +1,593 @@
+// Package llmtest levanta un servidor LLM falso y determinista para pruebas y desarrollo local.
+// Habla el formato de OpenAI (/v1/chat/completions, que también usa LM Studio) y el nativo de
+// Ollama (/api/chat y /api/generate), con y sin streaming. Las respuestas se programan en orden
+// con Enqueue o se generan con Handle, pueden demorarse, fallar o cortar la conexión, y cada
+// solicitud recibida queda registrada para inspeccionarla. Los endpoints de embeddings
+// (/v1/embeddings y /api/embed) responden con vectores deterministas (ver Embedding).
+package llmtest
+
+import (
+	"chatvis-chat/internal/llm"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format es el protocolo en que llegó una solicitud
+type Format string
+
+const (
+	FormatOpenAI         Format = "openai"
+	FormatOllamaChat     Format = "ollama_chat"
+	FormatOllamaGenerate Format = "ollama_generate"
+)
+
+// Request es una solicitud recibida por el servidor, ya decodificada
+type Request struct {
+	Format   Format
+	Path     string
+	Header   http.Header
+	Model    string
+	Messages []llm.ChatMessage
+	// System y Prompt solo se informan en /api/generate
+	System string
+	Prompt string
+	Stream bool
+	// Structured indica que se pidió salida con JSON schema (response_format o format)
+	Structured bool
+	// Tools son los nombres de las herramientas ofrecidas al modelo
+	Tools       []string
+	Temperature float64
+	MaxTokens   int
+	Body        []byte
+	ReceivedAt  time.Time
+}
+
+// LastUserMessage devuelve el último mensaje con rol user; en /api/generate, el último turno
+// user del prompt aplanado
+func (r Request) LastUserMessage() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+
+	prompt := strings.TrimSuffix(r.Prompt, "assistant: ")
+	if i := strings.LastIndex(prompt, "user: "); i >= 0 {
+		return strings.TrimSpace(prompt[i+len("user: "):])
+	}
+	return ""
+}
+
+// SystemPrompt devuelve el primer mensaje de sistema (o el campo system de /api/generate)
+func (r Request) SystemPrompt() string {
+	for _, m := range r.Messages {
+		if m.Role == "system" {
+			return m.Content
+		}
+	}
+	return r.System
+}
+
+// Response describe cómo contesta el servidor una solicitud
+type Response struct {
+	// Content es el texto generado por el modelo
+	Content string
+	// ToolCalls son las herramientas que pide el modelo; solo se envían en respuestas sin streaming
+	ToolCalls []llm.ToolCall
+	// Chunks son los fragmentos de un streaming; vacío divide Content por palabras
+	Chunks []string
+	// Delay se espera antes de responder y ChunkDelay entre dos fragmentos del streaming
+	Delay      time.Duration
+	ChunkDelay time.Duration
+
+	// Status distinto de cero y de 2xx responde con ese estado, Body como cuerpo y la cabecera
+	// Retry-After si se indica
+	Status     int
+	Body       string
+	RetryAfter time.Duration
+	// Disconnect corta la conexión sin terminar la respuesta; en un streaming, después de enviar Chunks
+	Disconnect bool
+
+	// PromptTokens y CompletionTokens se informan como consumo; en cero se estiman
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Reply responde con el texto indicado
+func Reply(content string) Response {
+	return Response{Content: content}
+}
+
+// Say responde con el schema de respuesta de los bots: un mensaje por contenido, el primero
+// como respuesta a answerID ("" para no responder a ninguno)
+func Say(answerID string, contents ...string) Response {
+	type part struct {
+		AnswerID any    `json:"answer_id"`
+		Content  string `json:"content"`
+	}
+
+	parts := make([]part, 0, len(contents))
+	for i, content := range contents {
+		p := part{Content: content}
+		if i == 0 && answerID != "" {
+			p.AnswerID = answerID
+		}
+		parts = append(parts, p)
+	}
+
+	data, _ := json.Marshal(map[string]any{"messages": parts})
+	return Response{Content: string(data)}
+}
+
+// CallTool responde pidiendo la herramienta name con los argumentos args (serializados a JSON)
+func CallTool(name string, args any) Response {
+	data, _ := json.Marshal(args)
+	return Response{ToolCalls: []llm.ToolCall{{Name: name, Arguments: data}}}
+}
+
+// Silence responde que el bot no habla
+func Silence() Response {
+	return Response{Content: `{"messages": []}`}
+}
+
+// Fail responde con el estado HTTP indicado
+func Fail(status int) Response {
+	return Response{Status: status, Body: http.StatusText(status)}
+}
+
+// Fake es el handler del servidor falso. NewServer lo sirve con httptest; para desarrollo
+// local puede montarse en cualquier http.Server (ver cmd/llm-fake).
+type Fake struct {
+	mu       sync.Mutex
+	script   []Response
+	handler  func(Request) Response
+	requests []Request
+	// embedded son los textos recibidos por los endpoints de embeddings
+	embedded []string
+	// received se cierra y reemplaza con cada solicitud para despertar a Wait
+	received chan struct{}
+}
+
+// NewFake crea el handler; sin respuestas programadas ni Handle, el bot guarda silencio
+func NewFake() *Fake {
+	return &Fake{received: make(chan struct{})}
+}
+
+// Enqueue programa respuestas que se entregan en orden, una por solicitud
+func (f *Fake) Enqueue(responses ...Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.script = append(f.script, responses...)
+}
+
+// Handle fija la función que responde cuando no quedan respuestas programadas
+func (f *Fake) Handle(handler func(Request) Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handler = handler
+}
+
+// Pending devuelve cuántas respuestas programadas quedan sin entregar
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.script)
+}
+
+// Requests devuelve una copia de las solicitudes recibidas, en orden de llegada
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.requests)
+}
+
+// Wait espera hasta haber recibido n solicitudes y las devuelve. Si vence timeout devuelve las
+// recibidas hasta entonces y un error.
+func (f *Fake) Wait(n int, timeout time.Duration) ([]Request, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		f.mu.Lock()
+		requests := slices.Clone(f.requests)
+		received := f.received
+		f.mu.Unlock()
+
+		if len(requests) >= n {
+			return requests, nil
+		}
+
+		select {
+		case <-received:
+		case <-deadline.C:
+			return requests, fmt.Errorf("llmtest: se esperaban %d solicitudes y llegaron %d", n, len(requests))
+		}
+	}
+}
+
+// Reset descarta las respuestas programadas, el handler y las solicitudes registradas
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.script = nil
+	f.handler = nil
+	f.requests = nil
+	f.embedded = nil
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && isEmbeddingsPath(r.URL.Path) {
+		f.serveEmbeddings(w, r)
+		return
+	}
+
+	format, ok := formatForPath(r.URL.Path)
+	if r.Method != http.MethodPost || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	req, err := decodeRequest(format, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := f.next(req)
+
+	if resp.Delay > 0 && !sleep(r, resp.Delay) {
+		return
+	}
+
+	if resp.Status != 0 && (resp.Status < 200 || resp.Status >= 300) {
+		if resp.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(resp.RetryAfter.Seconds())))
+		}
+		http.Error(w, resp.Body, resp.Status)
+		return
+	}
+
+	if resp.PromptTokens == 0 {
+		resp.PromptTokens = llm.EstimateMessagesTokens(req.Messages) + llm.EstimateTokens(req.System+req.Prompt)
+	}
+	if resp.CompletionTokens == 0 {
+		resp.CompletionTokens = llm.EstimateTokens(resp.Content)
+	}
+
+	if req.Stream {
+		writeStream(w, r, req, resp)
+		return
+	}
+	if resp.Disconnect {
+		disconnect(w)
+		return
+	}
+	writeJSON(w, completionBody(req, resp))
+}
+
+// next registra la solicitud y elige la respuesta: la próxima programada, la del handler o silencio
+func (f *Fake) next(req Request) Response {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	close(f.received)
+	f.received = make(chan struct{})
+
+	if len(f.script) > 0 {
+		resp := f.script[0]
+		f.script = f.script[1:]
+		f.mu.Unlock()
+		return resp
+	}
+	handler := f.handler
+	f.mu.Unlock()
+
+	if handler != nil {
+		return handler(req)
+	}
+	return Silence()
+}
+
+// Server es un Fake servido con httptest en una dirección local
+type Server struct {
+	*Fake
+	// URL es la URL base del servidor, sin la ruta de la API
+	URL string
+
+	srv *httptest.Server
+}
+
+// NewServer inicia el servidor falso; debe cerrarse con Close
+func NewServer() *Server {
+	fake := NewFake()
+	srv := httptest.NewServer(fake)
+	return &Server{Fake: fake, URL: srv.URL, srv: srv}
+}
+
+// ProviderConfig devuelve la configuración para que un proveedor del tipo indicado use este servidor
+func (s *Server) ProviderConfig(providerType llm.ProviderType) llm.ProviderConfig {
+	return llm.ProviderConfig{Type: providerType, BaseURL: s.URL, Timeout: 5 * time.Second}
+}
+
+// Close detiene el servidor y corta las conexiones abiertas
+func (s *Server) Close() {
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+}
+
+func formatForPath(path string) (Format, bool) {
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return FormatOpenAI, true
+	case path == "/api/chat":
+		return FormatOllamaChat, true
+	case path == "/api/generate":
+		return FormatOllamaGenerate, true
+	}
+	return "", false
+}
+
+func decodeRequest(format Format, r *http.Request) (Request, error) {
+	req := Request{
+		Format:     format,
+		Path:       r.URL.Path,
+		Header:     r.Header.Clone(),
+		ReceivedAt: time.Now(),
+	}
+
+	var err error
+	if req.Body, err = io.ReadAll(r.Body); err != nil {
+		return req, fmt.Errorf("error al leer la solicitud: %w", err)
+	}
+
+	// tools es común a OpenAI y /api/chat; solo interesan los nombres
+	var tools struct {
+		Tools []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+	}
+
+	switch format {
+	case FormatOpenAI:
+		var b struct {
+			llm.CompletionBody
+			ResponseFormat json.RawMessage `json:"response_format"`
+		}
+		err = json.Unmarshal(req.Body, &b)
+		req.Model, req.Messages, req.Stream = b.Model, b.Messages, b.Stream
+		req.Temperature, req.MaxTokens = b.Temperature, b.MaxTokens
+		req.Structured = len(b.ResponseFormat) > 0
+	case FormatOllamaChat:
+		// los mensajes se decodifican como ChatMessage, que acepta los argumentos como objeto
+		var b struct {
+			Model    string            `json:"model"`
+			Messages []llm.ChatMessage `json:"messages"`
+			Stream   bool              `json:"stream"`
+			Format   json.RawMessage   `json:"format"`
+			Options  llm.OllamaOptions `json:"options"`
+		}
+		err = json.Unmarshal(req.Body, &b)
+		req.Model, req.Messages, req.Stream = b.Model, b.Messages, b.Stream
+		req.Temperature, req.MaxTokens = b.Options.Temperature, b.Options.NumPredict
+		req.Structured = len(b.Format) > 0
+	case FormatOllamaGenerate:
+		var b llm.OllamaCompletionBody
+		err = json.Unmarshal(req.Body, &b)
+		req.Model, req.System, req.Prompt, req.Stream = b.Model, b.System, b.Prompt, b.Stream
+		req.Temperature, req.MaxTokens = b.Options.Temperature, b.Options.NumPredict
+		req.Structured = len(b.Format) > 0
+	}
+	if err == nil && format != FormatOllamaGenerate {
+		err = json.Unmarshal(req.Body, &tools)
+		for _, t := range tools.Tools {
+			req.Tools = append(req.Tools, t.Function.Name)
+		}
+	}
+	if err != nil {
+		return req, fmt.Errorf("cuerpo de la solicitud inválido: %w", err)
+	}
+	return req, nil
+}
+
+// completionBody arma la respuesta completa (sin streaming) en el formato de la solicitud
+func completionBody(req Request, resp Response) any {
+	switch req.Format {
+	case FormatOllamaChat:
+		return map[string]any{
+			"model":             req.Model,
+			"created_at":        time.Now().Format(time.RFC3339),
+			"message":           ollamaMessage(resp),
+			"done":              true,
+			"prompt_eval_count": resp.PromptTokens,
+			"eval_count":        resp.CompletionTokens,
+		}
+	case FormatOllamaGenerate:
+		return map[string]any{
+			"model":             req.Model,
+			"created_at":        time.Now().Format(time.RFC3339),
+			"response":          resp.Content,
+			"done":              true,
+			"prompt_eval_count": resp.PromptTokens,
+			"eval_count":        resp.CompletionTokens,
+		}
+	}
+
+	return map[string]any{
+		"object": "chat.completion",
+		"model":  req.Model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       llm.ChatMessage{Role: "assistant", Content: resp.Content, ToolCalls: openAIToolCalls(resp.ToolCalls)},
+			"finish_reason": finishReason(resp),
+		}},
+		"usage": openAIUsage(resp),
+	}
+}
+
+// openAIToolCalls completa los ID que no indicó la prueba, como haría la API
+func openAIToolCalls(calls []llm.ToolCall) []llm.ToolCall {
+	result := slices.Clone(calls)
+	for i := range result {
+		if result[i].ID == "" {
+			result[i].ID = "call_fake_" + strconv.Itoa(i+1)
+		}
+	}
+	return result
+}
+
+func finishReason(resp Response) string {
+	if len(resp.ToolCalls) > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+// ollamaMessage arma el mensaje de /api/chat: Ollama envía los argumentos como objeto y sin ID
+func ollamaMessage(resp Response) map[string]any {
+	message := map[string]any{"role": "assistant", "content": resp.Content}
+	if len(resp.ToolCalls) == 0 {
+		return message
+	}
+
+	calls := make([]map[string]any, 0, len(resp.ToolCalls))
+	for _, call := range resp.ToolCalls {
+		arguments := call.Arguments
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+		calls = append(calls, map[string]any{"function": map[string]any{"name": call.Name, "arguments": arguments}})
+	}
+	message["tool_calls"] = calls
+	return message
+}
+
+func openAIUsage(resp Response) map[string]int {
+	return map[string]int{
+		"prompt_tokens":     resp.PromptTokens,
+		"completion_tokens": resp.CompletionTokens,
+		"total_tokens":      resp.PromptTokens + resp.CompletionTokens,
+	}
+}
+
+// writeStream envía la respuesta en fragmentos: SSE para OpenAI y NDJSON para Ollama
+func writeStream(w http.ResponseWriter, r *http.Request, req Request, resp Response) {
+	chunks := resp.Chunks
+	if len(chunks) == 0 && resp.Content != "" {
+		chunks = strings.SplitAfter(resp.Content, " ")
+	}
+
+	if req.Format == FormatOpenAI {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	for i, chunk := range chunks {
+		if i > 0 && resp.ChunkDelay > 0 && !sleep(r, resp.ChunkDelay) {
+			return
+		}
+		writeChunk(w, req, chunk)
+	}
+
+	if resp.Disconnect {
+		disconnect(w)
+		return
+	}
+
+	switch req.Format {
+	case FormatOpenAI:
+		writeEvent(w, map[string]any{"choices": []any{}, "usage": openAIUsage(resp)})
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	case FormatOllamaChat:
+		writeLine(w, map[string]any{
+			"model":             req.Model,
+			"message":           llm.ChatMessage{Role: "assistant"},
+			"done":              true,
+			"prompt_eval_count": resp.PromptTokens,
+			"eval_count":        resp.CompletionTokens,
+		})
+	case FormatOllamaGenerate:
+		writeLine(w, map[string]any{
+			"model":             req.Model,
+			"response":          "",
+			"done":              true,
+			"prompt_eval_count": resp.PromptTokens,
+			"eval_count":        resp.CompletionTokens,
+		})
+	}
+	flush(w)
+}
+
+func writeChunk(w http.ResponseWriter, req Request, chunk string) {
+	switch req.Format {
+	case FormatOpenAI:
+		writeEvent(w, map[string]any{"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": chunk}}}})
+	case FormatOllamaChat:
+		writeLine(w, map[string]any{"model": req.Model, "message": llm.ChatMessage{Role: "assistant", Content: chunk}, "done": false})
+	case FormatOllamaGenerate:
+		writeLine(w, map[string]any{"model": req.Model, "response": chunk, "done": false})
+	}
+	flush(w)
+}
+
+func writeEvent(w http.ResponseWriter, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func writeLine(w http.ResponseWriter, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "%s\n", data)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// disconnect cierra la conexión sin terminar la respuesta
+func disconnect(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic("llmtest: el servidor no permite cortar la conexión")
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
+
+// sleep espera d o hasta que el cliente cancele la solicitud; devuelve false si la canceló
+func sleep(r *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
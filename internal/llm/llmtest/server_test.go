@@ -0,0 +1,287 @@
+package llmtest_test
+
+import (
+	"chatvis-chat/internal/llm"
+	"chatvis-chat/internal/llm/llmtest"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var providers = []llm.ProviderType{llm.ProviderOpenAI, llm.ProviderLMStudio, llm.ProviderOllamaChat, llm.ProviderOllamaGenerate}
+
+func newProvider(t *testing.T, srv *llmtest.Server, providerType llm.ProviderType) llm.StreamingProvider {
+	t.Helper()
+
+	provider, err := llm.NewProvider(srv.ProviderConfig(providerType))
+	if err != nil {
+		t.Fatalf("no se pudo crear el proveedor %s: %v", providerType, err)
+	}
+	return provider.(llm.StreamingProvider)
+}
+
+func request(content string) llm.CompletionRequest {
+	return llm.CompletionRequest{
+		Model: "fake-model",
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: "Sos un bot de pruebas"},
+			{Role: "user", Content: content},
+		},
+		Options: llm.CompletionOptions{Temperature: 0.3, MaxTokens: 64},
+	}
+}
+
+func TestCompleteAllFormats(t *testing.T) {
+	for _, providerType := range providers {
+		t.Run(string(providerType), func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+
+			srv.Enqueue(llmtest.Response{Content: "primera", PromptTokens: 11, CompletionTokens: 3}, llmtest.Reply("segunda"))
+			provider := newProvider(t, srv, providerType)
+
+			first, err := provider.Complete(context.Background(), request("hola"))
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if first.Content != "primera" || first.Usage.PromptTokens != 11 || first.Usage.CompletionTokens != 3 {
+				t.Fatalf("respuesta inesperada: %+v", first)
+			}
+
+			second, err := provider.Complete(context.Background(), request("chau"))
+			if err != nil || second.Content != "segunda" || second.Usage.CompletionTokens == 0 {
+				t.Fatalf("la segunda respuesta programada no llegó o no estima tokens: %+v, %v", second, err)
+			}
+
+			requests := srv.Requests()
+			if len(requests) != 2 {
+				t.Fatalf("se esperaban 2 solicitudes, hubo %d", len(requests))
+			}
+			req := requests[1]
+			if req.Model != "fake-model" || req.Temperature != 0.3 || req.MaxTokens != 64 || req.Stream {
+				t.Fatalf("solicitud mal decodificada: %+v", req)
+			}
+			if req.LastUserMessage() != "chau" || req.SystemPrompt() != "Sos un bot de pruebas" {
+				t.Fatalf("mensajes mal decodificados: %q / %q", req.LastUserMessage(), req.SystemPrompt())
+			}
+		})
+	}
+}
+
+func TestStreamAllFormats(t *testing.T) {
+	for _, providerType := range providers {
+		t.Run(string(providerType), func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+
+			srv.Enqueue(llmtest.Response{Chunks: []string{"ho", "la ", "mundo"}, Content: "hola mundo", ChunkDelay: time.Millisecond})
+			provider := newProvider(t, srv, providerType)
+
+			var deltas []string
+			completion, err := provider.Stream(context.Background(), request("hola"), func(delta string) {
+				deltas = append(deltas, delta)
+			})
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			if completion.Content != "hola mundo" || strings.Join(deltas, "|") != "ho|la |mundo" {
+				t.Fatalf("streaming inesperado: %q en %q", completion.Content, deltas)
+			}
+			if completion.Usage.TotalTokens == 0 {
+				t.Fatalf("el fragmento final no informó el consumo: %+v", completion.Usage)
+			}
+			if !srv.Requests()[0].Stream {
+				t.Fatal("la solicitud no se registró como streaming")
+			}
+		})
+	}
+}
+
+func TestErrorsAndRetries(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	srv.Enqueue(llmtest.Fail(http.StatusTooManyRequests), llmtest.Reply("ok"), llmtest.Fail(http.StatusBadRequest))
+	provider := newProvider(t, srv, llm.ProviderOpenAI)
+
+	// El 429 se reintenta y el segundo intento recibe la respuesta programada
+	completion, err := provider.Complete(context.Background(), request("hola"))
+	if err != nil || completion.Content != "ok" {
+		t.Fatalf("se esperaba la respuesta tras el reintento: %+v, %v", completion, err)
+	}
+
+	// El 400 no se reintenta
+	_, err = provider.Complete(context.Background(), request("hola"))
+	var httpErr *llm.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("se esperaba un HTTPError 400: %v", err)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Fatalf("se esperaban 3 solicitudes, hubo %d", n)
+	}
+
+	// Una solicitud que agota sus reintentos cuenta como un solo fallo del circuito
+	srv.Enqueue(llmtest.Fail(http.StatusServiceUnavailable), llmtest.Fail(http.StatusServiceUnavailable), llmtest.Fail(http.StatusServiceUnavailable))
+	if _, err := provider.Complete(context.Background(), request("hola")); err == nil {
+		t.Fatal("se esperaba el error tras agotar los reintentos")
+	}
+	for _, status := range llm.BreakerStatuses() {
+		if strings.Contains(status.Endpoint, srv.URL) && (status.Failures != 1 || status.State != llm.BreakerClosed) {
+			t.Fatalf("se esperaba 1 fallo con el circuito cerrado: %+v", status)
+		}
+	}
+}
+
+func TestDisconnectMidStream(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	srv.Enqueue(llmtest.Response{Chunks: []string{"a medias"}, Disconnect: true})
+	provider := newProvider(t, srv, llm.ProviderOllamaChat)
+
+	var received string
+	_, err := provider.Stream(context.Background(), request("hola"), func(delta string) { received += delta })
+	if err == nil {
+		t.Fatal("se esperaba un error por el corte de la conexión")
+	}
+	if received != "a medias" {
+		t.Fatalf("los fragmentos previos al corte no llegaron: %q", received)
+	}
+	// Ya se entregaron fragmentos, así que no se reintenta
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("se esperaba 1 solicitud, hubo %d", n)
+	}
+}
+
+func TestDelayRespectsContext(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	srv.Handle(func(req llmtest.Request) llmtest.Response {
+		resp := llmtest.Reply("tarde")
+		resp.Delay = time.Second
+		return resp
+	})
+	provider := newProvider(t, srv, llm.ProviderOllamaGenerate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if _, err := provider.Complete(ctx, request("hola")); err == nil {
+		t.Fatal("se esperaba un error por el vencimiento del contexto")
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Fatalf("la demora no respetó la cancelación: %v", elapsed)
+	}
+}
+
+func TestWaitAndAnswerQuestions(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+
+	srv.Handle(llmtest.AnswerQuestions)
+	provider := newProvider(t, srv, llm.ProviderOpenAI)
+
+	go provider.Complete(context.Background(), request("[id:7 | ana] ¿vamos?"))
+	requests, err := srv.Wait(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[0].LastUserMessage() != "[id:7 | ana] ¿vamos?" {
+		t.Fatalf("solicitud inesperada: %+v", requests[0])
+	}
+
+	completion, err := provider.Complete(context.Background(), request("[id:8 | ana] dale"))
+	if err != nil || completion.Content != `{"messages": []}` {
+		t.Fatalf("se esperaba silencio: %+v, %v", completion, err)
+	}
+
+	if resp := llmtest.AnswerQuestions(requests[0]); resp.Content != `{"messages":[{"answer_id":"7","content":"Stub: simon"}]}` {
+		t.Fatalf("respuesta inesperada a la pregunta: %s", resp.Content)
+	}
+}
+
+func TestEmbeddingsAllFormats(t *testing.T) {
+	for _, providerType := range providers {
+		t.Run(string(providerType), func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+
+			embedder, err := llm.NewEmbedder(llm.EmbeddingConfig{Provider: srv.ProviderConfig(providerType), Model: "fake-embed"})
+			if err != nil {
+				t.Fatalf("NewEmbedder: %v", err)
+			}
+
+			vectors, err := embedder.Embed(context.Background(), []string{"el asado es el sábado", "apuntes de cálculo", "¿cuándo es el asado?"})
+			if err != nil {
+				t.Fatalf("Embed: %v", err)
+			}
+			if len(vectors) != 3 || len(vectors[0]) != llmtest.EmbeddingDimensions {
+				t.Fatalf("vectores inesperados: %d", len(vectors))
+			}
+			if llm.CosineSimilarity(vectors[2], vectors[0]) <= llm.CosineSimilarity(vectors[2], vectors[1]) {
+				t.Fatal("la consulta debería parecerse más al mensaje que comparte palabras")
+			}
+			if len(srv.Embedded()) != 3 || len(srv.Requests()) != 0 {
+				t.Fatalf("los embeddings no deben contarse como completados: %d/%d", len(srv.Embedded()), len(srv.Requests()))
+			}
+		})
+	}
+}
+
+func TestToolCallsRoundTrip(t *testing.T) {
+	for _, providerType := range []llm.ProviderType{llm.ProviderOpenAI, llm.ProviderOllamaChat} {
+		t.Run(string(providerType), func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+
+			srv.Enqueue(llmtest.CallTool("obtener_mensaje", map[string]any{"id": 7}), llmtest.Reply("listo"))
+			provider := newProvider(t, srv, providerType)
+
+			req := request("¿qué dijo el mensaje 7?")
+			req.Tools = []llm.Tool{{
+				Name:        "obtener_mensaje",
+				Description: "Busca un mensaje por ID",
+				Parameters:  json.RawMessage(`{"type":"object","properties":{"id":{"type":"integer"}}}`),
+			}}
+
+			first, err := provider.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if len(first.ToolCalls) != 1 || first.ToolCalls[0].Name != "obtener_mensaje" || first.ToolCalls[0].ID == "" {
+				t.Fatalf("llamada inesperada: %+v", first.ToolCalls)
+			}
+			var args struct {
+				ID int `json:"id"`
+			}
+			if err := json.Unmarshal(first.ToolCalls[0].Arguments, &args); err != nil || args.ID != 7 {
+				t.Fatalf("argumentos mal decodificados: %s, %v", first.ToolCalls[0].Arguments, err)
+			}
+
+			req.Messages = append(req.Messages,
+				llm.ChatMessage{Role: "assistant", ToolCalls: first.ToolCalls},
+				llm.ChatMessage{Role: "tool", ToolCallID: first.ToolCalls[0].ID, Content: `{"contenido":"hola"}`},
+			)
+			second, err := provider.Complete(context.Background(), req)
+			if err != nil || second.Content != "listo" || len(second.ToolCalls) != 0 {
+				t.Fatalf("segunda respuesta inesperada: %+v, %v", second, err)
+			}
+
+			got := srv.Requests()[1]
+			if len(got.Tools) != 1 || got.Tools[0] != "obtener_mensaje" {
+				t.Fatalf("herramientas mal decodificadas: %v", got.Tools)
+			}
+			last := got.Messages[len(got.Messages)-1]
+			call := got.Messages[len(got.Messages)-2]
+			if last.Role != "tool" || last.Content != `{"contenido":"hola"}` || len(call.ToolCalls) != 1 || call.ToolCalls[0].Name != "obtener_mensaje" {
+				t.Fatalf("la conversación con la herramienta no llegó completa: %+v", got.Messages)
+			}
+		})
+	}
+}
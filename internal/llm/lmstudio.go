@@ -0,0 +1,16 @@
+package llm
+
+import "net/http"
+
+const lmStudioDefaultURL = "http://localhost:1234/v1"
+
+// newLMStudioProvider reutiliza el formato OpenAI, que es el que expone LM Studio
+func newLMStudioProvider(baseURL string, apiKey string, client *http.Client) *openAIProvider {
+	if baseURL == "" {
+		baseURL = lmStudioDefaultURL
+	}
+
+	p := newOpenAIProvider(baseURL, apiKey, client)
+	p.name = string(ProviderLMStudio)
+	return p
+}
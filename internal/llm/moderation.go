@@ -0,0 +1,23 @@
+package llm
+
+import "os"
+
+// ModerationConfig es el modelo que clasifica los mensajes en la cadena de moderación
+type ModerationConfig struct {
+	Provider ProviderConfig
+	Model    string
+}
+
+// Enabled indica si hay un modelo de moderación configurado
+func (c ModerationConfig) Enabled() bool {
+	return c.Model != ""
+}
+
+// ModerationConfigFromEnv lee la configuración del clasificador del entorno. MODERATION_MODEL
+// vacío lo desactiva; la URL base cae en LLM_BASE_URL si no se indica otra.
+func ModerationConfigFromEnv() ModerationConfig {
+	return ModerationConfig{
+		Provider: providerConfigFromEnv("MODERATION"),
+		Model:    os.Getenv("MODERATION_MODEL"),
+	}
+}
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ollamaBaseURL elimina sufijos de compatibilidad OpenAI para usar la API nativa
+func ollamaBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	for _, suffix := range []string{"/api/chat", "/api/generate", "/v1/chat/completions", "/v1"} {
+		baseURL = strings.TrimSuffix(baseURL, suffix)
+	}
+	return baseURL
+}
+
+func ollamaOptions(opts CompletionOptions) OllamaOptions {
+	return OllamaOptions{
+		Temperature: opts.Temperature,
+		NumPredict:  opts.MaxTokens,
+		Stop:        opts.Stop,
+	}
+}
+
+// ollamaChatProvider habla el endpoint nativo /api/chat de Ollama
+type ollamaChatProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newOllamaChatProvider(baseURL string, apiKey string, client *http.Client) *ollamaChatProvider {
+	return &ollamaChatProvider{
+		url:    ollamaBaseURL(baseURL) + "/api/chat",
+		apiKey: apiKey,
+		client: client,
+	}
+}
+
+func (p *ollamaChatProvider) Name() string {
+	return string(ProviderOllamaChat)
+}
+
+func (p *ollamaChatProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	requestBody := OllamaChatBody{
+		Model:    req.Model,
+		Messages: ollamaMessages(req.Messages),
+		Stream:   false,
+		Format:   ollamaFormat(req.Options.ResponseFormat),
+		Options:  ollamaOptions(req.Options),
+		Tools:    openAITools(req.Tools),
+	}
+
+	bodyBytes, err := postJSON(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResponse OllamaChatResponse
+	if err := json.Unmarshal(bodyBytes, &ollamaResponse); err != nil {
+		return nil, fmt.Errorf("fallo al decodificar la respuesta de Ollama: %w", err)
+	}
+
+	return &CompletionResponse{
+		Content:   ollamaResponse.Message.Content,
+		ToolCalls: withToolCallIDs(ollamaResponse.Message.ToolCalls),
+		Raw:       bodyBytes,
+		Usage:     ollamaUsage(ollamaResponse.PromptEvalCount, ollamaResponse.EvalCount),
+	}, nil
+}
+
+// ollamaGenerateProvider habla el endpoint nativo /api/generate de Ollama
+type ollamaGenerateProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newOllamaGenerateProvider(baseURL string, apiKey string, client *http.Client) *ollamaGenerateProvider {
+	return &ollamaGenerateProvider{
+		url:    ollamaBaseURL(baseURL) + "/api/generate",
+		apiKey: apiKey,
+		client: client,
+	}
+}
+
+func (p *ollamaGenerateProvider) Name() string {
+	return string(ProviderOllamaGenerate)
+}
+
+func (p *ollamaGenerateProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	system, prompt := flattenMessages(req.Messages)
+
+	requestBody := OllamaCompletionBody{
+		Model:   req.Model,
+		Prompt:  prompt,
+		System:  system,
+		Stream:  false,
+		Format:  ollamaFormat(req.Options.ResponseFormat),
+		Options: ollamaOptions(req.Options),
+	}
+
+	bodyBytes, err := postJSON(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResponse OllamaCompletionResponse
+	if err := json.Unmarshal(bodyBytes, &ollamaResponse); err != nil {
+		return nil, fmt.Errorf("fallo al decodificar la respuesta de Ollama: %w", err)
+	}
+
+	return &CompletionResponse{
+		Content: ollamaResponse.Response,
+		Raw:     bodyBytes,
+		Usage:   ollamaUsage(ollamaResponse.PromptEvalCount, ollamaResponse.EvalCount),
+	}, nil
+}
+
+// flattenMessages convierte la conversación en el par system/prompt que espera /api/generate
+func flattenMessages(messages []ChatMessage) (string, string) {
+	var system []string
+	var prompt strings.Builder
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		if msg.Content == "" {
+			// los mensajes que sólo llevan llamadas a herramientas no aportan texto
+			continue
+		}
+		prompt.WriteString(msg.Role)
+		prompt.WriteString(": ")
+		prompt.WriteString(msg.Content)
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("assistant: ")
+
+	return strings.Join(system, "\n\n"), prompt.String()
+}
+
+func (p *ollamaChatProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	requestBody := OllamaChatBody{
+		Model:    req.Model,
+		Messages: ollamaMessages(req.Messages),
+		Stream:   true,
+		Format:   ollamaFormat(req.Options.ResponseFormat),
+		Options:  ollamaOptions(req.Options),
+	}
+
+	resp, err := postStream(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, usage, err := readNDJSON(resp.Body, onDelta, func(line []byte) (ndjsonChunk, error) {
+		var chunk OllamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return ndjsonChunk{}, err
+		}
+		return ndjsonChunk{
+			Text:  chunk.Message.Content,
+			Done:  chunk.Done,
+			Usage: ollamaUsage(chunk.PromptEvalCount, chunk.EvalCount),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompletionResponse{
+		Content: content,
+		Raw:     []byte(content),
+		Usage:   usage,
+	}, nil
+}
+
+func (p *ollamaGenerateProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	system, prompt := flattenMessages(req.Messages)
+
+	requestBody := OllamaCompletionBody{
+		Model:   req.Model,
+		Prompt:  prompt,
+		System:  system,
+		Stream:  true,
+		Format:  ollamaFormat(req.Options.ResponseFormat),
+		Options: ollamaOptions(req.Options),
+	}
+
+	resp, err := postStream(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, usage, err := readNDJSON(resp.Body, onDelta, func(line []byte) (ndjsonChunk, error) {
+		var chunk OllamaCompletionResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return ndjsonChunk{}, err
+		}
+		return ndjsonChunk{
+			Text:  chunk.Response,
+			Done:  chunk.Done,
+			Usage: ollamaUsage(chunk.PromptEvalCount, chunk.EvalCount),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompletionResponse{
+		Content: content,
+		Raw:     []byte(content),
+		Usage:   usage,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// openAIProvider habla el formato /v1/chat/completions de OpenAI
+type openAIProvider struct {
+	name   string
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newOpenAIProvider(baseURL string, apiKey string, client *http.Client) *openAIProvider {
+	return &openAIProvider{
+		name:   string(ProviderOpenAI),
+		url:    endpointURL(baseURL, "/chat/completions"),
+		apiKey: apiKey,
+		client: client,
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	requestBody := CompletionBody{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Options.Temperature,
+		MaxTokens:   req.Options.MaxTokens,
+		Stop:        req.Options.Stop,
+		Stream:      false,
+
+		ResponseFormat: openAIFormat(req.Options.ResponseFormat),
+		Tools:          openAITools(req.Tools),
+	}
+
+	bodyBytes, err := postJSON(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var completionResponse ChatCompletionResponse
+	if err := json.Unmarshal(bodyBytes, &completionResponse); err != nil {
+		return nil, fmt.Errorf("fallo al decodificar la respuesta de %s: %w", p.name, err)
+	}
+
+	if len(completionResponse.Choices) == 0 {
+		return nil, fmt.Errorf("no se encontró contenido válido en la respuesta de %s", p.name)
+	}
+
+	message := completionResponse.Choices[0].Message
+	return &CompletionResponse{
+		Content:   message.Content,
+		ToolCalls: withToolCallIDs(message.ToolCalls),
+		Raw:       bodyBytes,
+		Usage:     completionResponse.Usage.toUsage(),
+	}, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	requestBody := CompletionBody{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Options.Temperature,
+		MaxTokens:   req.Options.MaxTokens,
+		Stop:        req.Options.Stop,
+		Stream:      true,
+
+		ResponseFormat: openAIFormat(req.Options.ResponseFormat),
+		StreamOptions:  &openAIStreamOptions{IncludeUsage: true},
+	}
+
+	resp, err := postStream(ctx, p.client, p.url, p.apiKey, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, usage, err := readSSE(resp.Body, onDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompletionResponse{
+		Content: content,
+		Raw:     []byte(content),
+		Usage:   usage,
+	}, nil
+}
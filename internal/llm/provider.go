@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ProviderType identifica el protocolo que habla el servidor del modelo
+type ProviderType string
+
+const (
+	ProviderOpenAI         ProviderType = "openai"
+	ProviderOllamaChat     ProviderType = "ollama_chat"
+	ProviderOllamaGenerate ProviderType = "ollama_generate"
+	ProviderLMStudio       ProviderType = "lmstudio"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Provider es la interfaz común para cualquier servidor de modelos de lenguaje
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+}
+
+// ProviderConfig contiene lo necesario para construir un Provider
+type ProviderConfig struct {
+	Type    ProviderType
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+
+	// Fallbacks son los proveedores y modelos que se prueban, en orden, cuando este no está disponible
+	Fallbacks []FallbackConfig
+	// Cache responde las solicitudes repetidas desde la caché configurada con ConfigureCache
+	Cache bool
+}
+
+// NewProvider construye el Provider correspondiente al tipo configurado. Cada solicitud se
+// reintenta ante 429/5xx y pasa por el circuit breaker de su endpoint; si hay Fallbacks, el
+// Provider devuelto recorre la cadena cuando el primario no está disponible. Con Cache, las
+// solicitudes repetidas se responden desde la caché antes de llegar al servidor.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	primary, err := newResilientFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider StreamingProvider = primary
+	if len(cfg.Fallbacks) > 0 {
+		chain := &fallbackProvider{entries: []fallbackEntry{{provider: primary}}}
+		for i, fallback := range cfg.Fallbacks {
+			provider, err := newResilientFromConfig(fallback.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("respaldo %d: %w", i+1, err)
+			}
+			chain.entries = append(chain.entries, fallbackEntry{provider: provider, model: fallback.Model})
+		}
+		provider = chain
+	}
+
+	if cfg.Cache {
+		return &cachingProvider{inner: provider, provider: string(cfg.Type) + " " + endpointKey(cfg)}, nil
+	}
+	return provider, nil
+}
+
+// newResilientFromConfig construye el proveedor base y lo envuelve con reintentos y circuit breaker
+func newResilientFromConfig(cfg ProviderConfig) (*resilientProvider, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var provider Provider
+	switch cfg.Type {
+	case ProviderOpenAI:
+		provider = newOpenAIProvider(cfg.BaseURL, cfg.APIKey, client)
+	case ProviderOllamaChat:
+		provider = newOllamaChatProvider(cfg.BaseURL, cfg.APIKey, client)
+	case ProviderOllamaGenerate:
+		provider = newOllamaGenerateProvider(cfg.BaseURL, cfg.APIKey, client)
+	case ProviderLMStudio:
+		provider = newLMStudioProvider(cfg.BaseURL, cfg.APIKey, client)
+	default:
+		return nil, fmt.Errorf("tipo de proveedor LLM desconocido: %q", cfg.Type)
+	}
+
+	return newResilientProvider(provider, endpointKey(cfg)), nil
+}
+
+// endpointKey identifica el servidor al que apunta la configuración. Los proveedores que hablan
+// con el mismo servidor comparten circuit breaker (por ejemplo, ollama_chat y ollama_generate).
+func endpointKey(cfg ProviderConfig) string {
+	switch cfg.Type {
+	case ProviderOllamaChat, ProviderOllamaGenerate:
+		return ollamaBaseURL(cfg.BaseURL)
+	case ProviderLMStudio:
+		if cfg.BaseURL == "" {
+			return lmStudioDefaultURL
+		}
+	}
+	return strings.TrimRight(cfg.BaseURL, "/")
+}
+
+// providerConfigFromEnv lee <prefix>_PROVIDER (ollama_chat por defecto), <prefix>_BASE_URL (cae en
+// LLM_BASE_URL) y <prefix>_API_KEY_REF, el nombre de la variable de entorno con la API key. Lo
+// comparten los modelos auxiliares que no dependen de un bot: embeddings, resúmenes, moderación y
+// traducciones.
+func providerConfigFromEnv(prefix string) ProviderConfig {
+	providerType := ProviderType(os.Getenv(prefix + "_PROVIDER"))
+	if providerType == "" {
+		providerType = ProviderOllamaChat
+	}
+
+	baseURL := os.Getenv(prefix + "_BASE_URL")
+	if baseURL == "" {
+		baseURL = os.Getenv("LLM_BASE_URL")
+	}
+
+	var apiKey string
+	if ref := os.Getenv(prefix + "_API_KEY_REF"); ref != "" {
+		apiKey = os.Getenv(ref)
+	}
+
+	return ProviderConfig{Type: providerType, BaseURL: baseURL, APIKey: apiKey}
+}
+
+// IsValidProviderType indica si el tipo de proveedor está soportado
+func IsValidProviderType(t ProviderType) bool {
+	switch t {
+	case ProviderOpenAI, ProviderOllamaChat, ProviderOllamaGenerate, ProviderLMStudio:
+		return true
+	}
+	return false
+}
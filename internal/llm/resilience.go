@@ -0,0 +1,354 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxAttempts es la cantidad de intentos por solicitud (el primero más los reintentos)
+	maxAttempts = 3
+	// retryBaseDelay y retryMaxDelay acotan la espera exponencial entre reintentos
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+
+	// breakerFailureThreshold es la cantidad de solicitudes fallidas seguidas, cada una tras agotar
+	// sus reintentos, que abre el circuito de un endpoint
+	breakerFailureThreshold = 5
+	// breakerOpenDuration es el tiempo que un circuito abierto rechaza solicitudes antes de probar de nuevo
+	breakerOpenDuration = 30 * time.Second
+)
+
+// Estados del circuit breaker de un endpoint
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen indica que el endpoint tiene el circuito abierto y no se intentó la solicitud
+var ErrCircuitOpen = errors.New("el circuito del endpoint LLM está abierto")
+
+// HTTPError es una respuesta del servidor del modelo con estado distinto de 2xx
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter es la espera indicada por el servidor en la cabecera Retry-After (0 si no la envió)
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("el LLM respondió con estado %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable indica si vale la pena repetir la solicitud (429 o 5xx)
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newHTTPError(resp *http.Response, body []byte) *HTTPError {
+	httpErr := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		httpErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return httpErr
+}
+
+// isRetryable indica si el error es un 429 o 5xx
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.Retryable()
+}
+
+// IsUnavailable indica si el error se debe a que el endpoint no está disponible (circuito abierto,
+// fallo de conexión, 429 o 5xx) y no a la solicitud en sí; en ese caso conviene probar otro proveedor.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Retryable()
+	}
+	// Sin respuesta HTTP: fallo de conexión o timeout
+	return true
+}
+
+// CallStatus clasifica el resultado de una llamada para las métricas de uso:
+// "ok", "circuit_open", "timeout", "canceled", "http_<estado>" o "error".
+func CallStatus(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return "circuit_open"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return "http_" + strconv.Itoa(httpErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	return "error"
+}
+
+// backoff devuelve la espera antes del reintento attempt (desde 1): exponencial con jitter completo,
+// o la indicada por el servidor si es mayor.
+func backoff(attempt int, err error) time.Duration {
+	delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	delay = time.Duration(rand.Int64N(int64(delay)) + 1)
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > delay {
+		delay = min(httpErr.RetryAfter, retryMaxDelay)
+	}
+	return delay
+}
+
+// CircuitBreaker deja de enviar solicitudes a un endpoint tras varios fallos seguidos. Pasado
+// breakerOpenDuration deja pasar una solicitud de prueba: si funciona se cierra, si no se vuelve a abrir.
+type CircuitBreaker struct {
+	endpoint string
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastError   string
+	lastFailure time.Time
+}
+
+// BreakerStatus es el estado de un circuit breaker para los endpoints de salud
+type BreakerStatus struct {
+	Endpoint       string
+	State          string
+	Failures       int
+	OpenedAt       time.Time
+	LastError      string
+	LastFailure    time.Time
+	RetryAvailable time.Time
+}
+
+// Allow indica si se puede enviar una solicitud al endpoint
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < breakerOpenDuration {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		// Solo una solicitud de prueba a la vez
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success registra una solicitud exitosa y cierra el circuito
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure registra un fallo del endpoint y abre el circuito si corresponde
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailure = time.Now()
+	b.probing = false
+
+	if b.state == BreakerHalfOpen || b.failures >= breakerFailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release libera la prueba de un circuito semiabierto cuando la solicitud terminó sin indicar
+// nada sobre el endpoint (por ejemplo, se canceló o el servidor rechazó la solicitud con 4xx).
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Status devuelve una copia del estado del circuito
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Endpoint:    b.endpoint,
+		State:       b.state,
+		Failures:    b.failures,
+		LastError:   b.lastError,
+		LastFailure: b.lastFailure,
+	}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+		status.RetryAvailable = b.openedAt.Add(breakerOpenDuration)
+	}
+	return status
+}
+
+// breakerRegistry comparte un circuit breaker por endpoint entre todos los bots
+type breakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+var breakers = &breakerRegistry{breakers: make(map[string]*CircuitBreaker)}
+
+func (r *breakerRegistry) get(endpoint string) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	breaker, ok := r.breakers[endpoint]
+	if !ok {
+		breaker = &CircuitBreaker{endpoint: endpoint, state: BreakerClosed}
+		r.breakers[endpoint] = breaker
+	}
+	return breaker
+}
+
+// BreakerStatuses devuelve el estado del circuito de cada endpoint usado, ordenado por endpoint
+func BreakerStatuses() []BreakerStatus {
+	breakers.mu.Lock()
+	list := make([]*CircuitBreaker, 0, len(breakers.breakers))
+	for _, breaker := range breakers.breakers {
+		list = append(list, breaker)
+	}
+	breakers.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(list))
+	for _, breaker := range list {
+		statuses = append(statuses, breaker.Status())
+	}
+	slices.SortFunc(statuses, func(a, b BreakerStatus) int {
+		return strings.Compare(a.Endpoint, b.Endpoint)
+	})
+	return statuses
+}
+
+// resilientProvider envuelve un Provider con reintentos ante 429/5xx y el circuit breaker de su endpoint
+type resilientProvider struct {
+	inner   Provider
+	breaker *CircuitBreaker
+}
+
+func newResilientProvider(inner Provider, endpoint string) *resilientProvider {
+	return &resilientProvider{inner: inner, breaker: breakers.get(endpoint)}
+}
+
+func (p *resilientProvider) Name() string {
+	return p.inner.Name()
+}
+
+func (p *resilientProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	return p.do(ctx, func() (*CompletionResponse, bool, error) {
+		completion, err := p.inner.Complete(ctx, req)
+		return completion, true, err
+	}, req.Model)
+}
+
+// Stream reintenta solo mientras no se haya entregado ningún fragmento. Si el proveedor no
+// soporta streaming, entrega la respuesta completa como un único fragmento.
+func (p *resilientProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error) {
+	streamer, ok := p.inner.(StreamingProvider)
+	if !ok {
+		completion, err := p.Complete(ctx, req)
+		if err == nil && completion.Content != "" {
+			onDelta(completion.Content)
+		}
+		return completion, err
+	}
+
+	return p.do(ctx, func() (*CompletionResponse, bool, error) {
+		sent := false
+		completion, err := streamer.Stream(ctx, req, func(delta string) {
+			sent = true
+			onDelta(delta)
+		})
+		return completion, !sent, err
+	}, req.Model)
+}
+
+// do ejecuta attempt con reintentos. attempt devuelve además si la solicitud se puede repetir.
+// El circuit breaker cuenta la solicitud una sola vez, con el resultado del último intento.
+func (p *resilientProvider) do(ctx context.Context, attempt func() (*CompletionResponse, bool, error), model string) (*CompletionResponse, error) {
+	if !p.breaker.Allow() {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, p.breaker.endpoint)
+	}
+
+	for i := 1; ; i++ {
+		completion, repeatable, err := attempt()
+		switch {
+		case err == nil:
+			p.breaker.Success()
+			completion.Model = model
+			return completion, nil
+		case ctx.Err() != nil || !IsUnavailable(err):
+			p.breaker.Release()
+			return nil, err
+		}
+
+		if !repeatable || !isRetryable(err) || i >= maxAttempts {
+			p.breaker.Failure(err)
+			return nil, err
+		}
+
+		delay := backoff(i, err)
+		log.Printf("LLM: %s falló (%v); reintento %d/%d en %s", p.breaker.endpoint, err, i, maxAttempts-1, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.breaker.Release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxStreamLine = 1024 * 1024
+
+// StreamingProvider es un Provider capaz de entregar la respuesta en fragmentos.
+// onDelta se invoca con cada fragmento de texto en el orden en que llega.
+type StreamingProvider interface {
+	Provider
+	Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) (*CompletionResponse, error)
+}
+
+// postStream envía la solicitud y devuelve la respuesta abierta para leerla en fragmentos
+func postStream(ctx context.Context, client *http.Client, url string, apiKey string, body any) (*http.Response, error) {
+	req, err := newJSONRequest(ctx, url, apiKey, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fallo al enviar la solicitud: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newHTTPError(resp, bodyBytes)
+	}
+
+	return resp, nil
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	return scanner
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// readSSE consume un flujo Server-Sent Events con el formato de OpenAI. El consumo de tokens
+// llega en el último fragmento cuando se pide con stream_options.include_usage.
+func readSSE(r io.Reader, onDelta func(string)) (string, Usage, error) {
+	var full strings.Builder
+	var usage Usage
+	scanner := newLineScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return full.String(), usage, fmt.Errorf("fragmento SSE inválido: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toUsage()
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			full.WriteString(choice.Delta.Content)
+			onDelta(choice.Delta.Content)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return full.String(), usage, fmt.Errorf("fallo al leer el flujo SSE: %w", err)
+	}
+
+	return full.String(), usage, nil
+}
+
+// ndjsonChunk es un fragmento ya decodificado de un flujo NDJSON
+type ndjsonChunk struct {
+	Text string
+	Done bool
+	// Usage solo viene informado en el fragmento final
+	Usage Usage
+}
+
+// readNDJSON consume un flujo de objetos JSON separados por saltos de línea (formato Ollama).
+// extract decodifica cada línea; el consumo de tokens se toma del fragmento final.
+func readNDJSON(r io.Reader, onDelta func(string), extract func(line []byte) (ndjsonChunk, error)) (string, Usage, error) {
+	var full strings.Builder
+	var usage Usage
+	scanner := newLineScanner(r)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		chunk, err := extract(line)
+		if err != nil {
+			return full.String(), usage, fmt.Errorf("fragmento NDJSON inválido: %w", err)
+		}
+
+		if chunk.Text != "" {
+			full.WriteString(chunk.Text)
+			onDelta(chunk.Text)
+		}
+
+		if chunk.Done {
+			usage = chunk.Usage
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return full.String(), usage, fmt.Errorf("fallo al leer el flujo NDJSON: %w", err)
+	}
+
+	return full.String(), usage, nil
+}
@@ -0,0 +1,24 @@
+package llm
+
+import "os"
+
+// SummaryConfig es el modelo con el que se generan los resúmenes de grupo bajo demanda y los
+// digests diarios. No depende de ningún bot.
+type SummaryConfig struct {
+	Provider ProviderConfig
+	Model    string
+}
+
+// Enabled indica si hay un modelo de resúmenes configurado
+func (c SummaryConfig) Enabled() bool {
+	return c.Model != ""
+}
+
+// SummaryConfigFromEnv lee la configuración de resúmenes del entorno. SUMMARIES_MODEL vacío
+// desactiva los resúmenes; la URL base cae en LLM_BASE_URL si no se indica otra.
+func SummaryConfigFromEnv() SummaryConfig {
+	return SummaryConfig{
+		Provider: providerConfigFromEnv("SUMMARIES"),
+		Model:    os.Getenv("SUMMARIES_MODEL"),
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultContextWindow se usa cuando el modelo no aparece en knownContextWindows
+const DefaultContextWindow = 8192
+
+// messageOverheadTokens aproxima los tokens de formato que cada mensaje agrega (rol, separadores)
+const messageOverheadTokens = 4
+
+// knownContextWindows relaciona prefijos de nombre de modelo con su ventana de contexto en tokens.
+// Se elige el prefijo más largo que coincida.
+var knownContextWindows = map[string]int{
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            128000,
+	"o3":            200000,
+	"llama2":        4096,
+	"llama3":        8192,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+	"llama3.3":      131072,
+	"mistral":       32768,
+	"mixtral":       32768,
+	"qwen2":         32768,
+	"qwen2.5":       32768,
+	"qwen3":         40960,
+	"gemma":         8192,
+	"gemma2":        8192,
+	"gemma3":        131072,
+	"phi3":          4096,
+	"phi4":          16384,
+	"deepseek":      65536,
+}
+
+// ContextWindow devuelve la ventana de contexto estimada del modelo
+func ContextWindow(model string) int {
+	name := strings.ToLower(model)
+	// Quitar prefijos de organización (ej. "meta-llama/llama3") y etiquetas de Ollama (ej. "llama3:70b")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.ReplaceAll(name, "-", "")
+
+	best, window := "", DefaultContextWindow
+	for prefix, size := range knownContextWindows {
+		normalized := strings.ReplaceAll(prefix, "-", "")
+		if strings.HasPrefix(name, normalized) && len(normalized) > len(best) {
+			best, window = normalized, size
+		}
+	}
+	return window
+}
+
+// EstimateTokens aproxima la cantidad de tokens de un texto (~4 caracteres por token).
+// Es una cota conservadora pensada para presupuestar el prompt, no un tokenizador exacto.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// EstimateMessagesTokens aproxima los tokens de una lista de mensajes de chat
+func EstimateMessagesTokens(messages []ChatMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += EstimateTokens(m.Content) + messageOverheadTokens
+	}
+	return total
+}
+
+// ChunkByTokens agrupa los elementos, en orden, en bloques cuyo texto no supera el presupuesto de
+// tokens. Un elemento que por sí solo lo supera queda en un bloque propio.
+func ChunkByTokens[T any](items []T, budget int, text func(T) string) [][]T {
+	var chunks [][]T
+	start, used := 0, 0
+	for i, item := range items {
+		cost := EstimateTokens(text(item)) + 1
+		if used+cost > budget && i > start {
+			chunks = append(chunks, items[start:i])
+			start, used = i, 0
+		}
+		used += cost
+	}
+	if start < len(items) {
+		chunks = append(chunks, items[start:])
+	}
+	return chunks
+}
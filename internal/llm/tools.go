@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Tool describe una función que el modelo puede pedir que se ejecute
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters es el JSON schema de los argumentos
+	Parameters json.RawMessage
+}
+
+// ToolCall es una llamada a una herramienta pedida por el modelo
+type ToolCall struct {
+	ID   string
+	Name string
+	// Arguments es el objeto JSON con los argumentos
+	Arguments json.RawMessage
+}
+
+// openAITool es el elemento de tools en OpenAI; Ollama acepta el mismo formato en /api/chat
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// openAITools convierte las herramientas neutrales al formato de la API
+func openAITools(tools []Tool) []openAITool {
+	if len(tools) == 0 {
+		return nil
+	}
+	result := make([]openAITool, 0, len(tools))
+	for _, t := range tools {
+		result = append(result, openAITool{
+			Type:     "function",
+			Function: openAIToolFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+	return result
+}
+
+// toolCallJSON es una llamada en el formato de OpenAI: los argumentos van como texto JSON
+type toolCallJSON struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// MarshalJSON serializa la llamada como la espera OpenAI (arguments como string)
+func (c ToolCall) MarshalJSON() ([]byte, error) {
+	var call toolCallJSON
+	call.ID = c.ID
+	call.Type = "function"
+	call.Function.Name = c.Name
+
+	arguments, err := json.Marshal(string(c.arguments()))
+	if err != nil {
+		return nil, err
+	}
+	call.Function.Arguments = arguments
+	return json.Marshal(call)
+}
+
+// UnmarshalJSON acepta los argumentos como texto JSON (OpenAI) o como objeto (Ollama)
+func (c *ToolCall) UnmarshalJSON(data []byte) error {
+	var call toolCallJSON
+	if err := json.Unmarshal(data, &call); err != nil {
+		return err
+	}
+
+	c.ID = call.ID
+	c.Name = call.Function.Name
+	c.Arguments = call.Function.Arguments
+
+	var text string
+	if err := json.Unmarshal(call.Function.Arguments, &text); err == nil {
+		c.Arguments = json.RawMessage(text)
+	}
+	return nil
+}
+
+// arguments devuelve los argumentos o un objeto vacío si el modelo no los indicó
+func (c ToolCall) arguments() json.RawMessage {
+	if len(c.Arguments) == 0 || string(c.Arguments) == "null" {
+		return json.RawMessage("{}")
+	}
+	return c.Arguments
+}
+
+// withToolCallIDs completa los ID que el servidor no informa (Ollama no los envía)
+func withToolCallIDs(calls []ToolCall) []ToolCall {
+	for i := range calls {
+		if calls[i].ID == "" {
+			calls[i].ID = "call_" + strconv.Itoa(i+1)
+		}
+	}
+	return calls
+}
+
+// ollamaChatMessage es un mensaje de /api/chat: las llamadas llevan los argumentos como objeto y
+// las respuestas de herramientas se identifican por nombre
+type ollamaChatMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function ollamaToolFunction `json:"function"`
+}
+
+type ollamaToolFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ollamaMessages convierte la conversación al formato de /api/chat
+func ollamaMessages(messages []ChatMessage) []ollamaChatMessage {
+	names := make(map[string]string)
+	result := make([]ollamaChatMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := ollamaChatMessage{Role: m.Role, Content: m.Content}
+		for _, call := range m.ToolCalls {
+			names[call.ID] = call.Name
+			msg.ToolCalls = append(msg.ToolCalls, ollamaToolCall{
+				Function: ollamaToolFunction{Name: call.Name, Arguments: call.arguments()},
+			})
+		}
+		if m.ToolCallID != "" {
+			msg.ToolName = names[m.ToolCallID]
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// SupportsTools indica si el proveedor admite llamadas a herramientas (/api/generate no las admite)
+func SupportsTools(t ProviderType) bool {
+	switch t {
+	case ProviderOpenAI, ProviderLMStudio, ProviderOllamaChat:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package llm
+
+import "os"
+
+// TranslationConfig es el modelo con el que se traducen los mensajes de los grupos con la
+// traducción habilitada. No depende de ningún bot.
+type TranslationConfig struct {
+	Provider ProviderConfig
+	Model    string
+}
+
+// Enabled indica si hay un modelo de traducción configurado
+func (c TranslationConfig) Enabled() bool {
+	return c.Model != ""
+}
+
+// TranslationConfigFromEnv lee la configuración de traducción del entorno. TRANSLATION_MODEL
+// vacío desactiva las traducciones; la URL base cae en LLM_BASE_URL si no se indica otra.
+func TranslationConfigFromEnv() TranslationConfig {
+	return TranslationConfig{
+		Provider: providerConfigFromEnv("TRANSLATION"),
+		Model:    os.Getenv("TRANSLATION_MODEL"),
+	}
+}
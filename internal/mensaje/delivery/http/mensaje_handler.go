@@ -3,6 +3,7 @@ package http
 import (
 	"chatvis-chat/internal/domain"
 	"chatvis-chat/internal/pkg"
+	"errors"
 	"log"
 	"time"
 
@@ -10,12 +11,14 @@ import (
 )
 
 type MensajeHandler struct {
-	MUsecase domain.MensajeUseCase
+	MUsecase   domain.MensajeUseCase
+	Traduccion domain.TraduccionUseCase
 }
 
-func NewMensajeHandler(group fiber.Router, mu domain.MensajeUseCase) {
+func NewMensajeHandler(group fiber.Router, mu domain.MensajeUseCase, tu domain.TraduccionUseCase) {
 	handler := &MensajeHandler{
-		MUsecase: mu,
+		MUsecase:   mu,
+		Traduccion: tu,
 	}
 
 	group.Get("/group/:id", handler.GetMensajesByChatID)
@@ -45,22 +48,9 @@ func (h *MensajeHandler) GetMensajesByChatID(c *fiber.Ctx) error {
 		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener mensajes", "Error parametro", err.Error())
 	}
 
-	fechaInicioStr := c.Query("fechaInicio", "")
-	fechaFinStr := c.Query("fechaFin", "")
-
-	var fechaInicio, fechaFin time.Time
-	if fechaInicioStr != "" {
-		fechaInicio, err = time.Parse(time.RFC3339, fechaInicioStr)
-		if err != nil {
-			return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener mensajes", "Error parametro", "La fecha de inicio debe estar en formato RFC3339")
-		}
-	}
-
-	if fechaFinStr != "" {
-		fechaFin, err = time.Parse(time.RFC3339, fechaFinStr)
-		if err != nil {
-			return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener mensajes", "Error parametro", "La fecha de fin debe estar en formato RFC3339")
-		}
+	fechaInicio, fechaFin, err := pkg.ValidateQueryRangoFechas(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener mensajes", "Error parametro", err.Error())
 	}
 
 	log.Println("Grupo ID:", grupoId, "Filtro:", fechaInicio.Format(time.RFC3339), fechaFin.Format(time.RFC3339))
@@ -84,6 +74,13 @@ func (h *MensajeHandler) GetMensajesByChatClave(c *fiber.Ctx) error {
 		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener mensajes", "Error interno", err.Error())
 	}
 
+	// Sin traducción los mensajes se devuelven igual, solo con el original
+	if userId, ok := pkg.GetUserId(c); ok && h.Traduccion != nil {
+		if err := h.Traduccion.TraducirMensajes(userId, clave, mensajes); err != nil {
+			log.Printf("Error al traducir los mensajes del grupo %s: %v", clave, err)
+		}
+	}
+
 	return pkg.ResponseJson(c, fiber.StatusOK, "Mensajes obtenidos correctamente", "", mensajes)
 }
 
@@ -94,6 +91,10 @@ func (h *MensajeHandler) CreateMensaje(c *fiber.Ctx) error {
 	}
 
 	newMensaje, err := h.MUsecase.Create(&mensaje)
+	var moderado *domain.ModeracionError
+	if errors.As(err, &moderado) {
+		return responderModeracion(c, moderado.Resultado)
+	}
 	if err != nil {
 		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al crear mensaje", "Error interno", err.Error())
 	}
@@ -112,9 +113,23 @@ func (h *MensajeHandler) UpdateMensaje(c *fiber.Ctx) error {
 		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar mensaje", "Error de parseo", err.Error())
 	}
 
-	if err := h.MUsecase.Update(id, &mensaje); err != nil {
+	err = h.MUsecase.Update(id, &mensaje)
+	var moderado *domain.ModeracionError
+	if errors.As(err, &moderado) {
+		return responderModeracion(c, moderado.Resultado)
+	}
+	if err != nil {
 		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al actualizar mensaje", "Error interno", err.Error())
 	}
 
 	return pkg.ResponseJson(c, fiber.StatusOK, "Mensaje actualizado correctamente", "", mensaje)
 }
+
+// responderModeracion responde 400 si la moderación bloqueó el mensaje o 202 si quedó retenido
+// para revisión; en ambos casos el mensaje no se guardó
+func responderModeracion(c *fiber.Ctx, resultado domain.ResultadoModeracion) error {
+	if resultado.Decision == domain.ModeracionMarcar {
+		return pkg.ResponseJson(c, fiber.StatusAccepted, "Mensaje retenido para revisión", "", resultado)
+	}
+	return pkg.ResponseJson(c, fiber.StatusBadRequest, "Mensaje bloqueado por moderación", "Error parametro", resultado)
+}
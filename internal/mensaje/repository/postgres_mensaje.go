@@ -5,6 +5,7 @@ import (
 	"chatvis-chat/internal/models"
 	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -46,6 +47,15 @@ func mapGormToDomainMensaje(gormMsg *models.Mensajes) *domain.Mensaje {
 		domainMsg.Respuesta = mapGormToDomainMensaje(gormMsg.Respuesta)
 	}
 
+	for _, m := range gormMsg.Menciones {
+		domainMsg.Menciones = append(domainMsg.Menciones, domain.Mencion{
+			Id:        m.Id,
+			MensajeId: m.MensajeId,
+			UsuarioId: m.UsuarioId,
+			Apodo:     m.Apodo,
+		})
+	}
+
 	return domainMsg
 }
 
@@ -53,7 +63,7 @@ func mapDomainToGormMensaje(domainMsg *domain.Mensaje) *models.Mensajes {
 	if domainMsg == nil {
 		return nil
 	}
-	return &models.Mensajes{
+	gormMsg := &models.Mensajes{
 		Id:         domainMsg.Id,
 		Contenido:  domainMsg.Contenido,
 		Fecha:      domainMsg.Fecha,
@@ -61,6 +71,15 @@ func mapDomainToGormMensaje(domainMsg *domain.Mensaje) *models.Mensajes {
 		UsuarioId:  domainMsg.UsuarioId,
 		ResponseId: domainMsg.ResponseId,
 	}
+
+	for _, m := range domainMsg.Menciones {
+		gormMsg.Menciones = append(gormMsg.Menciones, models.Menciones{
+			UsuarioId: m.UsuarioId,
+			Apodo:     m.Apodo,
+		})
+	}
+
+	return gormMsg
 }
 
 func (r *postgresMensajeRepository) GetAll() ([]domain.Mensaje, error) {
@@ -81,7 +100,7 @@ func (r *postgresMensajeRepository) GetAll() ([]domain.Mensaje, error) {
 func (r *postgresMensajeRepository) GetById(id uint64) (*domain.Mensaje, error) {
 	var gormMensaje models.Mensajes
 
-	if err := r.db.First(&gormMensaje, id).Error; err != nil {
+	if err := r.db.Preload("Menciones").First(&gormMensaje, id).Error; err != nil {
 		return nil, err
 	}
 	return mapGormToDomainMensaje(&gormMensaje), nil
@@ -94,8 +113,29 @@ func (r *postgresMensajeRepository) GetAllByGrupoId(grupoId uint64, startDate, e
 		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "nombre", "apodo")
 		}).
+		Preload("Menciones").
 		Where("id_grupo = ?", grupoId)
+	query = filtrarFechas(query, startDate, endDate)
+
+	err := query.Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(gormMensajes) == 0 {
+		return []domain.Mensaje{}, nil
+	}
 
+	var mensajes []domain.Mensaje
+	for _, gm := range gormMensajes {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gm))
+	}
+
+	return mensajes, nil
+}
+
+// filtrarFechas limita la consulta a los días completos entre las fechas (cero = sin límite)
+func filtrarFechas(query *gorm.DB, startDate, endDate time.Time) *gorm.DB {
 	if !startDate.IsZero() {
 		fechaInicio := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
 		query = query.Where("fecha >= ?", fechaInicio)
@@ -108,18 +148,46 @@ func (r *postgresMensajeRepository) GetAllByGrupoId(grupoId uint64, startDate, e
 		log.Printf("Agregado filtro fin: %v", fechaFin)
 	}
 
-	err := query.Find(&gormMensajes).Error
-	if err != nil {
-		return nil, err
+	return query
+}
+
+func (r *postgresMensajeRepository) ContarPorFechas(grupoId uint64, startDate, endDate time.Time) (int, uint64, error) {
+	var resultado struct {
+		Cantidad int
+		UltimoId uint64
 	}
 
-	if len(gormMensajes) == 0 {
-		return []domain.Mensaje{}, nil
+	query := r.db.Model(&models.Mensajes{}).
+		Select("COUNT(*) AS cantidad, COALESCE(MAX(id), 0) AS ultimo_id").
+		Where("id_grupo = ?", grupoId)
+	if err := filtrarFechas(query, startDate, endDate).Scan(&resultado).Error; err != nil {
+		return 0, 0, err
 	}
 
-	var mensajes []domain.Mensaje
-	for _, gm := range gormMensajes {
-		mensajes = append(mensajes, *mapGormToDomainMensaje(&gm))
+	return resultado.Cantidad, resultado.UltimoId, nil
+}
+
+func (r *postgresMensajeRepository) GetUltimosPorFechas(grupoId uint64, startDate, endDate time.Time, limite int) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	query := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo")
+		}).
+		Preload("Menciones").
+		Where("id_grupo = ?", grupoId)
+
+	err := filtrarFechas(query, startDate, endDate).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for i := len(gormMensajes) - 1; i >= 0; i-- {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gormMensajes[i]))
 	}
 
 	return mensajes, nil
@@ -142,6 +210,7 @@ func (r *postgresMensajeRepository) GetAllByGrupoClave(clave string) ([]domain.M
 		Preload("Respuesta.Usuario", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "nombre", "apodo")
 		}).
+		Preload("Menciones").
 		Where("id_grupo = ?", grupo.Id).
 		Find(&gormMensajes).Error
 
@@ -163,6 +232,10 @@ func (r *postgresMensajeRepository) Create(mensaje *domain.Mensaje) (*domain.Men
 		return nil, err
 	}
 	mensaje.Id = gormMensaje.Id
+	for i := range mensaje.Menciones {
+		mensaje.Menciones[i].Id = gormMensaje.Menciones[i].Id
+		mensaje.Menciones[i].MensajeId = gormMensaje.Id
+	}
 	return mensaje, nil
 }
 
@@ -197,6 +270,9 @@ func (r *postgresMensajeRepository) GetNuevosMensajesParaIA(aiID uint64, grupoID
 	r.db.Where("id_usuario = ? AND id_grupo = ?", aiID, grupoID).First(&checkpoint)
 
 	err := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo", "is_llm")
+		}).
 		Where("id_grupo = ? AND id > ? AND id_usuario != ?",
 			grupoID, checkpoint.UltimoMensajeId, aiID).
 		Order("id asc").
@@ -210,12 +286,175 @@ func (r *postgresMensajeRepository) GetNuevosMensajesParaIA(aiID uint64, grupoID
 	return mensajes, err
 }
 
-func (r *postgresMensajeRepository) ActualizarPuntoControl(aiID uint64, grupoID uint64, ultimoID uint64) error {
-	checkpoint := models.ModelSyncCheckpoint{
-		UsuarioId:       aiID,
-		GrupoId:         grupoID,
-		UltimoMensajeId: ultimoID,
-		UpdatedAt:       time.Now(),
+// GetUltimosMensajes devuelve los últimos mensajes del grupo en orden cronológico
+func (r *postgresMensajeRepository) GetUltimosMensajes(grupoID uint64, limite int) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	err := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo", "is_llm")
+		}).
+		Where("id_grupo = ?", grupoID).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for i := len(gormMensajes) - 1; i >= 0; i-- {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gormMensajes[i]))
+	}
+
+	return mensajes, nil
+}
+
+// GetUltimoMensajeId devuelve el ID del mensaje más reciente del grupo que no es del usuario indicado (0 si no hay)
+func (r *postgresMensajeRepository) GetUltimoMensajeId(grupoID uint64, excluirUsuarioID uint64) (uint64, error) {
+	var ultimoID uint64
+
+	err := r.db.Model(&models.Mensajes{}).
+		Select("COALESCE(MAX(id), 0)").
+		Where("id_grupo = ? AND id_usuario != ?", grupoID, excluirUsuarioID).
+		Scan(&ultimoID).Error
+
+	return ultimoID, err
+}
+
+// GetMensajesRango devuelve, en orden cronológico, los últimos `limite` mensajes del grupo
+// con ID en el intervalo abierto (desdeID, hastaID)
+func (r *postgresMensajeRepository) GetMensajesRango(grupoID uint64, desdeID uint64, hastaID uint64, limite int) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	err := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo", "is_llm")
+		}).
+		Where("id_grupo = ? AND id > ? AND id < ?", grupoID, desdeID, hastaID).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for i := len(gormMensajes) - 1; i >= 0; i-- {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gormMensajes[i]))
+	}
+
+	return mensajes, nil
+}
+
+func (r *postgresMensajeRepository) GetByIds(ids []uint64) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	if len(ids) == 0 {
+		return []domain.Mensaje{}, nil
+	}
+
+	err := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo", "is_llm")
+		}).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for i := range gormMensajes {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gormMensajes[i]))
+	}
+
+	return mensajes, nil
+}
+
+// BuscarTexto busca sin distinguir mayúsculas; los comodines de LIKE del texto se escapan
+func (r *postgresMensajeRepository) BuscarTexto(grupoID uint64, texto string, limite int) ([]domain.Mensaje, error) {
+	var gormMensajes []models.Mensajes
+
+	patron := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(texto) + "%"
+
+	err := r.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "nombre", "apodo", "is_llm")
+		}).
+		Where("id_grupo = ? AND contenido ILIKE ?", grupoID, patron).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormMensajes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mensajes := make([]domain.Mensaje, 0, len(gormMensajes))
+	for i := range gormMensajes {
+		mensajes = append(mensajes, *mapGormToDomainMensaje(&gormMensajes[i]))
 	}
-	return r.db.Save(&checkpoint).Error
+
+	return mensajes, nil
+}
+
+func (r *postgresMensajeRepository) GetResumen(aiID uint64, grupoID uint64) (*domain.ResumenGrupo, error) {
+	var checkpoint models.ModelSyncCheckpoint
+
+	err := r.db.Where("id_usuario = ? AND id_grupo = ?", aiID, grupoID).First(&checkpoint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.ResumenGrupo{}, nil
+		}
+		return nil, err
+	}
+
+	return &domain.ResumenGrupo{
+		Texto:          checkpoint.Resumen,
+		HastaMensajeId: checkpoint.ResumenHastaMensajeId,
+	}, nil
+}
+
+// GuardarResumen actualiza el resumen en el checkpoint del bot. Si el bot aún no tiene checkpoint
+// en el grupo no se guarda nada: el resumen se volverá a calcular en la siguiente respuesta.
+func (r *postgresMensajeRepository) GuardarResumen(aiID uint64, grupoID uint64, resumen *domain.ResumenGrupo) error {
+	return r.db.Model(&models.ModelSyncCheckpoint{}).
+		Where("id_usuario = ? AND id_grupo = ? AND resumen_hasta_mensaje_id <= ?", aiID, grupoID, resumen.HastaMensajeId).
+		Updates(map[string]any{
+			"resumen":                  resumen.Texto,
+			"resumen_hasta_mensaje_id": resumen.HastaMensajeId,
+		}).Error
+}
+
+func (r *postgresMensajeRepository) GetPuntoControl(aiID uint64, grupoID uint64) (uint64, error) {
+	var checkpoint models.ModelSyncCheckpoint
+
+	err := r.db.Where("id_usuario = ? AND id_grupo = ?", aiID, grupoID).First(&checkpoint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return checkpoint.UltimoMensajeId, nil
+}
+
+// ActualizarPuntoControl es un compare-and-swap: solo avanza si el checkpoint sigue en anteriorID
+func (r *postgresMensajeRepository) ActualizarPuntoControl(aiID uint64, grupoID uint64, anteriorID uint64, ultimoID uint64) (bool, error) {
+	result := r.db.Exec(`
+        INSERT INTO model_sync_checkpoints (id_usuario, id_grupo, ultimo_mensaje_id, updated_at)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (id_usuario, id_grupo) DO UPDATE
+        SET ultimo_mensaje_id = EXCLUDED.ultimo_mensaje_id, updated_at = EXCLUDED.updated_at
+        WHERE model_sync_checkpoints.ultimo_mensaje_id = ?
+          AND model_sync_checkpoints.ultimo_mensaje_id < EXCLUDED.ultimo_mensaje_id`,
+		aiID, grupoID, ultimoID, time.Now(), anteriorID)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
 }
@@ -2,18 +2,25 @@ package usecase
 
 import (
 	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 )
 
 type mensajeUseCase struct {
-	repo domain.MensajeRepository
+	repo        domain.MensajeRepository
+	repoUsuario domain.UsuarioRepository
+	// moderacion revisa el contenido antes de guardarlo; nil guarda sin moderar (por ejemplo, los
+	// mensajes aprobados en la cola de moderación)
+	moderacion domain.ModeracionUseCase
 }
 
-func NewMensajeUseCase(r domain.MensajeRepository) domain.MensajeUseCase {
-	return &mensajeUseCase{repo: r}
+func NewMensajeUseCase(r domain.MensajeRepository, ru domain.UsuarioRepository, modu domain.ModeracionUseCase) domain.MensajeUseCase {
+	return &mensajeUseCase{repo: r, repoUsuario: ru, moderacion: modu}
 }
 
 func (s *mensajeUseCase) GetAll() ([]domain.Mensaje, error) {
@@ -61,7 +68,12 @@ func (s *mensajeUseCase) Create(mensaje *domain.Mensaje) (*domain.Mensaje, error
 		return nil, errors.New("el contenido del mensaje no puede estar vacío")
 	}
 
+	if err := s.moderar(mensaje); err != nil {
+		return nil, err
+	}
+
 	mensaje.Fecha = time.Now()
+	mensaje.Menciones = s.resolveMenciones(mensaje)
 
 	mensajeCreado, err := s.repo.Create(mensaje)
 	if err != nil {
@@ -71,6 +83,57 @@ func (s *mensajeUseCase) Create(mensaje *domain.Mensaje) (*domain.Mensaje, error
 	return mensajeCreado, nil
 }
 
+// moderar pasa el mensaje por la cadena de moderación. Si no se permite devuelve un
+// *domain.ModeracionError con la decisión.
+func (s *mensajeUseCase) moderar(mensaje *domain.Mensaje) error {
+	if s.moderacion == nil {
+		return nil
+	}
+
+	resultado, err := s.moderacion.Moderar(context.Background(), mensaje)
+	if err != nil {
+		return fmt.Errorf("error al moderar el mensaje: %w", err)
+	}
+	if resultado.Decision != domain.ModeracionPermitir {
+		return &domain.ModeracionError{Resultado: *resultado}
+	}
+	return nil
+}
+
+// resolveMenciones convierte los @apodo del contenido en menciones a integrantes del grupo.
+// Un fallo al consultar los integrantes no impide guardar el mensaje.
+func (s *mensajeUseCase) resolveMenciones(mensaje *domain.Mensaje) []domain.Mencion {
+	apodos := pkg.ExtractMentions(mensaje.Contenido)
+	if len(apodos) == 0 {
+		return nil
+	}
+
+	miembros, err := s.repoUsuario.GetAllByGrupoId(mensaje.GrupoId)
+	if err != nil {
+		log.Printf("Error al obtener los integrantes del grupo %d para resolver menciones: %v", mensaje.GrupoId, err)
+		return nil
+	}
+
+	porApodo := make(map[string]domain.Usuario, len(miembros))
+	for _, miembro := range miembros {
+		porApodo[strings.ToLower(miembro.Apodo)] = miembro
+	}
+
+	var menciones []domain.Mencion
+	for _, apodo := range apodos {
+		miembro, ok := porApodo[apodo]
+		if !ok || miembro.Id == mensaje.UsuarioId {
+			continue
+		}
+		menciones = append(menciones, domain.Mencion{
+			UsuarioId: miembro.Id,
+			Apodo:     miembro.Apodo,
+		})
+	}
+
+	return menciones
+}
+
 func (s *mensajeUseCase) Update(id uint64, mensaje *domain.Mensaje) error {
 	if id <= 0 {
 		return errors.New("el ID del mensaje debe ser mayor que cero")
@@ -94,6 +157,17 @@ func (s *mensajeUseCase) Update(id uint64, mensaje *domain.Mensaje) error {
 		return fmt.Errorf("no se puede actualizar el mensaje: ha pasado más de 1 minuto desde su creación")
 	}
 
+	err = s.moderar(&domain.Mensaje{
+		Id:         id,
+		Contenido:  mensaje.Contenido,
+		GrupoId:    existingMensaje.GrupoId,
+		UsuarioId:  existingMensaje.UsuarioId,
+		ResponseId: existingMensaje.ResponseId,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = s.repo.Update(id, mensaje)
 	if err != nil {
 		return fmt.Errorf("error al actualizar el mensaje: %w", err)
@@ -106,6 +180,61 @@ func (s *mensajeUseCase) GetNuevosMensajesParaIA(aiID uint64, grupoID uint64) ([
 	return s.repo.GetNuevosMensajesParaIA(aiID, grupoID)
 }
 
-func (s *mensajeUseCase) ActualizarPuntoControl(aiID uint64, grupoID uint64, ultimoID uint64) error {
-	return s.repo.ActualizarPuntoControl(aiID, grupoID, ultimoID)
+func (s *mensajeUseCase) GetUltimosMensajes(grupoID uint64, limite int) ([]domain.Mensaje, error) {
+	if limite <= 0 {
+		return nil, errors.New("el límite de mensajes debe ser mayor a cero")
+	}
+	return s.repo.GetUltimosMensajes(grupoID, limite)
+}
+
+func (s *mensajeUseCase) GetUltimoMensajeId(grupoID uint64, excluirUsuarioID uint64) (uint64, error) {
+	return s.repo.GetUltimoMensajeId(grupoID, excluirUsuarioID)
+}
+
+func (s *mensajeUseCase) GetMensajesRango(grupoID uint64, desdeID uint64, hastaID uint64, limite int) ([]domain.Mensaje, error) {
+	if limite <= 0 {
+		return nil, errors.New("el límite de mensajes debe ser mayor a cero")
+	}
+	if hastaID <= desdeID+1 {
+		return []domain.Mensaje{}, nil
+	}
+	return s.repo.GetMensajesRango(grupoID, desdeID, hastaID, limite)
+}
+
+func (s *mensajeUseCase) GetByIds(ids []uint64) ([]domain.Mensaje, error) {
+	return s.repo.GetByIds(ids)
+}
+
+func (s *mensajeUseCase) BuscarTexto(grupoID uint64, texto string, limite int) ([]domain.Mensaje, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" {
+		return nil, errors.New("el texto a buscar no puede estar vacío")
+	}
+	if limite <= 0 {
+		return nil, errors.New("el límite debe ser mayor que cero")
+	}
+	return s.repo.BuscarTexto(grupoID, texto, limite)
+}
+
+func (s *mensajeUseCase) GetResumen(aiID uint64, grupoID uint64) (*domain.ResumenGrupo, error) {
+	return s.repo.GetResumen(aiID, grupoID)
+}
+
+func (s *mensajeUseCase) GuardarResumen(aiID uint64, grupoID uint64, resumen *domain.ResumenGrupo) error {
+	if resumen == nil {
+		return errors.New("el resumen no puede ser nulo")
+	}
+	return s.repo.GuardarResumen(aiID, grupoID, resumen)
+}
+
+func (s *mensajeUseCase) GetPuntoControl(aiID uint64, grupoID uint64) (uint64, error) {
+	return s.repo.GetPuntoControl(aiID, grupoID)
+}
+
+// ActualizarPuntoControl mueve el checkpoint solo si sigue en anteriorID y el nuevo valor es mayor
+func (s *mensajeUseCase) ActualizarPuntoControl(aiID uint64, grupoID uint64, anteriorID uint64, ultimoID uint64) (bool, error) {
+	if ultimoID <= anteriorID {
+		return false, nil
+	}
+	return s.repo.ActualizarPuntoControl(aiID, grupoID, anteriorID, ultimoID)
 }
@@ -3,8 +3,9 @@ package models
 import "time"
 
 type GruposUsuarios struct {
-	IdGrupo   uint64 `json:"grupoId" gorm:"primaryKey;not null;column:id_grupo"`
-	IdUsuario uint64 `json:"usuarioId" gorm:"primaryKey;not null;column:id_usuario"`
+	IdGrupo    uint64 `json:"grupoId" gorm:"primaryKey;not null;column:id_grupo"`
+	IdUsuario  uint64 `json:"usuarioId" gorm:"primaryKey;not null;column:id_usuario"`
+	Silenciado bool   `json:"silenciado" gorm:"type:boolean;not null;default:false"`
 }
 
 type Grupos struct {
@@ -30,6 +31,8 @@ type Usuarios struct {
 	IsLlm    bool      `json:"isLlm" gorm:"type:boolean;not null;default:false"`
 	IsAdmin  bool      `json:"isAdmin" gorm:"type:boolean;not null;default:false"`
 	IsActive bool      `json:"isActive" gorm:"type:boolean;not null;default:true"`
+	// Idioma preferido para las traducciones (código ISO 639-1); vacío = sin traducción
+	Idioma string `json:"idioma" gorm:"type:varchar(10);not null;default:''"`
 
 	GrupoCreatedBy []Grupos   `json:"gruposCreatedBy" gorm:"foreignKey:CreatedById;references:Id"`
 	Grupos         []Grupos   `json:"grupos" gorm:"many2many:grupos_usuarios;foreignKey:Id;joinForeignKey:IdUsuario;References:Id;JoinReferences:IdGrupo"`
@@ -39,15 +42,26 @@ type Usuarios struct {
 type Mensajes struct {
 	Id        uint64    `json:"id" gorm:"primaryKey"`
 	Contenido string    `json:"contenido" gorm:"type:text;not null"`
-	Fecha     time.Time `json:"fecha" gorm:"type:date;not null"`
+	Fecha     time.Time `json:"fecha" gorm:"type:timestamptz;not null"`
 	GrupoId   uint64    `json:"grupoId" gorm:"not null;column:id_grupo"`
 	UsuarioId uint64    `json:"usuarioId" gorm:"not null;column:id_usuario"`
 
 	ResponseId *uint64   `json:"respuestaId,omitempty" gorm:"column:respuesta_id;default:null"`
 	Respuesta  *Mensajes `json:"respuesta,omitempty" gorm:"foreignKey:ResponseId;references:Id"`
 
-	Grupo   Grupos   `json:"grupo" gorm:"foreignKey:GrupoId;references:Id"`
-	Usuario Usuarios `json:"usuario" gorm:"foreignKey:UsuarioId;references:Id"`
+	Grupo     Grupos      `json:"grupo" gorm:"foreignKey:GrupoId;references:Id"`
+	Usuario   Usuarios    `json:"usuario" gorm:"foreignKey:UsuarioId;references:Id"`
+	Menciones []Menciones `json:"menciones" gorm:"foreignKey:MensajeId;references:Id"`
+}
+
+type Menciones struct {
+	Id        uint64 `json:"id" gorm:"primaryKey"`
+	MensajeId uint64 `json:"mensajeId" gorm:"not null;column:id_mensaje;uniqueIndex:idx_mencion_mensaje_usuario"`
+	UsuarioId uint64 `json:"usuarioId" gorm:"not null;column:id_usuario;uniqueIndex:idx_mencion_mensaje_usuario;index"`
+	Apodo     string `json:"apodo" gorm:"type:varchar(100);not null"`
+
+	Mensaje Mensajes `json:"-" gorm:"foreignKey:MensajeId;references:Id;constraint:OnDelete:CASCADE"`
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
 }
 
 type ModelSyncCheckpoint struct {
@@ -56,17 +70,367 @@ type ModelSyncCheckpoint struct {
 	UltimoMensajeId uint64    `gorm:"not null;column:ultimo_mensaje_id"`
 	UpdatedAt       time.Time `json:"updatedAt"`
 
+	// Resumen acumulado de la conversación y último mensaje que cubre
+	Resumen               string `gorm:"type:text;not null;default:''"`
+	ResumenHastaMensajeId uint64 `gorm:"not null;default:0;column:resumen_hasta_mensaje_id"`
+
 	Usuario       Usuarios `gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
 	Grupo         Grupos   `gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
 	UltimoMensaje Mensajes `gorm:"foreignKey:UltimoMensajeId;references:Id"`
 }
 
+type Bots struct {
+	Id             uint64   `json:"id" gorm:"primaryKey"`
+	UsuarioId      uint64   `json:"usuarioId" gorm:"not null;unique;column:id_usuario"`
+	BaseURL        string   `json:"baseUrl" gorm:"type:varchar(255);not null;column:base_url"`
+	ModelName      string   `json:"modelName" gorm:"type:varchar(150);not null;column:model_name"`
+	ProviderType   string   `json:"providerType" gorm:"type:varchar(50);not null;column:provider_type"`
+	APIKeyRef      string   `json:"apiKeyRef" gorm:"type:varchar(100);column:api_key_ref"`
+	Prompt         string   `json:"prompt" gorm:"type:text"`
+	PromptVersion  int      `json:"promptVersion" gorm:"not null;default:0;column:prompt_version"`
+	HistoryFormat  string   `json:"historyFormat" gorm:"type:varchar(20);not null;default:'inline';column:history_format"`
+	IsPromt        bool     `json:"isPromt" gorm:"type:boolean;not null;default:false;column:is_promt"`
+	Temperature    float64  `json:"temperature" gorm:"not null"`
+	MaxTokens      int      `json:"maxTokens" gorm:"not null;default:0;column:max_tokens"`
+	Stop           []string `json:"stop" gorm:"type:text;serializer:json"`
+	TimeoutSeconds int      `json:"timeoutSeconds" gorm:"not null;default:30;column:timeout_seconds"`
+	Workers        int      `json:"workers" gorm:"not null;default:5"`
+	DebounceMs     int      `json:"debounceMs" gorm:"not null;default:1500;column:debounce_ms"`
+	Stream         bool     `json:"stream" gorm:"type:boolean;not null;default:false"`
+	// Segundos sin actividad en el grupo tras los que el bot habla solo; 0 lo desactiva
+	IdleAfterSeconds int `json:"idleAfterSeconds" gorm:"not null;default:0;column:idle_after_seconds"`
+	// Máximo de mensajes no solicitados seguidos mientras nadie más escribe
+	MaxIdleMessages int `json:"maxIdleMessages" gorm:"not null;default:1;column:max_idle_messages"`
+	// Si es true el bot solo responde cuando lo mencionan con @apodo o responden a uno de sus mensajes
+	SoloMenciones bool `json:"soloMenciones" gorm:"type:boolean;not null;default:false;column:solo_menciones"`
+	// Ventana de contexto del modelo en tokens; 0 la estima a partir del nombre del modelo
+	ContextTokens int `json:"contextTokens" gorm:"not null;default:0;column:context_tokens"`
+	// Máximo de mensajes recientes enviados como historial
+	HistoryMessages int `json:"historyMessages" gorm:"not null;default:40;column:history_messages"`
+	// Si es true se pide al proveedor una salida JSON con el schema de respuesta
+	StructuredOutput bool `json:"structuredOutput" gorm:"type:boolean;not null;default:false;column:structured_output"`
+	// Proveedores y modelos alternativos, en orden, para cuando el principal no está disponible
+	Fallbacks []BotFallback `json:"fallbacks" gorm:"type:text;serializer:json"`
+	// Máximo de tokens por día entre todos los grupos; 0 = sin límite
+	MaxTokensDia int `json:"maxTokensDia" gorm:"not null;default:0;column:max_tokens_dia"`
+	// Mensajes anteriores relevantes recuperados por búsqueda semántica; 0 lo desactiva
+	Recuerdos int `json:"recuerdos" gorm:"not null;default:0"`
+	// Herramientas que el bot puede invocar y máximo de rondas de llamadas por respuesta
+	Herramientas         []string `json:"herramientas" gorm:"type:text;serializer:json"`
+	MaxPasosHerramientas int      `json:"maxPasosHerramientas" gorm:"not null;default:3;column:max_pasos_herramientas"`
+	// Si es true los prompts repetidos se responden desde la caché de respuestas
+	UsarCache bool      `json:"usarCache" gorm:"type:boolean;not null;default:false;column:usar_cache"`
+	IsActive  bool      `json:"isActive" gorm:"type:boolean;not null;default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Usuario Usuarios `json:"usuario" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// BotFallback es un proveedor y modelo de respaldo de un bot; se guarda como JSON en bots.fallbacks
+type BotFallback struct {
+	ProviderType string `json:"providerType"`
+	BaseURL      string `json:"baseUrl"`
+	ModelName    string `json:"modelName"`
+	APIKeyRef    string `json:"apiKeyRef"`
+}
+
+type BotPrompts struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
+	BotId     uint64    `json:"botId" gorm:"not null;column:id_bot;uniqueIndex:idx_bot_prompt_version"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_bot_prompt_version"`
+	Template  string    `json:"template" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Bot Bots `json:"-" gorm:"foreignKey:BotId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+type PoliticasTurno struct {
+	Id                 uint64    `json:"id" gorm:"primaryKey"`
+	GrupoId            uint64    `json:"grupoId" gorm:"not null;unique;column:id_grupo"`
+	MaxBotConsecutivos int       `json:"maxBotConsecutivos" gorm:"not null;column:max_bot_consecutivos"`
+	CooldownSegundos   int       `json:"cooldownSegundos" gorm:"not null;column:cooldown_segundos"`
+	RequiereHumano     bool      `json:"requiereHumano" gorm:"type:boolean;not null;default:false;column:requiere_humano"`
+	RoundRobin         bool      `json:"roundRobin" gorm:"type:boolean;not null;default:false;column:round_robin"`
+	MaxLlamadasHora    int       `json:"maxLlamadasHora" gorm:"not null;default:0;column:max_llamadas_hora"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+
+	Grupo Grupos `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// GruposIA es la configuración de los bots de un grupo (tabla grupos_ia)
+type GruposIA struct {
+	Id                uint64    `json:"id" gorm:"primaryKey"`
+	GrupoId           uint64    `json:"grupoId" gorm:"not null;unique;column:id_grupo"`
+	BotsHabilitados   []uint64  `json:"botsHabilitados" gorm:"type:text;serializer:json;column:bots_habilitados"`
+	ModoRespuesta     string    `json:"modoRespuesta" gorm:"type:varchar(20);not null;default:'siempre';column:modo_respuesta"`
+	MaxRespuestasHora int       `json:"maxRespuestasHora" gorm:"not null;default:0;column:max_respuestas_hora"`
+	Persona           string    `json:"persona" gorm:"type:text"`
+	Traduccion        bool      `json:"traduccion" gorm:"type:boolean;not null;default:false"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+
+	Grupo Grupos `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// RespuestasCacheIA es la caché persistente de respuestas de los modelos (tabla respuestas_cache_ia)
+type RespuestasCacheIA struct {
+	Clave            string    `json:"clave" gorm:"type:char(64);primaryKey"`
+	Proveedor        string    `json:"proveedor" gorm:"type:varchar(255);not null"`
+	Modelo           string    `json:"modelo" gorm:"type:varchar(100);not null"`
+	Contenido        string    `json:"contenido" gorm:"type:text;not null"`
+	PromptTokens     int       `json:"promptTokens" gorm:"not null;default:0;column:prompt_tokens"`
+	CompletionTokens int       `json:"completionTokens" gorm:"not null;default:0;column:completion_tokens"`
+	ExpiraEn         time.Time `json:"expiraEn" gorm:"not null;index;column:expira_en"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+type SalidasFallidas struct {
+	Id         uint64    `json:"id" gorm:"primaryKey"`
+	UsuarioId  uint64    `json:"usuarioId" gorm:"not null;column:id_usuario;index"`
+	GrupoId    uint64    `json:"grupoId" gorm:"not null;column:id_grupo"`
+	Salida     string    `json:"salida" gorm:"type:text;not null"`
+	Reparacion string    `json:"reparacion" gorm:"type:text"`
+	Error      string    `json:"error" gorm:"type:text;not null"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// UsosIA acumula las llamadas al modelo de un bot en un grupo por día
+type UsosIA struct {
+	Id               uint64    `json:"id" gorm:"primaryKey"`
+	UsuarioId        uint64    `json:"usuarioId" gorm:"not null;column:id_usuario;uniqueIndex:idx_uso_bot_grupo_fecha"`
+	GrupoId          uint64    `json:"grupoId" gorm:"not null;column:id_grupo;uniqueIndex:idx_uso_bot_grupo_fecha"`
+	Fecha            time.Time `json:"fecha" gorm:"type:date;not null;uniqueIndex:idx_uso_bot_grupo_fecha"`
+	Llamadas         int       `json:"llamadas" gorm:"not null;default:0"`
+	Errores          int       `json:"errores" gorm:"not null;default:0"`
+	Bloqueadas       int       `json:"bloqueadas" gorm:"not null;default:0"`
+	PromptTokens     int       `json:"promptTokens" gorm:"not null;default:0;column:prompt_tokens"`
+	CompletionTokens int       `json:"completionTokens" gorm:"not null;default:0;column:completion_tokens"`
+	TotalTokens      int       `json:"totalTokens" gorm:"not null;default:0;column:total_tokens"`
+	LatenciaTotalMs  int64     `json:"latenciaTotalMs" gorm:"not null;default:0;column:latencia_total_ms"`
+	LatenciaMaxMs    int64     `json:"latenciaMaxMs" gorm:"not null;default:0;column:latencia_max_ms"`
+	UltimoError      string    `json:"ultimoError" gorm:"type:varchar(50);not null;default:'';column:ultimo_error"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// AiInteracciones guarda cada ejecución de un bot: prompt, respuesta cruda, resultado y tiempos
+type AiInteracciones struct {
+	Id            uint64               `json:"id" gorm:"primaryKey"`
+	UsuarioId     uint64               `json:"usuarioId" gorm:"not null;column:id_usuario;index:idx_interaccion_bot_fecha"`
+	GrupoId       uint64               `json:"grupoId" gorm:"not null;column:id_grupo;index"`
+	MensajeId     *uint64              `json:"mensajeId" gorm:"column:id_mensaje"`
+	Disparo       string               `json:"disparo" gorm:"type:varchar(20);not null"`
+	Proveedor     string               `json:"proveedor" gorm:"type:varchar(50);not null"`
+	Modelo        string               `json:"modelo" gorm:"type:varchar(100);not null"`
+	Prompt        []MensajePromptIA    `json:"prompt" gorm:"type:text;serializer:json"`
+	Respuesta     string               `json:"respuesta" gorm:"type:text"`
+	Reparacion    string               `json:"reparacion" gorm:"type:text"`
+	Mensajes      []RespuestaIA        `json:"mensajes" gorm:"type:text;serializer:json"`
+	Herramientas  []LlamadaHerramienta `json:"herramientas" gorm:"type:text;serializer:json"`
+	Resultado     string               `json:"resultado" gorm:"type:varchar(20);not null;index"`
+	Error         string               `json:"error" gorm:"type:text"`
+	DuracionLlmMs int64                `json:"duracionLlmMs" gorm:"not null;default:0;column:duracion_llm_ms"`
+	DuracionMs    int64                `json:"duracionMs" gorm:"not null;default:0;column:duracion_ms"`
+	CreatedAt     time.Time            `json:"createdAt" gorm:"index:idx_interaccion_bot_fecha"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// EmbeddingsMensajes guarda el vector del contenido de cada mensaje por modelo de embeddings. El
+// vector se guarda como JSON; si la base tiene la extensión pgvector, el repositorio agrega además
+// la columna vector_pg y busca con ella.
+type EmbeddingsMensajes struct {
+	MensajeId uint64    `json:"mensajeId" gorm:"primaryKey;column:id_mensaje"`
+	Modelo    string    `json:"modelo" gorm:"primaryKey;type:varchar(100)"`
+	GrupoId   uint64    `json:"grupoId" gorm:"not null;column:id_grupo;index"`
+	Vector    []float32 `json:"vector" gorm:"type:text;not null;serializer:json"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Mensaje Mensajes `json:"-" gorm:"foreignKey:MensajeId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// TraduccionesMensajes son las traducciones de los mensajes, una por idioma (tabla traducciones_mensajes)
+type TraduccionesMensajes struct {
+	MensajeId    uint64    `json:"mensajeId" gorm:"primaryKey;column:id_mensaje"`
+	Idioma       string    `json:"idioma" gorm:"primaryKey;type:varchar(10)"`
+	Contenido    string    `json:"contenido" gorm:"type:text;not null"`
+	HashOriginal string    `json:"hashOriginal" gorm:"type:char(64);not null;column:hash_original"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	Mensaje Mensajes `json:"-" gorm:"foreignKey:MensajeId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// Encuestas son votaciones de opción única dentro de un grupo
+type Encuestas struct {
+	Id        uint64     `json:"id" gorm:"primaryKey"`
+	GrupoId   uint64     `json:"grupoId" gorm:"not null;column:id_grupo;index"`
+	UsuarioId uint64     `json:"usuarioId" gorm:"not null;column:id_usuario"`
+	Pregunta  string     `json:"pregunta" gorm:"type:varchar(300);not null"`
+	Opciones  []string   `json:"opciones" gorm:"type:text;not null;serializer:json"`
+	CierraEn  *time.Time `json:"cierraEn" gorm:"type:timestamptz;column:cierra_en"`
+	CreatedAt time.Time  `json:"createdAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// VotosEncuestas guarda un voto por usuario y encuesta
+type VotosEncuestas struct {
+	EncuestaId uint64    `json:"encuestaId" gorm:"primaryKey;column:id_encuesta"`
+	UsuarioId  uint64    `json:"usuarioId" gorm:"primaryKey;column:id_usuario"`
+	Opcion     int       `json:"opcion" gorm:"not null"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Encuesta Encuestas `json:"-" gorm:"foreignKey:EncuestaId;references:Id;constraint:OnDelete:CASCADE"`
+	Usuario  Usuarios  `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// Recordatorios son avisos que un bot publica en el grupo al llegar la fecha
+type Recordatorios struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
+	GrupoId   uint64    `json:"grupoId" gorm:"not null;column:id_grupo;index"`
+	UsuarioId uint64    `json:"usuarioId" gorm:"not null;column:id_usuario"`
+	Texto     string    `json:"texto" gorm:"type:text;not null"`
+	Fecha     time.Time `json:"fecha" gorm:"type:timestamptz;not null;index:idx_recordatorio_pendiente"`
+	Enviado   bool      `json:"enviado" gorm:"type:boolean;not null;default:false;index:idx_recordatorio_pendiente"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// ResumenesIA guarda los resúmenes de grupo bajo demanda, uno por grupo y rango de días
+type ResumenesIA struct {
+	Id                uint64         `json:"id" gorm:"primaryKey"`
+	GrupoId           uint64         `json:"grupoId" gorm:"not null;column:id_grupo;uniqueIndex:idx_resumen_rango"`
+	Rango             string         `json:"rango" gorm:"type:varchar(30);not null;uniqueIndex:idx_resumen_rango"`
+	Resumen           string         `json:"resumen" gorm:"type:text;not null"`
+	Decisiones        []string       `json:"decisiones" gorm:"type:text;serializer:json"`
+	PreguntasAbiertas []string       `json:"preguntasAbiertas" gorm:"type:text;serializer:json"`
+	Tareas            []TareaResumen `json:"tareas" gorm:"type:text;serializer:json"`
+	CantidadMensajes  int            `json:"cantidadMensajes" gorm:"not null"`
+	HastaMensajeId    uint64         `json:"hastaMensajeId" gorm:"not null"`
+	Parcial           bool           `json:"parcial" gorm:"type:boolean;not null;default:false"`
+	Modelo            string         `json:"modelo" gorm:"type:varchar(100)"`
+	UpdatedAt         time.Time      `json:"updatedAt"`
+
+	Grupo Grupos `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// SuscripcionesDigest guarda quién recibe el digest diario y a qué hora (UTC)
+type SuscripcionesDigest struct {
+	UsuarioId    uint64     `json:"usuarioId" gorm:"primaryKey;column:id_usuario"`
+	Hora         int        `json:"hora" gorm:"not null;default:8"`
+	Activo       bool       `json:"activo" gorm:"type:boolean;not null;default:true"`
+	UltimoDigest *time.Time `json:"ultimoDigest" gorm:"type:timestamptz;column:ultimo_digest"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// Digests guarda cada digest diario generado para un usuario
+type Digests struct {
+	Id        uint64          `json:"id" gorm:"primaryKey"`
+	UsuarioId uint64          `json:"usuarioId" gorm:"not null;column:id_usuario;index"`
+	Desde     time.Time       `json:"desde" gorm:"type:timestamptz;not null"`
+	Hasta     time.Time       `json:"hasta" gorm:"type:timestamptz;not null"`
+	Secciones []SeccionDigest `json:"secciones" gorm:"type:text;serializer:json"`
+	CreatedAt time.Time       `json:"createdAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// MensajesModerados es la cola de moderación: mensajes marcados a revisar y bloqueados
+type MensajesModerados struct {
+	Id            uint64     `json:"id" gorm:"primaryKey"`
+	GrupoId       uint64     `json:"grupoId" gorm:"not null;column:id_grupo;index"`
+	UsuarioId     uint64     `json:"usuarioId" gorm:"not null;column:id_usuario"`
+	ResponseId    *uint64    `json:"respuestaId" gorm:"column:id_respuesta"`
+	Contenido     string     `json:"contenido" gorm:"type:text;not null"`
+	Decision      string     `json:"decision" gorm:"type:varchar(10);not null"`
+	Verificador   string     `json:"verificador" gorm:"type:varchar(50);not null"`
+	Motivo        string     `json:"motivo" gorm:"type:text"`
+	Estado        string     `json:"estado" gorm:"type:varchar(20);not null;index"`
+	MensajeId     *uint64    `json:"mensajeId" gorm:"column:id_mensaje"`
+	RevisadoPor   *uint64    `json:"revisadoPor" gorm:"column:revisado_por"`
+	FechaRevision *time.Time `json:"fechaRevision" gorm:"type:timestamptz"`
+	Nota          string     `json:"nota" gorm:"type:text"`
+	CreatedAt     time.Time  `json:"createdAt"`
+
+	Usuario Usuarios `json:"-" gorm:"foreignKey:UsuarioId;references:Id;constraint:OnDelete:CASCADE"`
+	Grupo   Grupos   `json:"-" gorm:"foreignKey:GrupoId;references:Id;constraint:OnDelete:CASCADE"`
+}
+
+// MensajePromptIA es un mensaje del prompt; se guarda como JSON en ai_interacciones.prompt
+type MensajePromptIA struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// RespuestaIA es un mensaje interpretado de la respuesta; se guarda como JSON en ai_interacciones.mensajes
+type RespuestaIA struct {
+	AnswerId string `json:"answerId"`
+	Content  string `json:"content"`
+	DelayMs  int64  `json:"delayMs"`
+}
+
+// LlamadaHerramienta es una herramienta invocada por el bot; se guarda como JSON en ai_interacciones.herramientas
+type LlamadaHerramienta struct {
+	Nombre     string `json:"nombre"`
+	Argumentos string `json:"argumentos"`
+	Resultado  string `json:"resultado"`
+	Error      string `json:"error"`
+	DuracionMs int64  `json:"duracionMs"`
+}
+
+// TareaResumen es una acción pendiente de un resumen; se guarda como JSON en resumenes_ia.tareas
+type TareaResumen struct {
+	Responsable string `json:"responsable"`
+	Tarea       string `json:"tarea"`
+}
+
+// SeccionDigest es el resumen de un grupo; se guarda como JSON en digests.secciones
+type SeccionDigest struct {
+	GrupoId           uint64         `json:"grupoId"`
+	GrupoNombre       string         `json:"grupoNombre"`
+	Resumen           string         `json:"resumen"`
+	Decisiones        []string       `json:"decisiones"`
+	PreguntasAbiertas []string       `json:"preguntasAbiertas"`
+	Tareas            []TareaResumen `json:"tareas"`
+	CantidadMensajes  int            `json:"cantidadMensajes"`
+}
+
 var Models = []any{
 	&GruposUsuarios{},
 	&Grupos{},
 	&Usuarios{},
 	&Mensajes{},
+	&Menciones{},
 	&ModelSyncCheckpoint{},
+	&Bots{},
+	&BotPrompts{},
+	&PoliticasTurno{},
+	&SalidasFallidas{},
+	&UsosIA{},
+	&AiInteracciones{},
+	&EmbeddingsMensajes{},
+	&Encuestas{},
+	&VotosEncuestas{},
+	&Recordatorios{},
+	&ResumenesIA{},
+	&SuscripcionesDigest{},
+	&Digests{},
+	&MensajesModerados{},
+	&GruposIA{},
+	&RespuestasCacheIA{},
+	&TraduccionesMensajes{},
 }
 
 type UsuarioLogin struct {
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModeracionHandler struct {
+	MUsecase domain.ModeracionUseCase
+}
+
+// NewAdminModeracionHandler registra la cola de revisión de los mensajes marcados por la moderación
+func NewAdminModeracionHandler(group fiber.Router, mu domain.ModeracionUseCase) {
+	handler := &ModeracionHandler{
+		MUsecase: mu,
+	}
+
+	group.Get("/moderacion", handler.GetCola)
+	group.Post("/moderacion/:id/aprobar", handler.Aprobar)
+	group.Post("/moderacion/:id/rechazar", handler.Rechazar)
+}
+
+func (h *ModeracionHandler) GetCola(c *fiber.Ctx) error {
+	limite := c.QueryInt("limite", 0)
+	if limite < 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener la cola de moderación", "Error parametro", "limite no puede ser negativo")
+	}
+
+	moderados, err := h.MUsecase.GetByEstado(c.Query("estado"), limite)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener la cola de moderación", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Cola de moderación obtenida correctamente", "", moderados)
+}
+
+func (h *ModeracionHandler) Aprobar(c *fiber.Ctx) error {
+	adminId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al aprobar el mensaje", "Error parametro", err.Error())
+	}
+
+	moderado, err := h.MUsecase.Aprobar(adminId, id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al aprobar el mensaje", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Mensaje aprobado y publicado correctamente", "", moderado)
+}
+
+func (h *ModeracionHandler) Rechazar(c *fiber.Ctx) error {
+	adminId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al rechazar el mensaje", "Error parametro", err.Error())
+	}
+
+	var body struct {
+		Nota string `json:"nota"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al rechazar el mensaje", "Error de parseo", err.Error())
+		}
+	}
+
+	moderado, err := h.MUsecase.Rechazar(adminId, id, body.Nota)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al rechazar el mensaje", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Mensaje rechazado correctamente", "", moderado)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type postgresModeracionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresModeracionRepository(db *gorm.DB) domain.ModeracionRepository {
+	return &postgresModeracionRepository{db: db}
+}
+
+func mapGormToDomainModerado(gormModerado *models.MensajesModerados) *domain.MensajeModerado {
+	if gormModerado == nil {
+		return nil
+	}
+
+	return &domain.MensajeModerado{
+		Id:            gormModerado.Id,
+		GrupoId:       gormModerado.GrupoId,
+		UsuarioId:     gormModerado.UsuarioId,
+		ResponseId:    gormModerado.ResponseId,
+		Contenido:     gormModerado.Contenido,
+		Decision:      domain.DecisionModeracion(gormModerado.Decision),
+		Verificador:   gormModerado.Verificador,
+		Motivo:        gormModerado.Motivo,
+		Estado:        gormModerado.Estado,
+		MensajeId:     gormModerado.MensajeId,
+		RevisadoPor:   gormModerado.RevisadoPor,
+		FechaRevision: gormModerado.FechaRevision,
+		Nota:          gormModerado.Nota,
+		CreatedAt:     gormModerado.CreatedAt,
+	}
+}
+
+func (r *postgresModeracionRepository) Create(moderado *domain.MensajeModerado) error {
+	gormModerado := models.MensajesModerados{
+		GrupoId:     moderado.GrupoId,
+		UsuarioId:   moderado.UsuarioId,
+		ResponseId:  moderado.ResponseId,
+		Contenido:   moderado.Contenido,
+		Decision:    string(moderado.Decision),
+		Verificador: moderado.Verificador,
+		Motivo:      moderado.Motivo,
+		Estado:      moderado.Estado,
+	}
+
+	if err := r.db.Create(&gormModerado).Error; err != nil {
+		return err
+	}
+
+	moderado.Id = gormModerado.Id
+	moderado.CreatedAt = gormModerado.CreatedAt
+	return nil
+}
+
+func (r *postgresModeracionRepository) GetById(id uint64) (*domain.MensajeModerado, error) {
+	var gormModerado models.MensajesModerados
+
+	if err := r.db.First(&gormModerado, id).Error; err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainModerado(&gormModerado), nil
+}
+
+// GetByEstado lista los mensajes con el estado indicado, los más antiguos primero
+func (r *postgresModeracionRepository) GetByEstado(estado string, limite int) ([]domain.MensajeModerado, error) {
+	var gormModerados []models.MensajesModerados
+
+	err := r.db.Where("estado = ?", estado).
+		Order("id asc").
+		Limit(limite).
+		Find(&gormModerados).Error
+	if err != nil {
+		return nil, err
+	}
+
+	moderados := make([]domain.MensajeModerado, 0, len(gormModerados))
+	for i := range gormModerados {
+		moderados = append(moderados, *mapGormToDomainModerado(&gormModerados[i]))
+	}
+
+	return moderados, nil
+}
+
+func (r *postgresModeracionRepository) Revisar(moderado *domain.MensajeModerado) (bool, error) {
+	result := r.db.Model(&models.MensajesModerados{}).
+		Where("id = ? AND estado = ?", moderado.Id, domain.EstadoModeracionPendiente).
+		Updates(map[string]any{
+			"estado":         moderado.Estado,
+			"id_mensaje":     moderado.MensajeId,
+			"revisado_por":   moderado.RevisadoPor,
+			"fecha_revision": moderado.FechaRevision,
+			"nota":           moderado.Nota,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *postgresModeracionRepository) AsignarMensaje(id uint64, mensajeId uint64) error {
+	return r.db.Model(&models.MensajesModerados{}).
+		Where("id = ?", id).
+		Update("id_mensaje", mensajeId).Error
+}
+
+func (r *postgresModeracionRepository) Reabrir(id uint64) error {
+	return r.db.Model(&models.MensajesModerados{}).
+		Where("id = ? AND estado = ? AND id_mensaje IS NULL", id, domain.EstadoModeracionAprobado).
+		Updates(map[string]any{
+			"estado":         domain.EstadoModeracionPendiente,
+			"revisado_por":   nil,
+			"fecha_revision": nil,
+		}).Error
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"bufio"
+	"chatvis-chat/internal/domain"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// entradaBloqueo es una palabra o expresión de la lista con la decisión que provoca
+type entradaBloqueo struct {
+	patron   *regexp.Regexp
+	texto    string
+	decision domain.DecisionModeracion
+}
+
+// ListaBloqueo es el verificador local: palabras completas y expresiones regulares, sin
+// distinguir mayúsculas. No consulta servicios externos, así que también se aplica a los
+// mensajes que llegan directo por WebSocket.
+type ListaBloqueo struct {
+	entradas []entradaBloqueo
+}
+
+// CargarListaBloqueo lee la lista desde un archivo con una entrada por línea:
+//
+//	palabra          bloquea la palabra completa
+//	flag:palabra     la marca para revisión
+//	re:expresión     bloquea lo que coincida con la expresión regular
+//	flag:re:expresión
+//
+// Las líneas vacías y las que empiezan con # se ignoran. Sin ruta devuelve una lista vacía.
+func CargarListaBloqueo(ruta string) (*ListaBloqueo, error) {
+	lista := &ListaBloqueo{}
+	if ruta == "" {
+		return lista, nil
+	}
+
+	archivo, err := os.Open(ruta)
+	if err != nil {
+		return lista, fmt.Errorf("error al abrir la lista de bloqueo: %w", err)
+	}
+	defer archivo.Close()
+
+	var lineas []string
+	scanner := bufio.NewScanner(archivo)
+	for scanner.Scan() {
+		lineas = append(lineas, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return lista, fmt.Errorf("error al leer la lista de bloqueo: %w", err)
+	}
+
+	return NewListaBloqueo(lineas)
+}
+
+// NewListaBloqueo construye la lista a partir de entradas con el formato de CargarListaBloqueo
+func NewListaBloqueo(lineas []string) (*ListaBloqueo, error) {
+	lista := &ListaBloqueo{}
+	for i, linea := range lineas {
+		linea = strings.TrimSpace(linea)
+		if linea == "" || strings.HasPrefix(linea, "#") {
+			continue
+		}
+
+		entrada := entradaBloqueo{decision: domain.ModeracionBloquear}
+		if resto, ok := strings.CutPrefix(linea, "flag:"); ok {
+			entrada.decision = domain.ModeracionMarcar
+			linea = resto
+		}
+
+		var expresion string
+		if resto, ok := strings.CutPrefix(linea, "re:"); ok {
+			expresion = resto
+			entrada.texto = "/" + resto + "/"
+		} else {
+			// Palabra completa: los límites incluyen letras acentuadas, a diferencia de \b
+			expresion = `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(linea) + `($|[^\p{L}\p{N}])`
+			entrada.texto = `"` + linea + `"`
+		}
+
+		patron, err := regexp.Compile("(?i)" + expresion)
+		if err != nil {
+			return lista, fmt.Errorf("lista de bloqueo, línea %d: %w", i+1, err)
+		}
+		entrada.patron = patron
+		lista.entradas = append(lista.entradas, entrada)
+	}
+
+	return lista, nil
+}
+
+func (l *ListaBloqueo) Nombre() string {
+	return "lista_bloqueo"
+}
+
+// Verificar devuelve la decisión más severa entre las entradas que coinciden
+func (l *ListaBloqueo) Verificar(ctx context.Context, mensaje *domain.Mensaje) (domain.ResultadoModeracion, error) {
+	resultado := domain.ResultadoModeracion{Decision: domain.ModeracionPermitir}
+	for _, entrada := range l.entradas {
+		if entrada.decision.Severidad() <= resultado.Decision.Severidad() || !entrada.patron.MatchString(mensaje.Contenido) {
+			continue
+		}
+		resultado = domain.ResultadoModeracion{
+			Decision:    entrada.decision,
+			Verificador: l.Nombre(),
+			Motivo:      "contiene " + entrada.texto,
+		}
+	}
+	return resultado, nil
+}
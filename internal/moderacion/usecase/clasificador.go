@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// timeoutClasificador acota cuánto puede demorar la publicación de un mensaje por el clasificador
+	timeoutClasificador   = 5 * time.Second
+	maxTokensClasificador = 200
+)
+
+// clasificacionSchema es el JSON schema de la respuesta que se pide al clasificador
+var clasificacionSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"decision": {"type": "string", "enum": ["allow", "flag", "block"]},
+		"motivo": {"type": "string"}
+	},
+	"required": ["decision", "motivo"]
+}`)
+
+const instruccionesClasificador = "Moderas los mensajes de un chat grupal. Clasifica el mensaje del usuario: " +
+	"\"block\" si contiene acoso, amenazas, discurso de odio, contenido sexual explícito o datos personales de terceros; " +
+	"\"flag\" si es dudoso y conviene que lo revise una persona; \"allow\" en cualquier otro caso. " +
+	"Responde solo con un objeto JSON {\"decision\": ..., \"motivo\": ...} con un motivo breve en español."
+
+// ClasificadorLLM pide a un modelo que clasifique el mensaje. Si el modelo falla, el mensaje se
+// permite salvo que Estricto esté activo, en cuyo caso se marca para revisión.
+type ClasificadorLLM struct {
+	provider llm.Provider
+	modelo   string
+	Estricto bool
+}
+
+func NewClasificadorLLM(provider llm.Provider, modelo string, estricto bool) *ClasificadorLLM {
+	return &ClasificadorLLM{
+		provider: provider,
+		modelo:   modelo,
+		Estricto: estricto,
+	}
+}
+
+func (c *ClasificadorLLM) Nombre() string {
+	return "clasificador_llm"
+}
+
+func (c *ClasificadorLLM) Verificar(ctx context.Context, mensaje *domain.Mensaje) (domain.ResultadoModeracion, error) {
+	resultado, err := c.clasificar(ctx, mensaje.Contenido)
+	if err != nil && c.Estricto {
+		return domain.ResultadoModeracion{
+			Decision:    domain.ModeracionMarcar,
+			Verificador: c.Nombre(),
+			Motivo:      "no se pudo clasificar: " + err.Error(),
+		}, nil
+	}
+	return resultado, err
+}
+
+func (c *ClasificadorLLM) clasificar(ctx context.Context, contenido string) (domain.ResultadoModeracion, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutClasificador)
+	defer cancel()
+
+	completion, err := c.provider.Complete(ctx, llm.CompletionRequest{
+		Model: c.modelo,
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: instruccionesClasificador},
+			{Role: "user", Content: contenido},
+		},
+		Options: llm.CompletionOptions{
+			MaxTokens:      maxTokensClasificador,
+			ResponseFormat: &llm.ResponseFormat{Name: "moderation", Schema: clasificacionSchema},
+		},
+	})
+	if err != nil {
+		return domain.ResultadoModeracion{}, fmt.Errorf("error al clasificar el mensaje: %w", err)
+	}
+
+	texto := completion.Content
+	inicio, fin := strings.Index(texto, "{"), strings.LastIndex(texto, "}")
+	if inicio < 0 || fin < inicio {
+		return domain.ResultadoModeracion{}, errors.New("el clasificador no devolvió un objeto JSON")
+	}
+
+	var clasificacion struct {
+		Decision domain.DecisionModeracion `json:"decision"`
+		Motivo   string                    `json:"motivo"`
+	}
+	if err := json.Unmarshal([]byte(texto[inicio:fin+1]), &clasificacion); err != nil {
+		return domain.ResultadoModeracion{}, fmt.Errorf("respuesta inválida del clasificador: %w", err)
+	}
+
+	switch clasificacion.Decision {
+	case domain.ModeracionPermitir:
+		return domain.ResultadoModeracion{Decision: domain.ModeracionPermitir}, nil
+	case domain.ModeracionMarcar, domain.ModeracionBloquear:
+		return domain.ResultadoModeracion{
+			Decision:    clasificacion.Decision,
+			Verificador: c.Nombre(),
+			Motivo:      strings.TrimSpace(clasificacion.Motivo),
+		}, nil
+	}
+	return domain.ResultadoModeracion{}, fmt.Errorf("decisión desconocida del clasificador: %q", clasificacion.Decision)
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	defaultLimiteCola = 50
+	maxLimiteCola     = 200
+)
+
+type moderacionUseCase struct {
+	repo        domain.ModeracionRepository
+	mensajes    domain.MensajeUseCase
+	repoGrupo   domain.GrupoRepository
+	repoUsuario domain.UsuarioRepository
+	// publicador envía los mensajes aprobados a los integrantes conectados; puede ser nil
+	publicador domain.PublicadorMensajes
+
+	// cadena son los verificadores en orden; el primero que bloquea corta la cadena
+	cadena []domain.VerificadorModeracion
+}
+
+// NewModeracionUseCase arma la cadena con la lista de bloqueo primero y luego los verificadores
+// indicados (por ejemplo, spam y el clasificador LLM, que conviene dejar al final por su costo)
+func NewModeracionUseCase(repo domain.ModeracionRepository, mu domain.MensajeUseCase, repoGrupo domain.GrupoRepository, repoUsuario domain.UsuarioRepository, publicador domain.PublicadorMensajes, lista *ListaBloqueo, verificadores ...domain.VerificadorModeracion) domain.ModeracionUseCase {
+	if lista == nil {
+		lista = &ListaBloqueo{}
+	}
+
+	return &moderacionUseCase{
+		repo:        repo,
+		mensajes:    mu,
+		repoGrupo:   repoGrupo,
+		repoUsuario: repoUsuario,
+		publicador:  publicador,
+		cadena:      append([]domain.VerificadorModeracion{lista}, verificadores...),
+	}
+}
+
+func (u *moderacionUseCase) Moderar(ctx context.Context, mensaje *domain.Mensaje) (*domain.ResultadoModeracion, error) {
+	if mensaje == nil {
+		return nil, errors.New("el mensaje no puede ser nulo")
+	}
+
+	resultado := u.evaluar(ctx, mensaje)
+	if resultado.Decision == domain.ModeracionPermitir {
+		return &resultado, nil
+	}
+	// Aprobar una edición desde la cola publicaría un mensaje nuevo, así que no se retiene
+	if mensaje.Id != 0 && resultado.Decision == domain.ModeracionMarcar {
+		resultado.Decision = domain.ModeracionBloquear
+		resultado.Motivo += " (edición)"
+	}
+
+	moderado := &domain.MensajeModerado{
+		GrupoId:     mensaje.GrupoId,
+		UsuarioId:   mensaje.UsuarioId,
+		ResponseId:  mensaje.ResponseId,
+		Contenido:   mensaje.Contenido,
+		Decision:    resultado.Decision,
+		Verificador: resultado.Verificador,
+		Motivo:      resultado.Motivo,
+		Estado:      domain.EstadoModeracionPendiente,
+	}
+	if resultado.Decision == domain.ModeracionBloquear {
+		moderado.Estado = domain.EstadoModeracionBloqueado
+	}
+
+	if err := u.repo.Create(moderado); err != nil {
+		return nil, fmt.Errorf("error al registrar el mensaje moderado: %w", err)
+	}
+	resultado.ModeradoId = moderado.Id
+
+	log.Printf("Moderación: mensaje del usuario %d en el grupo %d %s por %s: %s", mensaje.UsuarioId, mensaje.GrupoId, moderado.Estado, resultado.Verificador, resultado.Motivo)
+	return &resultado, nil
+}
+
+// evaluar recorre la cadena y devuelve la decisión más severa. Un verificador que falla se
+// omite: la moderación no debe impedir que el chat funcione.
+func (u *moderacionUseCase) evaluar(ctx context.Context, mensaje *domain.Mensaje) domain.ResultadoModeracion {
+	resultado := domain.ResultadoModeracion{Decision: domain.ModeracionPermitir}
+	for _, verificador := range u.cadena {
+		r, err := verificador.Verificar(ctx, mensaje)
+		if err != nil {
+			log.Printf("Moderación: el verificador %s falló: %v", verificador.Nombre(), err)
+			continue
+		}
+		if r.Decision.Severidad() > resultado.Decision.Severidad() {
+			resultado = r
+		}
+		if resultado.Decision == domain.ModeracionBloquear {
+			break
+		}
+	}
+	return resultado
+}
+
+func (u *moderacionUseCase) GetByEstado(estado string, limite int) ([]domain.MensajeModerado, error) {
+	if estado == "" {
+		estado = domain.EstadoModeracionPendiente
+	}
+	estados := []string{domain.EstadoModeracionPendiente, domain.EstadoModeracionAprobado, domain.EstadoModeracionRechazado, domain.EstadoModeracionBloqueado}
+	if !slices.Contains(estados, estado) {
+		return nil, fmt.Errorf("estado inválido %q; válidos: %v", estado, estados)
+	}
+
+	if limite <= 0 {
+		limite = defaultLimiteCola
+	}
+	limite = min(limite, maxLimiteCola)
+
+	return u.repo.GetByEstado(estado, limite)
+}
+
+func (u *moderacionUseCase) Aprobar(adminId uint64, id uint64) (*domain.MensajeModerado, error) {
+	moderado, err := u.pendiente(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Primero se reclama la revisión: si dos administradores aprueban a la vez, solo uno guarda
+	// y publica el mensaje
+	moderado.Estado = domain.EstadoModeracionAprobado
+	if err := u.revisar(moderado, adminId); err != nil {
+		return nil, err
+	}
+
+	mensaje, err := u.mensajes.Create(&domain.Mensaje{
+		Contenido:  moderado.Contenido,
+		GrupoId:    moderado.GrupoId,
+		UsuarioId:  moderado.UsuarioId,
+		ResponseId: moderado.ResponseId,
+	})
+	if err != nil {
+		if errReabrir := u.repo.Reabrir(moderado.Id); errReabrir != nil {
+			log.Printf("Moderación: no se pudo reabrir la revisión %d: %v", moderado.Id, errReabrir)
+		}
+		return nil, fmt.Errorf("error al guardar el mensaje aprobado: %w", err)
+	}
+
+	moderado.MensajeId = &mensaje.Id
+	if err := u.repo.AsignarMensaje(moderado.Id, mensaje.Id); err != nil {
+		log.Printf("Moderación: no se pudo enlazar la revisión %d con el mensaje %d: %v", moderado.Id, mensaje.Id, err)
+	}
+
+	u.publicar(mensaje)
+	return moderado, nil
+}
+
+func (u *moderacionUseCase) Rechazar(adminId uint64, id uint64, nota string) (*domain.MensajeModerado, error) {
+	moderado, err := u.pendiente(id)
+	if err != nil {
+		return nil, err
+	}
+
+	moderado.Estado = domain.EstadoModeracionRechazado
+	moderado.Nota = nota
+	if err := u.revisar(moderado, adminId); err != nil {
+		return nil, err
+	}
+
+	return moderado, nil
+}
+
+// pendiente busca el mensaje y comprueba que siga esperando revisión
+func (u *moderacionUseCase) pendiente(id uint64) (*domain.MensajeModerado, error) {
+	if id <= 0 {
+		return nil, errors.New("el ID del mensaje moderado debe ser mayor que cero")
+	}
+
+	moderado, err := u.repo.GetById(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el mensaje moderado: %w", err)
+	}
+	if moderado.Estado != domain.EstadoModeracionPendiente {
+		return nil, fmt.Errorf("el mensaje no está pendiente de revisión (estado %s)", moderado.Estado)
+	}
+
+	return moderado, nil
+}
+
+func (u *moderacionUseCase) revisar(moderado *domain.MensajeModerado, adminId uint64) error {
+	ahora := time.Now()
+	moderado.RevisadoPor = &adminId
+	moderado.FechaRevision = &ahora
+
+	ok, err := u.repo.Revisar(moderado)
+	if err != nil {
+		return fmt.Errorf("error al guardar la revisión: %w", err)
+	}
+	if !ok {
+		return errors.New("otro administrador ya revisó el mensaje")
+	}
+
+	return nil
+}
+
+// publicar envía el mensaje aprobado al grupo. Ya está guardado, así que un error solo se registra.
+func (u *moderacionUseCase) publicar(mensaje *domain.Mensaje) {
+	if u.publicador == nil {
+		return
+	}
+
+	grupo, err := u.repoGrupo.GetById(mensaje.GrupoId)
+	if err != nil {
+		log.Printf("Moderación: no se pudo publicar el mensaje %d: %v", mensaje.Id, err)
+		return
+	}
+
+	if mensaje.Usuario == nil {
+		usuario, err := u.repoUsuario.GetById(mensaje.UsuarioId)
+		if err != nil {
+			log.Printf("Moderación: no se pudo obtener el autor del mensaje %d: %v", mensaje.Id, err)
+		}
+		mensaje.Usuario = usuario
+	}
+
+	u.publicador.PublicarMensaje(grupo.Clave, mensaje)
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultVentanaSpam = 10 * time.Second
+
+// ConfigSpam define los umbrales del verificador de spam; un umbral en 0 no se aplica
+type ConfigSpam struct {
+	// MaxMensajes es la cantidad de mensajes por usuario dentro de Ventana a partir de la cual se bloquea
+	MaxMensajes int
+	// MaxRepetidos es la cantidad de mensajes iguales dentro de Ventana a partir de la cual se marca
+	MaxRepetidos int
+	Ventana      time.Duration
+}
+
+// Enabled indica si hay algún umbral configurado
+func (c ConfigSpam) Enabled() bool {
+	return c.MaxMensajes > 0 || c.MaxRepetidos > 0
+}
+
+// ConfigSpamFromEnv lee MODERATION_SPAM_MAX_MESSAGES, MODERATION_SPAM_MAX_REPEATS y
+// MODERATION_SPAM_WINDOW_SECONDS (10 por defecto)
+func ConfigSpamFromEnv() ConfigSpam {
+	config := ConfigSpam{Ventana: defaultVentanaSpam}
+	if n, err := strconv.Atoi(os.Getenv("MODERATION_SPAM_MAX_MESSAGES")); err == nil {
+		config.MaxMensajes = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MODERATION_SPAM_MAX_REPEATS")); err == nil {
+		config.MaxRepetidos = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MODERATION_SPAM_WINDOW_SECONDS")); err == nil && n > 0 {
+		config.Ventana = time.Duration(n) * time.Second
+	}
+	return config
+}
+
+// envioReciente es un mensaje de un usuario dentro de la ventana
+type envioReciente struct {
+	fecha     time.Time
+	contenido string
+}
+
+// VerificadorSpam limita la frecuencia de mensajes por usuario y detecta mensajes repetidos. El
+// historial es en memoria, por instancia del servidor.
+type VerificadorSpam struct {
+	config ConfigSpam
+
+	mu        sync.Mutex
+	recientes map[uint64][]envioReciente
+}
+
+func NewVerificadorSpam(config ConfigSpam) *VerificadorSpam {
+	if config.Ventana <= 0 {
+		config.Ventana = defaultVentanaSpam
+	}
+	return &VerificadorSpam{
+		config:    config,
+		recientes: make(map[uint64][]envioReciente),
+	}
+}
+
+func (v *VerificadorSpam) Nombre() string {
+	return "spam"
+}
+
+// Verificar registra el mensaje y lo compara con los enviados por el mismo usuario en la ventana
+func (v *VerificadorSpam) Verificar(ctx context.Context, mensaje *domain.Mensaje) (domain.ResultadoModeracion, error) {
+	ahora := time.Now()
+	contenido := strings.ToLower(strings.Join(strings.Fields(mensaje.Contenido), " "))
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	recientes := v.recientes[mensaje.UsuarioId][:0]
+	for _, envio := range v.recientes[mensaje.UsuarioId] {
+		if ahora.Sub(envio.fecha) < v.config.Ventana {
+			recientes = append(recientes, envio)
+		}
+	}
+	recientes = append(recientes, envioReciente{fecha: ahora, contenido: contenido})
+	v.recientes[mensaje.UsuarioId] = recientes
+
+	// Limpieza de los usuarios que dejaron de escribir
+	for usuarioId, envios := range v.recientes {
+		if len(envios) == 0 || ahora.Sub(envios[len(envios)-1].fecha) >= v.config.Ventana {
+			delete(v.recientes, usuarioId)
+		}
+	}
+
+	segundos := int(v.config.Ventana.Seconds())
+	if v.config.MaxMensajes > 0 && len(recientes) > v.config.MaxMensajes {
+		return domain.ResultadoModeracion{
+			Decision:    domain.ModeracionBloquear,
+			Verificador: v.Nombre(),
+			Motivo:      fmt.Sprintf("más de %d mensajes en %d segundos", v.config.MaxMensajes, segundos),
+		}, nil
+	}
+
+	if v.config.MaxRepetidos > 0 {
+		repetidos := 0
+		for _, envio := range recientes {
+			if envio.contenido == contenido {
+				repetidos++
+			}
+		}
+		if repetidos > v.config.MaxRepetidos {
+			return domain.ResultadoModeracion{
+				Decision:    domain.ModeracionMarcar,
+				Verificador: v.Nombre(),
+				Motivo:      fmt.Sprintf("mismo mensaje más de %d veces en %d segundos", v.config.MaxRepetidos, segundos),
+			}, nil
+		}
+	}
+
+	return domain.ResultadoModeracion{Decision: domain.ModeracionPermitir}, nil
+}
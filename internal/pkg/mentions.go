@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionRegex reconoce @apodo al inicio del texto o después de un carácter que no forma parte
+// de una palabra, para no confundir correos electrónicos con menciones.
+var mentionRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_][\p{L}\p{N}_.\-]*)`)
+
+// ExtractMentions devuelve los apodos mencionados en el contenido, en minúsculas y sin repetir
+func ExtractMentions(content string) []string {
+	matches := mentionRegex.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(matches))
+	var apodos []string
+	for _, match := range matches {
+		apodo := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if apodo == "" || seen[apodo] {
+			continue
+		}
+		seen[apodo] = true
+		apodos = append(apodos, apodo)
+	}
+
+	return apodos
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -21,3 +22,26 @@ func ValidateParamsId(c *fiber.Ctx) (uint64, error) {
 
 	return id, nil
 }
+
+// ValidateQueryRangoFechas lee los parámetros opcionales fechaInicio y fechaFin (RFC3339). Los que
+// no se envían quedan en cero, que para los repositorios significa "sin límite".
+func ValidateQueryRangoFechas(c *fiber.Ctx) (time.Time, time.Time, error) {
+	var fechaInicio, fechaFin time.Time
+	var err error
+
+	if fechaInicioStr := c.Query("fechaInicio", ""); fechaInicioStr != "" {
+		fechaInicio, err = time.Parse(time.RFC3339, fechaInicioStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("La fecha de inicio debe estar en formato RFC3339")
+		}
+	}
+
+	if fechaFinStr := c.Query("fechaFin", ""); fechaFinStr != "" {
+		fechaFin, err = time.Parse(time.RFC3339, fechaFinStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("La fecha de fin debe estar en formato RFC3339")
+		}
+	}
+
+	return fechaInicio, fechaFin, nil
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PoliticaTurnoHandler struct {
+	PTUsecase domain.PoliticaTurnoUseCase
+}
+
+// NewAdminPoliticaTurnoHandler registra los endpoints de administración de turnos de bots por grupo
+func NewAdminPoliticaTurnoHandler(group fiber.Router, ptu domain.PoliticaTurnoUseCase) {
+	handler := &PoliticaTurnoHandler{
+		PTUsecase: ptu,
+	}
+
+	group.Get("/group/:id/turnos", handler.GetPoliticaTurno)
+	group.Put("/group/:id/turnos", handler.UpdatePoliticaTurno)
+}
+
+func (h *PoliticaTurnoHandler) GetPoliticaTurno(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener la política de turnos", "Error parametro", err.Error())
+	}
+
+	politica, err := h.PTUsecase.GetByGrupoId(id)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener la política de turnos", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Política de turnos obtenida correctamente", "", politica)
+}
+
+func (h *PoliticaTurnoHandler) UpdatePoliticaTurno(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar la política de turnos", "Error parametro", err.Error())
+	}
+
+	var politica domain.PoliticaTurno
+	if err := c.BodyParser(&politica); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar la política de turnos", "Error de parseo", err.Error())
+	}
+
+	if err := h.PTUsecase.Update(id, &politica); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al actualizar la política de turnos", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Política de turnos actualizada correctamente", "", politica)
+}
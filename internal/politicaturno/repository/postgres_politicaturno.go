@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresPoliticaTurnoRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresPoliticaTurnoRepository(db *gorm.DB) domain.PoliticaTurnoRepository {
+	return &postgresPoliticaTurnoRepository{db: db}
+}
+
+func mapGormToDomainPolitica(gormPolitica *models.PoliticasTurno) *domain.PoliticaTurno {
+	if gormPolitica == nil {
+		return nil
+	}
+
+	return &domain.PoliticaTurno{
+		Id:                 gormPolitica.Id,
+		GrupoId:            gormPolitica.GrupoId,
+		MaxBotConsecutivos: gormPolitica.MaxBotConsecutivos,
+		CooldownSegundos:   gormPolitica.CooldownSegundos,
+		RequiereHumano:     gormPolitica.RequiereHumano,
+		RoundRobin:         gormPolitica.RoundRobin,
+		MaxLlamadasHora:    gormPolitica.MaxLlamadasHora,
+		UpdatedAt:          gormPolitica.UpdatedAt,
+	}
+}
+
+func (r *postgresPoliticaTurnoRepository) GetByGrupoId(grupoId uint64) (*domain.PoliticaTurno, int, error) {
+	var gormPolitica models.PoliticasTurno
+
+	err := r.db.Where("id_grupo = ?", grupoId).First(&gormPolitica).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 404, nil
+		}
+		return nil, 500, fmt.Errorf("error al buscar la política de turnos: %w", err)
+	}
+
+	return mapGormToDomainPolitica(&gormPolitica), 200, nil
+}
+
+// Save crea o actualiza la política del grupo (una por grupo)
+func (r *postgresPoliticaTurnoRepository) Save(politica *domain.PoliticaTurno) error {
+	gormPolitica := models.PoliticasTurno{
+		GrupoId:            politica.GrupoId,
+		MaxBotConsecutivos: politica.MaxBotConsecutivos,
+		CooldownSegundos:   politica.CooldownSegundos,
+		RequiereHumano:     politica.RequiereHumano,
+		RoundRobin:         politica.RoundRobin,
+		MaxLlamadasHora:    politica.MaxLlamadasHora,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_grupo"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_bot_consecutivos", "cooldown_segundos", "requiere_humano", "round_robin", "max_llamadas_hora", "updated_at"}),
+	}).Create(&gormPolitica).Error
+	if err != nil {
+		return err
+	}
+
+	politica.Id = gormPolitica.Id
+	politica.UpdatedAt = gormPolitica.UpdatedAt
+	return nil
+}
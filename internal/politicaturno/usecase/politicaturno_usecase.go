@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+)
+
+// Valores aplicados a los grupos sin política guardada
+const (
+	defaultMaxBotConsecutivos = 3
+	defaultCooldownSegundos   = 10
+)
+
+type politicaTurnoUseCase struct {
+	repo      domain.PoliticaTurnoRepository
+	repoGrupo domain.GrupoRepository
+}
+
+func NewPoliticaTurnoUseCase(repo domain.PoliticaTurnoRepository, repoGrupo domain.GrupoRepository) domain.PoliticaTurnoUseCase {
+	return &politicaTurnoUseCase{
+		repo:      repo,
+		repoGrupo: repoGrupo,
+	}
+}
+
+func (u *politicaTurnoUseCase) GetByGrupoId(grupoId uint64) (*domain.PoliticaTurno, error) {
+	politica, status, err := u.repo.GetByGrupoId(grupoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == 404 {
+		return &domain.PoliticaTurno{
+			GrupoId:            grupoId,
+			MaxBotConsecutivos: defaultMaxBotConsecutivos,
+			CooldownSegundos:   defaultCooldownSegundos,
+			PorDefecto:         true,
+		}, nil
+	}
+
+	return politica, nil
+}
+
+func (u *politicaTurnoUseCase) Update(grupoId uint64, politica *domain.PoliticaTurno) error {
+	if politica.MaxBotConsecutivos < 0 {
+		return errors.New("maxBotConsecutivos no puede ser negativo")
+	}
+
+	if politica.CooldownSegundos < 0 {
+		return errors.New("cooldownSegundos no puede ser negativo")
+	}
+
+	if politica.MaxLlamadasHora < 0 {
+		return errors.New("maxLlamadasHora no puede ser negativo")
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+	if grupo == nil {
+		return errors.New("el grupo no existe")
+	}
+
+	politica.GrupoId = grupoId
+	politica.PorDefecto = false
+	return u.repo.Save(politica)
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RecordatorioHandler struct {
+	RUsecase domain.RecordatorioUseCase
+}
+
+func NewRecordatorioHandler(group fiber.Router, ru domain.RecordatorioUseCase) {
+	handler := &RecordatorioHandler{
+		RUsecase: ru,
+	}
+
+	group.Get("/group/:id", handler.GetPendientesByGrupoId)
+}
+
+func (h *RecordatorioHandler) GetPendientesByGrupoId(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	grupoId, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener recordatorios", "Error parametro", err.Error())
+	}
+
+	result, err := h.RUsecase.GetPendientesByGrupoId(userId, grupoId)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener recordatorios", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Recordatorios obtenidos correctamente", "", result)
+}
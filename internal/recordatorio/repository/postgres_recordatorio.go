@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type postgresRecordatorioRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRecordatorioRepository(db *gorm.DB) domain.RecordatorioRepository {
+	return &postgresRecordatorioRepository{db: db}
+}
+
+func mapGormToDomainRecordatorio(gormRecordatorio *models.Recordatorios) *domain.Recordatorio {
+	if gormRecordatorio == nil {
+		return nil
+	}
+
+	return &domain.Recordatorio{
+		Id:        gormRecordatorio.Id,
+		GrupoId:   gormRecordatorio.GrupoId,
+		UsuarioId: gormRecordatorio.UsuarioId,
+		Texto:     gormRecordatorio.Texto,
+		Fecha:     gormRecordatorio.Fecha,
+		Enviado:   gormRecordatorio.Enviado,
+		CreatedAt: gormRecordatorio.CreatedAt,
+	}
+}
+
+func mapGormToDomainRecordatorios(gormRecordatorios []models.Recordatorios) []domain.Recordatorio {
+	recordatorios := make([]domain.Recordatorio, 0, len(gormRecordatorios))
+	for i := range gormRecordatorios {
+		recordatorios = append(recordatorios, *mapGormToDomainRecordatorio(&gormRecordatorios[i]))
+	}
+	return recordatorios
+}
+
+func (r *postgresRecordatorioRepository) Create(recordatorio *domain.Recordatorio) error {
+	gormRecordatorio := models.Recordatorios{
+		GrupoId:   recordatorio.GrupoId,
+		UsuarioId: recordatorio.UsuarioId,
+		Texto:     recordatorio.Texto,
+		Fecha:     recordatorio.Fecha,
+	}
+
+	if err := r.db.Create(&gormRecordatorio).Error; err != nil {
+		return err
+	}
+
+	recordatorio.Id = gormRecordatorio.Id
+	recordatorio.CreatedAt = gormRecordatorio.CreatedAt
+	return nil
+}
+
+func (r *postgresRecordatorioRepository) CountPendientesByGrupoId(grupoId uint64) (int64, error) {
+	var total int64
+
+	err := r.db.Model(&models.Recordatorios{}).
+		Where("id_grupo = ? AND enviado = ?", grupoId, false).
+		Count(&total).Error
+	return total, err
+}
+
+// GetPendientesByGrupoId lista los recordatorios sin enviar del grupo, los más próximos primero
+func (r *postgresRecordatorioRepository) GetPendientesByGrupoId(grupoId uint64) ([]domain.Recordatorio, error) {
+	var gormRecordatorios []models.Recordatorios
+
+	err := r.db.Where("id_grupo = ? AND enviado = ?", grupoId, false).
+		Order("fecha asc").
+		Find(&gormRecordatorios).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainRecordatorios(gormRecordatorios), nil
+}
+
+// GetVencidos lista los recordatorios sin enviar cuya fecha ya pasó, los más antiguos primero
+func (r *postgresRecordatorioRepository) GetVencidos(hasta time.Time, limite int) ([]domain.Recordatorio, error) {
+	var gormRecordatorios []models.Recordatorios
+
+	err := r.db.Where("enviado = ? AND fecha <= ?", false, hasta).
+		Order("fecha asc").
+		Limit(limite).
+		Find(&gormRecordatorios).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGormToDomainRecordatorios(gormRecordatorios), nil
+}
+
+func (r *postgresRecordatorioRepository) MarcarEnviado(id uint64) error {
+	return r.db.Model(&models.Recordatorios{}).Where("id = ?", id).Update("enviado", true).Error
+}
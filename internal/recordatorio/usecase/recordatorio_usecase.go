@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxLargoTexto = 500
+	// maxAnticipacion limita cuánto hacia adelante se puede programar un recordatorio
+	maxAnticipacion = 30 * 24 * time.Hour
+	// maxPendientesPorGrupo evita que un grupo (o un bot) acumule recordatorios sin límite
+	maxPendientesPorGrupo = 20
+	defaultLimiteVencidos = 50
+)
+
+type recordatorioUseCase struct {
+	repo             domain.RecordatorioRepository
+	repoGrupo        domain.GrupoRepository
+	repoGrupoUsuario domain.GrupoUsuarioRepository
+}
+
+func NewRecordatorioUseCase(repo domain.RecordatorioRepository, repoGrupo domain.GrupoRepository, repoGrupoUsuario domain.GrupoUsuarioRepository) domain.RecordatorioUseCase {
+	return &recordatorioUseCase{
+		repo:             repo,
+		repoGrupo:        repoGrupo,
+		repoGrupoUsuario: repoGrupoUsuario,
+	}
+}
+
+func (u *recordatorioUseCase) Programar(recordatorio *domain.Recordatorio) error {
+	if recordatorio == nil {
+		return errors.New("el recordatorio no puede ser nulo")
+	}
+
+	recordatorio.Texto = strings.TrimSpace(recordatorio.Texto)
+	if recordatorio.Texto == "" {
+		return errors.New("el texto del recordatorio no puede estar vacío")
+	}
+	if len([]rune(recordatorio.Texto)) > maxLargoTexto {
+		return fmt.Errorf("el texto del recordatorio no puede superar los %d caracteres", maxLargoTexto)
+	}
+
+	ahora := time.Now()
+	if !recordatorio.Fecha.After(ahora) {
+		return errors.New("la fecha del recordatorio debe ser futura")
+	}
+	if recordatorio.Fecha.After(ahora.Add(maxAnticipacion)) {
+		return fmt.Errorf("la fecha del recordatorio no puede superar los %d días", int(maxAnticipacion.Hours()/24))
+	}
+
+	if err := u.verificarMiembro(recordatorio.UsuarioId, recordatorio.GrupoId); err != nil {
+		return err
+	}
+
+	pendientes, err := u.repo.CountPendientesByGrupoId(recordatorio.GrupoId)
+	if err != nil {
+		return fmt.Errorf("error al contar los recordatorios pendientes: %w", err)
+	}
+	if pendientes >= maxPendientesPorGrupo {
+		return fmt.Errorf("el grupo ya tiene %d recordatorios pendientes", maxPendientesPorGrupo)
+	}
+
+	recordatorio.Enviado = false
+	return u.repo.Create(recordatorio)
+}
+
+func (u *recordatorioUseCase) GetPendientesByGrupoId(usuarioId uint64, grupoId uint64) ([]domain.Recordatorio, error) {
+	if err := u.verificarMiembro(usuarioId, grupoId); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetPendientesByGrupoId(grupoId)
+}
+
+func (u *recordatorioUseCase) GetVencidos(hasta time.Time, limite int) ([]domain.Recordatorio, error) {
+	if limite <= 0 {
+		limite = defaultLimiteVencidos
+	}
+
+	return u.repo.GetVencidos(hasta, limite)
+}
+
+func (u *recordatorioUseCase) MarcarEnviado(id uint64) error {
+	if id <= 0 {
+		return errors.New("el ID del recordatorio debe ser mayor que cero")
+	}
+
+	return u.repo.MarcarEnviado(id)
+}
+
+func (u *recordatorioUseCase) verificarMiembro(usuarioId uint64, grupoId uint64) error {
+	if grupoId <= 0 {
+		return errors.New("el ID del grupo debe ser mayor que cero")
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+
+	esMiembro, err := u.repoGrupoUsuario.VerifyMembership(usuarioId, grupo.Clave)
+	if err != nil {
+		return fmt.Errorf("error al verificar la membresía: %w", err)
+	}
+	if !esMiembro {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ResumenHandler struct {
+	RUsecase domain.ResumenUseCase
+}
+
+// NewResumenHandler registra el resumen bajo demanda dentro del grupo de rutas de grupos
+func NewResumenHandler(group fiber.Router, ru domain.ResumenUseCase) {
+	handler := &ResumenHandler{
+		RUsecase: ru,
+	}
+
+	group.Post("/:id/summary", handler.Resumir)
+}
+
+// NewDigestHandler registra la suscripción al digest diario y la consulta de digests
+func NewDigestHandler(group fiber.Router, ru domain.ResumenUseCase) {
+	handler := &ResumenHandler{
+		RUsecase: ru,
+	}
+
+	group.Get("", handler.GetDigests)
+	group.Get("/suscripcion", handler.GetSuscripcion)
+	group.Put("/suscripcion", handler.SaveSuscripcion)
+}
+
+func (h *ResumenHandler) Resumir(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	grupoId, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al resumir el grupo", "Error parametro", err.Error())
+	}
+
+	fechaInicio, fechaFin, err := pkg.ValidateQueryRangoFechas(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al resumir el grupo", "Error parametro", err.Error())
+	}
+
+	resumen, err := h.RUsecase.Resumir(c.UserContext(), userId, grupoId, fechaInicio, fechaFin)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al resumir el grupo", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Resumen generado correctamente", "", resumen)
+}
+
+func (h *ResumenHandler) GetDigests(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	limite := c.QueryInt("limite", 0)
+	if limite < 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener digests", "Error parametro", "limite no puede ser negativo")
+	}
+
+	digests, err := h.RUsecase.GetDigests(userId, limite)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener digests", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Digests obtenidos correctamente", "", digests)
+}
+
+func (h *ResumenHandler) GetSuscripcion(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	suscripcion, err := h.RUsecase.GetSuscripcion(userId)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener la suscripción", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Suscripción obtenida correctamente", "", suscripcion)
+}
+
+func (h *ResumenHandler) SaveSuscripcion(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	var suscripcion domain.SuscripcionDigest
+	if err := c.BodyParser(&suscripcion); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al guardar la suscripción", "Error de parseo", err.Error())
+	}
+	suscripcion.UsuarioId = userId
+	suscripcion.UltimoDigest = nil
+
+	if err := h.RUsecase.SaveSuscripcion(&suscripcion); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al guardar la suscripción", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Suscripción guardada correctamente", "", suscripcion)
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresResumenRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresResumenRepository(db *gorm.DB) domain.ResumenRepository {
+	return &postgresResumenRepository{db: db}
+}
+
+func mapGormToDomainTareas(tareas []models.TareaResumen) []domain.TareaResumen {
+	result := make([]domain.TareaResumen, 0, len(tareas))
+	for _, t := range tareas {
+		result = append(result, domain.TareaResumen(t))
+	}
+	return result
+}
+
+func mapDomainToGormTareas(tareas []domain.TareaResumen) []models.TareaResumen {
+	result := make([]models.TareaResumen, 0, len(tareas))
+	for _, t := range tareas {
+		result = append(result, models.TareaResumen(t))
+	}
+	return result
+}
+
+func mapGormToDomainResumen(gormResumen *models.ResumenesIA) *domain.ResumenIA {
+	if gormResumen == nil {
+		return nil
+	}
+
+	return &domain.ResumenIA{
+		Id:      gormResumen.Id,
+		GrupoId: gormResumen.GrupoId,
+		Rango:   gormResumen.Rango,
+		ContenidoResumen: domain.ContenidoResumen{
+			Resumen:           gormResumen.Resumen,
+			Decisiones:        gormResumen.Decisiones,
+			PreguntasAbiertas: gormResumen.PreguntasAbiertas,
+			Tareas:            mapGormToDomainTareas(gormResumen.Tareas),
+		},
+		CantidadMensajes: gormResumen.CantidadMensajes,
+		HastaMensajeId:   gormResumen.HastaMensajeId,
+		Parcial:          gormResumen.Parcial,
+		Modelo:           gormResumen.Modelo,
+		UpdatedAt:        gormResumen.UpdatedAt,
+	}
+}
+
+func mapGormToDomainDigest(gormDigest *models.Digests) domain.Digest {
+	secciones := make([]domain.SeccionDigest, 0, len(gormDigest.Secciones))
+	for _, s := range gormDigest.Secciones {
+		secciones = append(secciones, domain.SeccionDigest{
+			GrupoId:     s.GrupoId,
+			GrupoNombre: s.GrupoNombre,
+			ContenidoResumen: domain.ContenidoResumen{
+				Resumen:           s.Resumen,
+				Decisiones:        s.Decisiones,
+				PreguntasAbiertas: s.PreguntasAbiertas,
+				Tareas:            mapGormToDomainTareas(s.Tareas),
+			},
+			CantidadMensajes: s.CantidadMensajes,
+		})
+	}
+
+	return domain.Digest{
+		Id:        gormDigest.Id,
+		UsuarioId: gormDigest.UsuarioId,
+		Desde:     gormDigest.Desde,
+		Hasta:     gormDigest.Hasta,
+		Secciones: secciones,
+		CreatedAt: gormDigest.CreatedAt,
+	}
+}
+
+func (r *postgresResumenRepository) GetByRango(grupoId uint64, rango string) (*domain.ResumenIA, error) {
+	var gormResumen models.ResumenesIA
+
+	err := r.db.Where("id_grupo = ? AND rango = ?", grupoId, rango).First(&gormResumen).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return mapGormToDomainResumen(&gormResumen), nil
+}
+
+// Save crea o reemplaza el resumen del rango (uno por grupo y rango)
+func (r *postgresResumenRepository) Save(resumen *domain.ResumenIA) error {
+	gormResumen := models.ResumenesIA{
+		GrupoId:           resumen.GrupoId,
+		Rango:             resumen.Rango,
+		Resumen:           resumen.Resumen,
+		Decisiones:        resumen.Decisiones,
+		PreguntasAbiertas: resumen.PreguntasAbiertas,
+		Tareas:            mapDomainToGormTareas(resumen.Tareas),
+		CantidadMensajes:  resumen.CantidadMensajes,
+		HastaMensajeId:    resumen.HastaMensajeId,
+		Parcial:           resumen.Parcial,
+		Modelo:            resumen.Modelo,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_grupo"}, {Name: "rango"}},
+		DoUpdates: clause.AssignmentColumns([]string{"resumen", "decisiones", "preguntas_abiertas", "tareas", "cantidad_mensajes", "hasta_mensaje_id", "parcial", "modelo", "updated_at"}),
+	}).Create(&gormResumen).Error
+	if err != nil {
+		return err
+	}
+
+	resumen.Id = gormResumen.Id
+	resumen.UpdatedAt = gormResumen.UpdatedAt
+	return nil
+}
+
+func (r *postgresResumenRepository) GetSuscripcion(usuarioId uint64) (*domain.SuscripcionDigest, error) {
+	var gormSuscripcion models.SuscripcionesDigest
+
+	err := r.db.Where("id_usuario = ?", usuarioId).First(&gormSuscripcion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &domain.SuscripcionDigest{
+		UsuarioId:    gormSuscripcion.UsuarioId,
+		Hora:         gormSuscripcion.Hora,
+		Activo:       gormSuscripcion.Activo,
+		UltimoDigest: gormSuscripcion.UltimoDigest,
+	}, nil
+}
+
+// SaveSuscripcion crea o actualiza la suscripción del usuario sin tocar UltimoDigest
+func (r *postgresResumenRepository) SaveSuscripcion(suscripcion *domain.SuscripcionDigest) error {
+	gormSuscripcion := models.SuscripcionesDigest{
+		UsuarioId: suscripcion.UsuarioId,
+		Hora:      suscripcion.Hora,
+		Activo:    suscripcion.Activo,
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_usuario"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hora", "activo", "updated_at"}),
+	}).Create(&gormSuscripcion).Error
+}
+
+func (r *postgresResumenRepository) GetSuscripcionesPendientes(ahora time.Time, limite int) ([]domain.SuscripcionDigest, error) {
+	var gormSuscripciones []models.SuscripcionesDigest
+
+	ahora = ahora.UTC()
+	inicioDelDia := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.UTC)
+
+	err := r.db.Where("activo = ? AND hora <= ? AND (ultimo_digest IS NULL OR ultimo_digest < ?)", true, ahora.Hour(), inicioDelDia).
+		Order("id_usuario asc").
+		Limit(limite).
+		Find(&gormSuscripciones).Error
+	if err != nil {
+		return nil, err
+	}
+
+	suscripciones := make([]domain.SuscripcionDigest, 0, len(gormSuscripciones))
+	for _, s := range gormSuscripciones {
+		suscripciones = append(suscripciones, domain.SuscripcionDigest{
+			UsuarioId:    s.UsuarioId,
+			Hora:         s.Hora,
+			Activo:       s.Activo,
+			UltimoDigest: s.UltimoDigest,
+		})
+	}
+
+	return suscripciones, nil
+}
+
+func (r *postgresResumenRepository) CreateDigest(digest *domain.Digest) error {
+	secciones := make([]models.SeccionDigest, 0, len(digest.Secciones))
+	for _, s := range digest.Secciones {
+		secciones = append(secciones, models.SeccionDigest{
+			GrupoId:           s.GrupoId,
+			GrupoNombre:       s.GrupoNombre,
+			Resumen:           s.Resumen,
+			Decisiones:        s.Decisiones,
+			PreguntasAbiertas: s.PreguntasAbiertas,
+			Tareas:            mapDomainToGormTareas(s.Tareas),
+			CantidadMensajes:  s.CantidadMensajes,
+		})
+	}
+
+	gormDigest := models.Digests{
+		UsuarioId: digest.UsuarioId,
+		Desde:     digest.Desde,
+		Hasta:     digest.Hasta,
+		Secciones: secciones,
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&gormDigest).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.SuscripcionesDigest{}).
+			Where("id_usuario = ?", digest.UsuarioId).
+			Update("ultimo_digest", digest.Hasta).Error
+		if err != nil {
+			return err
+		}
+
+		digest.Id = gormDigest.Id
+		digest.CreatedAt = gormDigest.CreatedAt
+		return nil
+	})
+}
+
+// GetDigests lista los digests del usuario, los más recientes primero
+func (r *postgresResumenRepository) GetDigests(usuarioId uint64, limite int) ([]domain.Digest, error) {
+	var gormDigests []models.Digests
+
+	err := r.db.Where("id_usuario = ?", usuarioId).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormDigests).Error
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]domain.Digest, 0, len(gormDigests))
+	for i := range gormDigests {
+		digests = append(digests, mapGormToDomainDigest(&gormDigests[i]))
+	}
+
+	return digests, nil
+}
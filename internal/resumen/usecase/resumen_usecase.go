@@ -0,0 +1,413 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// maxMensajesResumen acota los mensajes de un rango que se resumen; se conservan los más recientes
+	maxMensajesResumen = 2000
+	// maxMensajesDigest acota los mensajes por grupo que entran en un digest
+	maxMensajesDigest = 500
+	// periodoDigest es cuánto hacia atrás cubre un digest cuando no hay uno anterior más reciente
+	periodoDigest = 24 * time.Hour
+	// respuestaTokens se reserva en la ventana de contexto para el resumen generado
+	respuestaTokens = 1024
+	// minBloqueTokens evita bloques demasiado pequeños en modelos con poco contexto
+	minBloqueTokens = 512
+
+	defaultHoraDigest    = 8
+	defaultLimiteDigests = 7
+	maxLimiteDigests     = 30
+	layoutMensaje        = "2006-01-02 15:04"
+	layoutRango          = "2006-01-02"
+)
+
+// ErrResumenesDeshabilitados indica que no hay un modelo de resúmenes configurado
+var ErrResumenesDeshabilitados = errors.New("los resúmenes no están habilitados (SUMMARIES_MODEL vacío)")
+
+// resumenSchema es el JSON schema del resumen que se pide al modelo
+var resumenSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"resumen": {"type": "string"},
+		"decisiones": {"type": "array", "items": {"type": "string"}},
+		"preguntasAbiertas": {"type": "array", "items": {"type": "string"}},
+		"tareas": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"responsable": {"type": "string"},
+					"tarea": {"type": "string"}
+				},
+				"required": ["responsable", "tarea"]
+			}
+		}
+	},
+	"required": ["resumen", "decisiones", "preguntasAbiertas", "tareas"]
+}`)
+
+const instruccionesResumen = "Resumes conversaciones de un chat grupal para alguien que no las leyó. " +
+	"Responde solo con un objeto JSON con: resumen (texto plano breve con los temas principales), " +
+	"decisiones (acuerdos tomados), preguntasAbiertas (preguntas que quedaron sin respuesta) y " +
+	"tareas (acciones pendientes con el @apodo del responsable, o vacío si nadie la tomó). " +
+	"Si recibes un resumen anterior, devuelve el resumen actualizado con los mensajes nuevos: " +
+	"quita las preguntas que ya se respondieron y las tareas que se cumplieron."
+
+type resumenUseCase struct {
+	repo             domain.ResumenRepository
+	repoMensaje      domain.MensajeRepository
+	repoGrupo        domain.GrupoRepository
+	repoGrupoUsuario domain.GrupoUsuarioRepository
+	// provider es nil cuando los resúmenes están deshabilitados
+	provider llm.Provider
+	modelo   string
+}
+
+func NewResumenUseCase(repo domain.ResumenRepository, repoMensaje domain.MensajeRepository, repoGrupo domain.GrupoRepository, repoGrupoUsuario domain.GrupoUsuarioRepository, provider llm.Provider, modelo string) domain.ResumenUseCase {
+	return &resumenUseCase{
+		repo:             repo,
+		repoMensaje:      repoMensaje,
+		repoGrupo:        repoGrupo,
+		repoGrupoUsuario: repoGrupoUsuario,
+		provider:         provider,
+		modelo:           modelo,
+	}
+}
+
+func (u *resumenUseCase) Resumir(ctx context.Context, usuarioId uint64, grupoId uint64, fechaInicio time.Time, fechaFin time.Time) (*domain.ResumenIA, error) {
+	if u.provider == nil {
+		return nil, ErrResumenesDeshabilitados
+	}
+
+	if !fechaInicio.IsZero() && !fechaFin.IsZero() && fechaInicio.After(fechaFin) {
+		return nil, errors.New("la fecha de inicio no puede ser posterior a la fecha de fin")
+	}
+
+	if err := u.verificarMiembro(usuarioId, grupoId); err != nil {
+		return nil, err
+	}
+
+	// El resumen guardado sirve mientras el rango tenga los mismos mensajes; se comprueba sin cargarlos
+	cantidad, ultimoId, err := u.repoMensaje.ContarPorFechas(grupoId, fechaInicio, fechaFin)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los mensajes: %w", err)
+	}
+
+	resumen := &domain.ResumenIA{
+		GrupoId:          grupoId,
+		Rango:            rangoClave(fechaInicio, fechaFin),
+		CantidadMensajes: cantidad,
+		HastaMensajeId:   ultimoId,
+		Modelo:           u.modelo,
+	}
+	if cantidad == 0 {
+		resumen.ContenidoResumen = contenidoVacio("No hay mensajes en el rango indicado.")
+		return resumen, nil
+	}
+
+	guardado, err := u.repo.GetByRango(grupoId, resumen.Rango)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el resumen guardado: %w", err)
+	}
+	if guardado != nil && guardado.CantidadMensajes == resumen.CantidadMensajes &&
+		guardado.HastaMensajeId == resumen.HastaMensajeId && guardado.Modelo == u.modelo {
+		return guardado, nil
+	}
+
+	mensajes, err := u.repoMensaje.GetUltimosPorFechas(grupoId, fechaInicio, fechaFin, maxMensajesResumen)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los mensajes: %w", err)
+	}
+	if len(mensajes) == 0 {
+		resumen.ContenidoResumen = contenidoVacio("No hay mensajes en el rango indicado.")
+		return resumen, nil
+	}
+	resumen.Parcial = cantidad > len(mensajes)
+
+	resumen.ContenidoResumen, err = u.resumirMensajes(ctx, mensajes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Save(resumen); err != nil {
+		return nil, fmt.Errorf("error al guardar el resumen: %w", err)
+	}
+
+	return resumen, nil
+}
+
+func (u *resumenUseCase) GetSuscripcion(usuarioId uint64) (*domain.SuscripcionDigest, error) {
+	suscripcion, err := u.repo.GetSuscripcion(usuarioId)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la suscripción: %w", err)
+	}
+	if suscripcion == nil {
+		return &domain.SuscripcionDigest{UsuarioId: usuarioId, Hora: defaultHoraDigest}, nil
+	}
+
+	return suscripcion, nil
+}
+
+func (u *resumenUseCase) SaveSuscripcion(suscripcion *domain.SuscripcionDigest) error {
+	if suscripcion == nil {
+		return errors.New("la suscripción no puede ser nula")
+	}
+	if suscripcion.UsuarioId <= 0 {
+		return errors.New("el ID del usuario debe ser mayor que cero")
+	}
+	if suscripcion.Hora < 0 || suscripcion.Hora > 23 {
+		return errors.New("la hora del digest debe estar entre 0 y 23")
+	}
+	if suscripcion.Activo && u.provider == nil {
+		return ErrResumenesDeshabilitados
+	}
+
+	return u.repo.SaveSuscripcion(suscripcion)
+}
+
+func (u *resumenUseCase) GetDigests(usuarioId uint64, limite int) ([]domain.Digest, error) {
+	if limite <= 0 {
+		limite = defaultLimiteDigests
+	}
+	limite = min(limite, maxLimiteDigests)
+
+	return u.repo.GetDigests(usuarioId, limite)
+}
+
+func (u *resumenUseCase) GenerarDigestsPendientes(ctx context.Context, ahora time.Time, limite int) (int, error) {
+	if u.provider == nil {
+		return 0, ErrResumenesDeshabilitados
+	}
+
+	suscripciones, err := u.repo.GetSuscripcionesPendientes(ahora, limite)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener las suscripciones pendientes: %w", err)
+	}
+
+	generados := 0
+	for _, suscripcion := range suscripciones {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := u.generarDigest(ctx, suscripcion, ahora); err != nil {
+			log.Printf("Resumen: error al generar el digest del usuario %d: %v", suscripcion.UsuarioId, err)
+			continue
+		}
+		generados++
+	}
+
+	return generados, nil
+}
+
+// generarDigest resume la actividad de cada grupo del usuario desde su último digest (como
+// mucho periodoDigest hacia atrás). Los grupos sin mensajes nuevos no aparecen.
+func (u *resumenUseCase) generarDigest(ctx context.Context, suscripcion domain.SuscripcionDigest, ahora time.Time) error {
+	desde := ahora.Add(-periodoDigest)
+	if suscripcion.UltimoDigest != nil && suscripcion.UltimoDigest.After(desde) {
+		desde = *suscripcion.UltimoDigest
+	}
+
+	grupos, err := u.repoGrupo.GetAllByUsuarioId(suscripcion.UsuarioId)
+	if err != nil {
+		return fmt.Errorf("error al obtener los grupos: %w", err)
+	}
+
+	digest := &domain.Digest{
+		UsuarioId: suscripcion.UsuarioId,
+		Desde:     desde,
+		Hasta:     ahora,
+		Secciones: []domain.SeccionDigest{},
+	}
+
+	for _, grupo := range grupos {
+		// El repositorio filtra por días completos; se recorta al período exacto
+		mensajes, err := u.repoMensaje.GetAllByGrupoId(grupo.Id, desde, ahora)
+		if err != nil {
+			return fmt.Errorf("error al obtener los mensajes del grupo %d: %w", grupo.Id, err)
+		}
+		mensajes = slices.DeleteFunc(mensajes, func(m domain.Mensaje) bool {
+			return !m.Fecha.After(desde) || m.Fecha.After(ahora)
+		})
+		if len(mensajes) == 0 {
+			continue
+		}
+		slices.SortFunc(mensajes, func(a, b domain.Mensaje) int {
+			return cmp.Compare(a.Id, b.Id)
+		})
+
+		cantidad := len(mensajes)
+		if cantidad > maxMensajesDigest {
+			mensajes = mensajes[cantidad-maxMensajesDigest:]
+		}
+
+		contenido, err := u.resumirMensajes(ctx, mensajes)
+		if err != nil {
+			return fmt.Errorf("error al resumir el grupo %d: %w", grupo.Id, err)
+		}
+
+		digest.Secciones = append(digest.Secciones, domain.SeccionDigest{
+			GrupoId:          grupo.Id,
+			GrupoNombre:      grupo.Nombre,
+			ContenidoResumen: contenido,
+			CantidadMensajes: cantidad,
+		})
+	}
+
+	return u.repo.CreateDigest(digest)
+}
+
+// resumirMensajes divide los mensajes en bloques que caben en la ventana del modelo y los va
+// incorporando a un resumen acumulado, del más antiguo al más reciente
+func (u *resumenUseCase) resumirMensajes(ctx context.Context, mensajes []domain.Mensaje) (domain.ContenidoResumen, error) {
+	presupuesto := max(llm.ContextWindow(u.modelo)/2-respuestaTokens, minBloqueTokens)
+
+	var contenido *domain.ContenidoResumen
+	for _, bloque := range llm.ChunkByTokens(mensajes, presupuesto, formatMensaje) {
+		actualizado, err := u.resumirBloque(ctx, contenido, bloque)
+		if err != nil {
+			return domain.ContenidoResumen{}, err
+		}
+		contenido = actualizado
+	}
+
+	return *contenido, nil
+}
+
+// resumirBloque pide al modelo el resumen del bloque, partiendo del resumen anterior si lo hay
+func (u *resumenUseCase) resumirBloque(ctx context.Context, previo *domain.ContenidoResumen, mensajes []domain.Mensaje) (*domain.ContenidoResumen, error) {
+	var prompt strings.Builder
+	if previo != nil {
+		anterior, err := json.Marshal(previo)
+		if err != nil {
+			return nil, err
+		}
+		prompt.WriteString("Resumen anterior:\n")
+		prompt.Write(anterior)
+		prompt.WriteString("\n\nMensajes nuevos:\n")
+	} else {
+		prompt.WriteString("Mensajes:\n")
+	}
+	for _, m := range mensajes {
+		prompt.WriteString(formatMensaje(m))
+		prompt.WriteString("\n")
+	}
+
+	completion, err := u.provider.Complete(ctx, llm.CompletionRequest{
+		Model: u.modelo,
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: instruccionesResumen},
+			{Role: "user", Content: prompt.String()},
+		},
+		Options: llm.CompletionOptions{
+			MaxTokens:      respuestaTokens,
+			ResponseFormat: &llm.ResponseFormat{Name: "group_summary", Schema: resumenSchema},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el resumen: %w", err)
+	}
+
+	texto := strings.TrimSpace(completion.Content)
+	if texto == "" {
+		return nil, errors.New("el modelo devolvió un resumen vacío")
+	}
+
+	contenido := decodeContenido(texto)
+	return &contenido, nil
+}
+
+// decodeContenido interpreta la respuesta del modelo. Si no es el JSON pedido (modelos sin salida
+// estructurada), el texto completo queda como resumen.
+func decodeContenido(texto string) domain.ContenidoResumen {
+	inicio, fin := strings.Index(texto, "{"), strings.LastIndex(texto, "}")
+	if inicio >= 0 && fin > inicio {
+		var contenido domain.ContenidoResumen
+		if err := json.Unmarshal([]byte(texto[inicio:fin+1]), &contenido); err == nil && strings.TrimSpace(contenido.Resumen) != "" {
+			contenido.Resumen = strings.TrimSpace(contenido.Resumen)
+			contenido.Decisiones = noVacios(contenido.Decisiones)
+			contenido.PreguntasAbiertas = noVacios(contenido.PreguntasAbiertas)
+			contenido.Tareas = slices.DeleteFunc(contenido.Tareas, func(t domain.TareaResumen) bool {
+				return strings.TrimSpace(t.Tarea) == ""
+			})
+			if contenido.Tareas == nil {
+				contenido.Tareas = []domain.TareaResumen{}
+			}
+			return contenido
+		}
+	}
+
+	return contenidoVacio(texto)
+}
+
+func contenidoVacio(resumen string) domain.ContenidoResumen {
+	return domain.ContenidoResumen{
+		Resumen:           resumen,
+		Decisiones:        []string{},
+		PreguntasAbiertas: []string{},
+		Tareas:            []domain.TareaResumen{},
+	}
+}
+
+// noVacios quita los elementos en blanco; nunca devuelve nil para que el JSON sea una lista
+func noVacios(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func formatMensaje(m domain.Mensaje) string {
+	apodo := fmt.Sprintf("usuario%d", m.UsuarioId)
+	if m.Usuario != nil && m.Usuario.Apodo != "" {
+		apodo = m.Usuario.Apodo
+	}
+	return fmt.Sprintf("[%s] @%s: %s", m.Fecha.Format(layoutMensaje), apodo, m.Contenido)
+}
+
+// rangoClave identifica el rango como lo aplica el repositorio de mensajes (días completos)
+func rangoClave(fechaInicio time.Time, fechaFin time.Time) string {
+	var desde, hasta string
+	if !fechaInicio.IsZero() {
+		desde = fechaInicio.Format(layoutRango)
+	}
+	if !fechaFin.IsZero() {
+		hasta = fechaFin.Format(layoutRango)
+	}
+	return desde + ".." + hasta
+}
+
+func (u *resumenUseCase) verificarMiembro(usuarioId uint64, grupoId uint64) error {
+	if grupoId <= 0 {
+		return errors.New("el ID del grupo debe ser mayor que cero")
+	}
+
+	grupo, err := u.repoGrupo.GetById(grupoId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+
+	esMiembro, err := u.repoGrupoUsuario.VerifyMembership(usuarioId, grupo.Clave)
+	if err != nil {
+		return fmt.Errorf("error al verificar la membresía: %w", err)
+	}
+	if !esMiembro {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SalidaFallidaHandler struct {
+	SFUsecase domain.SalidaFallidaUseCase
+}
+
+// NewAdminSalidaFallidaHandler registra los endpoints para inspeccionar las salidas fallidas de los bots
+func NewAdminSalidaFallidaHandler(group fiber.Router, sfu domain.SalidaFallidaUseCase) {
+	handler := &SalidaFallidaHandler{
+		SFUsecase: sfu,
+	}
+
+	group.Get("/bots/:id/salidas-fallidas", handler.GetSalidasFallidas)
+}
+
+func (h *SalidaFallidaHandler) GetSalidasFallidas(c *fiber.Ctx) error {
+	id, err := pkg.ValidateParamsId(c)
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener las salidas fallidas", "Error parametro", err.Error())
+	}
+
+	salidas, err := h.SFUsecase.GetByBotId(id, c.QueryInt("limit", 0))
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener las salidas fallidas", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Salidas fallidas obtenidas correctamente", "", salidas)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type postgresSalidaFallidaRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresSalidaFallidaRepository(db *gorm.DB) domain.SalidaFallidaRepository {
+	return &postgresSalidaFallidaRepository{db: db}
+}
+
+func mapGormToDomainSalida(gormSalida *models.SalidasFallidas) *domain.SalidaFallida {
+	if gormSalida == nil {
+		return nil
+	}
+
+	return &domain.SalidaFallida{
+		Id:         gormSalida.Id,
+		UsuarioId:  gormSalida.UsuarioId,
+		GrupoId:    gormSalida.GrupoId,
+		Salida:     gormSalida.Salida,
+		Reparacion: gormSalida.Reparacion,
+		Error:      gormSalida.Error,
+		CreatedAt:  gormSalida.CreatedAt,
+	}
+}
+
+func (r *postgresSalidaFallidaRepository) Create(salida *domain.SalidaFallida) error {
+	gormSalida := models.SalidasFallidas{
+		UsuarioId:  salida.UsuarioId,
+		GrupoId:    salida.GrupoId,
+		Salida:     salida.Salida,
+		Reparacion: salida.Reparacion,
+		Error:      salida.Error,
+	}
+
+	if err := r.db.Create(&gormSalida).Error; err != nil {
+		return err
+	}
+
+	salida.Id = gormSalida.Id
+	salida.CreatedAt = gormSalida.CreatedAt
+	return nil
+}
+
+func (r *postgresSalidaFallidaRepository) GetByUsuarioId(usuarioId uint64, limite int) ([]domain.SalidaFallida, error) {
+	var gormSalidas []models.SalidasFallidas
+
+	err := r.db.Where("id_usuario = ?", usuarioId).
+		Order("id desc").
+		Limit(limite).
+		Find(&gormSalidas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	salidas := make([]domain.SalidaFallida, 0, len(gormSalidas))
+	for i := range gormSalidas {
+		salidas = append(salidas, *mapGormToDomainSalida(&gormSalidas[i]))
+	}
+
+	return salidas, nil
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+)
+
+const (
+	defaultLimiteSalidas = 50
+	maxLimiteSalidas     = 500
+)
+
+type salidaFallidaUseCase struct {
+	repo    domain.SalidaFallidaRepository
+	repoBot domain.BotRepository
+}
+
+func NewSalidaFallidaUseCase(repo domain.SalidaFallidaRepository, repoBot domain.BotRepository) domain.SalidaFallidaUseCase {
+	return &salidaFallidaUseCase{
+		repo:    repo,
+		repoBot: repoBot,
+	}
+}
+
+func (u *salidaFallidaUseCase) Registrar(salida *domain.SalidaFallida) error {
+	if salida == nil {
+		return errors.New("la salida no puede ser nula")
+	}
+
+	if salida.UsuarioId <= 0 || salida.GrupoId <= 0 {
+		return errors.New("la salida debe indicar el usuario IA y el grupo")
+	}
+
+	return u.repo.Create(salida)
+}
+
+func (u *salidaFallidaUseCase) GetByBotId(botId uint64, limite int) ([]domain.SalidaFallida, error) {
+	if limite <= 0 {
+		limite = defaultLimiteSalidas
+	}
+	limite = min(limite, maxLimiteSalidas)
+
+	bot, err := u.repoBot.GetById(botId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el bot: %w", err)
+	}
+
+	return u.repo.GetByUsuarioId(bot.UsuarioId, limite)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgresTraduccionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresTraduccionRepository(db *gorm.DB) domain.TraduccionRepository {
+	return &postgresTraduccionRepository{db: db}
+}
+
+func mapGormToDomainTraduccion(gormTraduccion *models.TraduccionesMensajes) *domain.TraduccionMensaje {
+	if gormTraduccion == nil {
+		return nil
+	}
+
+	return &domain.TraduccionMensaje{
+		MensajeId:    gormTraduccion.MensajeId,
+		Idioma:       gormTraduccion.Idioma,
+		Contenido:    gormTraduccion.Contenido,
+		HashOriginal: gormTraduccion.HashOriginal,
+		CreatedAt:    gormTraduccion.CreatedAt,
+	}
+}
+
+func (r *postgresTraduccionRepository) GetByMensajes(mensajesIds []uint64, idioma string) ([]domain.TraduccionMensaje, error) {
+	traducciones := []domain.TraduccionMensaje{}
+	if len(mensajesIds) == 0 {
+		return traducciones, nil
+	}
+
+	var gormTraducciones []models.TraduccionesMensajes
+	err := r.db.Where("id_mensaje IN ? AND idioma = ?", mensajesIds, idioma).Find(&gormTraducciones).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar las traducciones: %w", err)
+	}
+
+	for i := range gormTraducciones {
+		traducciones = append(traducciones, *mapGormToDomainTraduccion(&gormTraducciones[i]))
+	}
+	return traducciones, nil
+}
+
+func (r *postgresTraduccionRepository) Save(traduccion *domain.TraduccionMensaje) error {
+	gormTraduccion := models.TraduccionesMensajes{
+		MensajeId:    traduccion.MensajeId,
+		Idioma:       traduccion.Idioma,
+		Contenido:    traduccion.Contenido,
+		HashOriginal: traduccion.HashOriginal,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_mensaje"}, {Name: "idioma"}},
+		DoUpdates: clause.AssignmentColumns([]string{"contenido", "hash_original", "created_at"}),
+	}).Create(&gormTraduccion).Error
+	if err != nil {
+		return err
+	}
+
+	traduccion.CreatedAt = gormTraduccion.CreatedAt
+	return nil
+}
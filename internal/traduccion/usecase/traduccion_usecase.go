@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/llm"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// timeoutTraduccion acota cada llamada al modelo de traducción
+	timeoutTraduccion   = 20 * time.Second
+	maxTokensTraduccion = 1024
+	// maxTraduccionesPorConsulta acota las traducciones nuevas que dispara cada consulta de los
+	// mensajes de un grupo; se traducen primero los más recientes y el resto en las siguientes
+	maxTraduccionesPorConsulta = 20
+	// traduccionesConcurrentes limita las llamadas simultáneas al modelo en todo el servidor
+	traduccionesConcurrentes = 4
+)
+
+const instruccionesTraduccion = "Traduces mensajes de un chat grupal al %s. Responde solo con la traducción, " +
+	"sin comillas, notas ni explicaciones. Conserva las @menciones, los enlaces, los emojis y el formato. " +
+	"Si el mensaje ya está en %s, devuélvelo sin cambios."
+
+type traduccionUseCase struct {
+	repo        domain.TraduccionRepository
+	repoGrupo   domain.GrupoRepository
+	repoUsuario domain.UsuarioRepository
+	grupoIA     domain.GrupoIAUseCase
+	publicador  domain.PublicadorTraducciones
+	// provider es nil cuando las traducciones están deshabilitadas
+	provider llm.Provider
+	modelo   string
+
+	llamadas chan struct{}
+	// enCurso tiene las traducciones en segundo plano que aún no terminaron, por mensaje e idioma,
+	// para no pedir la misma dos veces si el lector vuelve a consultar mientras tanto
+	enCurso sync.Map
+}
+
+func NewTraduccionUseCase(repo domain.TraduccionRepository, repoGrupo domain.GrupoRepository, repoUsuario domain.UsuarioRepository, giu domain.GrupoIAUseCase, publicador domain.PublicadorTraducciones, provider llm.Provider, modelo string) domain.TraduccionUseCase {
+	return &traduccionUseCase{
+		repo:        repo,
+		repoGrupo:   repoGrupo,
+		repoUsuario: repoUsuario,
+		grupoIA:     giu,
+		publicador:  publicador,
+		provider:    provider,
+		modelo:      modelo,
+		llamadas:    make(chan struct{}, traduccionesConcurrentes),
+	}
+}
+
+// claveEnCurso identifica una traducción en segundo plano
+type claveEnCurso struct {
+	mensajeId uint64
+	idioma    string
+}
+
+func (u *traduccionUseCase) TraducirMensajes(lectorId uint64, grupoClave string, mensajes []domain.Mensaje) error {
+	if u.provider == nil || len(mensajes) == 0 {
+		return nil
+	}
+
+	lector, err := u.repoUsuario.GetById(lectorId)
+	if err != nil {
+		return fmt.Errorf("error al obtener el usuario: %w", err)
+	}
+	if lector.Idioma == "" {
+		return nil
+	}
+
+	grupo, err := u.grupoTraducido(grupoClave)
+	if err != nil || grupo == nil {
+		return err
+	}
+
+	ids := make([]uint64, 0, len(mensajes))
+	for _, mensaje := range mensajes {
+		ids = append(ids, mensaje.Id)
+	}
+	guardadas, err := u.guardadas(ids, lector.Idioma)
+	if err != nil {
+		return err
+	}
+
+	// Del más reciente al más antiguo, para que los nuevos se traduzcan primero
+	pendientes := 0
+	for i := len(mensajes) - 1; i >= 0; i-- {
+		mensaje := &mensajes[i]
+		if mensaje.UsuarioId == lectorId || strings.TrimSpace(mensaje.Contenido) == "" {
+			continue
+		}
+		if guardada, ok := guardadas[mensaje.Id]; ok && guardada.HashOriginal == hashContenido(mensaje.Contenido) {
+			mensaje.Traduccion = mostrar(*mensaje, guardada)
+			continue
+		}
+		if pendientes < maxTraduccionesPorConsulta {
+			pendientes++
+			u.traducirEnSegundoPlano(lectorId, grupoClave, *mensaje, lector.Idioma)
+		}
+	}
+
+	return nil
+}
+
+// traducirEnSegundoPlano genera la traducción sin demorar la consulta y se la envía al lector.
+// Si ya se está traduciendo ese mensaje al idioma, no hace nada.
+func (u *traduccionUseCase) traducirEnSegundoPlano(lectorId uint64, grupoClave string, mensaje domain.Mensaje, idioma string) {
+	clave := claveEnCurso{mensajeId: mensaje.Id, idioma: idioma}
+	if _, enCurso := u.enCurso.LoadOrStore(clave, struct{}{}); enCurso {
+		return
+	}
+
+	go func() {
+		defer u.enCurso.Delete(clave)
+
+		traduccion, err := u.traducir(mensaje, idioma)
+		if err != nil {
+			log.Printf("Traducción: no se pudo traducir el mensaje %d al %s: %v", mensaje.Id, idioma, err)
+			return
+		}
+		if mostrada := mostrar(mensaje, *traduccion); mostrada != nil && u.publicador != nil {
+			u.publicador.PublicarTraduccion(lectorId, grupoClave, mensaje, *mostrada)
+		}
+	}()
+}
+
+func (u *traduccionUseCase) TraducirParaLectores(grupoClave string, mensaje domain.Mensaje, lectoresIds []uint64) (map[uint64]domain.Traduccion, error) {
+	if u.provider == nil || mensaje.Id == 0 || strings.TrimSpace(mensaje.Contenido) == "" || len(lectoresIds) == 0 {
+		return nil, nil
+	}
+
+	grupo, err := u.grupoTraducido(grupoClave)
+	if err != nil || grupo == nil {
+		return nil, err
+	}
+
+	integrantes, err := u.repoUsuario.GetAllByGrupoId(grupo.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los integrantes del grupo: %w", err)
+	}
+	idiomas := make(map[uint64]string, len(integrantes))
+	for _, integrante := range integrantes {
+		if integrante.Idioma != "" && !integrante.IsLlm {
+			idiomas[integrante.Id] = integrante.Idioma
+		}
+	}
+
+	// Una traducción por idioma, compartida por todos los lectores que lo eligieron
+	porIdioma := make(map[string]*domain.Traduccion)
+	traducciones := make(map[uint64]domain.Traduccion)
+	for _, lectorId := range lectoresIds {
+		idioma, ok := idiomas[lectorId]
+		if !ok || lectorId == mensaje.UsuarioId {
+			continue
+		}
+
+		traduccion, ok := porIdioma[idioma]
+		if !ok {
+			traduccion, err = u.obtener(mensaje, idioma)
+			if err != nil {
+				log.Printf("Traducción: no se pudo traducir el mensaje %d al %s: %v", mensaje.Id, idioma, err)
+			}
+			porIdioma[idioma] = traduccion
+		}
+		if traduccion != nil {
+			traducciones[lectorId] = *traduccion
+		}
+	}
+
+	return traducciones, nil
+}
+
+// grupoTraducido devuelve el grupo si tiene la traducción activada en su configuración de IA
+// (nil si no la tiene)
+func (u *traduccionUseCase) grupoTraducido(grupoClave string) (*domain.Grupo, error) {
+	grupo, status, err := u.repoGrupo.GetByClave(grupoClave)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el grupo: %w", err)
+	}
+	if status == 404 || grupo == nil {
+		return nil, errors.New("el grupo no existe")
+	}
+
+	config, err := u.grupoIA.GetByGrupoId(grupo.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la configuración de IA del grupo: %w", err)
+	}
+	if !config.Traduccion {
+		return nil, nil
+	}
+	return grupo, nil
+}
+
+// guardadas devuelve las traducciones existentes de los mensajes al idioma, por ID de mensaje
+func (u *traduccionUseCase) guardadas(ids []uint64, idioma string) (map[uint64]domain.TraduccionMensaje, error) {
+	traducciones, err := u.repo.GetByMensajes(ids, idioma)
+	if err != nil {
+		return nil, err
+	}
+
+	porMensaje := make(map[uint64]domain.TraduccionMensaje, len(traducciones))
+	for _, traduccion := range traducciones {
+		porMensaje[traduccion.MensajeId] = traduccion
+	}
+	return porMensaje, nil
+}
+
+// obtener devuelve la traducción a mostrar, usando la guardada si corresponde al contenido actual.
+// Devuelve nil si el mensaje ya está en ese idioma.
+func (u *traduccionUseCase) obtener(mensaje domain.Mensaje, idioma string) (*domain.Traduccion, error) {
+	guardadas, err := u.guardadas([]uint64{mensaje.Id}, idioma)
+	if err != nil {
+		return nil, err
+	}
+	if guardada, ok := guardadas[mensaje.Id]; ok && guardada.HashOriginal == hashContenido(mensaje.Contenido) {
+		return mostrar(mensaje, guardada), nil
+	}
+
+	traduccion, err := u.traducir(mensaje, idioma)
+	if err != nil {
+		return nil, err
+	}
+	return mostrar(mensaje, *traduccion), nil
+}
+
+// traducir pide la traducción al modelo y la guarda
+func (u *traduccionUseCase) traducir(mensaje domain.Mensaje, idioma string) (*domain.TraduccionMensaje, error) {
+	nombre := domain.NombreIdioma(idioma)
+	if nombre == "" {
+		return nil, fmt.Errorf("idioma no soportado %q", idioma)
+	}
+
+	u.llamadas <- struct{}{}
+	defer func() { <-u.llamadas }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutTraduccion)
+	defer cancel()
+
+	completion, err := u.provider.Complete(ctx, llm.CompletionRequest{
+		Model: u.modelo,
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: fmt.Sprintf(instruccionesTraduccion, nombre, nombre)},
+			{Role: "user", Content: mensaje.Contenido},
+		},
+		Options: llm.CompletionOptions{MaxTokens: maxTokensTraduccion},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al generar la traducción: %w", err)
+	}
+
+	texto := strings.TrimSpace(completion.Content)
+	if texto == "" {
+		return nil, errors.New("el modelo devolvió una traducción vacía")
+	}
+
+	traduccion := &domain.TraduccionMensaje{
+		MensajeId:    mensaje.Id,
+		Idioma:       idioma,
+		Contenido:    texto,
+		HashOriginal: hashContenido(mensaje.Contenido),
+	}
+	// Si no se puede guardar, la traducción se muestra igual y se vuelve a generar la próxima vez
+	if err := u.repo.Save(traduccion); err != nil {
+		log.Printf("Traducción: error al guardar la traducción del mensaje %d: %v", mensaje.Id, err)
+	}
+	return traduccion, nil
+}
+
+// mostrar devuelve la traducción para el lector, o nil si coincide con el original (el mensaje
+// ya estaba en su idioma)
+func mostrar(mensaje domain.Mensaje, traduccion domain.TraduccionMensaje) *domain.Traduccion {
+	if strings.EqualFold(normalizar(traduccion.Contenido), normalizar(mensaje.Contenido)) {
+		return nil
+	}
+	return &domain.Traduccion{Idioma: traduccion.Idioma, Contenido: traduccion.Contenido}
+}
+
+func normalizar(texto string) string {
+	return strings.Join(strings.Fields(texto), " ")
+}
+
+func hashContenido(contenido string) string {
+	sum := sha256.Sum256([]byte(contenido))
+	return hex.EncodeToString(sum[:])
+}
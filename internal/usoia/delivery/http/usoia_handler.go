@@ -0,0 +1,41 @@
+package http
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UsoIAHandler struct {
+	UIUsecase domain.UsoIAUseCase
+}
+
+// NewAdminUsoIAHandler registra el reporte de uso de los bots
+func NewAdminUsoIAHandler(group fiber.Router, uiu domain.UsoIAUseCase) {
+	handler := &UsoIAHandler{
+		UIUsecase: uiu,
+	}
+
+	group.Get("/uso-ia", handler.GetReporte)
+}
+
+func (h *UsoIAHandler) GetReporte(c *fiber.Ctx) error {
+	botId := c.QueryInt("botId", 0)
+	grupoId := c.QueryInt("grupoId", 0)
+	if botId < 0 || grupoId < 0 {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al obtener el uso de los bots", "Error parametro", "botId y grupoId deben ser positivos")
+	}
+
+	reporte, err := h.UIUsecase.GetReporte(domain.FiltroUsoIA{
+		BotId:   uint64(botId),
+		GrupoId: uint64(grupoId),
+		Desde:   c.Query("desde"),
+		Hasta:   c.Query("hasta"),
+	})
+	if err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al obtener el uso de los bots", "Error interno", err.Error())
+	}
+
+	return pkg.ResponseJson(c, fiber.StatusOK, "Uso de los bots obtenido correctamente", "", reporte)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const formatoFecha = "2006-01-02"
+
+type postgresUsoIARepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresUsoIARepository(db *gorm.DB) domain.UsoIARepository {
+	return &postgresUsoIARepository{db: db}
+}
+
+func mapGormToDomainUso(gormUso *models.UsosIA) *domain.UsoIA {
+	if gormUso == nil {
+		return nil
+	}
+
+	return &domain.UsoIA{
+		UsuarioId:        gormUso.UsuarioId,
+		GrupoId:          gormUso.GrupoId,
+		Fecha:            gormUso.Fecha.Format(formatoFecha),
+		Llamadas:         gormUso.Llamadas,
+		Errores:          gormUso.Errores,
+		Bloqueadas:       gormUso.Bloqueadas,
+		PromptTokens:     gormUso.PromptTokens,
+		CompletionTokens: gormUso.CompletionTokens,
+		TotalTokens:      gormUso.TotalTokens,
+		LatenciaTotalMs:  gormUso.LatenciaTotalMs,
+		LatenciaMaxMs:    gormUso.LatenciaMaxMs,
+		UltimoError:      gormUso.UltimoError,
+	}
+}
+
+// Sumar agrega el consumo con un upsert para que las llamadas concurrentes no se pisen
+func (r *postgresUsoIARepository) Sumar(uso *domain.UsoIA) error {
+	fecha, err := time.Parse(formatoFecha, uso.Fecha)
+	if err != nil {
+		return fmt.Errorf("fecha de uso inválida %q: %w", uso.Fecha, err)
+	}
+
+	gormUso := models.UsosIA{
+		UsuarioId:        uso.UsuarioId,
+		GrupoId:          uso.GrupoId,
+		Fecha:            fecha,
+		Llamadas:         uso.Llamadas,
+		Errores:          uso.Errores,
+		Bloqueadas:       uso.Bloqueadas,
+		PromptTokens:     uso.PromptTokens,
+		CompletionTokens: uso.CompletionTokens,
+		TotalTokens:      uso.TotalTokens,
+		LatenciaTotalMs:  uso.LatenciaTotalMs,
+		LatenciaMaxMs:    uso.LatenciaMaxMs,
+		UltimoError:      uso.UltimoError,
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id_usuario"}, {Name: "id_grupo"}, {Name: "fecha"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"llamadas":          gorm.Expr("usos_ia.llamadas + EXCLUDED.llamadas"),
+			"errores":           gorm.Expr("usos_ia.errores + EXCLUDED.errores"),
+			"bloqueadas":        gorm.Expr("usos_ia.bloqueadas + EXCLUDED.bloqueadas"),
+			"prompt_tokens":     gorm.Expr("usos_ia.prompt_tokens + EXCLUDED.prompt_tokens"),
+			"completion_tokens": gorm.Expr("usos_ia.completion_tokens + EXCLUDED.completion_tokens"),
+			"total_tokens":      gorm.Expr("usos_ia.total_tokens + EXCLUDED.total_tokens"),
+			"latencia_total_ms": gorm.Expr("usos_ia.latencia_total_ms + EXCLUDED.latencia_total_ms"),
+			"latencia_max_ms":   gorm.Expr("GREATEST(usos_ia.latencia_max_ms, EXCLUDED.latencia_max_ms)"),
+			"ultimo_error":      gorm.Expr("CASE WHEN EXCLUDED.ultimo_error <> '' THEN EXCLUDED.ultimo_error ELSE usos_ia.ultimo_error END"),
+			"updated_at":        gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&gormUso).Error
+}
+
+// GetTokensDia devuelve los tokens consumidos por el bot en todos sus grupos durante el día
+func (r *postgresUsoIARepository) GetTokensDia(usuarioId uint64, fecha string) (int, error) {
+	var total int
+
+	err := r.db.Model(&models.UsosIA{}).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Where("id_usuario = ? AND fecha = ?", usuarioId, fecha).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *postgresUsoIARepository) GetRango(usuarioId uint64, grupoId uint64, desde string, hasta string) ([]domain.UsoIA, error) {
+	var gormUsos []models.UsosIA
+
+	query := r.db.Where("fecha BETWEEN ? AND ?", desde, hasta)
+	if usuarioId > 0 {
+		query = query.Where("id_usuario = ?", usuarioId)
+	}
+	if grupoId > 0 {
+		query = query.Where("id_grupo = ?", grupoId)
+	}
+
+	if err := query.Order("fecha, id_usuario, id_grupo").Find(&gormUsos).Error; err != nil {
+		return nil, err
+	}
+
+	usos := make([]domain.UsoIA, 0, len(gormUsos))
+	for i := range gormUsos {
+		usos = append(usos, *mapGormToDomainUso(&gormUsos[i]))
+	}
+
+	return usos, nil
+}
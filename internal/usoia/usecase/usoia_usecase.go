@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"chatvis-chat/internal/domain"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	formatoFecha = "2006-01-02"
+	// diasReporteDefault es el rango del reporte cuando no se indica desde
+	diasReporteDefault = 7
+	// maxDiasReporte limita el rango de fechas de un reporte
+	maxDiasReporte = 366
+)
+
+type usoIAUseCase struct {
+	repo    domain.UsoIARepository
+	repoBot domain.BotRepository
+}
+
+func NewUsoIAUseCase(repo domain.UsoIARepository, repoBot domain.BotRepository) domain.UsoIAUseCase {
+	return &usoIAUseCase{
+		repo:    repo,
+		repoBot: repoBot,
+	}
+}
+
+func (u *usoIAUseCase) Registrar(llamada domain.LlamadaIA) error {
+	if llamada.UsuarioId <= 0 || llamada.GrupoId <= 0 {
+		return errors.New("la llamada debe indicar el usuario IA y el grupo")
+	}
+
+	fecha := llamada.Fecha
+	if fecha.IsZero() {
+		fecha = time.Now()
+	}
+
+	uso := &domain.UsoIA{
+		UsuarioId: llamada.UsuarioId,
+		GrupoId:   llamada.GrupoId,
+		Fecha:     fecha.Format(formatoFecha),
+	}
+
+	if llamada.Bloqueada {
+		uso.Bloqueadas = 1
+		return u.repo.Sumar(uso)
+	}
+
+	latencia := llamada.Latencia.Milliseconds()
+	uso.Llamadas = 1
+	uso.PromptTokens = llamada.PromptTokens
+	uso.CompletionTokens = llamada.CompletionTokens
+	uso.TotalTokens = llamada.PromptTokens + llamada.CompletionTokens
+	uso.LatenciaTotalMs = latencia
+	uso.LatenciaMaxMs = latencia
+	if llamada.Estado != "ok" {
+		uso.Errores = 1
+		uso.UltimoError = llamada.Estado
+	}
+
+	return u.repo.Sumar(uso)
+}
+
+func (u *usoIAUseCase) GetTokensDia(usuarioId uint64, fecha time.Time) (int, error) {
+	return u.repo.GetTokensDia(usuarioId, fecha.Format(formatoFecha))
+}
+
+func (u *usoIAUseCase) GetReporte(filtro domain.FiltroUsoIA) (*domain.ReporteUsoIA, error) {
+	hasta := time.Now()
+	if filtro.Hasta != "" {
+		parsed, err := time.Parse(formatoFecha, filtro.Hasta)
+		if err != nil {
+			return nil, fmt.Errorf("la fecha hasta debe tener el formato AAAA-MM-DD: %w", err)
+		}
+		hasta = parsed
+	}
+
+	desde := hasta.AddDate(0, 0, -(diasReporteDefault - 1))
+	if filtro.Desde != "" {
+		parsed, err := time.Parse(formatoFecha, filtro.Desde)
+		if err != nil {
+			return nil, fmt.Errorf("la fecha desde debe tener el formato AAAA-MM-DD: %w", err)
+		}
+		desde = parsed
+	}
+
+	if desde.After(hasta) {
+		return nil, errors.New("la fecha desde no puede ser posterior a la fecha hasta")
+	}
+
+	if hasta.Sub(desde) > maxDiasReporte*24*time.Hour {
+		return nil, fmt.Errorf("el rango del reporte no puede superar %d días", maxDiasReporte)
+	}
+
+	var usuarioId uint64
+	if filtro.BotId > 0 {
+		bot, err := u.repoBot.GetById(filtro.BotId)
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar el bot: %w", err)
+		}
+		usuarioId = bot.UsuarioId
+	}
+
+	reporte := &domain.ReporteUsoIA{
+		Desde: desde.Format(formatoFecha),
+		Hasta: hasta.Format(formatoFecha),
+	}
+
+	filas, err := u.repo.GetRango(usuarioId, filtro.GrupoId, reporte.Desde, reporte.Hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el uso de los bots: %w", err)
+	}
+
+	reporte.Totales = domain.UsoIA{UsuarioId: usuarioId, GrupoId: filtro.GrupoId}
+	for i := range filas {
+		filas[i].LatenciaPromedioMs = latenciaPromedio(filas[i])
+
+		reporte.Totales.Llamadas += filas[i].Llamadas
+		reporte.Totales.Errores += filas[i].Errores
+		reporte.Totales.Bloqueadas += filas[i].Bloqueadas
+		reporte.Totales.PromptTokens += filas[i].PromptTokens
+		reporte.Totales.CompletionTokens += filas[i].CompletionTokens
+		reporte.Totales.TotalTokens += filas[i].TotalTokens
+		reporte.Totales.LatenciaTotalMs += filas[i].LatenciaTotalMs
+		reporte.Totales.LatenciaMaxMs = max(reporte.Totales.LatenciaMaxMs, filas[i].LatenciaMaxMs)
+	}
+	reporte.Totales.LatenciaPromedioMs = latenciaPromedio(reporte.Totales)
+	reporte.Filas = filas
+
+	return reporte, nil
+}
+
+// latenciaPromedio divide la latencia acumulada entre las llamadas realizadas
+func latenciaPromedio(uso domain.UsoIA) int64 {
+	if uso.Llamadas == 0 {
+		return 0
+	}
+	return uso.LatenciaTotalMs / int64(uso.Llamadas)
+}
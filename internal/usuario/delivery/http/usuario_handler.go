@@ -21,6 +21,7 @@ func NewUsuarioHandler(group fiber.Router, uu domain.UsuarioUseCase) {
 
 	group.Get("/correo/:email", handler.GetUsuarioByEmail)
 	group.Get("/token", handler.GetUsuarioByEmailToken)
+	group.Put("/idioma", handler.UpdateIdioma)
 	group.Get("/:id", handler.GetUsuarioByID)
 	group.Post("/", handler.CreateUsuario)
 }
@@ -128,6 +129,33 @@ func (h *UsuarioHandler) CreateUsuario(c *fiber.Ctx) error {
 	return pkg.ResponseJson(c, fiber.StatusCreated, "Usuario creado correctamente", "", usuario)
 }
 
+// UpdateIdioma define el idioma al que se traducen los mensajes para el usuario autenticado;
+// un idioma vacío desactiva las traducciones
+func (h *UsuarioHandler) UpdateIdioma(c *fiber.Ctx) error {
+	userId, ok := pkg.GetUserId(c)
+	if !ok {
+		return pkg.ResponseJson(c, fiber.StatusUnauthorized, "Usuario no autenticado", "Error de autenticación", "No se pudo obtener el ID del usuario")
+	}
+
+	var body struct {
+		Idioma string `json:"idioma"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar idioma", "Error de parseo", err.Error())
+	}
+
+	idioma := strings.ToLower(strings.TrimSpace(body.Idioma))
+	if idioma != "" && !domain.IdiomaSoportado(idioma) {
+		return pkg.ResponseJson(c, fiber.StatusBadRequest, "Error al actualizar idioma", "Error parametro",
+			"Idioma no soportado; soportados: "+strings.Join(domain.CodigosIdioma(), ", "))
+	}
+
+	if err := h.UUsecase.UpdateIdioma(userId, idioma); err != nil {
+		return pkg.ResponseJson(c, fiber.StatusInternalServerError, "Error al actualizar idioma", "Error interno", err.Error())
+	}
+	return pkg.ResponseJson(c, fiber.StatusOK, "Idioma actualizado correctamente", "", nil)
+}
+
 func (h *UsuarioHandler) GetAllUsuarios(c *fiber.Ctx) error {
 	usuarios, err := h.UUsecase.GetAllUsuarios()
 	if err != nil {
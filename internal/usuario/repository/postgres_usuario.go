@@ -31,6 +31,7 @@ func mapGormToDomain(gormUser *models.Usuarios) *domain.Usuario {
 		IsLlm:    gormUser.IsLlm,
 		IsAdmin:  gormUser.IsAdmin,
 		IsActive: gormUser.IsActive,
+		Idioma:   gormUser.Idioma,
 	}
 }
 
@@ -49,6 +50,7 @@ func mapDomainToGorm(domainUser *domain.Usuario) *models.Usuarios {
 		IsLlm:    domainUser.IsLlm,
 		IsAdmin:  domainUser.IsAdmin,
 		IsActive: domainUser.IsActive,
+		Idioma:   domainUser.Idioma,
 	}
 }
 
@@ -123,6 +125,28 @@ func (r *postgresUsuarioRepository) GetAllUsuarios() ([]domain.Usuario, error) {
 	return users, nil
 }
 
+func (r *postgresUsuarioRepository) GetAllByGrupoId(grupoId uint64) ([]domain.Usuario, error) {
+	var gormUsers []models.Usuarios
+
+	err := r.db.
+		Joins("JOIN grupos_usuarios ON grupos_usuarios.id_usuario = usuarios.id").
+		Where("grupos_usuarios.id_grupo = ?", grupoId).
+		Order("usuarios.id asc").
+		Find(&gormUsers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := []domain.Usuario{}
+	for _, gu := range gormUsers {
+		user := mapGormToDomain(&gu)
+		user.Password = ""
+		user.Token = ""
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
 func (r *postgresUsuarioRepository) UpdateIsActive(id uint64, isActive bool) error {
 	var existingGormUser models.Usuarios
 	if err := r.db.First(&existingGormUser, id).Error; err != nil {
@@ -137,3 +161,15 @@ func (r *postgresUsuarioRepository) UpdateIsActive(id uint64, isActive bool) err
 
 	return nil
 }
+
+func (r *postgresUsuarioRepository) UpdateIdioma(id uint64, idioma string) error {
+	result := r.db.Model(&models.Usuarios{}).Where("id = ?", id).Update("idioma", idioma)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
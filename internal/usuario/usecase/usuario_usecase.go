@@ -121,6 +121,13 @@ func (uc *usuarioUseCase) GetAllUsuarios() ([]domain.Usuario, error) {
 	return uc.repo.GetAllUsuarios()
 }
 
+func (uc *usuarioUseCase) GetAllByGrupoId(grupoId uint64) ([]domain.Usuario, error) {
+	if grupoId == 0 {
+		return nil, errors.New("el ID del grupo no puede ser 0")
+	}
+	return uc.repo.GetAllByGrupoId(grupoId)
+}
+
 func (uc *usuarioUseCase) UpdateIsActive(id uint64, isActive bool) error {
 	if id == 0 {
 		return errors.New("id no puede ser 0")
@@ -128,6 +135,18 @@ func (uc *usuarioUseCase) UpdateIsActive(id uint64, isActive bool) error {
 	return uc.repo.UpdateIsActive(id, isActive)
 }
 
+func (uc *usuarioUseCase) UpdateIdioma(id uint64, idioma string) error {
+	if id == 0 {
+		return errors.New("id no puede ser 0")
+	}
+
+	idioma = strings.ToLower(strings.TrimSpace(idioma))
+	if idioma != "" && !domain.IdiomaSoportado(idioma) {
+		return fmt.Errorf("idioma no soportado %q; soportados: %s", idioma, strings.Join(domain.CodigosIdioma(), ", "))
+	}
+	return uc.repo.UpdateIdioma(id, idioma)
+}
+
 func (uc *usuarioUseCase) ClearToken(id uint64) error {
 	if id == 0 {
 		return errors.New("id no puede ser 0")
@@ -2,26 +2,42 @@ package websocket
 
 import (
 	"chatvis-chat/internal/domain"
+	"chatvis-chat/internal/pkg"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ventanaReenvio es cuánto después de guardarse se puede difundir un mensaje por WebSocket
+const ventanaReenvio = time.Minute
+
 // WebSocketController gestionará las conexiones y usará el Hub
 type WebSocketController struct {
-	Hub          *Hub
-	GrupoUseCase domain.GrupoUseCase
+	Hub                 *Hub
+	GrupoUseCase        domain.GrupoUseCase
+	UsuarioUseCase      domain.UsuarioUseCase
+	GrupoUsuarioUseCase domain.GrupoUsuarioUseCase
+	// MensajeUseCase, si no es nil, limita la difusión a los mensajes ya guardados (y moderados)
+	// por la API; el contenido que se reenvía es el guardado, no el del frame
+	MensajeUseCase domain.MensajeUseCase
+
+	// reenviados son los mensajes ya difundidos dentro de ventanaReenvio, para no volver a
+	// difundir (ni despertar a los bots) si un cliente repite el frame
+	mu         sync.Mutex
+	reenviados map[uint64]time.Time
 }
 
 // NewWebSocketController crea un nuevo controlador de WebSocket
-func NewWebSocketController(h *Hub, gu domain.GrupoUseCase) *WebSocketController {
-	return &WebSocketController{Hub: h, GrupoUseCase: gu}
+func NewWebSocketController(h *Hub, gu domain.GrupoUseCase, uu domain.UsuarioUseCase, guu domain.GrupoUsuarioUseCase, mu domain.MensajeUseCase) *WebSocketController {
+	return &WebSocketController{Hub: h, GrupoUseCase: gu, UsuarioUseCase: uu, GrupoUsuarioUseCase: guu, MensajeUseCase: mu, reenviados: make(map[uint64]time.Time)}
 }
 
 // WebSocketUpgrade es el handler que actualiza la conexión HTTP a una WebSocket
@@ -92,8 +108,15 @@ func (c *WebSocketController) WebSocketChat(conn *websocket.Conn) {
 		return
 	}
 
-	c.Hub.Register(userIDStr, conn)
+	mutedClaves, err := c.GrupoUsuarioUseCase.GetClavesSilenciadas(idUser)
+	if err != nil {
+		log.Printf("Error al obtener los grupos silenciados del usuario %s: %v", userIDStr, err)
+	}
+
+	// Suscribir antes de registrar para que el Hub le envíe los streams de IA en curso
 	c.Hub.SubscribeUserToGroups(userIDStr, groupClaves)
+	c.Hub.SetMutedGroups(userIDStr, mutedClaves)
+	c.Hub.Register(userIDStr, conn)
 
 	defer func() {
 		c.Hub.Unregister(userIDStr)
@@ -112,7 +135,121 @@ func (c *WebSocketController) WebSocketChat(conn *websocket.Conn) {
 			continue
 		}
 
+		if msg.Type == TypeMute || msg.Type == TypeUnmute {
+			muted := msg.Type == TypeMute
+			if err := c.GrupoUsuarioUseCase.SetSilenciado(idUser, msg.GroupID, muted); err != nil {
+				log.Printf("Error al actualizar el silencio del grupo %s para %s: %v", msg.GroupID, userIDStr, err)
+				continue
+			}
+			c.Hub.SetMuted(userIDStr, msg.GroupID, muted)
+			continue
+		}
+
+		// Solo se difunde lo que pasó por la moderación al guardarse: un mensaje bloqueado o
+		// retenido para revisión no tiene ID y no puede publicarse por aquí
+		if c.MensajeUseCase != nil {
+			mensaje, err := c.mensajeGuardado(idUser, msg)
+			if err != nil {
+				log.Printf("Mensaje de %s en el grupo %s no enviado: %v", userIDStr, msg.GroupID, err)
+				continue
+			}
+			msg.Content = mensaje.Contenido
+			msg.AnswerId = ""
+			if mensaje.ResponseId != nil {
+				msg.AnswerId = strconv.FormatUint(*mensaje.ResponseId, 10)
+			}
+		}
+
+		// Los tipos de frame de IA solo los genera el servidor
+		msg.Type = ""
+		msg.StreamId = ""
 		msg.SenderID = userIDStr
+		msg.Mentions = c.resolveMentions(msg)
 		c.Hub.Broadcast(msg)
 	}
 }
+
+// mensajeGuardado devuelve el mensaje al que se refiere el frame, comprobando que sea del
+// usuario y del grupo del frame
+func (c *WebSocketController) mensajeGuardado(userID uint64, msg Message) (*domain.Mensaje, error) {
+	id, err := strconv.ParseUint(msg.Id, 10, 64)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("el frame no referencia un mensaje guardado (Id %q)", msg.Id)
+	}
+
+	mensaje, err := c.MensajeUseCase.GetById(id)
+	if err != nil || mensaje == nil {
+		return nil, fmt.Errorf("el mensaje %d no existe: %v", id, err)
+	}
+
+	grupo, err := c.GrupoUseCase.GetByClave(msg.GroupID)
+	if err != nil || grupo == nil {
+		return nil, fmt.Errorf("error al obtener el grupo: %v", err)
+	}
+
+	if mensaje.UsuarioId != userID || mensaje.GrupoId != grupo.Id {
+		return nil, fmt.Errorf("el mensaje %d no es del usuario en el grupo", id)
+	}
+	if !c.marcarReenviado(mensaje) {
+		return nil, fmt.Errorf("el mensaje %d ya se difundió", id)
+	}
+	return mensaje, nil
+}
+
+// marcarReenviado registra la difusión del mensaje. Devuelve false si ya se difundió o si se
+// guardó hace más de ventanaReenvio; así basta con recordar los de esa ventana.
+func (c *WebSocketController) marcarReenviado(mensaje *domain.Mensaje) bool {
+	ahora := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, fecha := range c.reenviados {
+		if ahora.Sub(fecha) > ventanaReenvio {
+			delete(c.reenviados, id)
+		}
+	}
+
+	if ahora.Sub(mensaje.Fecha) > ventanaReenvio {
+		return false
+	}
+	if _, ok := c.reenviados[mensaje.Id]; ok {
+		return false
+	}
+	c.reenviados[mensaje.Id] = mensaje.Fecha
+	return true
+}
+
+// resolveMentions devuelve los IDs de los integrantes del grupo mencionados con @apodo
+func (c *WebSocketController) resolveMentions(msg Message) []string {
+	apodos := pkg.ExtractMentions(msg.Content)
+	if len(apodos) == 0 {
+		return nil
+	}
+
+	grupo, err := c.GrupoUseCase.GetByClave(msg.GroupID)
+	if err != nil || grupo == nil {
+		log.Printf("Error al obtener el grupo %s para resolver menciones: %v", msg.GroupID, err)
+		return nil
+	}
+
+	miembros, err := c.UsuarioUseCase.GetAllByGrupoId(grupo.Id)
+	if err != nil {
+		log.Printf("Error al obtener los integrantes del grupo %s: %v", msg.GroupID, err)
+		return nil
+	}
+
+	porApodo := make(map[string]string, len(miembros))
+	for _, miembro := range miembros {
+		porApodo[strings.ToLower(miembro.Apodo)] = strconv.FormatUint(miembro.Id, 10)
+	}
+
+	var mentions []string
+	for _, apodo := range apodos {
+		if userID, ok := porApodo[apodo]; ok && userID != msg.SenderID {
+			mentions = append(mentions, userID)
+		}
+	}
+
+	return mentions
+}
@@ -1,21 +1,67 @@
 package websocket
 
 import (
+	"chatvis-chat/internal/domain"
 	"encoding/json"
 	"log"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gofiber/websocket/v2"
 )
 
+// Tipos de frame usados para el streaming de respuestas de IA
+const (
+	TypeAIDelta   = "ai_delta"
+	TypeAIPartial = "ai_partial"
+	TypeAIFinal   = "ai_final"
+	TypeAICancel  = "ai_cancel"
+)
+
+// Tipos de frame para menciones y silencio de grupos
+const (
+	// TypeMention se envía a cada usuario mencionado, aunque haya silenciado el grupo
+	TypeMention = "mention"
+	// TypeMute y TypeUnmute los envía el cliente para silenciar o reactivar un grupo
+	TypeMute   = "mute"
+	TypeUnmute = "unmute"
+)
+
+// Tipos de frame del indicador de escritura; no se persisten ni llegan a las IA
+const (
+	TypeTyping     = "typing"
+	TypeTypingStop = "typing_stop"
+)
+
+// Tipos de frame de membresía; se envían solo al usuario que entra o sale del grupo
+const (
+	TypeGroupJoin  = "group_join"
+	TypeGroupLeave = "group_leave"
+)
+
+// TypeTranslation lleva la traducción de un mensaje ya enviado (mismo Id) al idioma del lector
+const TypeTranslation = "translation"
+
 // Hub gestiona la difusión de mensajes a clientes por grupo
 type Hub struct {
-	clients    map[string]*websocket.Conn // ID de usuario -> Conexión
-	userGroups map[string]map[string]bool // ID de usuario -> {ID de grupo: true}
+	clients    map[string]*websocket.Conn     // ID de usuario -> Conexión
+	userGroups map[string]map[string]bool     // ID de usuario -> {ID de grupo: true}
+	streams    map[string]map[string]*Message // ID de grupo -> {ID de stream: contenido parcial}
+	muted      map[string]map[string]bool     // ID de usuario -> {ID de grupo silenciado: true}
+
+	// membershipListeners reciben los cambios de membresía después de que el Hub los aplica
+	membershipListeners []func(domain.EventoMembresia)
+	// traductor genera las traducciones de los mensajes difundidos; nil no traduce
+	traductor domain.TraduccionUseCase
 
 	register   chan RegisterClient
 	unregister chan string
 	broadcast  chan Message
+	deltas     chan Message
+	streamEnd  chan Message
+	typing     chan Message
 	aiChannel  chan Message
 	done       chan struct{}
 
@@ -31,6 +77,8 @@ type RegisterClient struct {
 // Message representa un mensaje con el contenido y el grupo de destino
 type Message struct {
 	Id          string `json:"Id"`
+	Type        string `json:"Type,omitempty"`
+	StreamId    string `json:"StreamId,omitempty"`
 	SenderID    string `json:"SenderID"`
 	SenderName  string `json:"SenderName,omitempty"`
 	SenderApodo string `json:"SenderApodo,omitempty"`
@@ -38,15 +86,24 @@ type Message struct {
 	Content     string `json:"Content"`
 	Fecha       string `json:"Fecha"`
 	AnswerId    string `json:"AnswerId"`
+	// IDs de los usuarios mencionados con @apodo
+	Mentions []string `json:"Mentions,omitempty"`
+	// Idioma de Content en los frames de traducción
+	Language string `json:"Language,omitempty"`
 }
 
 func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[string]*websocket.Conn),
 		userGroups: make(map[string]map[string]bool),
+		streams:    make(map[string]map[string]*Message),
+		muted:      make(map[string]map[string]bool),
 		register:   make(chan RegisterClient),
 		unregister: make(chan string),
 		broadcast:  make(chan Message),
+		deltas:     make(chan Message, 256),
+		streamEnd:  make(chan Message),
+		typing:     make(chan Message),
 		aiChannel:  make(chan Message),
 		done:       make(chan struct{}),
 	}
@@ -61,6 +118,7 @@ func (h *Hub) Run() {
 		case reg := <-h.register:
 			h.mu.Lock()
 			h.clients[reg.UserID] = reg.Conn
+			h.sendPartials(reg.UserID, reg.Conn)
 			h.mu.Unlock()
 			log.Printf("Hub: Usuario %s registrado.\n", reg.UserID)
 
@@ -74,23 +132,213 @@ func (h *Hub) Run() {
 
 		case msg := <-h.broadcast:
 			h.mu.Lock()
-			jsonMsg, err := json.Marshal(msg)
-			if err != nil {
-				log.Printf("Hub: Error al serializar el mensaje a JSON: %v", err)
-				h.mu.Unlock()
-				continue
+			if msg.StreamId != "" {
+				h.removeStream(msg.GroupID, msg.StreamId)
 			}
-
-			for userID, conn := range h.clients {
-				if h.userGroups[userID] != nil && h.userGroups[userID][msg.GroupID] {
-					if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
-						log.Printf("Hub: Error al enviar a %s: %v", userID, err)
-						h.unregister <- userID
-					}
-				}
+			h.sendToGroup(msg)
+			h.sendMentions(msg)
+			traductor, lectores := h.translationReaders(msg)
+			h.mu.Unlock()
+			if len(lectores) > 0 {
+				go h.sendTranslations(traductor, msg, lectores)
 			}
 			h.aiChannel <- msg
+
+		case delta := <-h.deltas:
+			h.mu.Lock()
+			h.appendToStream(delta)
+			h.sendToGroup(delta)
+			h.mu.Unlock()
+
+		case end := <-h.streamEnd:
+			h.mu.Lock()
+			h.removeStream(end.GroupID, end.StreamId)
+			end.Type = TypeAICancel
+			h.sendToGroup(end)
 			h.mu.Unlock()
+
+		case typing := <-h.typing:
+			h.mu.Lock()
+			h.sendToGroup(typing)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// sendToGroup escribe el mensaje a todos los clientes suscritos al grupo. Requiere h.mu.
+func (h *Hub) sendToGroup(msg Message) {
+	jsonMsg, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Hub: Error al serializar el mensaje a JSON: %v", err)
+		return
+	}
+
+	for userID, conn := range h.clients {
+		if h.userGroups[userID] != nil && h.userGroups[userID][msg.GroupID] && !h.muted[userID][msg.GroupID] {
+			if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
+				log.Printf("Hub: Error al enviar a %s: %v", userID, err)
+				delete(h.clients, userID)
+			}
+		}
+	}
+}
+
+// sendMentions envía un frame de mención a cada usuario mencionado que pertenezca al grupo,
+// incluso si lo tiene silenciado. Requiere h.mu.
+func (h *Hub) sendMentions(msg Message) {
+	if len(msg.Mentions) == 0 {
+		return
+	}
+
+	mention := msg
+	mention.Type = TypeMention
+	jsonMsg, err := json.Marshal(mention)
+	if err != nil {
+		log.Printf("Hub: Error al serializar la mención a JSON: %v", err)
+		return
+	}
+
+	for _, userID := range msg.Mentions {
+		conn, ok := h.clients[userID]
+		if !ok || !h.userGroups[userID][msg.GroupID] {
+			continue
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
+			log.Printf("Hub: Error al enviar la mención a %s: %v", userID, err)
+			delete(h.clients, userID)
+		}
+	}
+}
+
+// translationReaders devuelve los usuarios conectados que recibieron el mensaje, salvo el
+// remitente, para traducírselo. Los fragmentos de streaming no se traducen. Requiere h.mu.
+func (h *Hub) translationReaders(msg Message) (domain.TraduccionUseCase, []uint64) {
+	if h.traductor == nil || msg.Id == "" || (msg.Type != "" && msg.Type != TypeAIFinal) {
+		return nil, nil
+	}
+
+	var lectores []uint64
+	for userID := range h.clients {
+		if userID == msg.SenderID || !h.userGroups[userID][msg.GroupID] || h.muted[userID][msg.GroupID] {
+			continue
+		}
+		if id, err := strconv.ParseUint(userID, 10, 64); err == nil {
+			lectores = append(lectores, id)
+		}
+	}
+	return h.traductor, lectores
+}
+
+// sendTranslations traduce el mensaje al idioma de cada lector y le envía un frame de traducción.
+// Se ejecuta fuera del bucle del Hub para no demorar los mensajes mientras responde el modelo.
+func (h *Hub) sendTranslations(traductor domain.TraduccionUseCase, msg Message, lectores []uint64) {
+	mensajeID, err := strconv.ParseUint(msg.Id, 10, 64)
+	if err != nil {
+		return
+	}
+	senderID, _ := strconv.ParseUint(msg.SenderID, 10, 64)
+
+	traducciones, err := traductor.TraducirParaLectores(msg.GroupID, domain.Mensaje{Id: mensajeID, Contenido: msg.Content, UsuarioId: senderID}, lectores)
+	if err != nil {
+		log.Printf("Hub: Error al traducir el mensaje %s del grupo %s: %v", msg.Id, msg.GroupID, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for lectorID, traduccion := range traducciones {
+		userID := strconv.FormatUint(lectorID, 10)
+		// El lector pudo salir del grupo mientras se generaba la traducción
+		if !h.userGroups[userID][msg.GroupID] {
+			continue
+		}
+		h.sendToUser(userID, Message{
+			Id:       msg.Id,
+			Type:     TypeTranslation,
+			SenderID: msg.SenderID,
+			GroupID:  msg.GroupID,
+			Content:  traduccion.Contenido,
+			Fecha:    msg.Fecha,
+			AnswerId: msg.AnswerId,
+			Language: traduccion.Idioma,
+		})
+	}
+}
+
+// PublicarTraduccion envía al lector, si sigue conectado al grupo, la traducción de un mensaje que
+// obtuvo al listar los mensajes y que se generó después de responderle
+func (h *Hub) PublicarTraduccion(lectorId uint64, grupoClave string, mensaje domain.Mensaje, traduccion domain.Traduccion) {
+	frame := Message{
+		Id:       strconv.FormatUint(mensaje.Id, 10),
+		Type:     TypeTranslation,
+		SenderID: strconv.FormatUint(mensaje.UsuarioId, 10),
+		GroupID:  grupoClave,
+		Content:  traduccion.Contenido,
+		Fecha:    mensaje.Fecha.Format(time.RFC3339),
+		Language: traduccion.Idioma,
+	}
+	if mensaje.ResponseId != nil {
+		frame.AnswerId = strconv.FormatUint(*mensaje.ResponseId, 10)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userID := strconv.FormatUint(lectorId, 10)
+	if !h.userGroups[userID][grupoClave] {
+		return
+	}
+	h.sendToUser(userID, frame)
+}
+
+// appendToStream acumula el fragmento en el contenido parcial del stream. Requiere h.mu.
+func (h *Hub) appendToStream(delta Message) {
+	groupStreams, ok := h.streams[delta.GroupID]
+	if !ok {
+		groupStreams = make(map[string]*Message)
+		h.streams[delta.GroupID] = groupStreams
+	}
+
+	partial, ok := groupStreams[delta.StreamId]
+	if !ok {
+		copyMsg := delta
+		copyMsg.Content = ""
+		copyMsg.Type = TypeAIPartial
+		partial = &copyMsg
+		groupStreams[delta.StreamId] = partial
+	}
+	partial.Content += delta.Content
+}
+
+// removeStream descarta el contenido parcial de un stream. Requiere h.mu.
+func (h *Hub) removeStream(groupID string, streamID string) {
+	groupStreams, ok := h.streams[groupID]
+	if !ok {
+		return
+	}
+	delete(groupStreams, streamID)
+	if len(groupStreams) == 0 {
+		delete(h.streams, groupID)
+	}
+}
+
+// sendPartials envía a un cliente recién conectado el contenido parcial de los streams
+// en curso de sus grupos. Requiere h.mu.
+func (h *Hub) sendPartials(userID string, conn *websocket.Conn) {
+	for groupID := range h.userGroups[userID] {
+		if h.muted[userID][groupID] {
+			continue
+		}
+		for _, partial := range h.streams[groupID] {
+			jsonMsg, err := json.Marshal(partial)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
+				log.Printf("Hub: Error al enviar stream parcial a %s: %v", userID, err)
+				return
+			}
 		}
 	}
 }
@@ -110,6 +358,60 @@ func (h *Hub) Broadcast(msg Message) {
 	h.broadcast <- msg
 }
 
+// PublicarMensaje difunde un mensaje ya guardado que no llegó por WebSocket (por ejemplo, uno
+// aprobado por la moderación). Como cualquier mensaje, también se reenvía al canal de IA.
+func (h *Hub) PublicarMensaje(grupoClave string, mensaje *domain.Mensaje) {
+	msg := Message{
+		Id:       strconv.FormatUint(mensaje.Id, 10),
+		SenderID: strconv.FormatUint(mensaje.UsuarioId, 10),
+		GroupID:  grupoClave,
+		Content:  mensaje.Contenido,
+		Fecha:    mensaje.Fecha.Format(time.RFC3339),
+	}
+	if mensaje.Usuario != nil {
+		msg.SenderName = mensaje.Usuario.Nombre
+		msg.SenderApodo = mensaje.Usuario.Apodo
+	}
+	if mensaje.ResponseId != nil {
+		msg.AnswerId = strconv.FormatUint(*mensaje.ResponseId, 10)
+	}
+	for _, mencion := range mensaje.Menciones {
+		msg.Mentions = append(msg.Mentions, strconv.FormatUint(mencion.UsuarioId, 10))
+	}
+
+	h.Broadcast(msg)
+}
+
+// BroadcastDelta envía un fragmento de una respuesta en streaming a los clientes del grupo.
+// Los fragmentos no se reenvían al canal de IA.
+func (h *Hub) BroadcastDelta(msg Message) {
+	msg.Type = TypeAIDelta
+	h.deltas <- msg
+}
+
+// CancelStream descarta un stream que no terminará en un mensaje persistido
+func (h *Hub) CancelStream(groupID string, streamID string) {
+	h.streamEnd <- Message{GroupID: groupID, StreamId: streamID}
+}
+
+// BroadcastTyping avisa a los clientes del grupo que el remitente de msg está escribiendo
+// (typing true) o que dejó de hacerlo sin enviar el mensaje. El indicador se retira también
+// cuando llega un mensaje del mismo remitente.
+func (h *Hub) BroadcastTyping(msg Message, typing bool) {
+	frame := Message{
+		Type:        TypeTypingStop,
+		SenderID:    msg.SenderID,
+		SenderName:  msg.SenderName,
+		SenderApodo: msg.SenderApodo,
+		GroupID:     msg.GroupID,
+		Fecha:       msg.Fecha,
+	}
+	if typing {
+		frame.Type = TypeTyping
+	}
+	h.typing <- frame
+}
+
 // Nueva función pública para acceder al canal de la IA
 func (h *Hub) AIChannel() chan Message {
 	return h.aiChannel
@@ -127,6 +429,112 @@ func (h *Hub) SubscribeUserToGroups(userID string, groupIDs []string) {
 	}
 }
 
+// SetMutedGroups reemplaza los grupos silenciados de un usuario
+func (h *Hub) SetMutedGroups(userID string, groupIDs []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.muted[userID] = make(map[string]bool, len(groupIDs))
+	for _, groupID := range groupIDs {
+		h.muted[userID][groupID] = true
+	}
+}
+
+// SetMuted silencia o reactiva un grupo para un usuario. Un grupo silenciado no recibe
+// mensajes por WebSocket salvo las menciones al usuario.
+func (h *Hub) SetMuted(userID string, groupID string, muted bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !muted {
+		delete(h.muted[userID], groupID)
+		return
+	}
+
+	if h.muted[userID] == nil {
+		h.muted[userID] = make(map[string]bool)
+	}
+	h.muted[userID][groupID] = true
+}
+
+// PublicarMembresia aplica un alta o baja en las suscripciones del usuario sin esperar a que se
+// reconecte, le avisa por WebSocket si está conectado y notifica a los interesados (ver OnMembership)
+func (h *Hub) PublicarMembresia(evento domain.EventoMembresia) {
+	userID := strconv.FormatUint(evento.UsuarioId, 10)
+	frame := Message{
+		SenderID: userID,
+		GroupID:  evento.GrupoClave,
+		Fecha:    time.Now().Format(time.RFC3339),
+	}
+
+	h.mu.Lock()
+	switch evento.Tipo {
+	case domain.EventoMembresiaAlta:
+		if h.userGroups[userID] == nil {
+			h.userGroups[userID] = make(map[string]bool)
+		}
+		h.userGroups[userID][evento.GrupoClave] = true
+		frame.Type = TypeGroupJoin
+		log.Printf("Usuario %s suscrito al grupo %s.\n", userID, evento.GrupoClave)
+	case domain.EventoMembresiaBaja:
+		delete(h.userGroups[userID], evento.GrupoClave)
+		delete(h.muted[userID], evento.GrupoClave)
+		frame.Type = TypeGroupLeave
+		log.Printf("Usuario %s desuscrito del grupo %s.\n", userID, evento.GrupoClave)
+	}
+	h.sendToUser(userID, frame)
+	listeners := slices.Clone(h.membershipListeners)
+	h.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(evento)
+	}
+}
+
+// SetTraductor habilita las traducciones de los mensajes difundidos para los lectores que eligieron
+// un idioma, en los grupos que las tengan activadas
+func (h *Hub) SetTraductor(traductor domain.TraduccionUseCase) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.traductor = traductor
+}
+
+// OnMembership registra una función que recibe cada cambio de membresía
+func (h *Hub) OnMembership(listener func(domain.EventoMembresia)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.membershipListeners = append(h.membershipListeners, listener)
+}
+
+// sendToUser escribe el frame al usuario si está conectado. Requiere h.mu.
+func (h *Hub) sendToUser(userID string, msg Message) {
+	conn, ok := h.clients[userID]
+	if !ok {
+		return
+	}
+
+	jsonMsg, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Hub: Error al serializar el mensaje a JSON: %v", err)
+		return
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, jsonMsg); err != nil {
+		log.Printf("Hub: Error al enviar a %s: %v", userID, err)
+		delete(h.clients, userID)
+	}
+}
+
+// UnsubscribeUser elimina todas las suscripciones de un usuario
+func (h *Hub) UnsubscribeUser(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.userGroups, userID)
+	log.Printf("Usuario %s desuscrito de todos sus grupos.\n", userID)
+}
+
 // CheckUserInGroup verifica si un usuario pertenece a un grupo
 func (h *Hub) CheckUserInGroup(userID, groupID string) bool {
 	h.mu.Lock()
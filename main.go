@@ -28,15 +28,66 @@ import (
 	grupoHttp "chatvis-chat/internal/grupo/delivery/http"
 	grupoRepo "chatvis-chat/internal/grupo/repository"
 	grupoUseCase "chatvis-chat/internal/grupo/usecase"
+	grupoIAHttp "chatvis-chat/internal/grupoia/delivery/http"
+	grupoIARepo "chatvis-chat/internal/grupoia/repository"
+	grupoIAUseCase "chatvis-chat/internal/grupoia/usecase"
 
 	grupoUsuarioHttp "chatvis-chat/internal/grupousuario/delivery/http"
 	grupoUsuarioRepo "chatvis-chat/internal/grupousuario/repository"
 	grupoUsuarioUseCase "chatvis-chat/internal/grupousuario/usecase"
 
+	botHttp "chatvis-chat/internal/bot/delivery/http"
+	botRepo "chatvis-chat/internal/bot/repository"
+	botUseCase "chatvis-chat/internal/bot/usecase"
+
+	politicaTurnoHttp "chatvis-chat/internal/politicaturno/delivery/http"
+	politicaTurnoRepo "chatvis-chat/internal/politicaturno/repository"
+	politicaTurnoUseCase "chatvis-chat/internal/politicaturno/usecase"
+
+	salidaFallidaHttp "chatvis-chat/internal/salidafallida/delivery/http"
+	salidaFallidaRepo "chatvis-chat/internal/salidafallida/repository"
+	salidaFallidaUseCase "chatvis-chat/internal/salidafallida/usecase"
+
+	usoIAHttp "chatvis-chat/internal/usoia/delivery/http"
+	usoIARepo "chatvis-chat/internal/usoia/repository"
+	usoIAUseCase "chatvis-chat/internal/usoia/usecase"
+
+	interaccionIAHttp "chatvis-chat/internal/interaccionia/delivery/http"
+	interaccionIARepo "chatvis-chat/internal/interaccionia/repository"
+	interaccionIAUseCase "chatvis-chat/internal/interaccionia/usecase"
+
+	embeddingMensajeHttp "chatvis-chat/internal/embeddingmensaje/delivery/http"
+	embeddingMensajeRepo "chatvis-chat/internal/embeddingmensaje/repository"
+	embeddingMensajeUseCase "chatvis-chat/internal/embeddingmensaje/usecase"
+
+	encuestaHttp "chatvis-chat/internal/encuesta/delivery/http"
+	encuestaRepo "chatvis-chat/internal/encuesta/repository"
+	encuestaUseCase "chatvis-chat/internal/encuesta/usecase"
+
+	recordatorioHttp "chatvis-chat/internal/recordatorio/delivery/http"
+	recordatorioRepo "chatvis-chat/internal/recordatorio/repository"
+	recordatorioUseCase "chatvis-chat/internal/recordatorio/usecase"
+
+	resumenHttp "chatvis-chat/internal/resumen/delivery/http"
+	resumenRepo "chatvis-chat/internal/resumen/repository"
+	resumenUseCase "chatvis-chat/internal/resumen/usecase"
+
+	moderacionHttp "chatvis-chat/internal/moderacion/delivery/http"
+	moderacionRepo "chatvis-chat/internal/moderacion/repository"
+	moderacionUseCase "chatvis-chat/internal/moderacion/usecase"
+
+	traduccionRepo "chatvis-chat/internal/traduccion/repository"
+	traduccionUseCase "chatvis-chat/internal/traduccion/usecase"
+
+	cacheIARepo "chatvis-chat/internal/cacheia/repository"
+	cacheIAUseCase "chatvis-chat/internal/cacheia/usecase"
+
 	authHttp "chatvis-chat/internal/auth/delivery/http"
 	authUseCase "chatvis-chat/internal/auth/usecase"
 
+	"chatvis-chat/internal/domain"
 	"chatvis-chat/internal/ia"
+	"chatvis-chat/internal/llm"
 	appWs "chatvis-chat/internal/websocket"
 
 	"chatvis-chat/config/db"
@@ -83,48 +134,187 @@ func main() {
 	userUseCase := usuarioUseCase.NewUsuarioUseCase(pgUserRepo)
 
 	pgMensajeRepo := mensajeRepo.NewPostgresMensajeRepository(db.DB)
-	msgUseCase := mensajeUseCase.NewMensajeUseCase(pgMensajeRepo)
+	// msgUseCase no modera. Solo lo usan caminos que no reciben texto nuevo sin revisar:
+	//   - los bots (aiManager) moderan cada respuesta en AIService antes de guardarla;
+	//   - la cola de moderación guarda con él los mensajes que un administrador ya aprobó;
+	//   - el WebSocket solo lo consulta para reenviar mensajes ya guardados por la API.
+	// Los mensajes que escriben los usuarios entran por la API de mensajes, que usa
+	// msgModeradoUseCase (más abajo, cuando existe la cadena de moderación).
+	msgUseCase := mensajeUseCase.NewMensajeUseCase(pgMensajeRepo, pgUserRepo, nil)
 
 	pgGrupoRepo := grupoRepo.NewPostgresGrupoRepository(db.DB)
 	grpUseCase := grupoUseCase.NewGrupoUseCase(pgGrupoRepo)
 
-	pgGrupoUsuarioRepo := grupoUsuarioRepo.NewPostgresGrupoUsuarioRepository(db.DB)
-	grpUsuarioUseCase := grupoUsuarioUseCase.NewGrupoUsuarioUseCase(pgGrupoUsuarioRepo, pgGrupoRepo)
-
-	authUsecase := authUseCase.NewAuthUseCase(pgUserRepo)
-
 	// Inicialización del Hub y el controlador de WebSocket
 	wsHub := appWs.NewHub()
 	go wsHub.Run()
 
+	// Las altas y bajas en grupos actualizan las suscripciones del Hub (y de los bots) en vivo
+	pgGrupoUsuarioRepo := grupoUsuarioRepo.NewPostgresGrupoUsuarioRepository(db.DB)
+	grpUsuarioUseCase := grupoUsuarioUseCase.NewGrupoUsuarioUseCase(pgGrupoUsuarioRepo, pgGrupoRepo, wsHub)
+
+	authUsecase := authUseCase.NewAuthUseCase(pgUserRepo)
+
 	// --- Inicialización de los servicios de IA ---
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	pgPoliticaTurnoRepo := politicaTurnoRepo.NewPostgresPoliticaTurnoRepository(db.DB)
+	politicaTurnoUsecase := politicaTurnoUseCase.NewPoliticaTurnoUseCase(pgPoliticaTurnoRepo, pgGrupoRepo)
+
+	pgGrupoIARepo := grupoIARepo.NewPostgresGrupoIARepository(db.DB)
+	grupoIAUsecase := grupoIAUseCase.NewGrupoIAUseCase(pgGrupoIARepo, pgGrupoRepo, pgGrupoUsuarioRepo, pgUserRepo)
+
+	pgBotRepo := botRepo.NewPostgresBotRepository(db.DB)
+
+	pgSalidaFallidaRepo := salidaFallidaRepo.NewPostgresSalidaFallidaRepository(db.DB)
+	salidaFallidaUsecase := salidaFallidaUseCase.NewSalidaFallidaUseCase(pgSalidaFallidaRepo, pgBotRepo)
+
+	pgUsoIARepo := usoIARepo.NewPostgresUsoIARepository(db.DB)
+	usoIAUsecase := usoIAUseCase.NewUsoIAUseCase(pgUsoIARepo, pgBotRepo)
+
+	pgInteraccionIARepo := interaccionIARepo.NewPostgresInteraccionIARepository(db.DB)
+	interaccionIAUsecase := interaccionIAUseCase.NewInteraccionIAUseCase(pgInteraccionIARepo, pgBotRepo, pgGrupoRepo, pgUserRepo)
+
+	// --- Búsqueda semántica: se habilita con EMBEDDINGS_MODEL ---
+	var embedder llm.Embedder
+	if embeddingConfig := llm.EmbeddingConfigFromEnv(); embeddingConfig.Enabled() {
+		embedder, err = llm.NewEmbedder(embeddingConfig)
+		if err != nil {
+			log.Printf("Búsqueda semántica deshabilitada: %v", err)
+		}
+	}
+
+	pgEmbeddingMensajeRepo := embeddingMensajeRepo.NewPostgresEmbeddingMensajeRepository(db.DB)
+	embeddingMensajeUsecase := embeddingMensajeUseCase.NewEmbeddingMensajeUseCase(pgEmbeddingMensajeRepo, pgMensajeRepo, pgGrupoRepo, pgGrupoUsuarioRepo, embedder)
+
+	var recuerdosUsecase domain.EmbeddingMensajeUseCase
+	if embedder != nil {
+		log.Printf("Búsqueda semántica habilitada (%s, %s)", embedder.Name(), embedder.Model())
+		recuerdosUsecase = embeddingMensajeUsecase
+		go ia.NewEmbeddingIndexer(embeddingMensajeUsecase).Run(ctx)
+	}
+
+	// Encuestas y recordatorios: endpoints propios y herramientas de los bots
+	pgEncuestaRepo := encuestaRepo.NewPostgresEncuestaRepository(db.DB)
+	encuestaUsecase := encuestaUseCase.NewEncuestaUseCase(pgEncuestaRepo, pgGrupoRepo, pgGrupoUsuarioRepo)
+
+	pgRecordatorioRepo := recordatorioRepo.NewPostgresRecordatorioRepository(db.DB)
+	recordatorioUsecase := recordatorioUseCase.NewRecordatorioUseCase(pgRecordatorioRepo, pgGrupoRepo, pgGrupoUsuarioRepo)
+
+	// --- Resúmenes bajo demanda y digests diarios: se habilitan con SUMMARIES_MODEL ---
+	var resumenProvider llm.Provider
+	summaryConfig := llm.SummaryConfigFromEnv()
+	if summaryConfig.Enabled() {
+		resumenProvider, err = llm.NewProvider(summaryConfig.Provider)
+		if err != nil {
+			log.Printf("Resúmenes deshabilitados: %v", err)
+		}
+	}
+
+	pgResumenRepo := resumenRepo.NewPostgresResumenRepository(db.DB)
+	resumenUsecase := resumenUseCase.NewResumenUseCase(pgResumenRepo, pgMensajeRepo, pgGrupoRepo, pgGrupoUsuarioRepo, resumenProvider, summaryConfig.Model)
+
+	if resumenProvider != nil {
+		log.Printf("Resúmenes habilitados (%s, %s)", resumenProvider.Name(), summaryConfig.Model)
+		go ia.NewDigestScheduler(resumenUsecase).Run(ctx)
+	}
+
+	// --- Moderación: lista de bloqueo, spam y clasificador LLM (cada uno opcional) ---
+	listaBloqueo, err := moderacionUseCase.CargarListaBloqueo(os.Getenv("MODERATION_BLOCKLIST_FILE"))
+	if err != nil {
+		log.Printf("Lista de bloqueo deshabilitada: %v", err)
+	}
+
+	var verificadores []domain.VerificadorModeracion
+	if spamConfig := moderacionUseCase.ConfigSpamFromEnv(); spamConfig.Enabled() {
+		verificadores = append(verificadores, moderacionUseCase.NewVerificadorSpam(spamConfig))
+	}
+	if moderationConfig := llm.ModerationConfigFromEnv(); moderationConfig.Enabled() {
+		clasificador, err := llm.NewProvider(moderationConfig.Provider)
+		if err != nil {
+			log.Printf("Clasificador de moderación deshabilitado: %v", err)
+		} else {
+			log.Printf("Clasificador de moderación habilitado (%s, %s)", clasificador.Name(), moderationConfig.Model)
+			verificadores = append(verificadores, moderacionUseCase.NewClasificadorLLM(clasificador, moderationConfig.Model, os.Getenv("MODERATION_STRICT") == "true"))
+		}
+	}
+
+	pgModeracionRepo := moderacionRepo.NewPostgresModeracionRepository(db.DB)
+	// Los mensajes aprobados se guardan con msgUseCase: volver a moderarlos los retendría otra vez
+	moderacionUsecase := moderacionUseCase.NewModeracionUseCase(pgModeracionRepo, msgUseCase, pgGrupoRepo, pgUserRepo, wsHub, listaBloqueo, verificadores...)
+	// Modera al crear y editar; es el único que recibe texto escrito por los usuarios
+	msgModeradoUseCase := mensajeUseCase.NewMensajeUseCase(pgMensajeRepo, pgUserRepo, moderacionUsecase)
+
+	// --- Traducciones de mensajes: se habilitan con TRANSLATION_MODEL y en cada grupo ---
+	var traduccionProvider llm.Provider
+	translationConfig := llm.TranslationConfigFromEnv()
+	if translationConfig.Enabled() {
+		traduccionProvider, err = llm.NewProvider(translationConfig.Provider)
+		if err != nil {
+			log.Printf("Traducciones deshabilitadas: %v", err)
+		}
+	}
+
+	pgTraduccionRepo := traduccionRepo.NewPostgresTraduccionRepository(db.DB)
+	traduccionUsecase := traduccionUseCase.NewTraduccionUseCase(pgTraduccionRepo, pgGrupoRepo, pgUserRepo, grupoIAUsecase, wsHub, traduccionProvider, translationConfig.Model)
+
+	if traduccionProvider != nil {
+		log.Printf("Traducciones habilitadas (%s, %s)", traduccionProvider.Name(), translationConfig.Model)
+		wsHub.SetTraductor(traduccionUsecase)
+	}
+
+	// --- Caché de respuestas de los bots con usarCache: se habilita con LLM_CACHE_TTL_SECONDS ---
+	if cacheConfig := llm.CacheConfigFromEnv(); cacheConfig.Enabled() {
+		var cacheStore llm.CacheStore
+		if os.Getenv("LLM_CACHE_PERSIST") == "true" {
+			pgCacheIARepo := cacheIARepo.NewPostgresCacheIARepository(db.DB)
+			cacheStore = cacheIAUseCase.NewCacheStore(pgCacheIARepo)
+		}
+		llm.ConfigureCache(cacheConfig, cacheStore)
+		log.Printf("Caché de respuestas habilitada (ttl %s, %d entradas, persistente: %t)", cacheConfig.TTL, cacheConfig.MaxEntries, cacheStore != nil)
+	}
+
 	enableAI := os.Getenv("ENABLE_AI_MODELS")
-	aiServices := make(map[string]*ia.AIService)
+	// Los bots guardan con msgUseCase: sus respuestas ya pasaron por ModeracionUseCase en AIService
+	aiManager := ia.NewManager(ctx, wsHub, msgUseCase, grpUseCase, userUseCase, politicaTurnoUsecase, usoIAUsecase, grupoIAUsecase)
+	aiManager.SalidaFallidaUseCase = salidaFallidaUsecase
+	aiManager.InteraccionIAUseCase = interaccionIAUsecase
+	aiManager.EmbeddingMensajeUseCase = recuerdosUsecase
+	aiManager.EncuestaUseCase = encuestaUsecase
+	aiManager.RecordatorioUseCase = recordatorioUsecase
+	aiManager.ModeracionUseCase = moderacionUsecase
+
+	var botRuntime domain.BotRuntime
+	if enableAI == "true" {
+		botRuntime = aiManager
+	}
+
+	botUsecase := botUseCase.NewBotUseCase(pgBotRepo, pgUserRepo, pgGrupoRepo, botRuntime)
 
 	if enableAI == "true" {
 		log.Println("Servicios de IA Habilitados (ENABLE_AI_MODELS=true)")
-		for _, config := range ia.AiConfigurations {
-			aiService := ia.NewAIService(wsHub, msgUseCase, grpUseCase, userUseCase, config)
-			aiServices[config.UserID] = aiService
-			go aiService.Start(ctx, 5) // 5 workers por servicio
+
+		bots, err := botUsecase.GetAllActive()
+		if err != nil {
+			log.Printf("Error al cargar los bots activos: %v", err)
 		}
 
-		// Enrutamiento de mensajes hacia las IA
-		go func() {
-			for msg := range wsHub.AIChannel() {
-				if _, ok := aiServices[msg.SenderID]; ok {
-					continue
-				}
-				for _, service := range aiServices {
-					if wsHub.CheckUserInGroup(service.Config.UserID, msg.GroupID) {
-						service.InputChannel() <- msg
-					}
-				}
+		for _, bot := range bots {
+			if err := aiManager.StartBot(bot); err != nil {
+				log.Printf("No se pudo iniciar la IA %d: %v", bot.UsuarioId, err)
 			}
-		}()
+		}
+
+		// Disparo de los bots cuando un grupo queda en silencio
+		idleScheduler := ia.NewIdleScheduler(aiManager)
+		go idleScheduler.Run(ctx)
+
+		// Publicación de los recordatorios programados por los bots
+		go ia.NewReminderScheduler(aiManager, recordatorioUsecase).Run(ctx)
+
+		// Enrutamiento de mensajes hacia las IA
+		go aiManager.Route(ctx, wsHub.AIChannel(), idleScheduler)
 	} else {
 		log.Println("Servicios de IA Deshabilitados. Cambiar ENABLE_AI_MODELS=true en .env para activar.")
 		go func() {
@@ -133,7 +323,8 @@ func main() {
 		}()
 	}
 
-	webSocketController := appWs.NewWebSocketController(wsHub, grpUseCase)
+	// El WebSocket no guarda mensajes: solo lee con msgUseCase los que ya guardó (y moderó) la API
+	webSocketController := appWs.NewWebSocketController(wsHub, grpUseCase, userUseCase, grpUsuarioUseCase, msgUseCase)
 
 	public := app.Group("/api/public")
 	usuarioHttp.NewUsuarioPublicHandler(public, userUseCase)
@@ -148,12 +339,24 @@ func main() {
 
 	grupo := protected.Group("/group")
 	grupoHttp.NewGrupoHandler(grupo, grpUseCase)
+	resumenHttp.NewResumenHandler(grupo, resumenUsecase)
+	grupoIAHttp.NewGrupoIAHandler(grupo, grupoIAUsecase)
 
 	usuarioGrp := protected.Group("/user")
 	usuarioHttp.NewUsuarioHandler(usuarioGrp, userUseCase)
 
 	mensajeGrp := protected.Group("/mensaje")
-	mensajeHttp.NewMensajeHandler(mensajeGrp, msgUseCase)
+	mensajeHttp.NewMensajeHandler(mensajeGrp, msgModeradoUseCase, traduccionUsecase)
+	embeddingMensajeHttp.NewEmbeddingMensajeHandler(mensajeGrp, embeddingMensajeUsecase)
+
+	encuestaGrp := protected.Group("/encuesta")
+	encuestaHttp.NewEncuestaHandler(encuestaGrp, encuestaUsecase)
+
+	recordatorioGrp := protected.Group("/recordatorio")
+	recordatorioHttp.NewRecordatorioHandler(recordatorioGrp, recordatorioUsecase)
+
+	digestGrp := protected.Group("/digest")
+	resumenHttp.NewDigestHandler(digestGrp, resumenUsecase)
 
 	grupoUsuarioGrp := protected.Group("/group-user")
 	grupoUsuarioHttp.NewGrupoUsuarioHandler(grupoUsuarioGrp, grpUsuarioUseCase)
@@ -163,6 +366,12 @@ func main() {
 	usuarioHttp.NewAdminUsuarioHandler(admin, userUseCase)
 	grupoHttp.NewAdminGrupoHandler(admin, grpUseCase)
 	grupoUsuarioHttp.NewAdminGrupoUsuarioHandler(admin, grpUsuarioUseCase)
+	botHttp.NewAdminBotHandler(admin, botUsecase)
+	politicaTurnoHttp.NewAdminPoliticaTurnoHandler(admin, politicaTurnoUsecase)
+	salidaFallidaHttp.NewAdminSalidaFallidaHandler(admin, salidaFallidaUsecase)
+	usoIAHttp.NewAdminUsoIAHandler(admin, usoIAUsecase)
+	interaccionIAHttp.NewAdminInteraccionIAHandler(admin, interaccionIAUsecase)
+	moderacionHttp.NewAdminModeracionHandler(admin, moderacionUsecase)
 
 	// --- Señales de cierre ---
 	c := make(chan os.Signal, 1)
@@ -181,6 +390,7 @@ func main() {
 
 	// Cancelar el contexto -> detiene workers y listeners
 	cancel()
+	aiManager.StopAll()
 	wsHub.Shutdown()
 
 	ctxTimeout, cancelTimeout := context.WithTimeout(context.Background(), 5*time.Second)